@@ -1,10 +1,15 @@
 package cmd
 
 // These are the default executors and remote clients included in flowctl
-// Additional executors and remote clients can be added here
+// Additional executors and remote clients can be added here, executors can also be
+// added without recompiling as plugins placed in the plugins directory (see sdk/executor.ServePlugin)
 import (
 	_ "github.com/cvhariharan/flowctl/executors/docker"
+	_ "github.com/cvhariharan/flowctl/executors/http"
+	_ "github.com/cvhariharan/flowctl/executors/kubernetes"
 	_ "github.com/cvhariharan/flowctl/executors/script"
+	_ "github.com/cvhariharan/flowctl/executors/sql"
+	_ "github.com/cvhariharan/flowctl/remoteclients/docker"
 	_ "github.com/cvhariharan/flowctl/remoteclients/qssh"
 	_ "github.com/cvhariharan/flowctl/remoteclients/ssh"
 	_ "github.com/cvhariharan/flowctl/sdk/executor"
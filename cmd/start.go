@@ -20,11 +20,14 @@ import (
 	"github.com/cvhariharan/flowctl/internal/scheduler"
 	"github.com/cvhariharan/flowctl/internal/scheduler/storage"
 	"github.com/cvhariharan/flowctl/internal/streamlogger"
+	"github.com/cvhariharan/flowctl/sdk/executor"
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	sqlxadapter "github.com/memwey/casbin-sqlx-adapter"
 	"github.com/spf13/cobra"
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/fileblob"
 	"gocloud.dev/secrets"
 	_ "gocloud.dev/secrets/localsecrets"
 )
@@ -70,6 +73,7 @@ type SharedComponents struct {
 	Scheduler *scheduler.Scheduler
 	Logger    *slog.Logger
 	Keeper    *secrets.Keeper
+	Artifacts *blob.Bucket
 }
 
 // Cleanup cleans up all shared resources
@@ -80,6 +84,9 @@ func (s *SharedComponents) Cleanup() {
 	if s.Keeper != nil {
 		s.Keeper.Close()
 	}
+	if s.Artifacts != nil {
+		s.Artifacts.Close()
+	}
 }
 
 // initializeSharedComponents sets up all shared components (DB, scheduler, core, etc.)
@@ -139,9 +146,38 @@ func initializeSharedComponents() *SharedComponents {
 		log.Fatalf("could not open secrets keeper: %v", err)
 	}
 
+	// Storing artifacts is disabled when no bucket is set
+	var artifactBucket *blob.Bucket
+	if appConfig.Artifacts.BucketURL != "" {
+		artifactBucket, err = blob.OpenBucket(context.Background(), appConfig.Artifacts.BucketURL)
+		if err != nil {
+			log.Fatalf("could not open artifact bucket: %v", err)
+		}
+	} else {
+		logger.Info("artifacts.bucket_url is not set, artifacts declared by actions will not be stored")
+	}
+
+	// Register executor plugins before flows referencing them are loaded
+	plugins, err := executor.LoadPlugins(context.Background(), appConfig.App.PluginsDirectory)
+	if err != nil {
+		logger.Error("could not load some executor plugins", "directory", appConfig.App.PluginsDirectory, "error", err)
+	}
+	for _, name := range plugins {
+		logger.Info("loaded executor plugin", "executor", name)
+	}
+
+	// The docker executor and remote client share the docker config
+	if err := executor.SetServerConfig("docker", appConfig.Docker); err != nil {
+		log.Fatal(err)
+	}
+
 	s := repo.NewPostgresStore(db)
 
 	jobStore := storage.NewPostgresStorage(db)
+	jobStore.SetNamespaceMaxConcurrency(appConfig.Scheduler.NamespaceMaxConcurrency, appConfig.Scheduler.NamespaceLimits)
+	if err := jobStore.Listen(dbConnectionString); err != nil {
+		logger.Error("could not listen for job notifications, falling back to polling", "error", err)
+	}
 
 	// Build scheduler
 	sch, err := scheduler.NewSchedulerBuilder(logger.WithGroup("scheduler")).
@@ -150,6 +186,9 @@ func initializeSharedComponents() *SharedComponents {
 		WithLogManager(fileLogManager).
 		WithWorkerCount(appConfig.Scheduler.WorkerCount).
 		WithCronSyncInterval(appConfig.Scheduler.CronSyncInterval).
+		WithNodeHealthInterval(appConfig.Scheduler.NodeHealthInterval).
+		WithArtifactBucket(artifactBucket).
+		WithArtifactRetention(appConfig.Artifacts.RetentionTime).
 		Build()
 
 	if err != nil {
@@ -162,10 +201,18 @@ func initializeSharedComponents() *SharedComponents {
 	}
 
 	co.LogManager = fileLogManager
+	co.SetInventoryConfig(appConfig.Inventory.Directory, appConfig.Inventory.ScriptTimeout)
+	co.SetArtifactBucket(artifactBucket)
 
 	// Set secrets provider and flow loader after core is created
 	sch.SetSecretsProvider(co.GetDecryptedFlowSecrets)
 	sch.SetFlowLoader(co.GetSchedulerFlow)
+	sch.SetCredentialProvider(co.GetExecutorCredential)
+	sch.SetBecomeCredentialProvider(co.GetBecomeCredential)
+	sch.SetHostKeyRecorder(co.RecordPendingHostKey)
+	sch.SetCertificateRecorder(co.RecordSSHCertificate)
+	sch.SetInventorySyncer(co.SyncDueInventorySources)
+	sch.SetNodeLoader(co.GetSchedulerNodes)
 
 	return &SharedComponents{
 		DB:        db,
@@ -173,6 +220,7 @@ func initializeSharedComponents() *SharedComponents {
 		Scheduler: sch,
 		Logger:    logger,
 		Keeper:    keeper,
+		Artifacts: artifactBucket,
 	}
 }
 
@@ -234,6 +282,7 @@ func startServer(db *sqlx.DB, co *core.Core, logger *slog.Logger) {
 	namespaceGroup.PUT("/flows/:flowID", h.HandleUpdateFlow, h.AuthorizeNamespaceAction(models.ResourceFlow, models.RBACActionUpdate))
 	namespaceGroup.DELETE("/flows/:flowID", h.HandleDeleteFlow, h.AuthorizeNamespaceAction(models.ResourceFlow, models.RBACActionDelete))
 	namespaceGroup.GET("/flows/executions/:execID", h.HandleGetExecutionSummary, h.AuthorizeNamespaceAction(models.ResourceFlow, models.RBACActionView))
+	namespaceGroup.GET("/flows/executions/:execID/artifacts/:artifactID", h.HandleDownloadExecutionArtifact, h.AuthorizeNamespaceAction(models.ResourceExecution, models.RBACActionView))
 	namespaceGroup.POST("/flows/executions/:execID/cancel", h.HandleCancelExecution, h.AuthorizeNamespaceAction(models.ResourceExecution, models.RBACActionUpdate))
 	namespaceGroup.GET("/flows/:flowID/executions", h.HandleExecutionsPagination, h.AuthorizeNamespaceAction(models.ResourceExecution, models.RBACActionView))
 	namespaceGroup.GET("/flows/executions", h.HandleAllExecutionsPagination, h.AuthorizeNamespaceAction(models.ResourceExecution, models.RBACActionView))
@@ -249,6 +298,7 @@ func startServer(db *sqlx.DB, co *core.Core, logger *slog.Logger) {
 	namespaceGroup.DELETE("/flows/:flowID/secrets/:secretID", h.HandleDeleteFlowSecret, h.AuthorizeNamespaceAction(models.ResourceFlowSecret, models.RBACActionDelete))
 	namespaceGroup.POST("/trigger/:flow", h.HandleFlowTrigger, h.AuthorizeNamespaceAction(models.ResourceFlow, models.RBACActionExecute))
 	namespaceGroup.GET("/logs/:logID", h.HandleLogStreaming, h.AuthorizeNamespaceAction(models.ResourceExecution, models.RBACActionView))
+	namespaceGroup.GET("/locks", h.HandleListResourceLocks, h.AuthorizeNamespaceAction(models.ResourceExecution, models.RBACActionView))
 
 	// Node routes - only admins can create/update/delete
 	namespaceGroup.GET("/nodes", h.HandleListNodes, h.AuthorizeNamespaceAction(models.ResourceNode, models.RBACActionView))
@@ -257,6 +307,17 @@ func startServer(db *sqlx.DB, co *core.Core, logger *slog.Logger) {
 	namespaceGroup.POST("/nodes", h.HandleCreateNode, h.AuthorizeNamespaceAction(models.ResourceNode, models.RBACActionCreate))
 	namespaceGroup.PUT("/nodes/:nodeID", h.HandleUpdateNode, h.AuthorizeNamespaceAction(models.ResourceNode, models.RBACActionUpdate))
 	namespaceGroup.DELETE("/nodes/:nodeID", h.HandleDeleteNode, h.AuthorizeNamespaceAction(models.ResourceNode, models.RBACActionDelete))
+	namespaceGroup.POST("/nodes/host-keys/import", h.HandleImportKnownHosts, h.AuthorizeNamespaceAction(models.ResourceNode, models.RBACActionUpdate))
+	namespaceGroup.POST("/nodes/import", h.HandleImportNodes, h.AuthorizeNamespaceAction(models.ResourceNode, models.RBACActionCreate))
+	namespaceGroup.GET("/nodes/inventory-sources", h.HandleListInventorySources, h.AuthorizeNamespaceAction(models.ResourceNode, models.RBACActionView))
+	namespaceGroup.GET("/nodes/inventory-sources/:sourceID", h.HandleGetInventorySource, h.AuthorizeNamespaceAction(models.ResourceNode, models.RBACActionView))
+	namespaceGroup.POST("/nodes/inventory-sources", h.HandleCreateInventorySource, h.AuthorizeNamespaceAction(models.ResourceNode, models.RBACActionCreate))
+	namespaceGroup.PUT("/nodes/inventory-sources/:sourceID", h.HandleUpdateInventorySource, h.AuthorizeNamespaceAction(models.ResourceNode, models.RBACActionUpdate))
+	namespaceGroup.DELETE("/nodes/inventory-sources/:sourceID", h.HandleDeleteInventorySource, h.AuthorizeNamespaceAction(models.ResourceNode, models.RBACActionDelete))
+	namespaceGroup.POST("/nodes/inventory-sources/:sourceID/sync", h.HandleSyncInventorySource, h.AuthorizeNamespaceAction(models.ResourceNode, models.RBACActionUpdate))
+	namespaceGroup.POST("/nodes/:nodeID/host-key/scan", h.HandleScanNodeHostKey, h.AuthorizeNamespaceAction(models.ResourceNode, models.RBACActionUpdate))
+	namespaceGroup.POST("/nodes/:nodeID/host-key/approve", h.HandleApproveNodeHostKey, h.AuthorizeNamespaceAction(models.ResourceNode, models.RBACActionUpdate))
+	namespaceGroup.DELETE("/nodes/:nodeID/host-key", h.HandleResetNodeHostKey, h.AuthorizeNamespaceAction(models.ResourceNode, models.RBACActionUpdate))
 
 	// Credential routes - only admins can create/update/delete
 	namespaceGroup.GET("/credentials", h.HandleListCredentials, h.AuthorizeNamespaceAction(models.ResourceCredential, models.RBACActionView))
@@ -4,23 +4,30 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"os"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/cvhariharan/flowctl/sdk/executor"
+	"github.com/distribution/reference"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-units"
 	"github.com/gosimple/slug"
-	"github.com/hashicorp/go-envparse"
 	"github.com/invopop/jsonschema"
 	"github.com/rs/xid"
 	"gopkg.in/yaml.v3"
@@ -30,9 +37,75 @@ const (
 	WORKING_DIR = "/flows"
 )
 
+const (
+	PullAlways       = "always"
+	PullIfNotPresent = "if_not_present"
+	PullNever        = "never"
+
+	dockerSocket = "/var/run/docker.sock"
+
+	// cleanupTimeout limits the time spent removing containers and networks
+	cleanupTimeout = 30 * time.Second
+
+	// RegistryCredentialType is the key type of credentials used for registry authentication
+	RegistryCredentialType = "registry"
+)
+
 type DockerWithConfig struct {
-	Image  string `yaml:"image" json:"image" jsonschema:"title=image,description=Docker Image" jsonschema_extras:"placeholder=docker.io/alpine:latest"`
-	Script string `yaml:"script" json:"script" jsonschema:"title=script" jsonschema_extras:"widget=codeeditor"`
+	Image              string          `yaml:"image" json:"image" jsonschema:"title=image,description=Docker Image" jsonschema_extras:"placeholder=docker.io/alpine:latest"`
+	Script             string          `yaml:"script" json:"script" jsonschema:"title=script" jsonschema_extras:"widget=codeeditor"`
+	Pull               string          `yaml:"pull,omitempty" json:"pull,omitempty" jsonschema:"title=pull,description=When to pull the image (default: always),enum=always,enum=if_not_present,enum=never"`
+	Entrypoint         []string        `yaml:"entrypoint,omitempty" json:"entrypoint,omitempty" jsonschema:"title=entrypoint,description=Overrides the entrypoint of the image. The script is passed as the only argument"`
+	User               string          `yaml:"user,omitempty" json:"user,omitempty" jsonschema:"title=user,description=User the container runs as e.g. 1000:1000"`
+	WorkingDir         string          `yaml:"working_dir,omitempty" json:"working_dir,omitempty" jsonschema:"title=working dir,description=Working directory inside the container (default: /flows)"`
+	Resources          ResourceConfig  `yaml:"resources,omitempty" json:"resources,omitempty" jsonschema:"title=resources"`
+	Volumes            []VolumeConfig  `yaml:"volumes,omitempty" json:"volumes,omitempty" jsonschema:"title=volumes,description=Bind mounts from the node. Only paths allowed in the flowctl config can be mounted"`
+	Networks           []string        `yaml:"networks,omitempty" json:"networks,omitempty" jsonschema:"title=networks,description=Docker networks the container is connected to. Networks other than bridge must be allowed in the flowctl config"`
+	ShowImagePull      bool            `yaml:"show_image_pull,omitempty" json:"show_image_pull,omitempty" jsonschema:"title=show image pull,description=Show the image pull progress in the logs"`
+	KeepContainer      bool            `yaml:"keep_container,omitempty" json:"keep_container,omitempty" jsonschema:"title=keep container,description=Do not remove the container after it exits"`
+	MountDockerSocket  bool            `yaml:"mount_docker_socket,omitempty" json:"mount_docker_socket,omitempty" jsonschema:"title=mount docker socket,description=Mount the docker socket of the node. The socket must be allowed in the flowctl config"`
+	RegistryCredential string          `yaml:"registry_credential,omitempty" json:"registry_credential,omitempty" jsonschema:"title=registry credential,description=Name of a registry credential used to pull the image"`
+	Services           []ServiceConfig `yaml:"services,omitempty" json:"services,omitempty" jsonschema:"title=services,description=Containers started before the action on a shared network. They are reachable using their name"`
+}
+
+type ResourceConfig struct {
+	CPUs   string `yaml:"cpus,omitempty" json:"cpus,omitempty" jsonschema:"title=cpus,description=Number of CPUs e.g. 1.5" jsonschema_extras:"placeholder=1.5"`
+	Memory string `yaml:"memory,omitempty" json:"memory,omitempty" jsonschema:"title=memory,description=Memory limit e.g. 512m" jsonschema_extras:"placeholder=512m"`
+}
+
+type VolumeConfig struct {
+	Source   string `yaml:"source" json:"source" jsonschema:"title=source,description=Absolute path on the node"`
+	Target   string `yaml:"target" json:"target" jsonschema:"title=target,description=Absolute path in the container"`
+	ReadOnly bool   `yaml:"read_only,omitempty" json:"read_only,omitempty" jsonschema:"title=read only"`
+}
+
+// ServerConfig is the configuration of the docker executor from the docker section of the flowctl config file
+type ServerConfig struct {
+	// AllowedVolumes are the paths on the nodes that actions can bind mount. A path also allows all paths under it
+	AllowedVolumes []string `json:"allowed_volumes"`
+	// AllowedNetworks are the networks that containers can be connected to in addition to the default networks
+	AllowedNetworks []string `json:"allowed_networks"`
+}
+
+// defaultNetworks keep containers isolated from the host and other containers, they are always allowed
+var defaultNetworks = []string{"default", "bridge", "none"}
+
+// isVolumeAllowed checks if the source path is one of the allowed paths or is under one of them
+func isVolumeAllowed(allowedVolumes []string, source string) bool {
+	source = path.Clean(source)
+	for _, allowed := range allowedVolumes {
+		allowed = path.Clean(allowed)
+		if source == allowed || allowed == "/" || strings.HasPrefix(source, allowed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isNetworkAllowed checks if containers can be connected to the network. The host network and the
+// networks of other containers are only allowed when they are listed explicitly
+func isNetworkAllowed(allowedNetworks []string, name string) bool {
+	return slices.Contains(defaultNetworks, name) || slices.Contains(allowedNetworks, name)
 }
 
 type DockerExecutor struct {
@@ -41,10 +114,17 @@ type DockerExecutor struct {
 	env              []string
 	cmd              []string
 	entrypoint       []string
+	user             string
+	workingDir       string
+	pullPolicy       string
+	resources        container.Resources
+	networks         []string
+	services         []ServiceConfig
 	containerID      string
 	mounts           []mount.Mount
 	dockerOptions    DockerRunnerOptions
 	authConfig       string
+	authRegistry     string
 	stdout           io.Writer
 	stderr           io.Writer
 	client           *client.Client
@@ -92,7 +172,7 @@ func (d *DockerExecutor) withEnv(env []map[string]any) *DockerExecutor {
 			return nil
 		}
 		for k, v := range v {
-			variables = append(variables, fmt.Sprintf("%s=%s", k, fmt.Sprint(v)))
+			variables = append(variables, fmt.Sprintf("%s=%s", k, executor.FormatValue(v)))
 		}
 	}
 	d.env = variables
@@ -109,10 +189,51 @@ func (d *DockerExecutor) withEntrypoint(entrypoint []string) *DockerExecutor {
 	return d
 }
 
-func (d *DockerExecutor) withCredentials(username, password string) *DockerExecutor {
+func (d *DockerExecutor) withUser(user string) *DockerExecutor {
+	d.user = user
+	return d
+}
+
+func (d *DockerExecutor) withWorkingDir(workingDir string) *DockerExecutor {
+	d.workingDir = workingDir
+	return d
+}
+
+func (d *DockerExecutor) withPullPolicy(policy string) *DockerExecutor {
+	d.pullPolicy = policy
+	return d
+}
+
+func (d *DockerExecutor) withResources(resources container.Resources) *DockerExecutor {
+	d.resources = resources
+	return d
+}
+
+func (d *DockerExecutor) withMounts(mounts []mount.Mount) *DockerExecutor {
+	d.mounts = append(d.mounts, mounts...)
+	return d
+}
+
+func (d *DockerExecutor) withNetworks(networks []string) *DockerExecutor {
+	d.networks = networks
+	return d
+}
+
+func (d *DockerExecutor) withServices(services []ServiceConfig) *DockerExecutor {
+	d.services = services
+	return d
+}
+
+func (d *DockerExecutor) withOptions(options DockerRunnerOptions) *DockerExecutor {
+	d.dockerOptions = options
+	return d
+}
+
+func (d *DockerExecutor) withCredentials(username, password, serverAddress string) *DockerExecutor {
 	authConfig := registry.AuthConfig{
-		Username: username,
-		Password: password,
+		Username:      username,
+		Password:      password,
+		ServerAddress: serverAddress,
 	}
 
 	jsonVal, err := json.Marshal(authConfig)
@@ -120,15 +241,102 @@ func (d *DockerExecutor) withCredentials(username, password string) *DockerExecu
 		log.Fatal("could not create auth config for docker authentication: ", err)
 	}
 	d.authConfig = base64.URLEncoding.EncodeToString(jsonVal)
+	d.authRegistry = registryDomain(serverAddress)
 	return d
 }
 
-func (d *DockerExecutor) Execute(ctx context.Context, execCtx executor.ExecutionContext) (map[string]string, error) {
+// registryAuthFor returns the registry auth to use when pulling an image. The credential is only sent
+// to the registry it was created for
+func (d *DockerExecutor) registryAuthFor(ref string) string {
+	if d.authConfig == "" {
+		return ""
+	}
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil || reference.Domain(named) != d.authRegistry {
+		return ""
+	}
+	return d.authConfig
+}
+
+// imageRegistryAuth returns the registry auth used to pull the image of the action. The registry credential
+// has to be for the registry of the image so that it is not sent to another registry
+func (d *DockerExecutor) imageRegistryAuth() (string, error) {
+	if d.authConfig == "" {
+		return "", nil
+	}
+	auth := d.registryAuthFor(d.image)
+	if auth == "" {
+		return "", fmt.Errorf("registry credential is for registry %s and cannot be used to pull image %s", d.authRegistry, d.image)
+	}
+	return auth, nil
+}
+
+// registryDomain normalizes the server address of a registry credential to the domain used in image
+// references. An empty address is Docker Hub
+func registryDomain(serverAddress string) string {
+	domain := strings.TrimPrefix(strings.TrimPrefix(serverAddress, "https://"), "http://")
+	domain, _, _ = strings.Cut(domain, "/")
+	switch domain {
+	case "", "index.docker.io", "registry-1.docker.io":
+		return "docker.io"
+	}
+	return domain
+}
+
+// getRegistryAuth reads the registry credential of the action
+func getRegistryAuth(ctx context.Context, name string, execCtx executor.ExecutionContext) (executor.RegistryAuth, error) {
+	if execCtx.GetCredential == nil {
+		return executor.RegistryAuth{}, errors.New("credentials are not available to the docker executor")
+	}
+
+	cred, err := execCtx.GetCredential(ctx, name, RegistryCredentialType)
+	if err != nil {
+		return executor.RegistryAuth{}, fmt.Errorf("could not get registry credential %s: %w", name, err)
+	}
+	if cred.KeyType != RegistryCredentialType {
+		return executor.RegistryAuth{}, fmt.Errorf("credential %s is of type %s, expected %s", name, cred.KeyType, RegistryCredentialType)
+	}
+
+	auth, err := executor.ParseRegistryAuth(cred.KeyData)
+	if err != nil {
+		return executor.RegistryAuth{}, fmt.Errorf("invalid registry credential %s: %w", name, err)
+	}
+	return auth, nil
+}
+
+func (d *DockerExecutor) Execute(ctx context.Context, execCtx executor.ExecutionContext) (map[string]any, error) {
 	var config DockerWithConfig
 	if err := yaml.Unmarshal(execCtx.WithConfig, &config); err != nil {
 		return nil, fmt.Errorf("could not read config for docker executor %s: %w", d.name, err)
 	}
 
+	var server ServerConfig
+	if err := executor.GetServerConfig("docker", &server); err != nil {
+		return nil, err
+	}
+
+	if err := validateConfig(config, server); err != nil {
+		return nil, err
+	}
+
+	resources, err := parseResources(config.Resources)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes, err := volumeMounts(config, server)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.RegistryCredential != "" {
+		auth, err := getRegistryAuth(ctx, config.RegistryCredential, execCtx)
+		if err != nil {
+			return nil, err
+		}
+		d.withCredentials(auth.Username, auth.Password, auth.ServerAddress)
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -158,6 +366,18 @@ func (d *DockerExecutor) Execute(ctx context.Context, execCtx executor.Execution
 		Target: "/tmp/flow/output",
 	})
 
+	// create a file for storing JSON outputs
+	jsonTempFile := d.driver.Join(d.driver.TempDir(), fmt.Sprintf("docker-executor-output-json-%s", xid.New().String()))
+	if err := d.driver.CreateFile(ctx, jsonTempFile); err != nil {
+		return nil, fmt.Errorf("failed to create temp file for output: %w", err)
+	}
+
+	d.mounts = append(d.mounts, mount.Mount{
+		Type:   mount.TypeBind,
+		Source: jsonTempFile,
+		Target: "/tmp/flow/output.json",
+	})
+
 	d.mounts = append(d.mounts, mount.Mount{
 		Type:   mount.TypeBind,
 		Source: artifactsDir,
@@ -170,12 +390,26 @@ func (d *DockerExecutor) Execute(ctx context.Context, execCtx executor.Execution
 	}
 	// Add output env variable
 	vars = append(vars, map[string]any{"FC_OUTPUT": "/tmp/flow/output"})
+	vars = append(vars, map[string]any{"FC_OUTPUT_JSON": "/tmp/flow/output.json"})
 	// Add artifacts env variable
 	vars = append(vars, map[string]any{"FC_ARTIFACTS": "/tmp/flow/artifacts"})
 
 	d.withImage(config.Image).
 		withCmd([]string{config.Script}).
-		withEnv(vars)
+		withEnv(vars).
+		withEntrypoint(config.Entrypoint).
+		withUser(config.User).
+		withWorkingDir(config.WorkingDir).
+		withPullPolicy(config.Pull).
+		withResources(resources).
+		withMounts(volumes).
+		withNetworks(config.Networks).
+		withServices(config.Services).
+		withOptions(DockerRunnerOptions{
+			ShowImagePull:     config.ShowImagePull,
+			MountDockerSocket: config.MountDockerSocket,
+			KeepContainer:     config.KeepContainer,
+		})
 	d.stdout = execCtx.Stdout
 	d.stderr = execCtx.Stderr
 
@@ -188,12 +422,84 @@ func (d *DockerExecutor) Execute(ctx context.Context, execCtx executor.Execution
 		return nil, fmt.Errorf("failed to read temp file contents: %w", err)
 	}
 
-	outputEnv, err := envparse.Parse(outputContents)
+	jsonContents, err := d.readTempFileContents(ctx, jsonTempFile)
 	if err != nil {
-		return nil, fmt.Errorf("could not load output env: %w", err)
+		return nil, fmt.Errorf("failed to read temp file contents: %w", err)
 	}
 
-	return outputEnv, nil
+	return executor.ParseOutputs(outputContents, jsonContents)
+}
+
+func validateConfig(config DockerWithConfig, server ServerConfig) error {
+	switch config.Pull {
+	case "", PullAlways, PullIfNotPresent, PullNever:
+	default:
+		return fmt.Errorf("invalid pull policy %q, must be one of %s, %s, %s", config.Pull, PullAlways, PullIfNotPresent, PullNever)
+	}
+
+	if config.WorkingDir != "" && !path.IsAbs(config.WorkingDir) {
+		return fmt.Errorf("working_dir %s must be an absolute path", config.WorkingDir)
+	}
+
+	if err := validateServices(config.Services); err != nil {
+		return err
+	}
+
+	if config.MountDockerSocket && !isVolumeAllowed(server.AllowedVolumes, dockerSocket) {
+		return fmt.Errorf("mounting the docker socket is not allowed, %s must be added to the allowed volumes", dockerSocket)
+	}
+
+	for _, n := range config.Networks {
+		if !isNetworkAllowed(server.AllowedNetworks, n) {
+			return fmt.Errorf("connecting to network %s is not allowed, it must be added to the allowed networks", n)
+		}
+	}
+
+	return nil
+}
+
+// parseResources converts the resource limits into the docker host config resources
+func parseResources(config ResourceConfig) (container.Resources, error) {
+	var resources container.Resources
+
+	if config.CPUs != "" {
+		cpus, err := strconv.ParseFloat(config.CPUs, 64)
+		if err != nil || cpus <= 0 {
+			return resources, fmt.Errorf("invalid resources.cpus %q", config.CPUs)
+		}
+		resources.NanoCPUs = int64(cpus * 1e9)
+	}
+
+	if config.Memory != "" {
+		memory, err := units.RAMInBytes(config.Memory)
+		if err != nil || memory <= 0 {
+			return resources, fmt.Errorf("invalid resources.memory %q", config.Memory)
+		}
+		resources.Memory = memory
+	}
+
+	return resources, nil
+}
+
+// volumeMounts creates bind mounts for the volumes, all sources must be in the allowed volumes
+func volumeMounts(config DockerWithConfig, server ServerConfig) ([]mount.Mount, error) {
+	mounts := make([]mount.Mount, 0, len(config.Volumes))
+	for i, v := range config.Volumes {
+		if !path.IsAbs(v.Source) || !path.IsAbs(v.Target) {
+			return nil, fmt.Errorf("volumes[%d]: source and target must be absolute paths", i)
+		}
+		if !isVolumeAllowed(server.AllowedVolumes, v.Source) {
+			return nil, fmt.Errorf("volumes[%d]: mounting %s is not allowed", i, v.Source)
+		}
+
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   path.Clean(v.Source),
+			Target:   path.Clean(v.Target),
+			ReadOnly: v.ReadOnly,
+		})
+	}
+	return mounts, nil
 }
 
 func (d *DockerExecutor) readTempFileContents(ctx context.Context, tempFile string) (io.Reader, error) {
@@ -216,23 +522,42 @@ func (d *DockerExecutor) readTempFileContents(ctx context.Context, tempFile stri
 }
 
 func (d *DockerExecutor) run(ctx context.Context) error {
-	if err := d.pullImage(ctx, d.client); err != nil {
+	imageAuth, err := d.imageRegistryAuth()
+	if err != nil {
+		return err
+	}
+
+	if len(d.services) > 0 {
+		teardown, err := d.startServices(ctx)
+		// Services that were started are removed even if others failed to start
+		defer teardown()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := d.ensureImage(ctx, d.client, d.image, imageAuth); err != nil {
 		return fmt.Errorf("could not pull image: %w", err)
 	}
 
 	resp, err := d.createContainer(ctx, d.client)
 	if err != nil {
+		if resp.ID != "" {
+			d.client.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+		}
 		return fmt.Errorf("unable to create container: %w", err)
 	}
 	d.containerID = resp.ID
 
-	// Only schedule removal if KeepContainer is false
+	// Only schedule removal if KeepContainer is false.
+	// The container is also removed when the execution is cancelled
 	if !d.dockerOptions.KeepContainer {
 		defer func() {
-			if ctx.Err() == nil {
-				if rErr := d.client.ContainerRemove(ctx, resp.ID, container.RemoveOptions{}); rErr != nil {
-					log.Printf("Error removing container: %v", rErr)
-				}
+			rmCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+			defer cancel()
+
+			if rErr := d.client.ContainerRemove(rmCtx, resp.ID, container.RemoveOptions{Force: true}); rErr != nil {
+				log.Printf("Error removing container: %v", rErr)
 			}
 		}()
 	}
@@ -284,8 +609,26 @@ func (d *DockerExecutor) run(ctx context.Context) error {
 	return nil
 }
 
-func (d *DockerExecutor) pullImage(ctx context.Context, cli *client.Client) error {
-	reader, err := cli.ImagePull(ctx, d.image, image.PullOptions{RegistryAuth: d.authConfig})
+// ensureImage pulls the image according to the pull policy
+func (d *DockerExecutor) ensureImage(ctx context.Context, cli *client.Client, ref string, registryAuth string) error {
+	switch d.pullPolicy {
+	case PullNever:
+		return nil
+	case PullIfNotPresent:
+		_, err := cli.ImageInspect(ctx, ref)
+		if err == nil {
+			return nil
+		}
+		if !errdefs.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return d.pullImage(ctx, cli, ref, registryAuth)
+}
+
+func (d *DockerExecutor) pullImage(ctx context.Context, cli *client.Client, ref string, registryAuth string) error {
+	reader, err := cli.ImagePull(ctx, ref, image.PullOptions{RegistryAuth: registryAuth})
 	if err != nil {
 		return err
 	}
@@ -315,24 +658,44 @@ func (d *DockerExecutor) createContainer(ctx context.Context, cli *client.Client
 	if d.dockerOptions.MountDockerSocket {
 		d.mounts = append(d.mounts, mount.Mount{
 			Type:   mount.TypeBind,
-			Source: "/var/run/docker.sock",
-			Target: "/var/run/docker.sock",
+			Source: dockerSocket,
+			Target: dockerSocket,
 		})
 	}
 
+	workingDir := d.workingDir
+	if workingDir == "" {
+		workingDir = WORKING_DIR
+	}
+
+	hostConfig := &container.HostConfig{
+		Mounts:      d.mounts,
+		SecurityOpt: []string{"label=disable"},
+		Resources:   d.resources,
+	}
+	// The container is created on the first network and connected to the others before it starts
+	if len(d.networks) > 0 {
+		hostConfig.NetworkMode = container.NetworkMode(d.networks[0])
+	}
+
 	resp, err := cli.ContainerCreate(ctx, &container.Config{
 		Image:      d.image,
 		Env:        d.env,
 		Entrypoint: d.entrypoint,
 		Cmd:        cmd,
-		WorkingDir: WORKING_DIR,
-	}, &container.HostConfig{
-		Mounts:      d.mounts,
-		SecurityOpt: []string{"label=disable"},
-	}, nil, nil, d.name)
+		WorkingDir: workingDir,
+		User:       d.user,
+	}, hostConfig, nil, nil, d.name)
 	if err != nil {
 		return container.CreateResponse{}, err
 	}
+
+	for _, n := range d.networks[min(1, len(d.networks)):] {
+		if err := cli.NetworkConnect(ctx, n, resp.ID, &network.EndpointSettings{}); err != nil {
+			return resp, fmt.Errorf("could not connect to network %s: %w", n, err)
+		}
+	}
+
 	return resp, nil
 }
 
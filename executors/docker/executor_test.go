@@ -0,0 +1,76 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+)
+
+func TestIsVolumeAllowed(t *testing.T) {
+	allowed := []string{"/srv/backups/", "/var/run/docker.sock"}
+
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{"/srv/backups", true},
+		{"/srv/backups/db", true},
+		{"/srv/backups/../secrets", false},
+		{"/srv/backups-old", false},
+		{"/srv", false},
+		{"/var/run/docker.sock", true},
+		{"/var/run", false},
+	}
+	for _, tt := range tests {
+		if got := isVolumeAllowed(allowed, tt.source); got != tt.want {
+			t.Errorf("isVolumeAllowed(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+
+	if !isVolumeAllowed([]string{"/"}, "/etc") {
+		t.Error("expected all paths to be allowed by /")
+	}
+	if isVolumeAllowed(nil, "/srv/backups") {
+		t.Error("expected no paths to be allowed by default")
+	}
+}
+
+func TestValidateNetworks(t *testing.T) {
+	server := ServerConfig{AllowedNetworks: []string{"monitoring"}}
+
+	tests := []struct {
+		network string
+		wantErr bool
+	}{
+		{"bridge", false},
+		{"none", false},
+		{"monitoring", false},
+		{"host", true},
+		{"container:flowctl", true},
+		{"flowctl_default", true},
+	}
+	for _, tt := range tests {
+		err := validateConfig(DockerWithConfig{Networks: []string{tt.network}}, server)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("network %s: error = %v, wantErr %v", tt.network, err, tt.wantErr)
+		}
+	}
+}
+
+func TestServerConfig(t *testing.T) {
+	if err := executor.SetServerConfig("docker", map[string]any{"allowed_volumes": []string{"/srv"}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { executor.SetServerConfig("docker", ServerConfig{}) })
+
+	var server ServerConfig
+	if err := executor.GetServerConfig("docker", &server); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateConfig(DockerWithConfig{MountDockerSocket: true}, server); err == nil {
+		t.Error("expected the docker socket to be refused when it is not allowed")
+	}
+	if _, err := volumeMounts(DockerWithConfig{Volumes: []VolumeConfig{{Source: "/srv/data", Target: "/data"}}}, server); err != nil {
+		t.Errorf("volumeMounts() error = %v", err)
+	}
+}
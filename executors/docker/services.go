@@ -0,0 +1,273 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+const (
+	defaultServiceWaitTimeout = 2 * time.Minute
+	serviceCheckInterval      = 500 * time.Millisecond
+	// serviceLogLines is the number of log lines shown when a service fails to start
+	serviceLogLines = "20"
+)
+
+// serviceNameRegex matches names that can be used as DNS names on the network
+var serviceNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+type ServiceConfig struct {
+	Name        string             `yaml:"name" json:"name" jsonschema:"title=name,description=Host name of the service on the network" jsonschema_extras:"placeholder=postgres"`
+	Image       string             `yaml:"image" json:"image" jsonschema:"title=image" jsonschema_extras:"placeholder=docker.io/postgres:16"`
+	Env         map[string]string  `yaml:"env,omitempty" json:"env,omitempty" jsonschema:"title=env,description=Environment variables of the service"`
+	Command     []string           `yaml:"command,omitempty" json:"command,omitempty" jsonschema:"title=command,description=Overrides the command of the image"`
+	HealthCheck *HealthCheckConfig `yaml:"healthcheck,omitempty" json:"healthcheck,omitempty" jsonschema:"title=healthcheck,description=Overrides the health check of the image"`
+	WaitTimeout string             `yaml:"wait_timeout,omitempty" json:"wait_timeout,omitempty" jsonschema:"title=wait timeout,description=Time to wait for the service to become healthy (default: 2m)" jsonschema_extras:"placeholder=2m"`
+}
+
+type HealthCheckConfig struct {
+	Test        []string `yaml:"test" json:"test" jsonschema:"title=test,description=Health check command in the docker format e.g. CMD-SHELL followed by the command"`
+	Interval    string   `yaml:"interval,omitempty" json:"interval,omitempty" jsonschema:"title=interval" jsonschema_extras:"placeholder=2s"`
+	Timeout     string   `yaml:"timeout,omitempty" json:"timeout,omitempty" jsonschema:"title=timeout" jsonschema_extras:"placeholder=5s"`
+	Retries     int      `yaml:"retries,omitempty" json:"retries,omitempty" jsonschema:"title=retries,minimum=0"`
+	StartPeriod string   `yaml:"start_period,omitempty" json:"start_period,omitempty" jsonschema:"title=start period" jsonschema_extras:"placeholder=0s"`
+}
+
+func validateServices(services []ServiceConfig) error {
+	names := make(map[string]bool, len(services))
+	for i, svc := range services {
+		if !serviceNameRegex.MatchString(svc.Name) {
+			return fmt.Errorf("services[%d]: invalid name %q", i, svc.Name)
+		}
+		if names[svc.Name] {
+			return fmt.Errorf("services[%d]: duplicate name %q", i, svc.Name)
+		}
+		names[svc.Name] = true
+
+		if svc.Image == "" {
+			return fmt.Errorf("services[%d]: image is required", i)
+		}
+		if _, err := parseServiceDuration(svc.WaitTimeout, defaultServiceWaitTimeout); err != nil {
+			return fmt.Errorf("services[%d]: invalid wait_timeout: %w", i, err)
+		}
+		if _, err := healthConfig(svc.HealthCheck); err != nil {
+			return fmt.Errorf("services[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// startServices creates a network for the execution and starts the services on it.
+// The action container is connected to the same network so that services can be reached by name.
+// The returned teardown function removes the services and the network and must always be called.
+func (d *DockerExecutor) startServices(ctx context.Context) (func(), error) {
+	networkName := fmt.Sprintf("flowctl-%s", d.name)
+	var containers []string
+	networkCreated := false
+
+	teardown := func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+
+		for _, id := range containers {
+			if err := d.client.ContainerRemove(cleanupCtx, id, container.RemoveOptions{Force: true}); err != nil {
+				log.Printf("Error removing service container %s: %v", id, err)
+			}
+		}
+
+		if !networkCreated {
+			return
+		}
+		// A kept action container would prevent the network from being removed
+		if d.containerID != "" && d.dockerOptions.KeepContainer {
+			d.client.NetworkDisconnect(cleanupCtx, networkName, d.containerID, true)
+		}
+		if err := d.client.NetworkRemove(cleanupCtx, networkName); err != nil {
+			log.Printf("Error removing network %s: %v", networkName, err)
+		}
+	}
+
+	if _, err := d.client.NetworkCreate(ctx, networkName, network.CreateOptions{
+		Driver: "bridge",
+		Labels: map[string]string{"flowctl.io/executor": d.name},
+	}); err != nil {
+		return teardown, fmt.Errorf("could not create network for services: %w", err)
+	}
+	networkCreated = true
+	d.networks = append([]string{networkName}, d.networks...)
+
+	for _, svc := range d.services {
+		id, err := d.startService(ctx, networkName, svc)
+		if id != "" {
+			containers = append(containers, id)
+		}
+		if err != nil {
+			return teardown, fmt.Errorf("could not start service %s: %w", svc.Name, err)
+		}
+	}
+
+	for i, svc := range d.services {
+		fmt.Fprintf(d.stdout, "waiting for service %s\n", svc.Name)
+		if err := d.waitForService(ctx, containers[i], svc); err != nil {
+			if ctx.Err() != nil {
+				return teardown, ctx.Err()
+			}
+			d.showServiceLogs(containers[i])
+			return teardown, fmt.Errorf("service %s is not ready: %w", svc.Name, err)
+		}
+		fmt.Fprintf(d.stdout, "service %s is ready\n", svc.Name)
+	}
+
+	return teardown, nil
+}
+
+// startService creates and starts a service container, the container id is returned if it was created
+func (d *DockerExecutor) startService(ctx context.Context, networkName string, svc ServiceConfig) (string, error) {
+	if err := d.ensureImage(ctx, d.client, svc.Image, d.registryAuthFor(svc.Image)); err != nil {
+		return "", fmt.Errorf("could not pull image: %w", err)
+	}
+
+	env := make([]string, 0, len(svc.Env))
+	for k, v := range svc.Env {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	health, _ := healthConfig(svc.HealthCheck)
+
+	resp, err := d.client.ContainerCreate(ctx, &container.Config{
+		Image:       svc.Image,
+		Env:         env,
+		Cmd:         svc.Command,
+		Healthcheck: health,
+	}, &container.HostConfig{
+		NetworkMode: container.NetworkMode(networkName),
+	}, &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			networkName: {Aliases: []string{svc.Name}},
+		},
+	}, nil, fmt.Sprintf("%s-%s", d.name, svc.Name))
+	if err != nil {
+		return "", err
+	}
+
+	if err := d.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return resp.ID, err
+	}
+	return resp.ID, nil
+}
+
+// waitForService waits until the service is healthy.
+// Services without a health check are ready once they are running
+func (d *DockerExecutor) waitForService(ctx context.Context, id string, svc ServiceConfig) error {
+	timeout, _ := parseServiceDuration(svc.WaitTimeout, defaultServiceWaitTimeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(serviceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		info, err := d.client.ContainerInspect(ctx, id)
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+
+		state := info.State
+		switch {
+		case err != nil, state == nil:
+		case !state.Running:
+			if state.Status == container.StateExited || state.Status == container.StateDead {
+				return fmt.Errorf("container exited with code %d", state.ExitCode)
+			}
+		case state.Health == nil:
+			return nil
+		case state.Health.Status == container.Healthy:
+			return nil
+		case state.Health.Status == container.Unhealthy:
+			return errors.New("health check failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("timed out after %s", timeout)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// showServiceLogs writes the last lines of the service logs to stderr to help debug startup failures
+func (d *DockerExecutor) showServiceLogs(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	logs, err := d.client.ContainerLogs(ctx, id, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       serviceLogLines,
+	})
+	if err != nil {
+		return
+	}
+	defer logs.Close()
+
+	stderr := d.stderr
+	if stderr == nil {
+		stderr = io.Discard
+	}
+	stdcopy.StdCopy(stderr, stderr, logs)
+}
+
+func healthConfig(config *HealthCheckConfig) (*container.HealthConfig, error) {
+	if config == nil {
+		return nil, nil
+	}
+	if len(config.Test) == 0 {
+		return nil, errors.New("healthcheck.test is required")
+	}
+
+	interval, err := parseServiceDuration(config.Interval, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid healthcheck.interval: %w", err)
+	}
+	timeout, err := parseServiceDuration(config.Timeout, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid healthcheck.timeout: %w", err)
+	}
+	startPeriod, err := parseServiceDuration(config.StartPeriod, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid healthcheck.start_period: %w", err)
+	}
+
+	return &container.HealthConfig{
+		Test:        config.Test,
+		Interval:    interval,
+		Timeout:     timeout,
+		Retries:     config.Retries,
+		StartPeriod: startPeriod,
+	}, nil
+}
+
+func parseServiceDuration(value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, errors.New("duration cannot be negative")
+	}
+	return d, nil
+}
@@ -0,0 +1,192 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/client"
+)
+
+// fakeDocker serves the parts of the docker API used to start services. Starting the container
+// of the service named in failStart fails, creating the network fails when failNetwork is set and
+// creating the container named failCreate fails
+type fakeDocker struct {
+	mu          sync.Mutex
+	failStart   string
+	failNetwork bool
+	failCreate  string
+	pulls       map[string]string
+	removed     []string
+}
+
+func (f *fakeDocker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := r.URL.Path[strings.Index(r.URL.Path[1:], "/")+1:]
+	switch {
+	case r.Method == http.MethodPost && path == "/networks/create":
+		if f.failNetwork {
+			http.Error(w, `{"message": "pool overlaps with other one on this address space"}`, http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"Id": "net"})
+	case r.Method == http.MethodPost && path == "/images/create":
+		f.pulls[r.URL.Query().Get("fromImage")] = r.Header.Get("X-Registry-Auth")
+		w.Write([]byte("{}"))
+	case r.Method == http.MethodPost && path == "/containers/create":
+		if r.URL.Query().Get("name") == f.failCreate {
+			http.Error(w, `{"message": "no space left on device"}`, http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"Id": r.URL.Query().Get("name")})
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/start"):
+		if strings.HasSuffix(strings.TrimSuffix(path, "/start"), "-"+f.failStart) {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"message": "port is already allocated"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		f.removed = append(f.removed, path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+path, http.StatusNotFound)
+	}
+}
+
+func newFakeDockerExecutor(t *testing.T, fake *fakeDocker, services []ServiceConfig) *DockerExecutor {
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+srv.Listener.Addr().String()), client.WithVersion("1.45"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cli.Close() })
+
+	return &DockerExecutor{
+		name:       "exec",
+		client:     cli,
+		services:   services,
+		pullPolicy: PullAlways,
+		stdout:     io.Discard,
+		stderr:     io.Discard,
+	}
+}
+
+func TestStartServicesTeardownOnFailure(t *testing.T) {
+	fake := &fakeDocker{failStart: "db", pulls: make(map[string]string)}
+	d := newFakeDockerExecutor(t, fake, []ServiceConfig{
+		{Name: "cache", Image: "ghcr.io/acme/cache:1"},
+		{Name: "db", Image: "postgres:16"},
+		{Name: "queue", Image: "ghcr.io/acme/queue:1"},
+	})
+	d.withCredentials("user", "token", "https://ghcr.io")
+
+	teardown, err := d.startServices(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "could not start service db") {
+		t.Fatalf("startServices() error = %v", err)
+	}
+	teardown()
+
+	// The started service, the service which failed to start and the network are removed
+	want := []string{"/containers/exec-cache", "/containers/exec-db", "/networks/flowctl-exec"}
+	if !slices.Equal(fake.removed, want) {
+		t.Errorf("removed %v, want %v", fake.removed, want)
+	}
+
+	// The registry credential is only sent to its registry
+	if fake.pulls["ghcr.io/acme/cache"] != d.authConfig {
+		t.Errorf("service image of the credential registry was pulled without the credential")
+	}
+	if auth, ok := fake.pulls["docker.io/library/postgres"]; !ok || auth != "" {
+		t.Errorf("service image of another registry was pulled with auth %q: %v", auth, fake.pulls)
+	}
+	if _, ok := fake.pulls["ghcr.io/acme/queue"]; ok {
+		t.Errorf("service was started after an earlier service failed")
+	}
+}
+
+func TestStartServicesTeardownNetworkFailure(t *testing.T) {
+	fake := &fakeDocker{failNetwork: true, pulls: make(map[string]string)}
+	d := newFakeDockerExecutor(t, fake, []ServiceConfig{{Name: "cache", Image: "redis:7"}})
+
+	teardown, err := d.startServices(context.Background())
+	if err == nil {
+		t.Fatal("startServices() succeeded without a network")
+	}
+	teardown()
+
+	// Nothing was created so nothing is removed
+	if len(fake.removed) != 0 || len(fake.pulls) != 0 {
+		t.Errorf("removed %v and pulled %v after the network could not be created", fake.removed, fake.pulls)
+	}
+}
+
+func TestRegistryAuthFor(t *testing.T) {
+	tests := []struct {
+		serverAddress string
+		image         string
+		want          bool
+	}{
+		{"", "alpine", true},
+		{"", "docker.io/library/postgres:16", true},
+		{"https://index.docker.io/v1/", "postgres", true},
+		{"", "ghcr.io/acme/app", false},
+		{"ghcr.io", "ghcr.io/acme/app:1", true},
+		{"https://ghcr.io", "postgres", false},
+		{"registry.local:5000", "registry.local:5000/app", true},
+		{"registry.local:5000", "registry.local/app", false},
+	}
+
+	for _, tt := range tests {
+		d := (&DockerExecutor{}).withCredentials("user", "token", tt.serverAddress)
+		if got := d.registryAuthFor(tt.image) != ""; got != tt.want {
+			t.Errorf("registryAuthFor(%q) with credential for %q = %v, want %v", tt.image, tt.serverAddress, got, tt.want)
+		}
+	}
+
+	if auth := (&DockerExecutor{}).registryAuthFor("alpine"); auth != "" {
+		t.Errorf("registryAuthFor() without a credential = %q", auth)
+	}
+}
+
+func TestRunImageRegistryAuth(t *testing.T) {
+	fake := &fakeDocker{failCreate: "exec", pulls: make(map[string]string)}
+	d := newFakeDockerExecutor(t, fake, nil)
+	d.image = "ghcr.io/acme/app:1"
+	d.withCredentials("user", "token", "https://ghcr.io")
+
+	// The run stops once the image is pulled
+	if err := d.run(context.Background()); err == nil || !strings.Contains(err.Error(), "unable to create container") {
+		t.Fatalf("run() error = %v", err)
+	}
+	if auth, ok := fake.pulls["ghcr.io/acme/app"]; !ok || auth != d.authConfig {
+		t.Errorf("image of the credential registry was pulled with auth %q: %v", auth, fake.pulls)
+	}
+}
+
+func TestRunImageOfAnotherRegistry(t *testing.T) {
+	fake := &fakeDocker{pulls: make(map[string]string)}
+	d := newFakeDockerExecutor(t, fake, []ServiceConfig{{Name: "db", Image: "ghcr.io/acme/db:1"}})
+	d.image = "registry.example.com/app:1"
+	d.withCredentials("user", "token", "ghcr.io")
+
+	err := d.run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "registry credential is for registry ghcr.io and cannot be used to pull image registry.example.com/app:1") {
+		t.Fatalf("run() error = %v", err)
+	}
+	if len(fake.pulls) != 0 || len(fake.removed) != 0 {
+		t.Errorf("pulled %v and removed %v before the credential was checked", fake.pulls, fake.removed)
+	}
+}
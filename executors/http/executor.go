@@ -0,0 +1,329 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	nethttp "net/http"
+	neturl "net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+	"github.com/invopop/jsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultTimeout    = 30 * time.Second
+	defaultRetryDelay = time.Second
+	// maxResponseSize limits the response body read into memory
+	maxResponseSize = 10 * 1024 * 1024
+	// maxLoggedResponseSize limits the part of the response body written to the logs
+	maxLoggedResponseSize = 4 * 1024
+)
+
+type HTTPWithConfig struct {
+	Method         string            `yaml:"method,omitempty" json:"method,omitempty" jsonschema:"title=method,description=HTTP method (default: GET),enum=GET,enum=POST,enum=PUT,enum=PATCH,enum=DELETE,enum=HEAD,enum=OPTIONS"`
+	URL            string            `yaml:"url" json:"url" jsonschema:"title=url,description=Request URL. Variables can be referenced as $name or ${name}" jsonschema_extras:"placeholder=https://api.example.com/deploy"`
+	Headers        map[string]string `yaml:"headers,omitempty" json:"headers,omitempty" jsonschema:"title=headers,description=Request headers. Variables can be referenced in values"`
+	Body           string            `yaml:"body,omitempty" json:"body,omitempty" jsonschema:"title=body,description=Request body. Variables can be referenced as $name or ${name}" jsonschema_extras:"widget=codeeditor"`
+	Timeout        string            `yaml:"timeout,omitempty" json:"timeout,omitempty" jsonschema:"title=timeout,description=Timeout for each attempt (default: 30s)" jsonschema_extras:"placeholder=30s"`
+	ExpectedStatus []int             `yaml:"expected_status,omitempty" json:"expected_status,omitempty" jsonschema:"title=expected status,description=Status codes treated as success (default: any 2xx)"`
+	Outputs        map[string]string `yaml:"outputs,omitempty" json:"outputs,omitempty" jsonschema:"title=outputs,description=Map of output names to JSON paths in the response body e.g. data.items[0].id"`
+	Retries        int               `yaml:"retries,omitempty" json:"retries,omitempty" jsonschema:"title=retries,description=Number of times a failed request is retried,minimum=0"`
+	RetryDelay     string            `yaml:"retry_delay,omitempty" json:"retry_delay,omitempty" jsonschema:"title=retry delay,description=Delay between retries (default: 1s)" jsonschema_extras:"placeholder=1s"`
+	RetryAll       bool              `yaml:"retry_non_idempotent,omitempty" json:"retry_non_idempotent,omitempty" jsonschema:"title=retry non idempotent,description=Also retry POST and PATCH requests which could be applied more than once"`
+	LogResponse    bool              `yaml:"log_response,omitempty" json:"log_response,omitempty" jsonschema:"title=log response,description=Write the response body to the logs. Long bodies are truncated"`
+	TLS            TLSConfig         `yaml:"tls,omitempty" json:"tls,omitempty" jsonschema:"title=tls"`
+}
+
+type TLSConfig struct {
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty" json:"insecure_skip_verify,omitempty" jsonschema:"title=insecure skip verify,description=Skip verification of the server certificate"`
+	ServerName         string `yaml:"server_name,omitempty" json:"server_name,omitempty" jsonschema:"title=server name,description=Server name used to verify the certificate"`
+	CACert             string `yaml:"ca_cert,omitempty" json:"ca_cert,omitempty" jsonschema:"title=ca cert,description=PEM encoded CA certificate used to verify the server" jsonschema_extras:"widget=codeeditor"`
+	ClientCert         string `yaml:"client_cert,omitempty" json:"client_cert,omitempty" jsonschema:"title=client cert,description=PEM encoded client certificate" jsonschema_extras:"widget=codeeditor"`
+	ClientKey          string `yaml:"client_key,omitempty" json:"client_key,omitempty" jsonschema:"title=client key,description=PEM encoded client key" jsonschema_extras:"widget=codeeditor"`
+}
+
+type HTTPExecutor struct {
+	name   string
+	stdout io.Writer
+	stderr io.Writer
+	driver executor.NodeDriver
+}
+
+func init() {
+	executor.RegisterExecutor("http", NewHTTPExecutor)
+	executor.RegisterSchema("http", GetSchema())
+}
+
+func GetSchema() interface{} {
+	return jsonschema.Reflect(&HTTPWithConfig{})
+}
+
+func NewHTTPExecutor(name string, driver executor.NodeDriver) (executor.Executor, error) {
+	return &HTTPExecutor{
+		name:   name,
+		driver: driver,
+	}, nil
+}
+
+func (h *HTTPExecutor) Execute(ctx context.Context, execCtx executor.ExecutionContext) (map[string]any, error) {
+	var config HTTPWithConfig
+	if err := yaml.Unmarshal(execCtx.WithConfig, &config); err != nil {
+		return nil, fmt.Errorf("could not read config for http executor %s: %w", h.name, err)
+	}
+
+	h.stdout = execCtx.Stdout
+	h.stderr = execCtx.Stderr
+
+	if config.Method == "" {
+		config.Method = nethttp.MethodGet
+	}
+	config.Method = strings.ToUpper(config.Method)
+
+	timeout, err := parseDuration(config.Timeout, defaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout: %w", err)
+	}
+	retryDelay, err := parseDuration(config.RetryDelay, defaultRetryDelay)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry_delay: %w", err)
+	}
+
+	client, err := h.newClient(config.TLS, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer client.CloseIdleConnections()
+
+	// Templated values are expanded with the action variables
+	url := executor.Expand(config.URL, execCtx.Inputs)
+	body := executor.Expand(config.Body, execCtx.Inputs)
+	headers := make(map[string]string, len(config.Headers))
+	for k, v := range config.Headers {
+		headers[k] = executor.Expand(v, execCtx.Inputs)
+	}
+
+	// Requests which are not idempotent are only retried when enabled, a failed attempt could have been applied
+	retries := config.Retries
+	if !config.RetryAll && !isIdempotent(config.Method) {
+		retries = 0
+	}
+
+	var (
+		status  int
+		resBody []byte
+	)
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			fmt.Fprintf(h.stderr, "retrying in %s (attempt %d of %d)\n", retryDelay, attempt, retries)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(retryDelay):
+			}
+		}
+
+		status, resBody, err = h.do(ctx, client, config.Method, url, config.URL, headers, body, config.LogResponse)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			fmt.Fprintf(h.stderr, "request failed: %v\n", err)
+			continue
+		}
+
+		if isExpectedStatus(status, config.ExpectedStatus) {
+			break
+		}
+		err = fmt.Errorf("unexpected status code %d", status)
+		fmt.Fprintf(h.stderr, "%v\n", err)
+	}
+	if err != nil {
+		if retries != config.Retries {
+			fmt.Fprintf(h.stderr, "%s requests are not retried, set retry_non_idempotent to retry them\n", config.Method)
+		}
+		return nil, fmt.Errorf("%s %s failed: %w", config.Method, config.URL, err)
+	}
+
+	outputs := map[string]any{
+		"status_code": status,
+	}
+	if len(config.Outputs) == 0 {
+		return outputs, nil
+	}
+
+	var doc any
+	if err := json.Unmarshal(resBody, &doc); err != nil {
+		return nil, fmt.Errorf("could not extract outputs, response is not valid JSON: %w", err)
+	}
+	for name, path := range config.Outputs {
+		value, err := extractJSONPath(doc, path)
+		if err != nil {
+			return nil, fmt.Errorf("could not extract output %s: %w", name, err)
+		}
+		outputs[name] = value
+	}
+
+	return outputs, nil
+}
+
+// do sends a single request and logs the response status, and the body when logBody is set.
+// The unexpanded URL is logged so that secrets passed as variables are not written to the logs
+func (h *HTTPExecutor) do(ctx context.Context, client *nethttp.Client, method, url, displayURL string, headers map[string]string, body string, logBody bool) (int, []byte, error) {
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+
+	req, err := nethttp.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return 0, nil, fmt.Errorf("could not create request: %w", stripURL(err))
+	}
+	for k, v := range headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	fmt.Fprintf(h.stdout, "%s %s\n", method, displayURL)
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, nil, stripURL(err)
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if err != nil {
+		return 0, nil, fmt.Errorf("could not read response: %w", err)
+	}
+
+	fmt.Fprintf(h.stdout, "%s\n", res.Status)
+	if logBody {
+		writeBody(h.stdout, resBody)
+	}
+
+	return res.StatusCode, resBody, nil
+}
+
+// stripURL removes the request URL from errors of the url package. The URL is expanded with the inputs
+// and can contain secrets, only the URL from the config is logged
+func stripURL(err error) error {
+	var urlErr *neturl.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// writeBody writes a response body to the logs, bodies longer than maxLoggedResponseSize are truncated
+func writeBody(w io.Writer, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+
+	truncated := len(body) > maxLoggedResponseSize
+	if truncated {
+		body = body[:maxLoggedResponseSize]
+	}
+	w.Write(body)
+	if !bytes.HasSuffix(body, []byte("\n")) {
+		fmt.Fprintln(w)
+	}
+	if truncated {
+		fmt.Fprintf(w, "... response truncated to %d bytes\n", maxLoggedResponseSize)
+	}
+}
+
+// newClient creates an HTTP client. On remote nodes, connections are tunnelled through the node
+// so that the request originates from the node's network.
+func (h *HTTPExecutor) newClient(config TLSConfig, timeout time.Duration) (*nethttp.Client, error) {
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &nethttp.Transport{
+		Proxy:               nethttp.ProxyFromEnvironment,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: timeout,
+	}
+
+	if h.driver.IsRemote() {
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return h.driver.Dial(network, addr)
+		}
+	}
+
+	return &nethttp.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}, nil
+}
+
+func newTLSConfig(config TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.InsecureSkipVerify,
+		ServerName:         config.ServerName,
+	}
+
+	if config.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(config.CACert)) {
+			return nil, errors.New("could not parse tls.ca_cert")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCert != "" || config.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(config.ClientCert), []byte(config.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// isIdempotent reports whether sending the request more than once has the same effect as sending it once
+func isIdempotent(method string) bool {
+	switch method {
+	case nethttp.MethodGet, nethttp.MethodHead, nethttp.MethodOptions, nethttp.MethodPut, nethttp.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isExpectedStatus(status int, expected []int) bool {
+	if len(expected) == 0 {
+		return status >= 200 && status < 300
+	}
+	return slices.Contains(expected, status)
+}
+
+func parseDuration(value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duration cannot be negative")
+	}
+	return d, nil
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+)
+
+func newTestExecutor(t *testing.T) *HTTPExecutor {
+	driver, err := executor.NewLocalLinux()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(driver.GetWorkingDirectory()) })
+	return &HTTPExecutor{name: "http", driver: driver}
+}
+
+func execute(t *testing.T, config string, inputs map[string]any) (map[string]any, string, error) {
+	t.Helper()
+	var stdout bytes.Buffer
+	outputs, err := newTestExecutor(t).Execute(context.Background(), executor.ExecutionContext{
+		WithConfig: []byte(config),
+		Inputs:     inputs,
+		Stdout:     &stdout,
+		Stderr:     io.Discard,
+	})
+	return outputs, stdout.String(), err
+}
+
+func TestExecuteOutputs(t *testing.T) {
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.Method != nethttp.MethodPost || r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(nethttp.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(nethttp.StatusCreated)
+		fmt.Fprintf(w, `{"data": {"id": "d1", "request": %s}}`, body)
+	}))
+	defer srv.Close()
+
+	config := fmt.Sprintf(`
+method: post
+url: %s/deployments
+headers:
+  Authorization: Bearer $token
+body: '{"version": "${version}"}'
+expected_status: [201]
+outputs:
+  deployment_id: data.id
+  version: data.request.version
+`, srv.URL)
+	outputs, logs, err := execute(t, config, map[string]any{"token": "s3cret", "version": "1.2"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if outputs["status_code"] != 201 || outputs["deployment_id"] != "d1" || outputs["version"] != "1.2" {
+		t.Errorf("unexpected outputs %v", outputs)
+	}
+	if strings.Contains(logs, "d1") {
+		t.Errorf("response body was logged without log_response: %q", logs)
+	}
+}
+
+func TestExecuteLogResponse(t *testing.T) {
+	body := strings.Repeat("a", maxLoggedResponseSize+100)
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	_, logs, err := execute(t, fmt.Sprintf("url: %s\nlog_response: true\n", srv.URL), nil)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if !strings.Contains(logs, body[:maxLoggedResponseSize]+"\n... response truncated") {
+		t.Errorf("response body was not truncated in the logs")
+	}
+	if strings.Contains(logs, body[:maxLoggedResponseSize+1]) {
+		t.Errorf("more than %d bytes of the response were logged", maxLoggedResponseSize)
+	}
+}
+
+func TestExecuteRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       string
+		wantAttempts int32
+	}{
+		{name: "get", config: "method: GET\nretries: 2", wantAttempts: 3},
+		{name: "delete", config: "method: DELETE\nretries: 1", wantAttempts: 2},
+		{name: "post", config: "method: POST\nretries: 2", wantAttempts: 1},
+		{name: "patch", config: "method: PATCH\nretries: 2", wantAttempts: 1},
+		{name: "post with retry_non_idempotent", config: "method: POST\nretries: 2\nretry_non_idempotent: true", wantAttempts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+				attempts.Add(1)
+				w.WriteHeader(nethttp.StatusServiceUnavailable)
+			}))
+			defer srv.Close()
+
+			config := fmt.Sprintf("url: %s\nretry_delay: 1ms\n%s\n", srv.URL, tt.config)
+			if _, _, err := execute(t, config, nil); err == nil || !strings.Contains(err.Error(), "unexpected status code 503") {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("sent %d requests, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestExecuteErrorsHideURL(t *testing.T) {
+	srv := httptest.NewServer(nethttp.NotFoundHandler())
+	srv.Close()
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{name: "connection refused", url: srv.URL + "/hooks/$token?key=$token"},
+		{name: "invalid url", url: "http://example.com/%zz/$token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			_, err := newTestExecutor(t).Execute(context.Background(), executor.ExecutionContext{
+				WithConfig: []byte(fmt.Sprintf("url: %s\n", tt.url)),
+				Inputs:     map[string]any{"token": "s3cret"},
+				Stdout:     &stdout,
+				Stderr:     &stderr,
+			})
+			if err == nil {
+				t.Fatal("Execute() succeeded")
+			}
+			if !strings.Contains(err.Error(), tt.url) {
+				t.Errorf("Execute() error = %v, want the unexpanded URL", err)
+			}
+			for _, s := range []string{err.Error(), stdout.String(), stderr.String()} {
+				if strings.Contains(s, "s3cret") {
+					t.Errorf("expanded URL was leaked: %q", s)
+				}
+			}
+		})
+	}
+}
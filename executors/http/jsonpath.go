@@ -0,0 +1,74 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// extractJSONPath returns the value at path in the decoded JSON document.
+// Paths are dot separated field names with optional array indexes, e.g. data.items[0].id.
+// A leading "$." is accepted. Values keep their JSON type.
+func extractJSONPath(doc any, path string) (any, error) {
+	tokens, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for i, token := range tokens {
+		switch v := current.(type) {
+		case map[string]any:
+			next, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("field %q not found at %s", token, strings.Join(tokens[:i], "."))
+			}
+			current = next
+		case []any:
+			idx, err := strconv.Atoi(token)
+			if err != nil {
+				return nil, fmt.Errorf("expected array index at %q", token)
+			}
+			if idx < 0 || idx >= len(v) {
+				return nil, fmt.Errorf("index %d out of range, array has %d elements", idx, len(v))
+			}
+			current = v[idx]
+		default:
+			return nil, fmt.Errorf("cannot access %q on a %T value", token, current)
+		}
+	}
+
+	return current, nil
+}
+
+// parseJSONPath splits a path like $.data.items[0].id into [data items 0 id]
+func parseJSONPath(path string) ([]string, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return nil, nil
+	}
+
+	var tokens []string
+	for _, part := range strings.Split(path, ".") {
+		name, rest, _ := strings.Cut(part, "[")
+		if name != "" {
+			tokens = append(tokens, name)
+		}
+		for rest != "" {
+			idx, after, ok := strings.Cut(rest, "]")
+			if !ok || idx == "" {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+			tokens = append(tokens, idx)
+			rest = strings.TrimPrefix(after, "[")
+			if after != "" && !strings.HasPrefix(after, "[") {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+		}
+		if name == "" && !strings.Contains(part, "[") {
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "", want: nil},
+		{path: "$", want: nil},
+		{path: "id", want: []string{"id"}},
+		{path: "$.data.id", want: []string{"data", "id"}},
+		{path: "data.items[0].id", want: []string{"data", "items", "0", "id"}},
+		{path: "matrix[1][2]", want: []string{"matrix", "1", "2"}},
+		{path: "[0].name", want: []string{"0", "name"}},
+		{path: "data..id", wantErr: true},
+		{path: "items[0", wantErr: true},
+		{path: "items[]", wantErr: true},
+		{path: "items[0]name", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseJSONPath(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseJSONPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseJSONPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExtractJSONPath(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(`{"data": {"id": "d1", "items": [{"id": 7, "tags": ["a", "b"]}], "ready": true, "none": null}}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		want    any
+		wantErr bool
+	}{
+		{path: "data.id", want: "d1"},
+		{path: "data.items[0].id", want: float64(7)},
+		{path: "data.items[0].tags[1]", want: "b"},
+		{path: "data.ready", want: true},
+		{path: "data.none", want: nil},
+		{path: "data.items[0].tags", want: []any{"a", "b"}},
+		{path: "data.missing", wantErr: true},
+		{path: "data.items[1]", wantErr: true},
+		{path: "data.items.id", wantErr: true},
+		{path: "data.id.length", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := extractJSONPath(doc, tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("extractJSONPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("extractJSONPath(%q) = %#v, want %#v", tt.path, got, tt.want)
+		}
+	}
+}
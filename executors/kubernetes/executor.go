@@ -0,0 +1,365 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+	"github.com/gosimple/slug"
+	"github.com/invopop/jsonschema"
+	"github.com/rs/xid"
+	"gopkg.in/yaml.v3"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	// CredentialType is the key type of credentials holding a kubeconfig
+	CredentialType = "kubeconfig"
+
+	outputPath       = "/tmp/flow/output"
+	defaultNamespace = "default"
+	containerName    = "flow"
+	execIDLabel      = "flowctl.io/exec-id"
+
+	cleanupTimeout = 30 * time.Second
+	// Finished jobs are removed by flowctl, this is a fallback if cleanup fails
+	jobTTLSeconds = int32(3600)
+)
+
+// podPollInterval is how often the pod of the job is checked
+var podPollInterval = time.Second
+
+type KubernetesWithConfig struct {
+	Image          string            `yaml:"image" json:"image" jsonschema:"title=image,description=Container image" jsonschema_extras:"placeholder=docker.io/alpine:latest"`
+	Script         string            `yaml:"script" json:"script" jsonschema:"title=script" jsonschema_extras:"widget=codeeditor"`
+	Namespace      string            `yaml:"namespace,omitempty" json:"namespace,omitempty" jsonschema:"title=namespace,description=Kubernetes namespace for the job (default: default)"`
+	Kubeconfig     string            `yaml:"kubeconfig,omitempty" json:"kubeconfig,omitempty" jsonschema:"title=kubeconfig,description=Name of a kubeconfig credential. The in-cluster config is used if empty"`
+	Context        string            `yaml:"context,omitempty" json:"context,omitempty" jsonschema:"title=context,description=Context to use from the kubeconfig"`
+	ServiceAccount string            `yaml:"service_account,omitempty" json:"service_account,omitempty" jsonschema:"title=service account"`
+	Resources      ResourceConfig    `yaml:"resources,omitempty" json:"resources,omitempty" jsonschema:"title=resources"`
+	NodeSelector   map[string]string `yaml:"node_selector,omitempty" json:"node_selector,omitempty" jsonschema:"title=node selector"`
+}
+
+type ResourceConfig struct {
+	CPU    string `yaml:"cpu,omitempty" json:"cpu,omitempty" jsonschema:"title=cpu,description=CPU limit e.g. 500m" jsonschema_extras:"placeholder=500m"`
+	Memory string `yaml:"memory,omitempty" json:"memory,omitempty" jsonschema:"title=memory,description=Memory limit e.g. 256Mi" jsonschema_extras:"placeholder=256Mi"`
+}
+
+// ClientFunc creates a kubernetes client from the kubeconfig contents.
+// An empty kubeconfig means the in-cluster config should be used
+type ClientFunc func(kubeconfig []byte, kubeContext string) (kubernetes.Interface, error)
+
+type KubernetesExecutor struct {
+	name      string
+	jobName   string
+	stdout    io.Writer
+	stderr    io.Writer
+	newClient ClientFunc
+}
+
+func init() {
+	executor.RegisterExecutor("kubernetes", NewKubernetesExecutor)
+	executor.RegisterSchema("kubernetes", GetSchema())
+}
+
+func GetSchema() interface{} {
+	return jsonschema.Reflect(&KubernetesWithConfig{})
+}
+
+func NewKubernetesExecutor(name string, driver executor.NodeDriver) (executor.Executor, error) {
+	return NewKubernetesExecutorWithClient(name, newClient), nil
+}
+
+// NewKubernetesExecutorWithClient creates an executor that uses newClient to connect to the cluster
+func NewKubernetesExecutorWithClient(name string, newClient ClientFunc) *KubernetesExecutor {
+	prefix := slug.Make(name)
+	if len(prefix) > 30 {
+		prefix = strings.TrimRight(prefix[:30], "-")
+	}
+
+	return &KubernetesExecutor{
+		name:      name,
+		jobName:   fmt.Sprintf("flowctl-%s-%s", prefix, xid.New().String()),
+		newClient: newClient,
+	}
+}
+
+// newClient creates a clientset from the kubeconfig, falling back to the in-cluster config
+func newClient(kubeconfig []byte, kubeContext string) (kubernetes.Interface, error) {
+	var (
+		config *rest.Config
+		err    error
+	)
+	if len(kubeconfig) == 0 {
+		config, err = rest.InClusterConfig()
+	} else {
+		var apiConfig *clientcmdapi.Config
+		apiConfig, err = clientcmd.Load(kubeconfig)
+		if err == nil {
+			config, err = clientcmd.NewDefaultClientConfig(*apiConfig, &clientcmd.ConfigOverrides{CurrentContext: kubeContext}).ClientConfig()
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not load kubernetes config: %w", err)
+	}
+
+	return kubernetes.NewForConfig(config)
+}
+
+func (k *KubernetesExecutor) Execute(ctx context.Context, execCtx executor.ExecutionContext) (map[string]any, error) {
+	var config KubernetesWithConfig
+	if err := yaml.Unmarshal(execCtx.WithConfig, &config); err != nil {
+		return nil, fmt.Errorf("could not read config for kubernetes executor %s: %w", k.name, err)
+	}
+
+	k.stdout = execCtx.Stdout
+	k.stderr = execCtx.Stderr
+
+	if config.Namespace == "" {
+		config.Namespace = defaultNamespace
+	}
+
+	kubeconfig, err := k.getKubeconfig(ctx, config.Kubeconfig, execCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := k.newClient(kubeconfig, config.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := k.newJob(config, execCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := client.BatchV1().Jobs(config.Namespace).Create(ctx, job, metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("could not create job: %w", err)
+	}
+	fmt.Fprintf(k.stdout, "created job %s/%s\n", config.Namespace, k.jobName)
+
+	// The job is removed even if the execution is cancelled, deleting it also stops the pod
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+
+		policy := metav1.DeletePropagationBackground
+		if err := client.BatchV1().Jobs(config.Namespace).Delete(cleanupCtx, k.jobName, metav1.DeleteOptions{PropagationPolicy: &policy}); err != nil {
+			log.Printf("could not delete job %s/%s: %v", config.Namespace, k.jobName, err)
+		}
+	}()
+
+	pod, err := k.waitForPod(ctx, client, config.Namespace, podStarted)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := k.streamLogs(ctx, client, config.Namespace, pod.Name); err != nil {
+		return nil, err
+	}
+
+	pod, err = k.waitForPod(ctx, client, config.Namespace, podFinished)
+	if err != nil {
+		return nil, err
+	}
+
+	state := containerState(pod)
+	if state == nil || state.Terminated == nil {
+		return nil, fmt.Errorf("pod %s finished with phase %s", pod.Name, pod.Status.Phase)
+	}
+	if state.Terminated.ExitCode != 0 {
+		return nil, fmt.Errorf("container exited with code %d", state.Terminated.ExitCode)
+	}
+
+	return executor.ParseOutputs(strings.NewReader(state.Terminated.Message), nil)
+}
+
+// getKubeconfig reads the kubeconfig credential, an empty name selects the in-cluster config
+func (k *KubernetesExecutor) getKubeconfig(ctx context.Context, name string, execCtx executor.ExecutionContext) ([]byte, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if execCtx.GetCredential == nil {
+		return nil, errors.New("credentials are not available to the kubernetes executor")
+	}
+
+	cred, err := execCtx.GetCredential(ctx, name, CredentialType)
+	if err != nil {
+		return nil, fmt.Errorf("could not get kubeconfig credential %s: %w", name, err)
+	}
+	if cred.KeyType != CredentialType {
+		return nil, fmt.Errorf("credential %s is of type %s, expected %s", name, cred.KeyType, CredentialType)
+	}
+
+	return []byte(cred.KeyData), nil
+}
+
+func (k *KubernetesExecutor) newJob(config KubernetesWithConfig, execCtx executor.ExecutionContext) (*batchv1.Job, error) {
+	env := make([]corev1.EnvVar, 0, len(execCtx.Inputs)+1)
+	for name, value := range execCtx.Inputs {
+		env = append(env, corev1.EnvVar{Name: name, Value: executor.FormatValue(value)})
+	}
+	// Outputs are written to the termination message of the container
+	env = append(env, corev1.EnvVar{Name: "FC_OUTPUT", Value: outputPath})
+
+	limits := corev1.ResourceList{}
+	if config.Resources.CPU != "" {
+		q, err := resource.ParseQuantity(config.Resources.CPU)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resources.cpu: %w", err)
+		}
+		limits[corev1.ResourceCPU] = q
+	}
+	if config.Resources.Memory != "" {
+		q, err := resource.ParseQuantity(config.Resources.Memory)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resources.memory: %w", err)
+		}
+		limits[corev1.ResourceMemory] = q
+	}
+
+	labels := map[string]string{
+		"app.kubernetes.io/managed-by": "flowctl",
+	}
+	if execCtx.ExecID != "" {
+		labels[execIDLabel] = execCtx.ExecID
+	}
+
+	backoffLimit := int32(0)
+	ttl := jobTTLSeconds
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      k.jobName,
+			Namespace: config.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			TTLSecondsAfterFinished: &ttl,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: config.ServiceAccount,
+					NodeSelector:       config.NodeSelector,
+					Containers: []corev1.Container{
+						{
+							Name:                   containerName,
+							Image:                  config.Image,
+							Command:                []string{"/bin/sh", "-c", config.Script},
+							Env:                    env,
+							TerminationMessagePath: outputPath,
+							Resources: corev1.ResourceRequirements{
+								Limits: limits,
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// podCondition reports whether the pod has reached the awaited state
+type podCondition func(pod *corev1.Pod) (bool, error)
+
+// podStarted is satisfied once the container is running or has finished, it fails on image errors
+func podStarted(pod *corev1.Pod) (bool, error) {
+	if pod.Status.Phase != corev1.PodPending {
+		return true, nil
+	}
+
+	state := containerState(pod)
+	if state != nil && state.Waiting != nil {
+		switch state.Waiting.Reason {
+		case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "CreateContainerConfigError", "CreateContainerError":
+			return false, fmt.Errorf("could not start pod %s: %s: %s", pod.Name, state.Waiting.Reason, state.Waiting.Message)
+		}
+	}
+	return false, nil
+}
+
+func podFinished(pod *corev1.Pod) (bool, error) {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed, nil
+}
+
+// waitForPod polls the pod created by the job until cond is satisfied
+func (k *KubernetesExecutor) waitForPod(ctx context.Context, client kubernetes.Interface, namespace string, cond podCondition) (*corev1.Pod, error) {
+	ticker := time.NewTicker(podPollInterval)
+	defer ticker.Stop()
+
+	for {
+		pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: "job-name=" + k.jobName,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("could not list pods of job %s: %w", k.jobName, err)
+		}
+
+		if len(pods.Items) > 0 {
+			pod := &pods.Items[0]
+			ok, err := cond(pod)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return pod, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (k *KubernetesExecutor) streamLogs(ctx context.Context, client kubernetes.Interface, namespace, podName string) error {
+	logs, err := client.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Container: containerName,
+		Follow:    true,
+	}).Stream(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("could not get logs of pod %s: %w", podName, err)
+	}
+	defer logs.Close()
+
+	if _, err := io.Copy(k.stdout, logs); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("error copying logs: %w", err)
+	}
+	return nil
+}
+
+func containerState(pod *corev1.Pod) *corev1.ContainerState {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == containerName {
+			return &status.State
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func init() {
+	podPollInterval = 10 * time.Millisecond
+}
+
+func newTestExecutor(client *fake.Clientset) *KubernetesExecutor {
+	return NewKubernetesExecutorWithClient("test", func(kubeconfig []byte, kubeContext string) (kubernetes.Interface, error) {
+		return client, nil
+	})
+}
+
+// runPod simulates the job controller by creating the pod of the job once the job exists
+func runPod(t *testing.T, ctx context.Context, client *fake.Clientset, jobName string, phase corev1.PodPhase, state corev1.ContainerState) {
+	t.Helper()
+
+	for {
+		if _, err := client.BatchV1().Jobs("default").Get(ctx, jobName, metav1.GetOptions{}); err == nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName + "-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{"job-name": jobName},
+		},
+		Status: corev1.PodStatus{
+			Phase: phase,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: containerName, State: state},
+			},
+		},
+	}
+	if _, err := client.CoreV1().Pods("default").Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		t.Errorf("could not create pod: %v", err)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := fake.NewSimpleClientset()
+	k := newTestExecutor(client)
+
+	go runPod(t, ctx, client, k.jobName, corev1.PodSucceeded, corev1.ContainerState{
+		Terminated: &corev1.ContainerStateTerminated{ExitCode: 0, Message: "FOO=bar\nCOUNT=2\n"},
+	})
+
+	var stdout bytes.Buffer
+	outputs, err := k.Execute(ctx, executor.ExecutionContext{
+		WithConfig: []byte("image: alpine\nscript: echo hello\nresources:\n  cpu: 500m\n"),
+		Inputs:     map[string]any{"NAME": "flowctl"},
+		Stdout:     &stdout,
+		Stderr:     &bytes.Buffer{},
+		ExecID:     "exec1",
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if outputs["FOO"] != "bar" || outputs["COUNT"] != "2" {
+		t.Errorf("outputs = %v, want FOO=bar COUNT=2", outputs)
+	}
+	if !strings.Contains(stdout.String(), "fake logs") {
+		t.Errorf("stdout = %q, want pod logs", stdout.String())
+	}
+
+	var created bool
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "create" && action.GetResource().Resource == "jobs" {
+			created = true
+		}
+	}
+	if !created {
+		t.Fatal("job was not created")
+	}
+
+	if _, err := client.BatchV1().Jobs("default").Get(ctx, k.jobName, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("job was not deleted, get error = %v", err)
+	}
+}
+
+func TestExecuteFailure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := fake.NewSimpleClientset()
+	k := newTestExecutor(client)
+
+	go runPod(t, ctx, client, k.jobName, corev1.PodFailed, corev1.ContainerState{
+		Terminated: &corev1.ContainerStateTerminated{ExitCode: 3},
+	})
+
+	_, err := k.Execute(ctx, executor.ExecutionContext{
+		WithConfig: []byte("image: alpine\nscript: exit 3\n"),
+		Stdout:     &bytes.Buffer{},
+		Stderr:     &bytes.Buffer{},
+	})
+	if err == nil || !strings.Contains(err.Error(), "exited with code 3") {
+		t.Fatalf("Execute() error = %v, want exit code error", err)
+	}
+}
+
+func TestExecuteCancel(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	k := newTestExecutor(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The pod never starts, cancelling the execution should still remove the job
+	go func() {
+		for {
+			if _, err := client.BatchV1().Jobs("default").Get(context.Background(), k.jobName, metav1.GetOptions{}); err == nil {
+				cancel()
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	_, err := k.Execute(ctx, executor.ExecutionContext{
+		WithConfig: []byte("image: alpine\nscript: sleep 100\n"),
+		Stdout:     &bytes.Buffer{},
+		Stderr:     &bytes.Buffer{},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Execute() error = %v, want context.Canceled", err)
+	}
+
+	if _, err := client.BatchV1().Jobs("default").Get(context.Background(), k.jobName, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("job was not deleted after cancellation, get error = %v", err)
+	}
+}
+
+func TestKubeconfigCredential(t *testing.T) {
+	k := newTestExecutor(fake.NewSimpleClientset())
+
+	_, err := k.getKubeconfig(context.Background(), "prod", executor.ExecutionContext{
+		GetCredential: func(ctx context.Context, name string, keyType string) (executor.Credential, error) {
+			return executor.Credential{Name: name, KeyType: "password", KeyData: "secret"}, nil
+		},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a credential that is not a kubeconfig")
+	}
+}
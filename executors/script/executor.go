@@ -8,15 +8,16 @@ import (
 	"strings"
 
 	"github.com/cvhariharan/flowctl/sdk/executor"
-	"github.com/hashicorp/go-envparse"
 	"github.com/invopop/jsonschema"
 	"github.com/rs/xid"
 	"gopkg.in/yaml.v3"
 )
 
 type ScriptWithConfig struct {
-	Script      string `yaml:"script" json:"script" jsonschema:"title=script" jsonschema_extras:"widget=codeeditor"`
-	Interpreter string `yaml:"interpreter,omitempty" json:"interpreter,omitempty" jsonschema:"title=interpreter,description=Shell interpreter to use (default: /bin/bash)" jsonschema_extras:"placeholder=/bin/bash"`
+	Script       string   `yaml:"script" json:"script" jsonschema:"title=script" jsonschema_extras:"widget=codeeditor"`
+	Language     string   `yaml:"language,omitempty" json:"language,omitempty" jsonschema:"title=language,description=Language of the script (default: bash),enum=bash,enum=python,enum=node,enum=pwsh"`
+	Interpreter  string   `yaml:"interpreter,omitempty" json:"interpreter,omitempty" jsonschema:"title=interpreter,description=Interpreter to use instead of the default for the language e.g. python3.12" jsonschema_extras:"placeholder=/bin/bash"`
+	Dependencies []string `yaml:"dependencies,omitempty" json:"dependencies,omitempty" jsonschema:"title=dependencies,description=Packages installed into an environment created for the execution. Uses pip for python; npm for node and the PowerShell Gallery for pwsh"`
 }
 
 type ScriptExecutor struct {
@@ -48,15 +49,18 @@ func NewScriptExecutor(name string, driver executor.NodeDriver) (executor.Execut
 	return executor, nil
 }
 
-func (s *ScriptExecutor) Execute(ctx context.Context, execCtx executor.ExecutionContext) (map[string]string, error) {
+func (s *ScriptExecutor) Execute(ctx context.Context, execCtx executor.ExecutionContext) (map[string]any, error) {
 	var config ScriptWithConfig
 	if err := yaml.Unmarshal(execCtx.WithConfig, &config); err != nil {
 		return nil, fmt.Errorf("could not read config for script executor %s: %w", s.name, err)
 	}
 
-	// Set default interpreter
-	if config.Interpreter == "" {
-		config.Interpreter = "/bin/bash"
+	lang, err := getLanguage(config.Language)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.Dependencies) > 0 && lang.install == nil {
+		return nil, fmt.Errorf("dependencies are not supported for %s scripts", lang.name)
 	}
 
 	s.stdout = execCtx.Stdout
@@ -77,11 +81,31 @@ func (s *ScriptExecutor) Execute(ctx context.Context, execCtx executor.Execution
 		return nil, fmt.Errorf("failed to create artifacts directory: %w", err)
 	}
 
+	jsonOutputFile := s.driver.Join(s.driver.TempDir(), fmt.Sprintf("script-executor-output-json-%s", xid.New().String()))
+	if err := s.driver.CreateFile(ctx, jsonOutputFile); err != nil {
+		return nil, fmt.Errorf("failed to create temp file for output: %w", err)
+	}
+	defer s.driver.Remove(context.Background(), jsonOutputFile)
+
 	// Prepare environment variables
 	env := s.prepareEnvironment(execCtx.Inputs, tempFile, artifactsDir)
+	env = append(env, fmt.Sprintf("FC_OUTPUT_JSON=%s", jsonOutputFile))
+
+	// The helper library and dependencies are placed in a directory that is removed after the execution
+	envDir := s.driver.Join(s.driver.TempDir(), fmt.Sprintf("script-env-%s", xid.New().String()))
+	if err := s.driver.CreateDir(ctx, envDir); err != nil {
+		return nil, fmt.Errorf("failed to create script environment directory: %w", err)
+	}
+	defer s.driver.Remove(context.Background(), envDir)
+
+	interpreter, langEnv, err := s.setupLanguage(ctx, lang, config, envDir)
+	if err != nil {
+		return nil, err
+	}
+	env = append(env, langEnv...)
 
 	// Execute the script
-	if err := s.runScript(ctx, config, env); err != nil {
+	if err := s.runScript(ctx, config.Script, interpreter, lang.extension, env); err != nil {
 		return nil, err
 	}
 
@@ -91,19 +115,20 @@ func (s *ScriptExecutor) Execute(ctx context.Context, execCtx executor.Execution
 		return nil, fmt.Errorf("failed to read temp file contents: %w", err)
 	}
 
-	outputEnv, err := envparse.Parse(outputContents)
+	// Outputs set using the helper library take precedence
+	jsonContents, err := s.readTempFileContents(ctx, jsonOutputFile)
 	if err != nil {
-		return nil, fmt.Errorf("could not load output env: %w", err)
+		return nil, fmt.Errorf("failed to read temp file contents: %w", err)
 	}
 
-	return outputEnv, nil
+	return executor.ParseOutputs(outputContents, jsonContents)
 }
 
 func (s *ScriptExecutor) prepareEnvironment(inputs map[string]interface{}, outputFile string, artifactsDir string) []string {
 	var env []string
 
 	for k, v := range inputs {
-		env = append(env, fmt.Sprintf("%s=%s", k, fmt.Sprint(v)))
+		env = append(env, fmt.Sprintf("%s=%s", k, executor.FormatValue(v)))
 	}
 
 	env = append(env, fmt.Sprintf("FC_OUTPUT=%s", outputFile))
@@ -112,14 +137,14 @@ func (s *ScriptExecutor) prepareEnvironment(inputs map[string]interface{}, outpu
 	return env
 }
 
-func (s *ScriptExecutor) runScript(ctx context.Context, config ScriptWithConfig, env []string) error {
-	localScriptFile := fmt.Sprintf("/tmp/local-script-%s.sh", xid.New().String())
-	if err := os.WriteFile(localScriptFile, []byte(config.Script), 0755); err != nil {
+func (s *ScriptExecutor) runScript(ctx context.Context, script string, interpreter string, extension string, env []string) error {
+	localScriptFile := fmt.Sprintf("/tmp/local-script-%s%s", xid.New().String(), extension)
+	if err := os.WriteFile(localScriptFile, []byte(script), 0755); err != nil {
 		return fmt.Errorf("failed to write local script file: %w", err)
 	}
 	defer os.Remove(localScriptFile)
 
-	remoteScriptFile := s.driver.Join(s.driver.TempDir(), fmt.Sprintf("script-%s.sh", xid.New().String()))
+	remoteScriptFile := s.driver.Join(s.driver.TempDir(), fmt.Sprintf("script-%s%s", xid.New().String(), extension))
 	if err := s.driver.Upload(ctx, localScriptFile, remoteScriptFile); err != nil {
 		return fmt.Errorf("failed to upload script: %w", err)
 	}
@@ -129,7 +154,7 @@ func (s *ScriptExecutor) runScript(ctx context.Context, config ScriptWithConfig,
 		return fmt.Errorf("failed to set executable permissions: %w", err)
 	}
 
-	command := fmt.Sprintf("%s %s", config.Interpreter, remoteScriptFile)
+	command := fmt.Sprintf("%s %s", interpreter, remoteScriptFile)
 	return s.driver.Exec(ctx, command, s.workingDirectory, env, s.stdout, s.stderr)
 }
 
@@ -0,0 +1,16 @@
+// Helpers for flowctl node scripts
+"use strict";
+
+const fs = require("fs");
+
+// setOutput sets an action output. The value can be any JSON serializable value
+function setOutput(name, value) {
+  fs.appendFileSync(process.env.FC_OUTPUT_JSON, JSON.stringify({ [name]: value }) + "\n");
+}
+
+// artifactsDir returns the directory where artifacts should be written
+function artifactsDir() {
+  return process.env.FC_ARTIFACTS;
+}
+
+module.exports = { setOutput, artifactsDir };
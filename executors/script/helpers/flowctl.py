@@ -0,0 +1,15 @@
+"""Helpers for flowctl python scripts."""
+
+import json
+import os
+
+
+def set_output(name, value):
+    """Set an action output. The value can be any JSON serializable value."""
+    with open(os.environ["FC_OUTPUT_JSON"], "a") as f:
+        f.write(json.dumps({name: value}) + "\n")
+
+
+def artifacts_dir():
+    """Return the directory where artifacts should be written."""
+    return os.environ["FC_ARTIFACTS"]
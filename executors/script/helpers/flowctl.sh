@@ -0,0 +1,28 @@
+# Helpers for flowctl bash scripts, loaded using BASH_ENV
+
+# fc_output sets an action output to a string value
+# usage: fc_output name value
+fc_output() {
+    local name value
+    name=$(_fc_json_string "$1")
+    value=$(_fc_json_string "$2")
+    printf '{%s:%s}\n' "$name" "$value" >> "$FC_OUTPUT_JSON"
+}
+
+# fc_output_json sets an action output to a JSON value
+# usage: fc_output_json name '["a", "b"]'
+fc_output_json() {
+    local name
+    name=$(_fc_json_string "$1")
+    printf '{%s:%s}\n' "$name" "$2" >> "$FC_OUTPUT_JSON"
+}
+
+_fc_json_string() {
+    local s=$1
+    s=${s//\\/\\\\}
+    s=${s//\"/\\\"}
+    s=${s//$'\n'/\\n}
+    s=${s//$'\r'/\\r}
+    s=${s//$'\t'/\\t}
+    printf '"%s"' "$s"
+}
@@ -0,0 +1,195 @@
+package script
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// helpers contains the output helper libraries uploaded for each language
+//
+//go:embed helpers
+var helpers embed.FS
+
+const (
+	LanguageBash   = "bash"
+	LanguagePython = "python"
+	LanguageNode   = "node"
+	LanguagePwsh   = "pwsh"
+)
+
+// installFunc returns the command that installs the dependencies into envDir, the interpreter
+// that should be used to run the script and the directory containing the dependencies
+type installFunc func(interpreter string, envDir string, deps []string) (command string, newInterpreter string, depDir string)
+
+type language struct {
+	name        string
+	interpreter string
+	extension   string
+	// helper is the path of the helper library in the helpers directory
+	helper string
+	// libEnv returns the environment variables used to load libraries from the given directories
+	libEnv  func(dirs ...string) []string
+	install installFunc
+}
+
+var languages = map[string]language{
+	LanguageBash: {
+		name:        LanguageBash,
+		interpreter: "/bin/bash",
+		extension:   ".sh",
+		helper:      "flowctl.sh",
+		libEnv: func(dirs ...string) []string {
+			// BASH_ENV is sourced by non-interactive shells before the script runs
+			return []string{"BASH_ENV=" + path.Join(dirs[0], "flowctl.sh")}
+		},
+	},
+	LanguagePython: {
+		name:        LanguagePython,
+		interpreter: "python3",
+		extension:   ".py",
+		helper:      "flowctl.py",
+		libEnv: func(dirs ...string) []string {
+			return []string{"PYTHONPATH=" + strings.Join(dirs, ":")}
+		},
+		install: func(interpreter, envDir string, deps []string) (string, string, string) {
+			venv := path.Join(envDir, "venv")
+			python := path.Join(venv, "bin", "python")
+			command := fmt.Sprintf("%s -m venv %s && %s -m pip install --quiet --disable-pip-version-check -- %s",
+				interpreter, shellQuote(venv), shellQuote(python), shellQuoteAll(deps))
+			// Packages are found by the python interpreter of the virtual environment
+			return command, python, ""
+		},
+	},
+	LanguageNode: {
+		name:        LanguageNode,
+		interpreter: "node",
+		extension:   ".js",
+		helper:      "flowctl.js",
+		libEnv: func(dirs ...string) []string {
+			return []string{"NODE_PATH=" + strings.Join(dirs, ":")}
+		},
+		install: func(interpreter, envDir string, deps []string) (string, string, string) {
+			prefix := path.Join(envDir, "npm")
+			command := fmt.Sprintf("npm install --prefix %s --no-save --no-audit --no-fund --loglevel=error -- %s",
+				shellQuote(prefix), shellQuoteAll(deps))
+			return command, interpreter, path.Join(prefix, "node_modules")
+		},
+	},
+	LanguagePwsh: {
+		name:        LanguagePwsh,
+		interpreter: "pwsh -NoLogo -NoProfile -NonInteractive -File",
+		extension:   ".ps1",
+		helper:      "Flowctl/Flowctl.psm1",
+		libEnv: func(dirs ...string) []string {
+			// pwsh adds the default module paths after these
+			return []string{"PSModulePath=" + strings.Join(dirs, ":")}
+		},
+		install: func(interpreter, envDir string, deps []string) (string, string, string) {
+			modules := path.Join(envDir, "modules")
+			var names []string
+			for _, d := range deps {
+				names = append(names, "'"+strings.ReplaceAll(d, "'", "''")+"'")
+			}
+			script := fmt.Sprintf("$ErrorActionPreference = 'Stop'; New-Item -ItemType Directory -Force -Path '%s' | Out-Null; Save-Module -Name %s -Path '%s' -Repository PSGallery -Force",
+				modules, strings.Join(names, ","), modules)
+			command := fmt.Sprintf("pwsh -NoLogo -NoProfile -NonInteractive -Command %s", shellQuote(script))
+			return command, interpreter, modules
+		},
+	},
+}
+
+func getLanguage(name string) (language, error) {
+	if name == "" {
+		name = LanguageBash
+	}
+	lang, ok := languages[name]
+	if !ok {
+		return language{}, fmt.Errorf("unsupported language %q, must be one of bash, python, node, pwsh", name)
+	}
+	return lang, nil
+}
+
+// setupLanguage uploads the helper library and installs the dependencies into envDir.
+// It returns the interpreter command and the environment variables for the script.
+func (s *ScriptExecutor) setupLanguage(ctx context.Context, lang language, config ScriptWithConfig, envDir string) (string, []string, error) {
+	interpreter := lang.interpreter
+	if config.Interpreter != "" {
+		interpreter = config.Interpreter
+	}
+
+	// The bash helpers are loaded using BASH_ENV, which replaces a BASH_ENV set on the node.
+	// They are only loaded when bash is chosen explicitly
+	if lang.name == LanguageBash && config.Language == "" {
+		return interpreter, nil, nil
+	}
+
+	libDir := s.driver.Join(envDir, "lib")
+	if err := s.uploadHelper(ctx, lang.helper, libDir); err != nil {
+		return "", nil, fmt.Errorf("failed to upload helper library: %w", err)
+	}
+	if len(config.Dependencies) == 0 {
+		return interpreter, lang.libEnv(libDir), nil
+	}
+
+	command, interpreter, depDir := lang.install(interpreter, envDir, config.Dependencies)
+	fmt.Fprintf(s.stdout, "installing dependencies: %s\n", strings.Join(config.Dependencies, " "))
+	if err := s.driver.Exec(ctx, command, s.workingDirectory, nil, s.stdout, s.stderr); err != nil {
+		return "", nil, fmt.Errorf("failed to install dependencies: %w", err)
+	}
+
+	// Dependencies are searched after the helper library
+	dirs := []string{libDir}
+	if depDir != "" {
+		dirs = append(dirs, depDir)
+	}
+	return interpreter, lang.libEnv(dirs...), nil
+}
+
+// uploadHelper uploads the helper library keeping its path relative to the helpers directory
+func (s *ScriptExecutor) uploadHelper(ctx context.Context, helper string, libDir string) error {
+	content, err := fs.ReadFile(helpers, path.Join("helpers", helper))
+	if err != nil {
+		return err
+	}
+
+	remoteDir := libDir
+	if dir := path.Dir(helper); dir != "." {
+		remoteDir = s.driver.Join(libDir, dir)
+	}
+	if err := s.driver.CreateDir(ctx, remoteDir); err != nil {
+		return err
+	}
+
+	local, err := os.CreateTemp("", "script-helper-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(local.Name())
+
+	if _, err := local.Write(content); err != nil {
+		local.Close()
+		return err
+	}
+	if err := local.Close(); err != nil {
+		return err
+	}
+
+	return s.driver.Upload(ctx, local.Name(), s.driver.Join(remoteDir, path.Base(helper)))
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func shellQuoteAll(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,222 @@
+package script
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+)
+
+func TestInstallCommands(t *testing.T) {
+	tests := []struct {
+		language        string
+		wantCommand     string
+		wantInterpreter string
+		wantDepDir      string
+	}{
+		{
+			language:        LanguagePython,
+			wantCommand:     `python3 -m venv '/env/venv' && '/env/venv/bin/python' -m pip install --quiet --disable-pip-version-check -- 'requests==2.32.3' 'it'\''s'`,
+			wantInterpreter: "/env/venv/bin/python",
+		},
+		{
+			language:        LanguageNode,
+			wantCommand:     `npm install --prefix '/env/npm' --no-save --no-audit --no-fund --loglevel=error -- 'requests==2.32.3' 'it'\''s'`,
+			wantInterpreter: "node",
+			wantDepDir:      "/env/npm/node_modules",
+		},
+		{
+			language:        LanguagePwsh,
+			wantCommand:     `pwsh -NoLogo -NoProfile -NonInteractive -Command '$ErrorActionPreference = '\''Stop'\''; New-Item -ItemType Directory -Force -Path '\''/env/modules'\'' | Out-Null; Save-Module -Name '\''requests==2.32.3'\'','\''it'\'''\''s'\'' -Path '\''/env/modules'\'' -Repository PSGallery -Force'`,
+			wantInterpreter: "pwsh -NoLogo -NoProfile -NonInteractive -File",
+			wantDepDir:      "/env/modules",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			lang, err := getLanguage(tt.language)
+			if err != nil {
+				t.Fatal(err)
+			}
+			command, interpreter, depDir := lang.install(lang.interpreter, "/env", []string{"requests==2.32.3", "it's"})
+			if command != tt.wantCommand {
+				t.Errorf("command =\n%s\nwant\n%s", command, tt.wantCommand)
+			}
+			if interpreter != tt.wantInterpreter || depDir != tt.wantDepDir {
+				t.Errorf("interpreter = %q, dependency directory = %q, want %q, %q", interpreter, depDir, tt.wantInterpreter, tt.wantDepDir)
+			}
+		})
+	}
+}
+
+func TestGetLanguage(t *testing.T) {
+	lang, err := getLanguage("")
+	if err != nil || lang.name != LanguageBash {
+		t.Errorf("getLanguage(\"\") = %q, %v, want bash", lang.name, err)
+	}
+	if lang.install != nil {
+		t.Error("bash should not support dependencies")
+	}
+	if _, err := getLanguage("ruby"); err == nil {
+		t.Error("getLanguage(ruby) succeeded")
+	}
+}
+
+// recordingDriver records the commands run on the node instead of running them
+type recordingDriver struct {
+	executor.NodeDriver
+	commands []string
+}
+
+func (d *recordingDriver) Exec(ctx context.Context, command string, workingDir string, env []string, stdout, stderr io.Writer) error {
+	d.commands = append(d.commands, command)
+	return nil
+}
+
+func TestSetupLanguage(t *testing.T) {
+	local, err := executor.NewLocalLinux()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(local.GetWorkingDirectory()) })
+	driver := &recordingDriver{NodeDriver: local}
+	s := &ScriptExecutor{driver: driver, stdout: io.Discard, stderr: io.Discard}
+
+	tests := []struct {
+		name            string
+		config          ScriptWithConfig
+		wantInterpreter string
+		wantEnv         []string
+		wantHelper      string
+		wantCommands    int
+	}{
+		{name: "default language", config: ScriptWithConfig{}, wantInterpreter: "/bin/bash"},
+		{name: "bash", config: ScriptWithConfig{Language: LanguageBash}, wantInterpreter: "/bin/bash", wantEnv: []string{"BASH_ENV={lib}/flowctl.sh"}, wantHelper: "flowctl.sh"},
+		{name: "python", config: ScriptWithConfig{Language: LanguagePython, Interpreter: "python3.12"}, wantInterpreter: "python3.12", wantEnv: []string{"PYTHONPATH={lib}"}, wantHelper: "flowctl.py"},
+		{name: "node with dependencies", config: ScriptWithConfig{Language: LanguageNode, Dependencies: []string{"left-pad"}}, wantInterpreter: "node", wantEnv: []string{"NODE_PATH={lib}:{env}/npm/node_modules"}, wantHelper: "flowctl.js", wantCommands: 1},
+		{name: "pwsh", config: ScriptWithConfig{Language: LanguagePwsh}, wantInterpreter: "pwsh -NoLogo -NoProfile -NonInteractive -File", wantEnv: []string{"PSModulePath={lib}"}, wantHelper: "Flowctl/Flowctl.psm1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver.commands = nil
+			envDir := t.TempDir()
+			lang, err := getLanguage(tt.config.Language)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			interpreter, env, err := s.setupLanguage(context.Background(), lang, tt.config, envDir)
+			if err != nil {
+				t.Fatalf("setupLanguage() error = %v", err)
+			}
+
+			libDir := filepath.Join(envDir, "lib")
+			var wantEnv []string
+			for _, e := range tt.wantEnv {
+				wantEnv = append(wantEnv, strings.NewReplacer("{lib}", libDir, "{env}", envDir).Replace(e))
+			}
+			if interpreter != tt.wantInterpreter || !reflect.DeepEqual(env, wantEnv) {
+				t.Errorf("setupLanguage() = %q, %q, want %q, %q", interpreter, env, tt.wantInterpreter, wantEnv)
+			}
+			if len(driver.commands) != tt.wantCommands {
+				t.Errorf("ran %d install commands, want %d", len(driver.commands), tt.wantCommands)
+			}
+
+			if tt.wantHelper == "" {
+				if _, err := os.Stat(libDir); !os.IsNotExist(err) {
+					t.Errorf("helper library was uploaded without a language")
+				}
+				return
+			}
+			if _, err := os.Stat(filepath.Join(libDir, tt.wantHelper)); err != nil {
+				t.Errorf("helper library was not uploaded: %v", err)
+			}
+		})
+	}
+}
+
+func TestHelpers(t *testing.T) {
+	tests := []struct {
+		language string
+		script   string
+	}{
+		{
+			language: LanguageBash,
+			script: `fc_output name "it's a \"test\"
+line two"
+fc_output_json items '[1, "two", {"three": 3}]'
+echo "plain=from dotenv" >> "$FC_OUTPUT"`,
+		},
+		{
+			language: LanguagePython,
+			script: `import flowctl, os
+flowctl.set_output("name", "it's a \"test\"\nline two")
+flowctl.set_output("items", [1, "two", {"three": 3}])
+open(os.environ["FC_OUTPUT"], "a").write("plain=from dotenv\n")`,
+		},
+		{
+			language: LanguageNode,
+			script: `const flowctl = require("flowctl");
+flowctl.setOutput("name", "it's a \"test\"\nline two");
+flowctl.setOutput("items", [1, "two", {three: 3}]);
+require("fs").appendFileSync(process.env.FC_OUTPUT, "plain=from dotenv\n");`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			lang, err := getLanguage(tt.language)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := exec.LookPath(strings.Fields(lang.interpreter)[0]); err != nil {
+				t.Skipf("%s is not installed", lang.interpreter)
+			}
+
+			local, err := executor.NewLocalLinux()
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { os.RemoveAll(local.GetWorkingDirectory()) })
+			e, err := NewScriptExecutor("test", local)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			execID := fmt.Sprintf("helpers-%s", filepath.Base(t.TempDir()))
+			t.Cleanup(func() { os.RemoveAll(filepath.Join(local.TempDir(), "artifacts-"+execID)) })
+
+			var stderr bytes.Buffer
+			outputs, err := e.Execute(context.Background(), executor.ExecutionContext{
+				ExecID:     execID,
+				WithConfig: []byte(fmt.Sprintf("language: %s\nscript: %q\n", tt.language, tt.script)),
+				// The local driver runs scripts with only the given environment
+				Inputs: map[string]any{"PATH": os.Getenv("PATH")},
+				Stdout: io.Discard,
+				Stderr: &stderr,
+			})
+			if err != nil {
+				t.Fatalf("Execute() error = %v: %s", err, stderr.String())
+			}
+
+			want := map[string]any{
+				"name":  "it's a \"test\"\nline two",
+				"items": []any{1, "two", map[string]any{"three": 3}},
+				"plain": "from dotenv",
+			}
+			if !reflect.DeepEqual(outputs, want) {
+				t.Errorf("outputs = %#v, want %#v", outputs, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,389 @@
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"database/sql/driver"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+	"github.com/go-sql-driver/mysql"
+	"github.com/invopop/jsonschema"
+	"github.com/lib/pq"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+
+	defaultTimeout = 5 * time.Minute
+	dialTimeout    = 30 * time.Second
+
+	// rowCountOutput is the output holding the number of rows returned or affected
+	rowCountOutput = "row_count"
+)
+
+type SQLWithConfig struct {
+	Driver      string   `yaml:"driver" json:"driver" jsonschema:"title=driver,enum=postgres,enum=mysql"`
+	DSN         string   `yaml:"dsn" json:"dsn" jsonschema:"title=dsn,description=Connection string. Use a variable referencing a flow secret e.g. $db_dsn" jsonschema_extras:"placeholder=$db_dsn"`
+	Query       string   `yaml:"query,omitempty" json:"query,omitempty" jsonschema:"title=query,description=Single statement to run. Use $1 (postgres) or ? (mysql) for parameters" jsonschema_extras:"widget=codeeditor"`
+	File        string   `yaml:"file,omitempty" json:"file,omitempty" jsonschema:"title=file,description=SQL file in the flow directory run as a script e.g. migrations/001_init.sql"`
+	Params      []string `yaml:"params,omitempty" json:"params,omitempty" jsonschema:"title=params,description=Query parameters. Variables can be referenced as $name or ${name}"`
+	Transaction bool     `yaml:"transaction,omitempty" json:"transaction,omitempty" jsonschema:"title=transaction,description=Run in a transaction that is rolled back on failure"`
+	CSV         string   `yaml:"csv,omitempty" json:"csv,omitempty" jsonschema:"title=csv,description=Write the query results as CSV with this file name into the artifacts directory"`
+	Timeout     string   `yaml:"timeout,omitempty" json:"timeout,omitempty" jsonschema:"title=timeout,description=Query timeout (default: 5m)" jsonschema_extras:"placeholder=5m"`
+}
+
+type SQLExecutor struct {
+	name   string
+	stdout io.Writer
+	stderr io.Writer
+	driver executor.NodeDriver
+}
+
+func init() {
+	executor.RegisterExecutor("sql", NewSQLExecutor)
+	executor.RegisterSchema("sql", GetSchema())
+}
+
+func GetSchema() interface{} {
+	return jsonschema.Reflect(&SQLWithConfig{})
+}
+
+func NewSQLExecutor(name string, driver executor.NodeDriver) (executor.Executor, error) {
+	return &SQLExecutor{
+		name:   name,
+		driver: driver,
+	}, nil
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (dbsql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*dbsql.Rows, error)
+}
+
+func (e *SQLExecutor) Execute(ctx context.Context, execCtx executor.ExecutionContext) (map[string]any, error) {
+	var config SQLWithConfig
+	if err := yaml.Unmarshal(execCtx.WithConfig, &config); err != nil {
+		return nil, fmt.Errorf("could not read config for sql executor %s: %w", e.name, err)
+	}
+
+	e.stdout = execCtx.Stdout
+	e.stderr = execCtx.Stderr
+
+	if (config.Query == "") == (config.File == "") {
+		return nil, fmt.Errorf("exactly one of query or file should be set")
+	}
+	if config.File != "" && len(config.Params) > 0 {
+		return nil, fmt.Errorf("params are not supported when running a file")
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		d, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid timeout: %s, must be greater than zero", config.Timeout)
+		}
+		timeout = d
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	query := config.Query
+	if config.File != "" {
+		q, err := readFlowFile(execCtx.FlowDirectory, config.File)
+		if err != nil {
+			return nil, err
+		}
+		query = q
+	}
+
+	params := make([]any, 0, len(config.Params))
+	for _, p := range config.Params {
+		params = append(params, executor.Expand(p, execCtx.Inputs))
+	}
+
+	db, err := e.open(config.Driver, executor.Expand(config.DSN, execCtx.Inputs), config.File != "")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var q queryer = db
+	var tx *dbsql.Tx
+	if config.Transaction {
+		tx, err = db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("could not begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+		q = tx
+	}
+
+	var outputs map[string]any
+	if config.File == "" && returnsRows(query) {
+		outputs, err = e.query(ctx, q, query, params, config.CSV, execCtx.ExecID)
+	} else {
+		outputs, err = e.exec(ctx, q, query, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("could not commit transaction: %w", err)
+		}
+		fmt.Fprintln(e.stdout, "transaction committed")
+	}
+
+	return outputs, nil
+}
+
+// open connects to the database. On remote nodes, connections are tunnelled through the node
+// so that databases reachable only from the node can be used.
+func (e *SQLExecutor) open(driverName, dsn string, multiStatements bool) (*dbsql.DB, error) {
+	var connector driver.Connector
+	switch driverName {
+	case DriverPostgres:
+		c, err := pq.NewConnector(dsn)
+		if err != nil {
+			// The error is not wrapped as it contains the dsn, including the password
+			return nil, errors.New("invalid postgres dsn")
+		}
+		if e.driver.IsRemote() {
+			c.Dialer(&nodeDialer{driver: e.driver})
+		}
+		connector = c
+	case DriverMySQL:
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			// The error is not wrapped as it could contain parts of the dsn
+			return nil, errors.New("invalid mysql dsn")
+		}
+		// Files contain multiple statements
+		cfg.MultiStatements = cfg.MultiStatements || multiStatements
+		if e.driver.IsRemote() {
+			cfg.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return e.driver.Dial(network, addr)
+			}
+		}
+		c, err := mysql.NewConnector(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mysql config: %w", err)
+		}
+		connector = c
+	default:
+		return nil, fmt.Errorf("unsupported driver %q, supported drivers are %s and %s", driverName, DriverPostgres, DriverMySQL)
+	}
+
+	db := dbsql.OpenDB(connector)
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// exec runs statements that do not return rows and reports the number of affected rows
+func (e *SQLExecutor) exec(ctx context.Context, q queryer, query string, params []any) (map[string]any, error) {
+	res, err := q.ExecContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute query: %w", err)
+	}
+
+	// Not all drivers report affected rows for scripts
+	affected, err := res.RowsAffected()
+	if err != nil {
+		affected = 0
+	}
+	fmt.Fprintf(e.stdout, "%d rows affected\n", affected)
+
+	return map[string]any{
+		rowCountOutput: affected,
+	}, nil
+}
+
+// query runs a statement that returns rows. The columns of the first row are returned as outputs
+// and all rows are optionally written as CSV into the artifacts directory.
+func (e *SQLExecutor) query(ctx context.Context, q queryer, query string, params []any, csvName string, execID string) (map[string]any, error) {
+	rows, err := q.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("could not read columns: %w", err)
+	}
+	// Columns are set as outputs along with row_count
+	if slices.Contains(columns, rowCountOutput) {
+		return nil, fmt.Errorf("column %s clashes with the %s output, rename it using an alias", rowCountOutput, rowCountOutput)
+	}
+
+	var (
+		csvFile *os.File
+		w       *csv.Writer
+	)
+	if csvName != "" {
+		if !filepath.IsLocal(csvName) || filepath.Base(csvName) != csvName {
+			return nil, fmt.Errorf("invalid csv file name %q", csvName)
+		}
+		csvFile, err = os.CreateTemp("", "sql-executor-*.csv")
+		if err != nil {
+			return nil, fmt.Errorf("could not create csv file: %w", err)
+		}
+		defer os.Remove(csvFile.Name())
+		defer csvFile.Close()
+
+		w = csv.NewWriter(csvFile)
+		if err := w.Write(columns); err != nil {
+			return nil, fmt.Errorf("could not write csv: %w", err)
+		}
+	}
+
+	outputs := make(map[string]any)
+	values := make([]any, len(columns))
+	scanArgs := make([]any, len(columns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	record := make([]string, len(columns))
+	var count int64
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return nil, fmt.Errorf("could not read row: %w", err)
+		}
+		for i, v := range values {
+			record[i] = formatValue(v)
+		}
+
+		if count == 0 {
+			for i, col := range columns {
+				outputs[col] = record[i]
+			}
+		}
+		if w != nil {
+			if err := w.Write(record); err != nil {
+				return nil, fmt.Errorf("could not write csv: %w", err)
+			}
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read rows: %w", err)
+	}
+
+	outputs[rowCountOutput] = count
+	fmt.Fprintf(e.stdout, "%d rows returned\n", count)
+
+	if w != nil {
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, fmt.Errorf("could not write csv: %w", err)
+		}
+		if err := e.uploadArtifact(ctx, csvFile.Name(), csvName, execID); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(e.stdout, "results written to %s\n", csvName)
+	}
+
+	return outputs, nil
+}
+
+// uploadArtifact copies the file into the artifacts directory of the execution on the node
+func (e *SQLExecutor) uploadArtifact(ctx context.Context, localPath, name, execID string) error {
+	artifactsDir := e.driver.Join(e.driver.TempDir(), fmt.Sprintf("artifacts-%s", execID))
+	if err := e.driver.CreateDir(ctx, artifactsDir); err != nil {
+		return fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+	if err := e.driver.Upload(ctx, localPath, e.driver.Join(artifactsDir, name)); err != nil {
+		return fmt.Errorf("failed to write %s to artifacts: %w", name, err)
+	}
+	return nil
+}
+
+// readFlowFile reads a file from the flow directory, paths outside the flow directory are rejected
+func readFlowFile(flowDir, name string) (string, error) {
+	if flowDir == "" {
+		return "", fmt.Errorf("flow directory is not available to read %s", name)
+	}
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("file %s should be a relative path inside the flow directory", name)
+	}
+
+	b, err := os.ReadFile(filepath.Join(flowDir, name))
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %w", name, err)
+	}
+	return string(b), nil
+}
+
+var rowReturningRegex = regexp.MustCompile(`(?is)^\s*(select|with|show|explain|describe|desc|values|table)\b|\breturning\b`)
+
+// returnsRows reports whether the statement returns rows
+func returnsRows(query string) bool {
+	return rowReturningRegex.MatchString(query)
+}
+
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(val)
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// nodeDialer dials database connections through the node
+type nodeDialer struct {
+	driver executor.NodeDriver
+}
+
+func (d *nodeDialer) Dial(network, address string) (net.Conn, error) {
+	return d.driver.Dial(network, address)
+}
+
+func (d *nodeDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		conn, err := d.driver.Dial(network, address)
+		ch <- result{conn, err}
+	}()
+
+	if timeout <= 0 {
+		timeout = dialTimeout
+	}
+	select {
+	case r := <-ch:
+		return r.conn, r.err
+	case <-time.After(timeout):
+		go func() {
+			if r := <-ch; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, fmt.Errorf("dial %s: timed out after %s", address, timeout)
+	}
+}
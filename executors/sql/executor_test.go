@@ -0,0 +1,250 @@
+package sql
+
+import (
+	"bytes"
+	"context"
+	dbsql "database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+)
+
+// fakeResult is the result of a query run by the fake driver
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+// fakeResults maps the queries known to the fake driver to their results
+var fakeResults = map[string]fakeResult{
+	"SELECT id, name FROM users": {
+		columns: []string{"id", "name"},
+		rows:    [][]driver.Value{{int64(1), []byte("alice")}, {int64(2), nil}},
+	},
+	"SELECT count(*) AS row_count FROM users": {
+		columns: []string{"row_count"},
+		rows:    [][]driver.Value{{int64(2)}},
+	},
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query: query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct {
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(3), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	res, ok := fakeResults[s.query]
+	if !ok {
+		return nil, errors.New("unknown query")
+	}
+	return &fakeRows{fakeResult: res}, nil
+}
+
+type fakeRows struct {
+	fakeResult
+	next int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+func init() {
+	dbsql.Register("fake", fakeDriver{})
+}
+
+func newTestExecutor(t *testing.T) (*SQLExecutor, *bytes.Buffer, *dbsql.DB) {
+	driver, err := executor.NewLocalLinux()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(driver.GetWorkingDirectory()) })
+
+	db, err := dbsql.Open("fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	stdout := &bytes.Buffer{}
+	return &SQLExecutor{name: "sql", driver: driver, stdout: stdout, stderr: io.Discard}, stdout, db
+}
+
+func TestQuery(t *testing.T) {
+	e, stdout, db := newTestExecutor(t)
+	execID := "exec-" + time.Now().Format("150405.000000")
+	t.Cleanup(func() { os.RemoveAll(e.driver.Join(e.driver.TempDir(), "artifacts-"+execID)) })
+
+	outputs, err := e.query(context.Background(), db, "SELECT id, name FROM users", nil, "users.csv", execID)
+	if err != nil {
+		t.Fatalf("query() error = %v", err)
+	}
+
+	// Columns of the first row are set as outputs
+	want := map[string]any{"id": "1", "name": "alice", "row_count": int64(2)}
+	if !reflect.DeepEqual(outputs, want) {
+		t.Errorf("outputs = %v, want %v", outputs, want)
+	}
+	if !strings.Contains(stdout.String(), "2 rows returned") {
+		t.Errorf("unexpected logs %q", stdout.String())
+	}
+
+	b, err := os.ReadFile(filepath.Join(e.driver.TempDir(), "artifacts-"+execID, "users.csv"))
+	if err != nil {
+		t.Fatalf("csv was not written to the artifacts: %v", err)
+	}
+	if string(b) != "id,name\n1,alice\n2,\n" {
+		t.Errorf("unexpected csv %q", b)
+	}
+}
+
+func TestQueryRowCountColumn(t *testing.T) {
+	e, _, db := newTestExecutor(t)
+
+	_, err := e.query(context.Background(), db, "SELECT count(*) AS row_count FROM users", nil, "", "exec")
+	if err == nil || !strings.Contains(err.Error(), "column row_count clashes") {
+		t.Fatalf("query() error = %v, want the row_count column to be rejected", err)
+	}
+}
+
+func TestQueryInvalidCSVName(t *testing.T) {
+	e, _, db := newTestExecutor(t)
+
+	for _, name := range []string{"../users.csv", "out/users.csv", "/tmp/users.csv"} {
+		if _, err := e.query(context.Background(), db, "SELECT id, name FROM users", nil, name, "exec"); err == nil {
+			t.Errorf("query() with csv %q succeeded", name)
+		}
+	}
+}
+
+func TestExec(t *testing.T) {
+	e, stdout, db := newTestExecutor(t)
+
+	outputs, err := e.exec(context.Background(), db, "DELETE FROM users", nil)
+	if err != nil {
+		t.Fatalf("exec() error = %v", err)
+	}
+	if outputs["row_count"] != int64(3) || !strings.Contains(stdout.String(), "3 rows affected") {
+		t.Errorf("outputs = %v, logs %q", outputs, stdout.String())
+	}
+}
+
+func TestExecuteConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{name: "no query", config: "driver: postgres", wantErr: "exactly one of query or file"},
+		{name: "query and file", config: "query: SELECT 1\nfile: q.sql", wantErr: "exactly one of query or file"},
+		{name: "file with params", config: "file: q.sql\nparams: [a]", wantErr: "params are not supported"},
+		{name: "invalid timeout", config: "query: SELECT 1\ntimeout: soon", wantErr: "invalid timeout"},
+		{name: "negative timeout", config: "query: SELECT 1\ntimeout: -5s", wantErr: "must be greater than zero"},
+		{name: "zero timeout", config: "query: SELECT 1\ntimeout: 0s", wantErr: "must be greater than zero"},
+		{name: "file outside the flow", config: "file: ../q.sql", wantErr: "relative path inside the flow directory"},
+		{name: "unsupported driver", config: "driver: sqlite\nquery: SELECT 1", wantErr: "unsupported driver"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _, _ := newTestExecutor(t)
+			_, err := e.Execute(context.Background(), executor.ExecutionContext{
+				WithConfig:    []byte(tt.config),
+				FlowDirectory: t.TempDir(),
+				Stdout:        io.Discard,
+				Stderr:        io.Discard,
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Execute() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOpenInvalidDSN(t *testing.T) {
+	e, _, _ := newTestExecutor(t)
+	for driverName, dsn := range map[string]string{
+		DriverPostgres: "postgres://admin:s3cret pw@db/app",
+		DriverMySQL:    "admin:s3cret@tcp(db:3306)/app?parseTime=maybe",
+	} {
+		_, err := e.open(driverName, dsn, false)
+		if err == nil {
+			t.Errorf("open(%s) succeeded with an invalid dsn", driverName)
+			continue
+		}
+		if strings.Contains(err.Error(), "s3cret") {
+			t.Errorf("open(%s) error contains the password: %v", driverName, err)
+		}
+	}
+}
+
+func TestReturnsRows(t *testing.T) {
+	tests := map[string]bool{
+		"SELECT 1":                                true,
+		"  with t AS (SELECT 1) SELECT * FROM t":  true,
+		"SHOW TABLES":                             true,
+		"INSERT INTO t VALUES (1) RETURNING id":   true,
+		"UPDATE t SET a = 1":                      false,
+		"DELETE FROM selected_items":              false,
+		"CREATE TABLE t (id int)":                 false,
+		"VALUES (1), (2)":                         true,
+		"insert into audit select * from staging": false,
+	}
+	for query, want := range tests {
+		if got := returnsRows(query); got != want {
+			t.Errorf("returnsRows(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{nil, ""},
+		{[]byte("text"), "text"},
+		{ts, "2024-05-01T10:00:00Z"},
+		{int64(42), "42"},
+		{1.5, "1.5"},
+		{true, "true"},
+	}
+	for _, tt := range tests {
+		if got := formatValue(tt.value); got != tt.want {
+			t.Errorf("formatValue(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -23,6 +23,9 @@ type Config struct {
 	OIDC      OIDCConfig      `koanf:"oidc"`
 	Scheduler SchedulerConfig `koanf:"scheduler"`
 	Logger    Logger          `koanf:"logger"`
+	Docker    DockerConfig    `koanf:"docker"`
+	Inventory InventoryConfig `koanf:"inventory"`
+	Artifacts ArtifactsConfig `koanf:"artifacts"`
 }
 
 type DBConfig struct {
@@ -34,9 +37,30 @@ type DBConfig struct {
 }
 
 type SchedulerConfig struct {
-	WorkerCount      int           `koanf:"workers"`
-	Backend          string        `koanf:"backend"`
-	CronSyncInterval time.Duration `koanf:"cron_sync_interval"`
+	WorkerCount             int            `koanf:"workers"`
+	Backend                 string         `koanf:"backend"`
+	CronSyncInterval        time.Duration  `koanf:"cron_sync_interval"`
+	NamespaceMaxConcurrency int            `koanf:"namespace_max_concurrent"`
+	NamespaceLimits         map[string]int `koanf:"namespace_limits"`
+	NodeHealthInterval      time.Duration  `koanf:"node_health_interval"`
+}
+
+// DockerConfig is passed to the docker executor and the docker remote client
+type DockerConfig struct {
+	AllowedVolumes  []string `koanf:"allowed_volumes" json:"allowed_volumes"`
+	AllowedNetworks []string `koanf:"allowed_networks" json:"allowed_networks"`
+	// AllowedContainers are the containers that docker nodes can connect to
+	AllowedContainers []string `koanf:"allowed_containers" json:"allowed_containers"`
+}
+
+type InventoryConfig struct {
+	Directory     string        `koanf:"directory"`
+	ScriptTimeout time.Duration `koanf:"script_timeout"`
+}
+
+type ArtifactsConfig struct {
+	BucketURL     string        `koanf:"bucket_url"`
+	RetentionTime time.Duration `koanf:"retention_time"`
 }
 
 type Logger struct {
@@ -48,15 +72,16 @@ type Logger struct {
 }
 
 type AppConfig struct {
-	AdminUsername   string `koanf:"admin_username"`
-	AdminPassword   string `koanf:"admin_password"`
-	RootURL         string `koanf:"root_url"`
-	Address         string `koanf:"address"`
-	UseTLS          bool   `koanf:"use_tls"`
-	HTTPTLSCert     string `koanf:"http_tls_cert"`
-	HTTPTLSKey      string `koanf:"http_tls_key"`
-	FlowsDirectory  string `koanf:"flows_directory"`
-	SecureCookieKey string `koanf:"secure_cookie_key"`
+	AdminUsername    string `koanf:"admin_username"`
+	AdminPassword    string `koanf:"admin_password"`
+	RootURL          string `koanf:"root_url"`
+	Address          string `koanf:"address"`
+	UseTLS           bool   `koanf:"use_tls"`
+	HTTPTLSCert      string `koanf:"http_tls_cert"`
+	HTTPTLSKey       string `koanf:"http_tls_key"`
+	FlowsDirectory   string `koanf:"flows_directory"`
+	PluginsDirectory string `koanf:"plugins_directory"`
+	SecureCookieKey  string `koanf:"secure_cookie_key"`
 }
 
 type KeystoreConfig struct {
@@ -136,15 +161,16 @@ func GetDefaultConfig() Config {
 			Port:     5432,
 		},
 		App: AppConfig{
-			AdminUsername:   "flowctl_admin",
-			AdminPassword:   "flowctl_password",
-			RootURL:         "http://localhost:7000",
-			Address:         ":7000",
-			UseTLS:          false,
-			HTTPTLSCert:     "server_cert.pem",
-			HTTPTLSKey:      "server_key.pem",
-			FlowsDirectory:  "flows",
-			SecureCookieKey: genKey(16),
+			AdminUsername:    "flowctl_admin",
+			AdminPassword:    "flowctl_password",
+			RootURL:          "http://localhost:7000",
+			Address:          ":7000",
+			UseTLS:           false,
+			HTTPTLSCert:      "server_cert.pem",
+			HTTPTLSKey:       "server_key.pem",
+			FlowsDirectory:   "flows",
+			PluginsDirectory: "plugins",
+			SecureCookieKey:  genKey(16),
 		},
 		Keystore: KeystoreConfig{
 			KeeperURL: fmt.Sprintf("base64key://%s", genKey(32)),
@@ -155,8 +181,16 @@ func GetDefaultConfig() Config {
 			ClientSecret: "",
 		},
 		Scheduler: SchedulerConfig{
-			WorkerCount:      runtime.NumCPU(),
-			CronSyncInterval: 5 * time.Minute,
+			WorkerCount:        runtime.NumCPU(),
+			CronSyncInterval:   5 * time.Minute,
+			NodeHealthInterval: 5 * time.Minute,
+		},
+		Inventory: InventoryConfig{
+			ScriptTimeout: 5 * time.Minute,
+		},
+		Artifacts: ArtifactsConfig{
+			BucketURL:     "",
+			RetentionTime: 0,
 		},
 		Logger: Logger{
 			Backend:       "file",
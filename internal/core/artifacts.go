@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cvhariharan/flowctl/internal/core/models"
+	"github.com/cvhariharan/flowctl/internal/repo"
+	"github.com/cvhariharan/flowctl/internal/scheduler"
+	"github.com/google/uuid"
+	"gocloud.dev/blob"
+)
+
+// SetArtifactBucket sets the bucket where the scheduler stores the artifacts declared by actions
+func (c *Core) SetArtifactBucket(bucket *blob.Bucket) {
+	c.artifactBucket = bucket
+}
+
+// getExecutionArtifacts returns the artifacts stored for an execution
+func (c *Core) getExecutionArtifacts(ctx context.Context, execID string, namespaceUUID uuid.UUID) ([]models.ExecutionArtifact, error) {
+	rows, err := c.store.GetExecutionArtifacts(ctx, repo.GetExecutionArtifactsParams{
+		ExecID: execID,
+		Uuid:   namespaceUUID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get artifacts for exec %s: %w", execID, err)
+	}
+
+	artifacts := make([]models.ExecutionArtifact, 0, len(rows))
+	for _, r := range rows {
+		artifacts = append(artifacts, repoArtifactToArtifact(r))
+	}
+	return artifacts, nil
+}
+
+// GetExecutionArtifact returns an artifact of an execution along with a reader for its contents.
+// The reader must be closed by the caller
+func (c *Core) GetExecutionArtifact(ctx context.Context, execID string, artifactID string, namespaceID string) (models.ExecutionArtifact, io.ReadCloser, error) {
+	if c.artifactBucket == nil {
+		return models.ExecutionArtifact{}, nil, errors.New("artifact storage is not configured")
+	}
+
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return models.ExecutionArtifact{}, nil, fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	artifactUUID, err := uuid.Parse(artifactID)
+	if err != nil {
+		return models.ExecutionArtifact{}, nil, fmt.Errorf("invalid artifact UUID: %w", err)
+	}
+
+	a, err := c.store.GetExecutionArtifactByUUID(ctx, repo.GetExecutionArtifactByUUIDParams{
+		Uuid:   artifactUUID,
+		ExecID: execID,
+		Uuid_2: namespaceUUID,
+	})
+	if err != nil {
+		return models.ExecutionArtifact{}, nil, fmt.Errorf("could not get artifact %s: %w", artifactID, err)
+	}
+
+	r, err := c.artifactBucket.NewReader(ctx, a.StorageKey, nil)
+	if err != nil {
+		return models.ExecutionArtifact{}, nil, fmt.Errorf("could not read artifact %s: %w", artifactID, err)
+	}
+
+	return repoArtifactToArtifact(a), r, nil
+}
+
+// deleteNamespaceArtifacts deletes the stored artifacts of a namespace
+func (c *Core) deleteNamespaceArtifacts(ctx context.Context, namespaceID string) error {
+	if c.artifactBucket == nil {
+		return nil
+	}
+
+	iter := c.artifactBucket.List(&blob.ListOptions{Prefix: scheduler.NamespaceArtifactsPrefix(namespaceID)})
+	for {
+		obj, err := iter.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not list artifacts of namespace %s: %w", namespaceID, err)
+		}
+		if err := c.artifactBucket.Delete(ctx, obj.Key); err != nil {
+			return fmt.Errorf("could not delete artifact %s: %w", obj.Key, err)
+		}
+	}
+}
+
+func repoArtifactToArtifact(a repo.ExecutionArtifact) models.ExecutionArtifact {
+	return models.ExecutionArtifact{
+		ID:         a.Uuid.String(),
+		ActionID:   a.ActionID,
+		NodeName:   a.NodeName,
+		Path:       a.Path,
+		SizeBytes:  a.SizeBytes,
+		Checksum:   a.Checksum,
+		StorageKey: a.StorageKey,
+		CreatedAt:  a.CreatedAt,
+	}
+}
@@ -3,12 +3,14 @@ package core
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/cvhariharan/flowctl/internal/core/models"
 	"github.com/cvhariharan/flowctl/internal/repo"
 	"github.com/cvhariharan/flowctl/internal/scheduler"
 	"github.com/cvhariharan/flowctl/internal/streamlogger"
+	"gocloud.dev/blob"
 	"gocloud.dev/secrets"
 )
 
@@ -29,6 +31,13 @@ type Core struct {
 	enforcer *casbin.Enforcer
 
 	flowDirectory string
+
+	// inventoryDirectory contains the files and scripts read by inventory sources
+	inventoryDirectory     string
+	inventoryScriptTimeout time.Duration
+
+	// artifactBucket stores the artifacts declared by actions, artifacts can not be downloaded when it is nil
+	artifactBucket *blob.Bucket
 }
 
 func NewCore(flowsDirectory string, s repo.Store, sch scheduler.TaskScheduler, keeper *secrets.Keeper, enforcer *casbin.Enforcer) (*Core, error) {
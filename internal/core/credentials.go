@@ -2,12 +2,15 @@ package core
 
 import (
 	"context"
+	"database/sql"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/cvhariharan/flowctl/internal/core/models"
 	"github.com/cvhariharan/flowctl/internal/repo"
+	"github.com/cvhariharan/flowctl/sdk/executor"
 	"github.com/google/uuid"
 )
 
@@ -29,6 +32,10 @@ func (c *Core) CreateCredential(ctx context.Context, cred models.Credential, nam
 		return models.Credential{}, errors.New("key type is required")
 	}
 
+	if err := cred.ValidateKeyData(); err != nil {
+		return models.Credential{}, err
+	}
+
 	enc, err := c.keeper.Encrypt(ctx, []byte(cred.KeyData))
 	if err != nil {
 		return models.Credential{}, err
@@ -146,6 +153,10 @@ func (c *Core) UpdateCredential(ctx context.Context, id string, cred *models.Cre
 		return models.Credential{}, errors.New("key type is required")
 	}
 
+	if err := cred.ValidateKeyData(); err != nil {
+		return models.Credential{}, err
+	}
+
 	uuidID, err := uuid.Parse(id)
 	if err != nil {
 		return models.Credential{}, err
@@ -198,3 +209,75 @@ func (c *Core) DeleteCredential(ctx context.Context, id string, namespaceID stri
 		Uuid_2: namespaceUUID,
 	})
 }
+
+// executorCredentialTypes are the credential types which can be read by executors. Credentials used to
+// authenticate to nodes, including SSH passwords and certificate authorities, are not available to executors
+var executorCredentialTypes = []string{
+	models.CredentialTypeKubeconfig,
+	models.CredentialTypeRegistry,
+}
+
+// GetExecutorCredential returns the decrypted credential by name for use in executors.
+// The credential must be of the key type requested by the executor
+func (c *Core) GetExecutorCredential(ctx context.Context, name string, keyType string, namespaceID string) (executor.Credential, error) {
+	if !slices.Contains(executorCredentialTypes, keyType) {
+		return executor.Credential{}, fmt.Errorf("credentials of type %q are not available to executors", keyType)
+	}
+	return c.getDecryptedCredential(ctx, name, keyType, namespaceID)
+}
+
+// GetBecomeCredential returns the decrypted password credential used for privilege escalation on nodes.
+// It is only used by the scheduler and is never passed to executors
+func (c *Core) GetBecomeCredential(ctx context.Context, name string, namespaceID string) (string, error) {
+	cred, err := c.getDecryptedCredential(ctx, name, models.CredentialTypePassword, namespaceID)
+	if err != nil {
+		return "", err
+	}
+	return cred.KeyData, nil
+}
+
+// getDecryptedCredential returns the decrypted credential by name, it must be of the given key type
+func (c *Core) getDecryptedCredential(ctx context.Context, name string, keyType string, namespaceID string) (executor.Credential, error) {
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return executor.Credential{}, fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	cred, err := c.store.GetCredentialByName(ctx, repo.GetCredentialByNameParams{
+		Name: name,
+		Uuid: namespaceUUID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return executor.Credential{}, fmt.Errorf("credential %s not found", name)
+		}
+		return executor.Credential{}, fmt.Errorf("could not get credential %s: %w", name, err)
+	}
+
+	if cred.KeyType != keyType {
+		return executor.Credential{}, fmt.Errorf("credential %s is of type %s, expected %s", name, cred.KeyType, keyType)
+	}
+
+	encrypted, err := hex.DecodeString(cred.KeyData)
+	if err != nil {
+		return executor.Credential{}, fmt.Errorf("could not decode credential %s: %w", name, err)
+	}
+
+	decrypted, err := c.keeper.Decrypt(ctx, encrypted)
+	if err != nil {
+		return executor.Credential{}, fmt.Errorf("could not decrypt credential %s: %w", name, err)
+	}
+
+	if _, err := c.store.AccessCredential(ctx, repo.AccessCredentialParams{
+		Uuid:   cred.Uuid,
+		Uuid_2: namespaceUUID,
+	}); err != nil {
+		return executor.Credential{}, fmt.Errorf("could not update credential %s: %w", name, err)
+	}
+
+	return executor.Credential{
+		Name:    cred.Name,
+		KeyType: cred.KeyType,
+		KeyData: string(decrypted),
+	}, nil
+}
@@ -157,7 +157,8 @@ func (c *Core) GetFlowFromLogID(logID string, namespaceID string) (models.Flow,
 
 // QueueFlowExecution adds a flow in the execution queue. The ID returned is the execution queue ID.
 // Exec ID should be universally unique, this is used to create the log stream and identify each execution
-func (c *Core) QueueFlowExecution(ctx context.Context, f models.Flow, input map[string]interface{}, userUUID string, namespaceID string) (string, error) {
+// Executions with a higher priority are picked up first within the namespace's share of workers
+func (c *Core) QueueFlowExecution(ctx context.Context, f models.Flow, input map[string]interface{}, priority int, userUUID string, namespaceID string) (string, error) {
 	if !f.Meta.AllowOverlap {
 		namespaceUUID, err := uuid.Parse(namespaceID)
 		if err != nil {
@@ -175,7 +176,7 @@ func (c *Core) QueueFlowExecution(ctx context.Context, f models.Flow, input map[
 		}
 	}
 
-	info, err := c.queueFlow(ctx, f, input, "", 0, userUUID, namespaceID)
+	info, err := c.queueFlow(ctx, f, input, "", 0, priority, userUUID, namespaceID)
 	if err != nil {
 		return "", err
 	}
@@ -200,7 +201,8 @@ func (c *Core) ResumeFlowExecution(ctx context.Context, execID string, actionID
 		return err
 	}
 
-	if _, err := c.queueFlow(ctx, f, exec.Input, execID, actionIndex, userUUID, namespaceID); err != nil {
+	// Resumed executions keep the priority they were triggered with
+	if _, err := c.queueFlow(ctx, f, exec.Input, execID, actionIndex, exec.Priority, userUUID, namespaceID); err != nil {
 		return err
 	}
 
@@ -224,17 +226,48 @@ func (c *Core) GetNodesByNames(ctx context.Context, nodeNames []string, namespac
 
 	var nodes []models.Node
 	for _, v := range n {
-		key := v.CredentialKeyData.String
+		// Docker nodes do not have a credential
+		var decryptedKey []byte
+		var credentialID string
+		if v.CredentialKeyData.Valid {
+			credentialID = v.CredentialUuid.UUID.String()
+
+			// decrypt the key
+			dKey, err := hex.DecodeString(v.CredentialKeyData.String)
+			if err != nil {
+				return nil, fmt.Errorf("could not decode key for node %s: %w", v.Name, err)
+			}
 
-		// decrypt the key
-		dKey, err := hex.DecodeString(key)
-		if err != nil {
-			return nil, fmt.Errorf("could not decode key for node %s: %w", v.Name, err)
+			decryptedKey, err = c.keeper.Decrypt(ctx, []byte(dKey))
+			if err != nil {
+				return nil, fmt.Errorf("could not decrypt key for node %s: %w", v.Name, err)
+			}
 		}
 
-		decryptedKey, err := c.keeper.Decrypt(ctx, []byte(dKey))
-		if err != nil {
-			return nil, fmt.Errorf("could not decrypt key for node %s: %w", v.Name, err)
+		var becomeCredID string
+		var becomePassword []byte
+		if v.BecomeCredentialKeyData.Valid {
+			becomeCredID = v.BecomeCredentialUuid.UUID.String()
+
+			dKey, err := hex.DecodeString(v.BecomeCredentialKeyData.String)
+			if err != nil {
+				return nil, fmt.Errorf("could not decode become password for node %s: %w", v.Name, err)
+			}
+
+			becomePassword, err = c.keeper.Decrypt(ctx, dKey)
+			if err != nil {
+				return nil, fmt.Errorf("could not decrypt become password for node %s: %w", v.Name, err)
+			}
+		}
+
+		var jumpHost *models.Node
+		var jumpHostID string
+		if v.JumpHostID.Valid {
+			jumpHost, err = c.getJumpHost(ctx, v.JumpHostID.Int32, namespaceUUID)
+			if err != nil {
+				return nil, fmt.Errorf("could not get jump host for node %s: %w", v.Name, err)
+			}
+			jumpHostID = jumpHost.ID
 		}
 
 		nodes = append(nodes, models.Node{
@@ -246,11 +279,22 @@ func (c *Core) GetNodesByNames(ctx context.Context, nodeNames []string, namespac
 			OSFamily:       v.OsFamily,
 			Tags:           v.Tags,
 			ConnectionType: string(v.ConnectionType),
+			MaxConcurrency: int(v.MaxConcurrency),
 			Auth: models.NodeAuth{
-				CredentialID: v.CredentialUuid.UUID.String(),
+				CredentialID: credentialID,
 				Method:       models.AuthMethod(v.AuthMethod),
 				Key:          string(decryptedKey),
 			},
+			Become: models.NodeBecome{
+				Enabled:      v.Become,
+				User:         v.BecomeUser,
+				Method:       v.BecomeMethod,
+				CredentialID: becomeCredID,
+				Password:     string(becomePassword),
+			},
+			HostKey:    v.HostKey,
+			JumpHostID: jumpHostID,
+			JumpHost:   jumpHost,
 		})
 	}
 
@@ -262,7 +306,7 @@ func (c *Core) GetNodesByNames(ctx context.Context, nodeNames []string, namespac
 }
 
 // queueFlow adds a flow to the execution queue. If the actionIndex is not zero, it is moved to a resume queue.
-func (c *Core) queueFlow(ctx context.Context, f models.Flow, input map[string]interface{}, execID string, actionIndex int, userUUID string, namespaceID string) (string, error) {
+func (c *Core) queueFlow(ctx context.Context, f models.Flow, input map[string]interface{}, execID string, actionIndex int, priority int, userUUID string, namespaceID string) (string, error) {
 	// If execID is empty, it is a new flow execution
 	if execID == "" {
 		execID = uuid.NewString()
@@ -303,6 +347,7 @@ func (c *Core) queueFlow(ctx context.Context, f models.Flow, input map[string]in
 		TriggerType:       scheduler.TriggerTypeManual,
 		UserUUID:          userUUID,
 		FlowDirectory:     filepath.Dir(fl.FilePath),
+		Priority:          priority,
 	}
 
 	// Create execution log for manual flows before queuing (needed for immediate API calls)
@@ -318,6 +363,7 @@ func (c *Core) queueFlow(ctx context.Context, f models.Flow, input map[string]in
 		TriggerType: repo.TriggerTypeManual,
 		Uuid:        userID,
 		Uuid_2:      namespaceUUID,
+		Priority:    int32(priority),
 	})
 	if err != nil {
 		return "", fmt.Errorf("could not add entry to execution log: %w", err)
@@ -428,6 +474,33 @@ func (c *Core) GetExecutionSummaryByExecID(ctx context.Context, execID string, n
 		return models.ExecutionSummary{}, fmt.Errorf("could not get exec %s by exec id: %w", execID, err)
 	}
 
+	certs, err := c.store.GetExecutionSSHCertificates(ctx, repo.GetExecutionSSHCertificatesParams{
+		ExecID: execID,
+		Uuid:   namespaceUUID,
+	})
+	if err != nil {
+		return models.ExecutionSummary{}, fmt.Errorf("could not get SSH certificates for exec %s: %w", execID, err)
+	}
+
+	sshCerts := make([]models.SSHCertificate, 0, len(certs))
+	for _, cert := range certs {
+		sshCerts = append(sshCerts, models.SSHCertificate{
+			ActionID:    cert.ActionID,
+			NodeName:    cert.NodeName,
+			Serial:      uint64(cert.Serial),
+			KeyID:       cert.KeyID,
+			Principals:  cert.Principals,
+			ValidAfter:  cert.ValidAfter,
+			ValidBefore: cert.ValidBefore,
+			CreatedAt:   cert.CreatedAt,
+		})
+	}
+
+	artifacts, err := c.getExecutionArtifacts(ctx, execID, namespaceUUID)
+	if err != nil {
+		return models.ExecutionSummary{}, err
+	}
+
 	return models.ExecutionSummary{
 		ExecID:          execID,
 		Input:           e.Input,
@@ -440,9 +513,34 @@ func (c *Core) GetExecutionSummaryByExecID(ctx context.Context, execID string, n
 		TriggeredByName: e.TriggeredByName,
 		TriggeredByID:   e.TriggeredByUuid.String(),
 		CurrentActionID: e.CurrentActionID.String,
+		SSHCertificates: sshCerts,
+		Artifacts:       artifacts,
 	}, nil
 }
 
+// RecordSSHCertificate stores a certificate issued during an execution so that connections made with it can be audited
+func (c *Core) RecordSSHCertificate(ctx context.Context, namespaceID string, cert scheduler.SSHCertificate) error {
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	if err := c.store.AddExecutionSSHCertificate(ctx, repo.AddExecutionSSHCertificateParams{
+		ExecID:      cert.ExecID,
+		ActionID:    cert.ActionID,
+		NodeName:    cert.NodeName,
+		Serial:      int64(cert.Serial),
+		KeyID:       cert.KeyID,
+		Principals:  cert.Principals,
+		ValidAfter:  cert.ValidAfter,
+		ValidBefore: cert.ValidBefore,
+		Uuid:        namespaceUUID,
+	}); err != nil {
+		return fmt.Errorf("could not record SSH certificate %d for exec %s: %w", cert.Serial, cert.ExecID, err)
+	}
+	return nil
+}
+
 func (c *Core) GetInputForExec(ctx context.Context, execID string, namespaceID string) (map[string]interface{}, error) {
 	var input map[string]interface{}
 	namespaceUUID, err := uuid.Parse(namespaceID)
@@ -493,6 +591,7 @@ func (c *Core) GetExecutionByExecID(ctx context.Context, execID string, namespac
 		Input:       input,
 		ErrorMsg:    e.Error.String,
 		TriggeredBy: u.Uuid.String(),
+		Priority:    int(e.Priority),
 	}, nil
 }
 
@@ -0,0 +1,598 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cvhariharan/flowctl/internal/core/models"
+	"github.com/cvhariharan/flowctl/internal/inventory"
+	"github.com/cvhariharan/flowctl/internal/repo"
+	"github.com/google/uuid"
+)
+
+const (
+	// minInventorySyncInterval prevents sources from running scripts more often than the scheduler checks them
+	minInventorySyncInterval = time.Minute
+	// maxInventoryScriptError is the length of the script output included in sync errors
+	maxInventoryScriptError = 1024
+)
+
+// SetInventoryConfig sets the directory containing the files and scripts of inventory sources.
+// Inventory sources can not be synced when the directory is empty
+func (c *Core) SetInventoryConfig(directory string, scriptTimeout time.Duration) {
+	c.inventoryDirectory = directory
+	c.inventoryScriptTimeout = scriptTimeout
+}
+
+// ImportNodes creates nodes for the hosts of an inventory. Nodes with the same name which are not
+// managed by an inventory source are updated. Nodes are never removed by an import
+func (c *Core) ImportNodes(ctx context.Context, format string, data []byte, defaults models.InventoryDefaults, namespaceID string) (models.InventoryReport, error) {
+	hosts, err := inventory.Parse(format, data)
+	if err != nil {
+		return models.InventoryReport{}, fmt.Errorf("could not parse inventory: %w", err)
+	}
+	if len(hosts) == 0 {
+		return models.InventoryReport{}, errors.New("inventory does not contain any hosts")
+	}
+
+	return c.applyInventory(ctx, hosts, defaults, 0, namespaceID)
+}
+
+// applyInventory creates or updates a node for each host. When sourceID is set, the created nodes
+// are managed by the source and its nodes which are not in the inventory are removed
+func (c *Core) applyInventory(ctx context.Context, hosts []inventory.Host, defaults models.InventoryDefaults, sourceID int32, namespaceID string) (models.InventoryReport, error) {
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return models.InventoryReport{}, fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	existing, err := c.store.GetNodesByNamespace(ctx, namespaceUUID)
+	if err != nil {
+		return models.InventoryReport{}, fmt.Errorf("could not get nodes: %w", err)
+	}
+	byName := make(map[string]repo.Node)
+	for _, n := range existing {
+		byName[n.Name] = n
+	}
+
+	report := models.InventoryReport{
+		Added:   make([]string, 0),
+		Updated: make([]string, 0),
+		Removed: make([]string, 0),
+		Errors:  make([]string, 0),
+	}
+	addError := func(name string, err error) {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", name, err))
+	}
+
+	seen := make(map[string]bool)
+	for _, h := range hosts {
+		seen[h.Name] = true
+
+		node, err := c.inventoryNode(ctx, h, defaults, namespaceUUID)
+		if err != nil {
+			addError(h.Name, err)
+			continue
+		}
+
+		current, ok := byName[h.Name]
+		if !ok {
+			created, err := c.CreateNode(ctx, &node, namespaceID)
+			if err != nil {
+				addError(h.Name, fmt.Errorf("could not create node: %w", err))
+				continue
+			}
+			if sourceID != 0 {
+				if err := c.store.SetNodeInventorySource(ctx, repo.SetNodeInventorySourceParams{
+					Uuid:              uuid.MustParse(created.ID),
+					InventorySourceID: sql.NullInt32{Int32: sourceID, Valid: true},
+				}); err != nil {
+					addError(h.Name, fmt.Errorf("could not set inventory source of node: %w", err))
+					continue
+				}
+			}
+			report.Added = append(report.Added, h.Name)
+			continue
+		}
+
+		// Nodes created by hand or by another source are not taken over
+		if current.InventorySourceID.Int32 != sourceID {
+			addError(h.Name, errors.New("a node with this name already exists and is not managed by this inventory"))
+			continue
+		}
+
+		currentNode, err := c.GetNodeByID(ctx, current.Uuid.String(), namespaceID)
+		if err != nil {
+			addError(h.Name, fmt.Errorf("could not get node: %w", err))
+			continue
+		}
+
+		// Settings which are not part of the inventory, like become and jump hosts, are kept
+		updated := currentNode
+		updated.Hostname = node.Hostname
+		updated.Port = node.Port
+		updated.Username = node.Username
+		updated.ConnectionType = node.ConnectionType
+		updated.Tags = node.Tags
+		updated.Auth = node.Auth
+		if !inventoryNodeChanged(currentNode, updated) {
+			continue
+		}
+
+		if _, err := c.UpdateNode(ctx, current.Uuid.String(), &updated, namespaceID); err != nil {
+			addError(h.Name, fmt.Errorf("could not update node: %w", err))
+			continue
+		}
+		report.Updated = append(report.Updated, h.Name)
+	}
+
+	if sourceID == 0 {
+		return report, nil
+	}
+
+	for _, n := range existing {
+		if !n.InventorySourceID.Valid || n.InventorySourceID.Int32 != sourceID || seen[n.Name] {
+			continue
+		}
+		if err := c.DeleteNode(ctx, n.Uuid.String(), namespaceID); err != nil {
+			addError(n.Name, fmt.Errorf("could not remove node: %w", err))
+			continue
+		}
+		report.Removed = append(report.Removed, n.Name)
+	}
+
+	return report, nil
+}
+
+// inventoryNode creates the node for an inventory host, the defaults are used for fields
+// which are not set by the host
+func (c *Core) inventoryNode(ctx context.Context, h inventory.Host, defaults models.InventoryDefaults, namespaceUUID uuid.UUID) (models.Node, error) {
+	node := models.Node{
+		Name:           h.Name,
+		Hostname:       h.Hostname,
+		Port:           h.Port,
+		Username:       h.Username,
+		OSFamily:       "linux",
+		ConnectionType: h.ConnectionType,
+		Tags:           h.Tags,
+	}
+	if node.Port == 0 {
+		node.Port = defaults.Port
+	}
+	if node.Username == "" {
+		node.Username = defaults.Username
+	}
+	if node.ConnectionType == "" {
+		node.ConnectionType = defaults.ConnectionType
+	}
+	if node.ConnectionType == "" {
+		node.ConnectionType = models.ConnectionTypeSSH
+	}
+
+	// Docker nodes are containers on the server and do not use the SSH settings
+	if node.ConnectionType == models.ConnectionTypeDocker {
+		node.Port = 0
+		return node, nil
+	}
+
+	if node.Port == 0 {
+		node.Port = 22
+	}
+	if node.Username == "" {
+		return models.Node{}, errors.New("username is required, set ansible_user or a default username")
+	}
+
+	var credentialID uuid.UUID
+	var keyType string
+	switch {
+	case h.Credential != "":
+		credential, err := c.store.GetCredentialByName(ctx, repo.GetCredentialByNameParams{
+			Name: h.Credential,
+			Uuid: namespaceUUID,
+		})
+		if err != nil {
+			return models.Node{}, fmt.Errorf("credential %s not found", h.Credential)
+		}
+		credentialID, keyType = credential.Uuid, credential.KeyType
+	case defaults.CredentialID != "":
+		id, err := uuid.Parse(defaults.CredentialID)
+		if err != nil {
+			return models.Node{}, errors.New("invalid credential ID format")
+		}
+		credential, err := c.store.GetCredentialByUUID(ctx, repo.GetCredentialByUUIDParams{
+			Uuid:   id,
+			Uuid_2: namespaceUUID,
+		})
+		if err != nil {
+			return models.Node{}, errors.New("default credential not found")
+		}
+		credentialID, keyType = credential.Uuid, credential.KeyType
+	default:
+		return models.Node{}, errors.New("credential is required, set flowctl_credential or a default credential")
+	}
+
+	// The auth method follows from the type of the credential
+	switch keyType {
+	case models.CredentialTypePrivateKey:
+		node.Auth.Method = models.AuthMethodPrivateKey
+	case models.CredentialTypePassword:
+		node.Auth.Method = models.AuthMethodPassword
+	case models.CredentialTypeSSHCA:
+		node.Auth.Method = models.AuthMethodSSHCA
+	default:
+		return models.Node{}, fmt.Errorf("%s credentials can not be used to connect to nodes", keyType)
+	}
+	node.Auth.CredentialID = credentialID.String()
+
+	return node, nil
+}
+
+func inventoryNodeChanged(a, b models.Node) bool {
+	return a.Hostname != b.Hostname ||
+		a.Port != b.Port ||
+		a.Username != b.Username ||
+		a.ConnectionType != b.ConnectionType ||
+		!slices.Equal(a.Tags, b.Tags) ||
+		a.Auth.CredentialID != b.Auth.CredentialID ||
+		a.Auth.Method != b.Auth.Method
+}
+
+func (c *Core) CreateInventorySource(ctx context.Context, source models.InventorySource, namespaceID string) (models.InventorySource, error) {
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return models.InventorySource{}, fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	credentialID, err := c.validateInventorySource(ctx, source, namespaceUUID)
+	if err != nil {
+		return models.InventorySource{}, err
+	}
+
+	created, err := c.store.CreateInventorySource(ctx, repo.CreateInventorySourceParams{
+		Name:           source.Name,
+		SourceType:     repo.InventorySourceType(source.Type),
+		Path:           source.Path,
+		Format:         source.Format,
+		SyncInterval:   int32(source.SyncInterval.Seconds()),
+		Port:           int32(source.Defaults.Port),
+		Username:       source.Defaults.Username,
+		ConnectionType: repo.ConnectionType(source.Defaults.ConnectionType),
+		CredentialID:   credentialID,
+		Uuid:           namespaceUUID,
+	})
+	if err != nil {
+		return models.InventorySource{}, fmt.Errorf("could not create inventory source: %w", err)
+	}
+
+	return c.GetInventorySourceByID(ctx, created.Uuid.String(), namespaceID)
+}
+
+func (c *Core) GetInventorySourceByID(ctx context.Context, id string, namespaceID string) (models.InventorySource, error) {
+	source, err := c.getInventorySource(ctx, id, namespaceID)
+	if err != nil {
+		return models.InventorySource{}, err
+	}
+	return inventorySourceFromRow(source), nil
+}
+
+func (c *Core) getInventorySource(ctx context.Context, id string, namespaceID string) (repo.GetInventorySourceByUUIDRow, error) {
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return repo.GetInventorySourceByUUIDRow{}, fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return repo.GetInventorySourceByUUIDRow{}, fmt.Errorf("invalid inventory source UUID: %w", err)
+	}
+
+	return c.store.GetInventorySourceByUUID(ctx, repo.GetInventorySourceByUUIDParams{
+		Uuid:   uuidID,
+		Uuid_2: namespaceUUID,
+	})
+}
+
+func (c *Core) ListInventorySources(ctx context.Context, namespaceID string) ([]models.InventorySource, error) {
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	sources, err := c.store.ListInventorySources(ctx, namespaceUUID)
+	if err != nil {
+		return nil, fmt.Errorf("could not list inventory sources: %w", err)
+	}
+
+	results := make([]models.InventorySource, 0, len(sources))
+	for _, s := range sources {
+		results = append(results, inventorySourceFromRow(repo.GetInventorySourceByUUIDRow(s)))
+	}
+	return results, nil
+}
+
+func (c *Core) UpdateInventorySource(ctx context.Context, id string, source models.InventorySource, namespaceID string) (models.InventorySource, error) {
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return models.InventorySource{}, fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return models.InventorySource{}, fmt.Errorf("invalid inventory source UUID: %w", err)
+	}
+
+	credentialID, err := c.validateInventorySource(ctx, source, namespaceUUID)
+	if err != nil {
+		return models.InventorySource{}, err
+	}
+
+	if _, err := c.store.UpdateInventorySource(ctx, repo.UpdateInventorySourceParams{
+		Uuid:           uuidID,
+		Name:           source.Name,
+		SourceType:     repo.InventorySourceType(source.Type),
+		Path:           source.Path,
+		Format:         source.Format,
+		SyncInterval:   int32(source.SyncInterval.Seconds()),
+		Port:           int32(source.Defaults.Port),
+		Username:       source.Defaults.Username,
+		ConnectionType: repo.ConnectionType(source.Defaults.ConnectionType),
+		CredentialID:   credentialID,
+		Uuid_2:         namespaceUUID,
+	}); err != nil {
+		return models.InventorySource{}, fmt.Errorf("could not update inventory source: %w", err)
+	}
+
+	return c.GetInventorySourceByID(ctx, id, namespaceID)
+}
+
+// DeleteInventorySource deletes the source, its nodes are kept and can be managed by hand
+func (c *Core) DeleteInventorySource(ctx context.Context, id string, namespaceID string) error {
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid inventory source UUID: %w", err)
+	}
+
+	return c.store.DeleteInventorySource(ctx, repo.DeleteInventorySourceParams{
+		Uuid:   uuidID,
+		Uuid_2: namespaceUUID,
+	})
+}
+
+// validateInventorySource checks the source and returns the database ID of its default credential
+func (c *Core) validateInventorySource(ctx context.Context, source models.InventorySource, namespaceUUID uuid.UUID) (sql.NullInt32, error) {
+	if source.Type != models.InventorySourceFile && source.Type != models.InventorySourceScript {
+		return sql.NullInt32{}, fmt.Errorf("unsupported inventory source type %q", source.Type)
+	}
+	switch source.Format {
+	case inventory.FormatCSV, inventory.FormatINI, inventory.FormatYAML, inventory.FormatJSON:
+	default:
+		return sql.NullInt32{}, fmt.Errorf("unsupported inventory format %q", source.Format)
+	}
+	if source.SyncInterval < minInventorySyncInterval {
+		return sql.NullInt32{}, fmt.Errorf("sync interval should be at least %s", minInventorySyncInterval)
+	}
+	if _, err := c.inventoryPath(source.Path); err != nil {
+		return sql.NullInt32{}, err
+	}
+
+	if source.Defaults.CredentialID == "" {
+		return sql.NullInt32{}, nil
+	}
+	credID, err := uuid.Parse(source.Defaults.CredentialID)
+	if err != nil {
+		return sql.NullInt32{}, errors.New("invalid credential ID format")
+	}
+	credential, err := c.store.GetCredentialByUUID(ctx, repo.GetCredentialByUUIDParams{
+		Uuid:   credID,
+		Uuid_2: namespaceUUID,
+	})
+	if err != nil {
+		return sql.NullInt32{}, errors.New("credential not found")
+	}
+	return sql.NullInt32{Int32: credential.ID, Valid: true}, nil
+}
+
+// inventoryPath resolves the path of a source in the inventory directory.
+// Paths outside the directory, including through symlinks, are rejected
+func (c *Core) inventoryPath(path string) (string, error) {
+	if c.inventoryDirectory == "" {
+		return "", errors.New("inventory sources are disabled, the inventory directory is not configured")
+	}
+
+	dir, err := filepath.Abs(c.inventoryDirectory)
+	if err != nil {
+		return "", fmt.Errorf("invalid inventory directory: %w", err)
+	}
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("could not read inventory directory: %w", err)
+	}
+
+	resolved, err := filepath.EvalSymlinks(filepath.Join(dir, path))
+	if err != nil {
+		return "", fmt.Errorf("could not find inventory %s: %w", path, err)
+	}
+
+	rel, err := filepath.Rel(dir, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("inventory %s is outside the inventory directory", path)
+	}
+	return resolved, nil
+}
+
+// readInventory reads an inventory file or the output of an inventory script
+func (c *Core) readInventory(ctx context.Context, sourceType string, path string) ([]byte, error) {
+	resolved, err := c.inventoryPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if sourceType == models.InventorySourceFile {
+		data, err := os.ReadFile(resolved)
+		if err != nil {
+			return nil, fmt.Errorf("could not read inventory: %w", err)
+		}
+		return data, nil
+	}
+
+	if c.inventoryScriptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.inventoryScriptTimeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, resolved, "--list")
+	cmd.Dir = filepath.Dir(resolved)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		output := strings.TrimSpace(stderr.String())
+		if len(output) > maxInventoryScriptError {
+			output = output[len(output)-maxInventoryScriptError:]
+		}
+		return nil, fmt.Errorf("inventory script failed: %w: %s", err, output)
+	}
+	return stdout.Bytes(), nil
+}
+
+// SyncInventorySource syncs the nodes of the source with its inventory and records the result
+func (c *Core) SyncInventorySource(ctx context.Context, id string, namespaceID string) (models.InventorySource, error) {
+	source, err := c.getInventorySource(ctx, id, namespaceID)
+	if err != nil {
+		return models.InventorySource{}, err
+	}
+
+	if _, err := c.runInventorySync(ctx, source); err != nil {
+		return models.InventorySource{}, err
+	}
+	return c.GetInventorySourceByID(ctx, id, namespaceID)
+}
+
+// SyncDueInventorySources syncs the sources in all namespaces whose sync interval has passed.
+// Sources are claimed before they are synced so that each is synced by a single instance
+func (c *Core) SyncDueInventorySources(ctx context.Context) error {
+	if c.inventoryDirectory == "" {
+		return nil
+	}
+
+	due, err := c.store.ClaimDueInventorySources(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get inventory sources to sync: %w", err)
+	}
+
+	for _, d := range due {
+		source, err := c.getInventorySource(ctx, d.Uuid.String(), d.NamespaceUuid.String())
+		if err != nil {
+			log.Printf("could not get inventory source %s: %v", d.Uuid, err)
+			continue
+		}
+
+		report, err := c.runInventorySync(ctx, source)
+		if err != nil {
+			log.Printf("could not sync inventory source %s: %v", source.Name, err)
+			continue
+		}
+		if len(report.Added)+len(report.Updated)+len(report.Removed)+len(report.Errors) > 0 {
+			log.Printf("synced inventory source %s: %d added, %d updated, %d removed, %d errors",
+				source.Name, len(report.Added), len(report.Updated), len(report.Removed), len(report.Errors))
+		}
+	}
+	return nil
+}
+
+func (c *Core) runInventorySync(ctx context.Context, source repo.GetInventorySourceByUUIDRow) (models.InventoryReport, error) {
+	report, syncErr := c.syncInventorySource(ctx, source)
+
+	// A failed sync keeps the report of the last successful one
+	lastReport := source.LastReport
+	lastError := ""
+	if syncErr != nil {
+		lastError = syncErr.Error()
+	} else {
+		data, err := json.Marshal(report)
+		if err != nil {
+			return report, fmt.Errorf("could not encode sync report: %w", err)
+		}
+		lastReport = data
+	}
+
+	if err := c.store.SetInventorySourceResult(ctx, repo.SetInventorySourceResultParams{
+		ID:         source.ID,
+		LastError:  lastError,
+		LastReport: lastReport,
+	}); err != nil {
+		return report, fmt.Errorf("could not record sync result: %w", err)
+	}
+	return report, syncErr
+}
+
+func (c *Core) syncInventorySource(ctx context.Context, source repo.GetInventorySourceByUUIDRow) (models.InventoryReport, error) {
+	data, err := c.readInventory(ctx, string(source.SourceType), source.Path)
+	if err != nil {
+		return models.InventoryReport{}, err
+	}
+
+	hosts, err := inventory.Parse(source.Format, data)
+	if err != nil {
+		return models.InventoryReport{}, fmt.Errorf("could not parse inventory: %w", err)
+	}
+	// An empty inventory is more likely a broken script than every node being decommissioned
+	if len(hosts) == 0 {
+		return models.InventoryReport{}, errors.New("inventory does not contain any hosts, nodes were not removed")
+	}
+
+	defaults := models.InventoryDefaults{
+		Port:           int(source.Port),
+		Username:       source.Username,
+		ConnectionType: string(source.ConnectionType),
+	}
+	if source.CredentialUuid.Valid {
+		defaults.CredentialID = source.CredentialUuid.UUID.String()
+	}
+
+	return c.applyInventory(ctx, hosts, defaults, source.ID, source.NamespaceUuid.String())
+}
+
+func inventorySourceFromRow(s repo.GetInventorySourceByUUIDRow) models.InventorySource {
+	source := models.InventorySource{
+		ID:           s.Uuid.String(),
+		Name:         s.Name,
+		Type:         string(s.SourceType),
+		Path:         s.Path,
+		Format:       s.Format,
+		SyncInterval: time.Duration(s.SyncInterval) * time.Second,
+		Defaults: models.InventoryDefaults{
+			Port:           int(s.Port),
+			Username:       s.Username,
+			ConnectionType: string(s.ConnectionType),
+		},
+		NextSyncAt: s.NextSyncAt,
+		LastError:  s.LastError,
+	}
+	if s.CredentialUuid.Valid {
+		source.Defaults.CredentialID = s.CredentialUuid.UUID.String()
+	}
+	if s.LastSyncedAt.Valid {
+		source.LastSyncedAt = &s.LastSyncedAt.Time
+	}
+	// The report is empty until the first successful sync
+	json.Unmarshal(s.LastReport, &source.LastReport)
+	return source
+}
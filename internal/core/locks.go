@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cvhariharan/flowctl/internal/core/models"
+	"github.com/cvhariharan/flowctl/internal/repo"
+	"github.com/google/uuid"
+)
+
+// ListResourceLocks returns all the named locks in a namespace along with their holders and waiters
+func (c *Core) ListResourceLocks(ctx context.Context, namespaceID string) ([]models.ResourceLock, error) {
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	rows, err := c.store.ListResourceLocks(ctx, namespaceUUID)
+	if err != nil {
+		return nil, fmt.Errorf("could not list resource locks: %w", err)
+	}
+
+	locks := make([]models.ResourceLock, 0)
+	index := make(map[string]int)
+	for _, row := range rows {
+		i, ok := index[row.Name]
+		if !ok {
+			locks = append(locks, models.ResourceLock{Name: row.Name})
+			i = len(locks) - 1
+			index[row.Name] = i
+		}
+
+		entry := models.ResourceLockEntry{
+			ExecID:    row.ExecID,
+			ActionID:  row.ActionID.String,
+			FlowID:    row.FlowSlug,
+			FlowName:  row.FlowName,
+			CreatedAt: row.CreatedAt,
+		}
+		if row.AcquiredAt.Valid {
+			entry.AcquiredAt = &row.AcquiredAt.Time
+		}
+
+		if row.Status == repo.ResourceLockStatusHeld {
+			locks[i].Holder = &entry
+		} else {
+			locks[i].Waiters = append(locks[i].Waiters, entry)
+		}
+	}
+
+	return locks, nil
+}
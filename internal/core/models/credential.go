@@ -1,7 +1,23 @@
 package models
 
+import (
+	"fmt"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+	"golang.org/x/crypto/ssh"
+)
+
 const TimeFormat = "2006-01-02T15:04:05Z"
 
+const (
+	CredentialTypePrivateKey = "private_key"
+	CredentialTypePassword   = "password"
+	CredentialTypeKubeconfig = "kubeconfig"
+	CredentialTypeRegistry   = "registry"
+	// CredentialTypeSSHCA is the private key of an SSH certificate authority used to sign short-lived certificates
+	CredentialTypeSSHCA = "ssh_ca"
+)
+
 type Credential struct {
 	ID            string
 	Name          string
@@ -10,3 +26,17 @@ type Credential struct {
 	NamespaceUUID string
 	LastAccessed  string
 }
+
+// ValidateKeyData checks that the key data is valid for the credential type
+func (c Credential) ValidateKeyData() error {
+	switch c.KeyType {
+	case CredentialTypeRegistry:
+		_, err := executor.ParseRegistryAuth(c.KeyData)
+		return err
+	case CredentialTypeSSHCA:
+		if _, err := ssh.ParsePrivateKey([]byte(c.KeyData)); err != nil {
+			return fmt.Errorf("SSH CA credentials should be an unencrypted private key: %w", err)
+		}
+	}
+	return nil
+}
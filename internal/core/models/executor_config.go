@@ -0,0 +1,135 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+	"github.com/go-playground/validator/v10"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+var (
+	// compiled executor schemas, executors are registered at startup so schemas do not change afterwards
+	executorSchemas   = make(map[string]*jsonschema.Schema)
+	executorSchemasMu sync.Mutex
+
+	schemaErrPrinter = message.NewPrinter(language.English)
+)
+
+// RegisteredExecutor validates that the executor is registered with flowctl
+func RegisteredExecutor(fl validator.FieldLevel) bool {
+	return slices.Contains(executor.GetAllExecutors(), fl.Field().String())
+}
+
+// compileExecutorSchema returns the compiled config schema registered by the executor
+func compileExecutorSchema(name string) (*jsonschema.Schema, error) {
+	executorSchemasMu.Lock()
+	defer executorSchemasMu.Unlock()
+
+	if s, ok := executorSchemas[name]; ok {
+		return s, nil
+	}
+
+	schema, err := executor.GetSchema(name)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := toJSONValue(schema)
+	if err != nil {
+		return nil, fmt.Errorf("could not read schema for executor %s: %w", name, err)
+	}
+
+	url := fmt.Sprintf("executor://%s/schema.json", name)
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(url, doc); err != nil {
+		return nil, fmt.Errorf("invalid schema for executor %s: %w", name, err)
+	}
+	s, err := c.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema for executor %s: %w", name, err)
+	}
+
+	executorSchemas[name] = s
+	return s, nil
+}
+
+// validateActionConfig validates the with config of an action against the schema registered by its executor.
+// field is the path of the action used in error messages, e.g. actions[0]
+func validateActionConfig(field string, action Action) error {
+	schema, err := compileExecutorSchema(action.Executor)
+	if err != nil {
+		return err
+	}
+
+	with := action.With
+	if with == nil {
+		with = map[string]any{}
+	}
+	value, err := toJSONValue(with)
+	if err != nil {
+		return fmt.Errorf("%s.with: %w", field, err)
+	}
+
+	err = schema.Validate(value)
+	if err == nil {
+		return nil
+	}
+
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return fmt.Errorf("%s.with: %w", field, err)
+	}
+
+	var errs []error
+	for _, leaf := range validationLeaves(verr) {
+		errs = append(errs, fmt.Errorf("%s: %s", configFieldPath(field+".with", leaf.InstanceLocation), leaf.ErrorKind.LocalizedString(schemaErrPrinter)))
+	}
+	return errors.Join(errs...)
+}
+
+// validationLeaves returns the errors at the bottom of the validation error tree,
+// these point to the exact fields that failed validation
+func validationLeaves(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+
+	var leaves []*jsonschema.ValidationError
+	for _, cause := range err.Causes {
+		leaves = append(leaves, validationLeaves(cause)...)
+	}
+	return leaves
+}
+
+// configFieldPath converts a JSON pointer location into a path like actions[0].with.env[1].name
+func configFieldPath(prefix string, location []string) string {
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	for _, token := range location {
+		if _, err := strconv.Atoi(token); err == nil {
+			sb.WriteString("[" + token + "]")
+			continue
+		}
+		sb.WriteString("." + token)
+	}
+	return sb.String()
+}
+
+// toJSONValue converts a value into the generic representation expected by the schema validator
+func toJSONValue(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(b))
+}
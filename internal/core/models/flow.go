@@ -2,12 +2,19 @@ package models
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path"
 	"regexp"
 	"slices"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/cvhariharan/flowctl/internal/scheduler"
+	"github.com/cvhariharan/flowctl/internal/scheduler/storage"
+	"github.com/cvhariharan/flowctl/sdk/executor"
 	"github.com/expr-lang/expr"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
@@ -39,13 +46,24 @@ type Input struct {
 }
 
 type Action struct {
-	ID        string         `yaml:"id" huml:"id" validate:"required,alphanum_underscore"`
-	Name      string         `yaml:"name" huml:"name" validate:"required"`
-	Executor  string         `yaml:"executor" huml:"executor" validate:"required,oneof=script docker"`
-	With      map[string]any `yaml:"with" huml:"with" validate:"required"`
-	Approval  bool           `yaml:"approval" huml:"approval"`
-	Variables []Variable     `yaml:"variables" huml:"variables"`
-	On        []string       `yaml:"on" huml:"on"`
+	ID          string         `yaml:"id" huml:"id" validate:"required,alphanum_underscore"`
+	Name        string         `yaml:"name" huml:"name" validate:"required"`
+	Executor    string         `yaml:"executor" huml:"executor" validate:"required"`
+	With        map[string]any `yaml:"with" huml:"with" validate:"required"`
+	Approval    bool           `yaml:"approval" huml:"approval"`
+	Variables   []Variable     `yaml:"variables" huml:"variables"`
+	On          []string       `yaml:"on" huml:"on"`
+	Locks       []string       `yaml:"locks,omitempty" huml:"locks,omitempty" validate:"omitempty,dive,resource_name"`
+	LockTimeout string         `yaml:"lock_timeout,omitempty" huml:"lock_timeout,omitempty"`
+	// If is an expression evaluated for each node, the action runs only on nodes where it is true
+	If string `yaml:"if,omitempty" huml:"if,omitempty"`
+	// Artifacts are glob patterns of the files in $FC_ARTIFACTS that are stored after the action runs on a node
+	Artifacts []string `yaml:"artifacts,omitempty" huml:"artifacts,omitempty"`
+	// Become runs the action with privilege escalation on remote nodes, the node defaults are used when unset
+	Become           *bool  `yaml:"become,omitempty" huml:"become,omitempty"`
+	BecomeUser       string `yaml:"become_user,omitempty" huml:"become_user,omitempty"`
+	BecomeMethod     string `yaml:"become_method,omitempty" huml:"become_method,omitempty" validate:"omitempty,oneof=sudo su doas"`
+	BecomeCredential string `yaml:"become_credential,omitempty" huml:"become_credential,omitempty"`
 }
 
 func SchedulerActionToAction(a scheduler.Action) Action {
@@ -59,14 +77,28 @@ func SchedulerActionToAction(a scheduler.Action) Action {
 		nodeNames = append(nodeNames, node.Name)
 	}
 
+	var lockTimeout string
+	if a.LockTimeout > 0 {
+		lockTimeout = a.LockTimeout.String()
+	}
+
 	return Action{
-		ID:        a.ID,
-		Name:      a.Name,
-		With:      a.With,
-		On:        nodeNames,
-		Executor:  a.Executor,
-		Approval:  a.Approval,
-		Variables: variables,
+		ID:          a.ID,
+		Name:        a.Name,
+		With:        a.With,
+		On:          nodeNames,
+		Executor:    a.Executor,
+		Approval:    a.Approval,
+		Variables:   variables,
+		Locks:       a.Locks,
+		LockTimeout: lockTimeout,
+		If:          a.If,
+		Artifacts:   a.Artifacts,
+
+		Become:           a.Become,
+		BecomeUser:       a.BecomeUser,
+		BecomeMethod:     a.BecomeMethod,
+		BecomeCredential: a.BecomeCredential,
 	}
 }
 
@@ -79,6 +111,9 @@ type Metadata struct {
 	SrcDir       string   `yaml:"-" huml:"-"`
 	Namespace    string   `yaml:"namespace" huml:"namespace"`
 	AllowOverlap bool     `yaml:"allow_overlap" huml:"allow_overlap"`
+	Idempotent   bool     `yaml:"idempotent,omitempty" huml:"idempotent,omitempty"`
+	Locks        []string `yaml:"locks,omitempty" huml:"locks,omitempty" validate:"omitempty,dive,resource_name"`
+	LockTimeout  string   `yaml:"lock_timeout,omitempty" huml:"lock_timeout,omitempty"`
 }
 
 type Variable map[string]any
@@ -144,10 +179,26 @@ func AlphanumericSpace(fl validator.FieldLevel) bool {
 	return regex.MatchString(value)
 }
 
+// Priority validates the priority of an execution
+func Priority(fl validator.FieldLevel) bool {
+	p := fl.Field().Int()
+	return p >= storage.MinPriority && p <= storage.MaxPriority
+}
+
+// ResourceName validates names used for shared resources like locks.
+// Alphabets, numbers, hyphens and underscores are allowed
+func ResourceName(fl validator.FieldLevel) bool {
+	regex := regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	value := fl.Field().String()
+
+	return regex.MatchString(value)
+}
+
 func (f Flow) Validate() error {
 	validate := validator.New()
 
 	validate.RegisterValidation("alphanum_underscore", AlphanumericUnderscore)
+	validate.RegisterValidation("resource_name", ResourceName)
 
 	actionsIDs := make(map[string]int)
 	for _, action := range f.Actions {
@@ -158,6 +209,41 @@ func (f Flow) Validate() error {
 		actionsIDs[action.ID] = 1
 	}
 
+	if _, err := ParseLockTimeout(f.Meta.LockTimeout); err != nil {
+		return fmt.Errorf("invalid lock_timeout for flow %s: %w", f.Meta.ID, err)
+	}
+	for _, action := range f.Actions {
+		if _, err := ParseLockTimeout(action.LockTimeout); err != nil {
+			return fmt.Errorf("invalid lock_timeout for action %s: %w", action.ID, err)
+		}
+		if action.If != "" {
+			if _, err := expr.Compile(action.If, expr.AsBool()); err != nil {
+				return fmt.Errorf("invalid if expression for action %s: %w", action.ID, err)
+			}
+		}
+		for _, pattern := range action.Artifacts {
+			if !doublestar.ValidatePattern(pattern) || path.IsAbs(pattern) {
+				return fmt.Errorf("invalid artifact pattern %q for action %s", pattern, action.ID)
+			}
+		}
+	}
+
+	// Validate the executor config of each action against the schema registered by the executor
+	executors := executor.GetAllExecutors()
+	slices.Sort(executors)
+	var configErrs []error
+	for i, action := range f.Actions {
+		if !slices.Contains(executors, action.Executor) {
+			return fmt.Errorf("actions[%d].executor: unknown executor %q for action %s, available executors: %s", i, action.Executor, action.ID, strings.Join(executors, ", "))
+		}
+		if err := validateActionConfig(fmt.Sprintf("actions[%d]", i), action); err != nil {
+			configErrs = append(configErrs, err)
+		}
+	}
+	if len(configErrs) > 0 {
+		return fmt.Errorf("invalid executor config: %w", errors.Join(configErrs...))
+	}
+
 	// Validate default values for inputs
 	for _, input := range f.Inputs {
 		if err := validateDefaultValue(input); err != nil {
@@ -201,6 +287,23 @@ func (f Flow) IsSchedulable() bool {
 	return true
 }
 
+// ParseLockTimeout parses the lock_timeout value of a flow or an action.
+// An empty value means the execution fails fast if the lock is already held
+func ParseLockTimeout(timeout string) (time.Duration, error) {
+	if timeout == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("lock timeout cannot be negative")
+	}
+	return d, nil
+}
+
 // validateDefaultValue validates that a default value matches the expected input type
 func validateDefaultValue(input Input) error {
 	if input.Default == "" {
@@ -308,6 +411,7 @@ type Execution struct {
 	Version     int64                  `json:"version"`
 	ErrorMsg    string                 `json:"error_msg"`
 	TriggeredBy string                 `json:"triggered_by"`
+	Priority    int                    `json:"priority"`
 }
 
 // FlowFormat represents the file format for flows
@@ -360,6 +464,47 @@ func MarshalFlow(f Flow, format FlowFormat) ([]byte, error) {
 	return data, nil
 }
 
+// convertToSchedulerNode converts a Node and its jump hosts to scheduler.Node
+func convertToSchedulerNode(node Node) scheduler.Node {
+	n := scheduler.Node{
+		ID:             node.ID,
+		Name:           node.Name,
+		Hostname:       node.Hostname,
+		Port:           node.Port,
+		Username:       node.Username,
+		OSFamily:       node.OSFamily,
+		ConnectionType: node.ConnectionType,
+		Tags:           node.Tags,
+		MaxConcurrency: node.MaxConcurrency,
+		Auth: scheduler.NodeAuth{
+			CredentialID: node.Auth.CredentialID,
+			Method:       scheduler.AuthMethod(node.Auth.Method),
+			Key:          node.Auth.Key,
+		},
+		Become: scheduler.NodeBecome{
+			Enabled:  node.Become.Enabled,
+			User:     node.Become.User,
+			Method:   node.Become.Method,
+			Password: node.Become.Password,
+		},
+		HostKey: node.HostKey,
+	}
+	if node.JumpHost != nil {
+		jumpHost := convertToSchedulerNode(*node.JumpHost)
+		n.JumpHost = &jumpHost
+	}
+	return n
+}
+
+// ConvertToSchedulerNodes converts nodes and their jump hosts to scheduler.Node
+func ConvertToSchedulerNodes(nodes []Node) []scheduler.Node {
+	schedulerNodes := make([]scheduler.Node, 0, len(nodes))
+	for _, node := range nodes {
+		schedulerNodes = append(schedulerNodes, convertToSchedulerNode(node))
+	}
+	return schedulerNodes
+}
+
 // convertToSchedulerFlow converts a Flow to scheduler.Flow
 func ConvertToSchedulerFlow(ctx context.Context, f Flow, namespaceUUID uuid.UUID, getNodesByNames func(context.Context, []string, uuid.UUID) ([]Node, error)) (scheduler.Flow, error) {
 	// Convert inputs
@@ -388,21 +533,7 @@ func ConvertToSchedulerFlow(ctx context.Context, f Flow, namespaceUUID uuid.UUID
 		// Convert nodes to scheduler format
 		var schedulerNodes []scheduler.Node
 		for _, node := range nodes {
-			schedulerNodes = append(schedulerNodes, scheduler.Node{
-				ID:             node.ID,
-				Name:           node.Name,
-				Hostname:       node.Hostname,
-				Port:           node.Port,
-				Username:       node.Username,
-				OSFamily:       node.OSFamily,
-				ConnectionType: node.ConnectionType,
-				Tags:           node.Tags,
-				Auth: scheduler.NodeAuth{
-					CredentialID: node.Auth.CredentialID,
-					Method:       scheduler.AuthMethod(node.Auth.Method),
-					Key:          node.Auth.Key,
-				},
-			})
+			schedulerNodes = append(schedulerNodes, convertToSchedulerNode(node))
 		}
 
 		// Convert variables
@@ -411,14 +542,28 @@ func ConvertToSchedulerFlow(ctx context.Context, f Flow, namespaceUUID uuid.UUID
 			variables = append(variables, scheduler.Variable(v))
 		}
 
+		lockTimeout, err := ParseLockTimeout(act.LockTimeout)
+		if err != nil {
+			return scheduler.Flow{}, fmt.Errorf("invalid lock_timeout for action %s: %w", act.ID, err)
+		}
+
 		actions = append(actions, scheduler.Action{
-			ID:        act.ID,
-			Name:      act.Name,
-			Executor:  act.Executor,
-			With:      act.With,
-			Approval:  act.Approval,
-			Variables: variables,
-			On:        schedulerNodes,
+			ID:          act.ID,
+			Name:        act.Name,
+			Executor:    act.Executor,
+			With:        act.With,
+			Approval:    act.Approval,
+			Variables:   variables,
+			On:          schedulerNodes,
+			Locks:       act.Locks,
+			LockTimeout: lockTimeout,
+			If:          act.If,
+			Artifacts:   act.Artifacts,
+
+			Become:           act.Become,
+			BecomeUser:       act.BecomeUser,
+			BecomeMethod:     act.BecomeMethod,
+			BecomeCredential: act.BecomeCredential,
 		})
 	}
 
@@ -428,6 +573,11 @@ func ConvertToSchedulerFlow(ctx context.Context, f Flow, namespaceUUID uuid.UUID
 		outputs = append(outputs, scheduler.Output(out))
 	}
 
+	flowLockTimeout, err := ParseLockTimeout(f.Meta.LockTimeout)
+	if err != nil {
+		return scheduler.Flow{}, fmt.Errorf("invalid lock_timeout for flow %s: %w", f.Meta.ID, err)
+	}
+
 	return scheduler.Flow{
 		Meta: scheduler.Metadata{
 			ID:          f.Meta.ID,
@@ -437,6 +587,9 @@ func ConvertToSchedulerFlow(ctx context.Context, f Flow, namespaceUUID uuid.UUID
 			Schedules:   f.Meta.Schedules,
 			SrcDir:      f.Meta.SrcDir,
 			Namespace:   f.Meta.Namespace,
+			Idempotent:  f.Meta.Idempotent,
+			Locks:       f.Meta.Locks,
+			LockTimeout: flowLockTimeout,
 		},
 		Inputs:  inputs,
 		Actions: actions,
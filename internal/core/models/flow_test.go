@@ -0,0 +1,106 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+)
+
+func init() {
+	executor.RegisterExecutor("schema_test", func(name string, driver executor.NodeDriver) (executor.Executor, error) {
+		return nil, nil
+	})
+	executor.RegisterSchema("schema_test", map[string]any{
+		"type":     "object",
+		"required": []string{"command"},
+		"properties": map[string]any{
+			"command": map[string]any{"type": "string"},
+			"retries": map[string]any{"type": "integer", "minimum": 0},
+			"env": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type":     "object",
+					"required": []string{"name"},
+					"properties": map[string]any{
+						"name": map[string]any{"type": "string"},
+					},
+				},
+			},
+		},
+		"additionalProperties": false,
+	})
+}
+
+func testFlow(actions ...Action) Flow {
+	return Flow{
+		Meta:    Metadata{ID: "deploy", Name: "Deploy"},
+		Inputs:  []Input{},
+		Actions: actions,
+	}
+}
+
+func TestValidateExecutorConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		with     map[string]any
+		wantErrs []string
+	}{
+		{name: "valid", with: map[string]any{"command": "make", "retries": 2, "env": []any{map[string]any{"name": "A"}}}},
+		{name: "wrong type", with: map[string]any{"command": "make", "retries": "3"}, wantErrs: []string{"actions[0].with.retries: got string, want integer"}},
+		{name: "missing field", with: map[string]any{}, wantErrs: []string{"actions[0].with: missing property 'command'"}},
+		{name: "unknown field", with: map[string]any{"command": "make", "comand": "make"}, wantErrs: []string{"actions[0].with: additional properties 'comand' not allowed"}},
+		{name: "nested field", with: map[string]any{"command": "make", "env": []any{map[string]any{"name": 1}}}, wantErrs: []string{"actions[0].with.env[0].name: got number, want string"}},
+		{
+			name:     "multiple errors",
+			with:     map[string]any{"command": 1, "retries": -1},
+			wantErrs: []string{"actions[0].with.command: got number, want string", "actions[0].with.retries: minimum: got -1, want 0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := testFlow(Action{ID: "build", Name: "Build", Executor: "schema_test", With: tt.with}).Validate()
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() succeeded, want %q", tt.wantErrs)
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %q, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateErrorsOfAllActions(t *testing.T) {
+	err := testFlow(
+		Action{ID: "first", Name: "First", Executor: "schema_test", With: map[string]any{"command": "make", "retries": "3"}},
+		Action{ID: "second", Name: "Second", Executor: "schema_test", With: map[string]any{"command": "make"}},
+		Action{ID: "third", Name: "Third", Executor: "schema_test", With: map[string]any{}},
+	).Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded")
+	}
+	for _, want := range []string{"actions[0].with.retries", "actions[2].with: missing property"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %q, want %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "actions[1]") {
+		t.Errorf("Validate() reported the valid action: %q", err)
+	}
+}
+
+func TestValidateUnknownExecutor(t *testing.T) {
+	err := testFlow(Action{ID: "build", Name: "Build", Executor: "scirpt", With: map[string]any{}}).Validate()
+	if err == nil || !strings.Contains(err.Error(), `actions[0].executor: unknown executor "scirpt"`) {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+const (
+	// InventorySourceFile reads an inventory file
+	InventorySourceFile = "file"
+	// InventorySourceScript runs an executable with --list and reads the inventory from its output
+	InventorySourceScript = "script"
+)
+
+// InventorySource is a dynamic inventory which is periodically synced to the nodes of a namespace.
+// Nodes created by a source are updated from the inventory and removed when they are no longer in it
+type InventorySource struct {
+	ID   string
+	Name string
+	Type string
+	// Path is relative to the inventory directory of the server
+	Path         string
+	Format       string
+	SyncInterval time.Duration
+	Defaults     InventoryDefaults
+	NextSyncAt   time.Time
+	LastSyncedAt *time.Time
+	LastError    string
+	LastReport   InventoryReport
+}
+
+// InventoryDefaults are used for hosts which do not set them in the inventory
+type InventoryDefaults struct {
+	Port           int
+	Username       string
+	ConnectionType string
+	// CredentialID is used by hosts which do not name a credential with flowctl_credential
+	CredentialID string
+}
+
+// InventoryReport lists the nodes changed by an import or a sync
+type InventoryReport struct {
+	Added   []string `json:"added"`
+	Updated []string `json:"updated"`
+	Removed []string `json:"removed"`
+	// Errors are hosts which could not be imported, they do not stop the other hosts from being imported
+	Errors []string `json:"errors"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type ResourceLockEntry struct {
+	ExecID     string
+	ActionID   string
+	FlowID     string
+	FlowName   string
+	CreatedAt  time.Time
+	AcquiredAt *time.Time
+}
+
+// ResourceLock is a named lock shared across flows in a namespace.
+// At most one execution holds a lock at a time, others wait in the order they requested it
+type ResourceLock struct {
+	Name    string
+	Holder  *ResourceLockEntry
+	Waiters []ResourceLockEntry
+}
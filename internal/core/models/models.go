@@ -149,6 +149,34 @@ type ExecutionSummary struct {
 	CurrentActionID string
 	CreatedAt       time.Time
 	CompletedAt     time.Time
+	// SSHCertificates are the certificates issued to connect to nodes, only set for a single execution
+	SSHCertificates []SSHCertificate
+	// Artifacts are the files stored for the actions of the execution, only set for a single execution
+	Artifacts []ExecutionArtifact
+}
+
+// ExecutionArtifact is a file declared as an artifact by an action, stored after the action ran on a node
+type ExecutionArtifact struct {
+	ID         string
+	ActionID   string
+	NodeName   string
+	Path       string
+	SizeBytes  int64
+	Checksum   string
+	StorageKey string
+	CreatedAt  time.Time
+}
+
+// SSHCertificate is a short-lived certificate signed by an SSH CA credential during an execution
+type SSHCertificate struct {
+	ActionID    string
+	NodeName    string
+	Serial      uint64
+	KeyID       string
+	Principals  []string
+	ValidAfter  time.Time
+	ValidBefore time.Time
+	CreatedAt   time.Time
 }
 
 func (e ExecutionSummary) Duration() string {
@@ -7,6 +7,8 @@ type Namespace struct {
 	Name      string    `json:"name"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// ArtifactRetentionDays overrides the retention of stored artifacts when set, 0 keeps them forever
+	ArtifactRetentionDays *int32 `json:"artifact_retention_days"`
 }
 
 type GroupNamespaceAccess struct {
@@ -1,10 +1,39 @@
 package models
 
+import (
+	"regexp"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var containerNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ContainerName validates the name or ID of a Docker container
+func ContainerName(fl validator.FieldLevel) bool {
+	return containerNameRegex.MatchString(fl.Field().String())
+}
+
 type AuthMethod string
 
 const (
 	AuthMethodPrivateKey AuthMethod = "private_key"
 	AuthMethodPassword   AuthMethod = "password"
+	// AuthMethodSSHCA authenticates using certificates signed by an SSH CA credential for each connection
+	AuthMethodSSHCA AuthMethod = "ssh_ca"
+)
+
+const (
+	ConnectionTypeSSH  = "ssh"
+	ConnectionTypeQSSH = "qssh"
+	// ConnectionTypeDocker nodes are running containers, commands are run in them using docker exec
+	ConnectionTypeDocker = "docker"
+)
+
+const (
+	BecomeMethodSudo = "sudo"
+	BecomeMethodSu   = "su"
+	BecomeMethodDoas = "doas"
 )
 
 type Node struct {
@@ -16,8 +45,20 @@ type Node struct {
 	OSFamily       string
 	ConnectionType string
 	Tags           []string
+	MaxConcurrency int
 	Auth           NodeAuth
-	NamespaceUUID  string
+	Become         NodeBecome
+	// HostKey is the trusted SSH host key in the authorized_keys format
+	HostKey string
+	// PendingHostKey is the host key presented by the node which has not been approved yet
+	PendingHostKey string
+	// JumpHostID is the node through which this node is reached
+	JumpHostID string
+	// JumpHost is the resolved jump host chain with credentials, it is only set for nodes used in executions
+	JumpHost      *Node
+	NamespaceUUID string
+	// Facts are recorded by the health check, nil until the node has been checked
+	Facts *NodeFacts
 }
 
 type NodeAuth struct {
@@ -26,8 +67,37 @@ type NodeAuth struct {
 	Key          string
 }
 
+// NodeBecome contains the default privilege escalation settings for actions run on the node
+type NodeBecome struct {
+	Enabled bool
+	// User is the user commands are run as, root when empty
+	User   string
+	Method string
+	// CredentialID is the password credential used when the method asks for a password
+	CredentialID string
+	Password     string
+}
+
+// NodeFacts are gathered from a node by the periodic health check. The facts of the last
+// successful check are kept while the node is unreachable
+type NodeFacts struct {
+	Reachable     bool
+	Error         string
+	LatencyMs     int
+	OS            string
+	Kernel        string
+	Arch          string
+	UptimeSeconds int64
+	DiskFreeBytes int64
+	CheckedAt     time.Time
+	// GatheredAt is the time of the last successful check, zero if the node was never reachable
+	GatheredAt time.Time
+}
+
 type NodeStats struct {
-	TotalHosts int64 `json:"total_hosts"`
-	SSHHosts   int64 `json:"ssh_hosts"`
-	QSSHHosts  int64 `json:"qssh_hosts"`
+	TotalHosts       int64 `json:"total_hosts"`
+	SSHHosts         int64 `json:"ssh_hosts"`
+	QSSHHosts        int64 `json:"qssh_hosts"`
+	DockerHosts      int64 `json:"docker_hosts"`
+	UnreachableHosts int64 `json:"unreachable_hosts"`
 }
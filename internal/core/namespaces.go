@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 
@@ -15,15 +16,15 @@ func (c *Core) CreateNamespace(ctx context.Context, namespace *models.Namespace)
 		return models.Namespace{}, errors.New("namespace name is required")
 	}
 
-	created, err := c.store.CreateNamespace(ctx, namespace.Name)
+	created, err := c.store.CreateNamespace(ctx, repo.CreateNamespaceParams{
+		Name:                  namespace.Name,
+		ArtifactRetentionDays: toNullRetention(namespace.ArtifactRetentionDays),
+	})
 	if err != nil {
 		return models.Namespace{}, err
 	}
 
-	return models.Namespace{
-		ID:   created.Uuid.String(),
-		Name: created.Name,
-	}, nil
+	return repoNamespaceToNamespace(created), nil
 }
 
 func (c *Core) GetNamespaceByID(ctx context.Context, id string) (models.Namespace, error) {
@@ -37,10 +38,7 @@ func (c *Core) GetNamespaceByID(ctx context.Context, id string) (models.Namespac
 		return models.Namespace{}, err
 	}
 
-	return models.Namespace{
-		ID:   namespace.Uuid.String(),
-		Name: namespace.Name,
-	}, nil
+	return repoNamespaceToNamespace(namespace), nil
 }
 
 func (c *Core) GetNamespaceByName(ctx context.Context, name string) (models.Namespace, error) {
@@ -74,8 +72,9 @@ func (c *Core) ListNamespaces(ctx context.Context, userID string, name string, l
 	results := make([]models.Namespace, len(namespaces))
 	for i, n := range namespaces {
 		results[i] = models.Namespace{
-			ID:   n.Uuid.String(),
-			Name: n.Name,
+			ID:                    n.Uuid.String(),
+			Name:                  n.Name,
+			ArtifactRetentionDays: fromNullRetention(n.ArtifactRetentionDays),
 		}
 	}
 
@@ -96,17 +95,15 @@ func (c *Core) UpdateNamespace(ctx context.Context, id string, namespace models.
 	}
 
 	updated, err := c.store.UpdateNamespace(ctx, repo.UpdateNamespaceParams{
-		Uuid: uuidID,
-		Name: namespace.Name,
+		Uuid:                  uuidID,
+		Name:                  namespace.Name,
+		ArtifactRetentionDays: toNullRetention(namespace.ArtifactRetentionDays),
 	})
 	if err != nil {
 		return models.Namespace{}, err
 	}
 
-	return models.Namespace{
-		ID:   updated.Uuid.String(),
-		Name: updated.Name,
-	}, nil
+	return repoNamespaceToNamespace(updated), nil
 }
 
 func (c *Core) DeleteNamespace(ctx context.Context, id string) error {
@@ -114,5 +111,32 @@ func (c *Core) DeleteNamespace(ctx context.Context, id string) error {
 	if err != nil {
 		return err
 	}
-	return c.store.DeleteNamespace(ctx, uuidID)
+	if err := c.store.DeleteNamespace(ctx, uuidID); err != nil {
+		return err
+	}
+
+	// The records of the artifacts are deleted with the namespace
+	return c.deleteNamespaceArtifacts(ctx, uuidID.String())
+}
+
+func repoNamespaceToNamespace(n repo.Namespace) models.Namespace {
+	return models.Namespace{
+		ID:                    n.Uuid.String(),
+		Name:                  n.Name,
+		ArtifactRetentionDays: fromNullRetention(n.ArtifactRetentionDays),
+	}
+}
+
+func toNullRetention(days *int32) sql.NullInt32 {
+	if days == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: *days, Valid: true}
+}
+
+func fromNullRetention(days sql.NullInt32) *int32 {
+	if !days.Valid {
+		return nil
+	}
+	return &days.Int32
 }
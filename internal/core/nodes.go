@@ -3,14 +3,21 @@ package core
 import (
 	"context"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
 
 	"github.com/cvhariharan/flowctl/internal/core/models"
 	"github.com/cvhariharan/flowctl/internal/repo"
+	"github.com/cvhariharan/flowctl/internal/scheduler"
+	"github.com/cvhariharan/flowctl/sdk/remoteclient"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// ErrJumpHostInUse is returned when deleting a node which is the jump host of other nodes
+var ErrJumpHostInUse = errors.New("node is the jump host of other nodes, change their jump host before deleting it")
+
 func (c *Core) CreateNode(ctx context.Context, node *models.Node, namespaceID string) (models.Node, error) {
 	namespaceUUID, err := uuid.Parse(namespaceID)
 	if err != nil {
@@ -24,37 +31,43 @@ func (c *Core) CreateNode(ctx context.Context, node *models.Node, namespaceID st
 		return models.Node{}, errors.New("hostname is required")
 	}
 
-	credID, err := uuid.Parse(node.Auth.CredentialID)
+	credential, err := c.getNodeCredential(ctx, node, namespaceUUID)
 	if err != nil {
-		return models.Node{}, errors.New("invalid credential ID format")
+		return models.Node{}, err
 	}
 
-	credential, err := c.store.GetCredentialByUUID(ctx, repo.GetCredentialByUUIDParams{
-		Uuid:   credID,
-		Uuid_2: namespaceUUID,
-	})
+	becomeCredID, err := c.getBecomeCredentialID(ctx, node.Become.CredentialID, namespaceUUID)
 	if err != nil {
-		return models.Node{}, errors.New("credential not found")
+		return models.Node{}, err
+	}
+
+	jumpHostID, err := c.getJumpHostID(ctx, node.JumpHostID, 0, node.ConnectionType, namespaceUUID)
+	if err != nil {
+		return models.Node{}, err
 	}
 
 	created, err := c.store.CreateNode(ctx, repo.CreateNodeParams{
-		Name:           node.Name,
-		Hostname:       node.Hostname,
-		Port:           int32(node.Port),
-		Username:       node.Username,
-		OsFamily:       node.OSFamily,
-		Tags:           node.Tags,
-		AuthMethod:     repo.AuthenticationMethod(node.Auth.Method),
-		ConnectionType: repo.ConnectionType(node.ConnectionType),
-		CredentialID:   sql.NullInt32{Int32: credential.ID, Valid: true},
-		Uuid:           namespaceUUID,
+		Name:               node.Name,
+		Hostname:           node.Hostname,
+		Port:               int32(node.Port),
+		Username:           node.Username,
+		OsFamily:           node.OSFamily,
+		Tags:               node.Tags,
+		AuthMethod:         authMethod(node.Auth.Method),
+		ConnectionType:     repo.ConnectionType(node.ConnectionType),
+		CredentialID:       sql.NullInt32{Int32: credential.ID, Valid: credential.ID != 0},
+		MaxConcurrency:     int32(node.MaxConcurrency),
+		Become:             node.Become.Enabled,
+		BecomeUser:         node.Become.User,
+		BecomeMethod:       becomeMethod(node.Become.Method),
+		BecomeCredentialID: becomeCredID,
+		JumpHostID:         jumpHostID,
+		Uuid:               namespaceUUID,
 	})
 	if err != nil {
 		return models.Node{}, err
 	}
 
-	key := credential.KeyData
-
 	return models.Node{
 		ID:             created.Uuid.String(),
 		Name:           created.Name,
@@ -64,11 +77,17 @@ func (c *Core) CreateNode(ctx context.Context, node *models.Node, namespaceID st
 		OSFamily:       created.OsFamily,
 		ConnectionType: string(created.ConnectionType),
 		Tags:           created.Tags,
-		Auth: models.NodeAuth{
-			Method:       node.Auth.Method,
-			CredentialID: credential.Uuid.String(),
-			Key:          key,
+		MaxConcurrency: int(created.MaxConcurrency),
+		Auth:           nodeAuth(created.AuthMethod, credential.ID != 0, credential.Uuid, credential.KeyData),
+		Become: models.NodeBecome{
+			Enabled:      created.Become,
+			User:         created.BecomeUser,
+			Method:       created.BecomeMethod,
+			CredentialID: node.Become.CredentialID,
 		},
+		HostKey:        created.HostKey,
+		PendingHostKey: created.PendingHostKey,
+		JumpHostID:     node.JumpHostID,
 	}, nil
 }
 
@@ -91,15 +110,39 @@ func (c *Core) GetNodeByID(ctx context.Context, id string, namespaceID string) (
 		return models.Node{}, err
 	}
 
-	credential, err := c.store.GetCredentialByID(ctx, repo.GetCredentialByIDParams{
-		ID:   node.CredentialID.Int32,
-		Uuid: namespaceUUID,
-	})
-	if err != nil {
-		return models.Node{}, errors.New("credential not found")
+	var auth models.NodeAuth
+	if node.CredentialID.Valid {
+		credential, err := c.store.GetCredentialByID(ctx, repo.GetCredentialByIDParams{
+			ID:   node.CredentialID.Int32,
+			Uuid: namespaceUUID,
+		})
+		if err != nil {
+			return models.Node{}, errors.New("credential not found")
+		}
+		auth = nodeAuth(node.AuthMethod, true, credential.Uuid, credential.KeyData)
+	}
+
+	var becomeCredID string
+	if node.BecomeCredentialID.Valid {
+		becomeCred, err := c.store.GetCredentialByID(ctx, repo.GetCredentialByIDParams{
+			ID:   node.BecomeCredentialID.Int32,
+			Uuid: namespaceUUID,
+		})
+		if err != nil {
+			return models.Node{}, errors.New("become credential not found")
+		}
+		becomeCredID = becomeCred.Uuid.String()
+	}
+
+	var jumpHostID string
+	if node.JumpHostUuid.Valid {
+		jumpHostID = node.JumpHostUuid.UUID.String()
 	}
 
-	key := credential.KeyData
+	facts, err := c.getNodeFacts(ctx, node.ID)
+	if err != nil {
+		return models.Node{}, err
+	}
 
 	return models.Node{
 		ID:             node.Uuid.String(),
@@ -110,14 +153,63 @@ func (c *Core) GetNodeByID(ctx context.Context, id string, namespaceID string) (
 		OSFamily:       node.OsFamily,
 		ConnectionType: string(node.ConnectionType),
 		Tags:           node.Tags,
-		Auth: models.NodeAuth{
-			Method:       models.AuthMethod(node.AuthMethod),
-			CredentialID: credential.Uuid.String(),
-			Key:          key,
+		MaxConcurrency: int(node.MaxConcurrency),
+		Auth:           auth,
+		Become: models.NodeBecome{
+			Enabled:      node.Become,
+			User:         node.BecomeUser,
+			Method:       node.BecomeMethod,
+			CredentialID: becomeCredID,
 		},
+		HostKey:        node.HostKey,
+		PendingHostKey: node.PendingHostKey,
+		JumpHostID:     jumpHostID,
+		Facts:          facts,
+	}, nil
+}
+
+// getNodeFacts returns the facts recorded by the health check, nil if the node has not been checked yet
+func (c *Core) getNodeFacts(ctx context.Context, nodeID int32) (*models.NodeFacts, error) {
+	f, err := c.store.GetNodeFacts(ctx, nodeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not get node facts: %w", err)
+	}
+	if !f.CheckedAt.Valid {
+		return nil, nil
+	}
+
+	return &models.NodeFacts{
+		Reachable:     f.Reachable.Bool,
+		Error:         f.Error,
+		LatencyMs:     int(f.LatencyMs),
+		OS:            f.Os,
+		Kernel:        f.Kernel,
+		Arch:          f.Arch,
+		UptimeSeconds: f.UptimeSeconds,
+		DiskFreeBytes: f.DiskFreeBytes,
+		CheckedAt:     f.CheckedAt.Time,
+		GatheredAt:    f.GatheredAt.Time,
 	}, nil
 }
 
+// GetSchedulerNodes returns the nodes with the given names with their credentials, it is used by
+// the scheduler to connect to nodes outside of executions
+func (c *Core) GetSchedulerNodes(ctx context.Context, nodeNames []string, namespaceID string) ([]scheduler.Node, error) {
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	nodes, err := c.GetNodesByNames(ctx, nodeNames, namespaceUUID)
+	if err != nil {
+		return nil, err
+	}
+	return models.ConvertToSchedulerNodes(nodes), nil
+}
+
 func (c *Core) SearchNodes(ctx context.Context, filter string, limit, offset int, namespaceID string) ([]models.Node, int64, int64, error) {
 	namespaceUUID, err := uuid.Parse(namespaceID)
 	if err != nil {
@@ -125,9 +217,9 @@ func (c *Core) SearchNodes(ctx context.Context, filter string, limit, offset int
 	}
 
 	nodes, err := c.store.SearchNodes(ctx, repo.SearchNodesParams{
-		Uuid:   namespaceUUID,
-		Limit:  int32(limit),
-		Offset: int32(offset),
+		Uuid:    namespaceUUID,
+		Limit:   int32(limit),
+		Offset:  int32(offset),
 		Column4: filter,
 	})
 	if err != nil {
@@ -146,7 +238,6 @@ func (c *Core) SearchNodes(ctx context.Context, filter string, limit, offset int
 		totalCount = n.TotalCount
 	}
 
-
 	return results, pageCount, totalCount, nil
 }
 
@@ -168,34 +259,52 @@ func (c *Core) UpdateNode(ctx context.Context, id string, node *models.Node, nam
 		return models.Node{}, err
 	}
 
-	credID, _ := uuid.Parse(node.Auth.CredentialID)
-	credential, err := c.store.GetCredentialByUUID(ctx, repo.GetCredentialByUUIDParams{
-		Uuid:   credID,
+	credential, err := c.getNodeCredential(ctx, node, namespaceUUID)
+	if err != nil {
+		return models.Node{}, err
+	}
+
+	becomeCredID, err := c.getBecomeCredentialID(ctx, node.Become.CredentialID, namespaceUUID)
+	if err != nil {
+		return models.Node{}, err
+	}
+
+	existing, err := c.store.GetNodeByUUID(ctx, repo.GetNodeByUUIDParams{
+		Uuid:   uuidID,
 		Uuid_2: namespaceUUID,
 	})
 	if err != nil {
-		return models.Node{}, errors.New("credential not found")
+		return models.Node{}, fmt.Errorf("could not get node: %w", err)
+	}
+
+	jumpHostID, err := c.getJumpHostID(ctx, node.JumpHostID, existing.ID, node.ConnectionType, namespaceUUID)
+	if err != nil {
+		return models.Node{}, err
 	}
 
 	updated, err := c.store.UpdateNode(ctx, repo.UpdateNodeParams{
-		Uuid:           uuidID,
-		Name:           node.Name,
-		Hostname:       node.Hostname,
-		Port:           int32(node.Port),
-		Username:       node.Username,
-		OsFamily:       node.OSFamily,
-		Tags:           node.Tags,
-		AuthMethod:     repo.AuthenticationMethod(node.Auth.Method),
-		ConnectionType: repo.ConnectionType(node.ConnectionType),
-		CredentialID:   sql.NullInt32{Int32: credential.ID, Valid: true},
-		Uuid_2:         namespaceUUID,
+		Uuid:               uuidID,
+		Name:               node.Name,
+		Hostname:           node.Hostname,
+		Port:               int32(node.Port),
+		Username:           node.Username,
+		OsFamily:           node.OSFamily,
+		Tags:               node.Tags,
+		AuthMethod:         authMethod(node.Auth.Method),
+		ConnectionType:     repo.ConnectionType(node.ConnectionType),
+		CredentialID:       sql.NullInt32{Int32: credential.ID, Valid: credential.ID != 0},
+		MaxConcurrency:     int32(node.MaxConcurrency),
+		Become:             node.Become.Enabled,
+		BecomeUser:         node.Become.User,
+		BecomeMethod:       becomeMethod(node.Become.Method),
+		BecomeCredentialID: becomeCredID,
+		JumpHostID:         jumpHostID,
+		Uuid_2:             namespaceUUID,
 	})
 	if err != nil {
 		return models.Node{}, err
 	}
 
-	key := credential.KeyData
-
 	return models.Node{
 		ID:             updated.Uuid.String(),
 		Name:           updated.Name,
@@ -205,14 +314,244 @@ func (c *Core) UpdateNode(ctx context.Context, id string, node *models.Node, nam
 		OSFamily:       updated.OsFamily,
 		ConnectionType: string(updated.ConnectionType),
 		Tags:           updated.Tags,
-		Auth: models.NodeAuth{
-			Method:       models.AuthMethod(updated.AuthMethod),
-			CredentialID: credential.Uuid.String(),
-			Key:          key,
+		MaxConcurrency: int(updated.MaxConcurrency),
+		Auth:           nodeAuth(updated.AuthMethod, credential.ID != 0, credential.Uuid, credential.KeyData),
+		Become: models.NodeBecome{
+			Enabled:      updated.Become,
+			User:         updated.BecomeUser,
+			Method:       updated.BecomeMethod,
+			CredentialID: node.Become.CredentialID,
 		},
+		HostKey:        updated.HostKey,
+		PendingHostKey: updated.PendingHostKey,
+		JumpHostID:     node.JumpHostID,
 	}, nil
 }
 
+// getNodeCredential returns the credential used to authenticate to the node.
+// Docker nodes run commands through the Docker daemon of the server and do not use a credential,
+// the returned credential has an ID of 0 for them
+func (c *Core) getNodeCredential(ctx context.Context, node *models.Node, namespaceUUID uuid.UUID) (repo.GetCredentialByUUIDRow, error) {
+	if node.ConnectionType == models.ConnectionTypeDocker {
+		return repo.GetCredentialByUUIDRow{}, nil
+	}
+
+	credID, err := uuid.Parse(node.Auth.CredentialID)
+	if err != nil {
+		return repo.GetCredentialByUUIDRow{}, errors.New("invalid credential ID format")
+	}
+
+	credential, err := c.store.GetCredentialByUUID(ctx, repo.GetCredentialByUUIDParams{
+		Uuid:   credID,
+		Uuid_2: namespaceUUID,
+	})
+	if err != nil {
+		return repo.GetCredentialByUUIDRow{}, errors.New("credential not found")
+	}
+	if err := validateNodeAuth(node.Auth.Method, credential.KeyType); err != nil {
+		return repo.GetCredentialByUUIDRow{}, err
+	}
+	return credential, nil
+}
+
+// nodeAuth returns the authentication settings of a node, they are empty for nodes without a credential
+func nodeAuth(method repo.AuthenticationMethod, hasCredential bool, credentialID uuid.UUID, key string) models.NodeAuth {
+	if !hasCredential {
+		return models.NodeAuth{}
+	}
+	return models.NodeAuth{
+		Method:       models.AuthMethod(method),
+		CredentialID: credentialID.String(),
+		Key:          key,
+	}
+}
+
+// authMethod returns the auth method stored for a node. Nodes without a credential use the default of the column
+func authMethod(method models.AuthMethod) repo.AuthenticationMethod {
+	if method == "" {
+		return repo.AuthenticationMethod(models.AuthMethodPrivateKey)
+	}
+	return repo.AuthenticationMethod(method)
+}
+
+// validateNodeAuth checks that SSH CA credentials are only used with the ssh_ca auth method
+func validateNodeAuth(method models.AuthMethod, keyType string) error {
+	if (method == models.AuthMethodSSHCA) != (keyType == models.CredentialTypeSSHCA) {
+		return fmt.Errorf("auth method %s can not be used with a %s credential", method, keyType)
+	}
+	return nil
+}
+
+// getBecomeCredentialID returns the database ID of the password credential used for privilege escalation.
+// The ID is not set when no credential is used
+func (c *Core) getBecomeCredentialID(ctx context.Context, credentialID string, namespaceUUID uuid.UUID) (sql.NullInt32, error) {
+	if credentialID == "" {
+		return sql.NullInt32{}, nil
+	}
+
+	credID, err := uuid.Parse(credentialID)
+	if err != nil {
+		return sql.NullInt32{}, errors.New("invalid become credential ID format")
+	}
+
+	credential, err := c.store.GetCredentialByUUID(ctx, repo.GetCredentialByUUIDParams{
+		Uuid:   credID,
+		Uuid_2: namespaceUUID,
+	})
+	if err != nil {
+		return sql.NullInt32{}, errors.New("become credential not found")
+	}
+	if credential.KeyType != models.CredentialTypePassword {
+		return sql.NullInt32{}, fmt.Errorf("become credential must be of type %s", models.CredentialTypePassword)
+	}
+
+	return sql.NullInt32{Int32: credential.ID, Valid: true}, nil
+}
+
+// maxJumpHosts is the maximum number of jump hosts used to reach a node
+const maxJumpHosts = 5
+
+// getJumpHostID returns the database ID of the jump host of a node.
+// nodeID is the database ID of the node being updated, it is 0 for new nodes. The jump host chain
+// must not loop back to the node
+func (c *Core) getJumpHostID(ctx context.Context, jumpHostID string, nodeID int32, connectionType string, namespaceUUID uuid.UUID) (sql.NullInt32, error) {
+	if jumpHostID == "" {
+		return sql.NullInt32{}, nil
+	}
+
+	if connectionType == "qssh" {
+		return sql.NullInt32{}, errors.New("qssh nodes can not be reached through a jump host")
+	}
+	if connectionType == models.ConnectionTypeDocker {
+		return sql.NullInt32{}, errors.New("docker nodes can not be reached through a jump host")
+	}
+
+	jhID, err := uuid.Parse(jumpHostID)
+	if err != nil {
+		return sql.NullInt32{}, errors.New("invalid jump host ID format")
+	}
+
+	jumpHost, err := c.store.GetNodeByUUID(ctx, repo.GetNodeByUUIDParams{
+		Uuid:   jhID,
+		Uuid_2: namespaceUUID,
+	})
+	if err != nil {
+		return sql.NullInt32{}, errors.New("jump host not found")
+	}
+	if string(jumpHost.ConnectionType) == models.ConnectionTypeDocker {
+		return sql.NullInt32{}, errors.New("docker nodes can not be used as jump hosts")
+	}
+
+	id := jumpHost.ID
+	for depth := 1; ; depth++ {
+		if id == nodeID {
+			return sql.NullInt32{}, errors.New("jump host chain can not include the node itself")
+		}
+		if depth > maxJumpHosts {
+			return sql.NullInt32{}, fmt.Errorf("jump host chain can not be longer than %d nodes", maxJumpHosts)
+		}
+
+		hop, err := c.store.GetJumpHostByID(ctx, repo.GetJumpHostByIDParams{
+			ID:   id,
+			Uuid: namespaceUUID,
+		})
+		if err != nil {
+			return sql.NullInt32{}, fmt.Errorf("could not get jump host: %w", err)
+		}
+		if !hop.JumpHostID.Valid {
+			break
+		}
+		id = hop.JumpHostID.Int32
+	}
+
+	return sql.NullInt32{Int32: jumpHost.ID, Valid: true}, nil
+}
+
+// getJumpHost returns the jump host chain starting at the node with the database ID id.
+// The credentials of the jump hosts are decrypted
+func (c *Core) getJumpHost(ctx context.Context, id int32, namespaceUUID uuid.UUID) (*models.Node, error) {
+	return c.getJumpHostAtDepth(ctx, id, namespaceUUID, 1)
+}
+
+func (c *Core) getJumpHostAtDepth(ctx context.Context, id int32, namespaceUUID uuid.UUID, depth int) (*models.Node, error) {
+	if depth > maxJumpHosts {
+		return nil, fmt.Errorf("jump host chain is longer than %d nodes", maxJumpHosts)
+	}
+
+	jh, err := c.store.GetJumpHostByID(ctx, repo.GetJumpHostByIDParams{
+		ID:   id,
+		Uuid: namespaceUUID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get jump host: %w", err)
+	}
+
+	dKey, err := hex.DecodeString(jh.CredentialKeyData.String)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode key for jump host %s: %w", jh.Name, err)
+	}
+
+	decryptedKey, err := c.keeper.Decrypt(ctx, dKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt key for jump host %s: %w", jh.Name, err)
+	}
+
+	node := &models.Node{
+		ID:             jh.Uuid.String(),
+		Name:           jh.Name,
+		Hostname:       jh.Hostname,
+		Port:           int(jh.Port),
+		Username:       jh.Username,
+		OSFamily:       jh.OsFamily,
+		ConnectionType: string(jh.ConnectionType),
+		Tags:           jh.Tags,
+		Auth: models.NodeAuth{
+			Method: models.AuthMethod(jh.AuthMethod),
+			Key:    string(decryptedKey),
+		},
+		HostKey: jh.HostKey,
+	}
+
+	if jh.JumpHostID.Valid {
+		node.JumpHost, err = c.getJumpHostAtDepth(ctx, jh.JumpHostID.Int32, namespaceUUID, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		node.JumpHostID = node.JumpHost.ID
+	}
+
+	return node, nil
+}
+
+// remoteJumpHost converts the jump host chain of a node to the configuration used by remote clients
+func remoteJumpHost(jumpHost *models.Node) *remoteclient.JumpHost {
+	if jumpHost == nil {
+		return nil
+	}
+	return &remoteclient.JumpHost{
+		Name:           jumpHost.Name,
+		ConnectionType: jumpHost.ConnectionType,
+		Config: remoteclient.NodeConfig{
+			Hostname: jumpHost.Hostname,
+			Port:     jumpHost.Port,
+			Username: jumpHost.Username,
+			Auth: remoteclient.NodeAuth{
+				Method: string(jumpHost.Auth.Method),
+				Key:    jumpHost.Auth.Key,
+			},
+			HostKey:  jumpHost.HostKey,
+			JumpHost: remoteJumpHost(jumpHost.JumpHost),
+		},
+	}
+}
+
+func becomeMethod(method string) string {
+	if method == "" {
+		return models.BecomeMethodSudo
+	}
+	return method
+}
+
 func (c *Core) DeleteNode(ctx context.Context, id string, namespaceID string) error {
 	namespaceUUID, err := uuid.Parse(namespaceID)
 	if err != nil {
@@ -223,10 +562,15 @@ func (c *Core) DeleteNode(ctx context.Context, id string, namespaceID string) er
 	if err != nil {
 		return fmt.Errorf("invalid node UUID: %w", err)
 	}
-	return c.store.DeleteNode(ctx, repo.DeleteNodeParams{
+	err = c.store.DeleteNode(ctx, repo.DeleteNodeParams{
 		Uuid:   uuidID,
 		Uuid_2: namespaceUUID,
 	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Constraint == "nodes_jump_host_id_fkey" {
+		return ErrJumpHostInUse
+	}
+	return err
 }
 
 func (c *Core) GetNodeStats(ctx context.Context, namespaceID string) (models.NodeStats, error) {
@@ -241,8 +585,222 @@ func (c *Core) GetNodeStats(ctx context.Context, namespaceID string) (models.Nod
 	}
 
 	return models.NodeStats{
-		TotalHosts: stats.TotalHosts,
-		SSHHosts:   stats.SshHosts,
-		QSSHHosts:  stats.QsshHosts,
+		TotalHosts:       stats.TotalHosts,
+		SSHHosts:         stats.SshHosts,
+		QSSHHosts:        stats.QsshHosts,
+		DockerHosts:      stats.DockerHosts,
+		UnreachableHosts: stats.UnreachableHosts,
 	}, nil
 }
+
+// ScanNodeHostKey connects to the node and records the presented host key as pending until it is approved
+func (c *Core) ScanNodeHostKey(ctx context.Context, id string, namespaceID string) (models.Node, error) {
+	node, err := c.GetNodeByID(ctx, id, namespaceID)
+	if err != nil {
+		return models.Node{}, err
+	}
+	if node.ConnectionType == models.ConnectionTypeDocker {
+		return models.Node{}, fmt.Errorf("node %s is a docker node and does not have a host key", node.Name)
+	}
+
+	var jumpHost *models.Node
+	if node.JumpHostID != "" {
+		jumpHost, err = c.getNodeJumpHost(ctx, id, namespaceID)
+		if err != nil {
+			return models.Node{}, err
+		}
+	}
+
+	// The host key is verified before authentication, credentials are not needed to read it
+	client, err := remoteclient.GetClient(node.ConnectionType, remoteclient.NodeConfig{
+		Hostname: node.Hostname,
+		Port:     node.Port,
+		Username: node.Username,
+		Auth: remoteclient.NodeAuth{
+			Method: string(models.AuthMethodPassword),
+		},
+		JumpHost: remoteJumpHost(jumpHost),
+	})
+	if err == nil {
+		client.Close()
+		return models.Node{}, fmt.Errorf("could not read host key of node %s", node.Name)
+	}
+
+	// Host key errors of jump hosts are reported as connection errors, the jump host has to be scanned instead
+	var jhErr *remoteclient.JumpHostError
+	var hkErr *remoteclient.HostKeyError
+	if errors.As(err, &jhErr) || !errors.As(err, &hkErr) {
+		return models.Node{}, fmt.Errorf("could not connect to node %s: %w", node.Name, err)
+	}
+	if hkErr.Key == node.HostKey {
+		return node, nil
+	}
+
+	if err := c.RecordPendingHostKey(ctx, id, namespaceID, hkErr.Key); err != nil {
+		return models.Node{}, err
+	}
+	node.PendingHostKey = hkErr.Key
+	return node, nil
+}
+
+// getNodeJumpHost returns the jump host chain of a node with decrypted credentials
+func (c *Core) getNodeJumpHost(ctx context.Context, id string, namespaceID string) (*models.Node, error) {
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node UUID: %w", err)
+	}
+
+	node, err := c.store.GetNodeByUUID(ctx, repo.GetNodeByUUIDParams{
+		Uuid:   uuidID,
+		Uuid_2: namespaceUUID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get node: %w", err)
+	}
+	if !node.JumpHostID.Valid {
+		return nil, nil
+	}
+
+	return c.getJumpHost(ctx, node.JumpHostID.Int32, namespaceUUID)
+}
+
+// RecordPendingHostKey stores a host key presented by the node which has to be approved before it is trusted
+func (c *Core) RecordPendingHostKey(ctx context.Context, id string, namespaceID string, hostKey string) error {
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid node UUID: %w", err)
+	}
+
+	if _, err := remoteclient.ParseHostKey(hostKey); err != nil {
+		return err
+	}
+
+	return c.store.SetNodePendingHostKey(ctx, repo.SetNodePendingHostKeyParams{
+		Uuid:           uuidID,
+		PendingHostKey: hostKey,
+		Uuid_2:         namespaceUUID,
+	})
+}
+
+// ApproveNodeHostKey trusts the pending host key of the node.
+// The fingerprint must match the pending host key so that only the key that was reviewed is approved
+func (c *Core) ApproveNodeHostKey(ctx context.Context, id string, fingerprint string, namespaceID string) (models.Node, error) {
+	node, err := c.GetNodeByID(ctx, id, namespaceID)
+	if err != nil {
+		return models.Node{}, err
+	}
+
+	if node.PendingHostKey == "" {
+		return models.Node{}, errors.New("node does not have a pending host key")
+	}
+
+	_, pendingFingerprint, err := remoteclient.HostKeyFingerprint(node.PendingHostKey)
+	if err != nil {
+		return models.Node{}, err
+	}
+	if pendingFingerprint != fingerprint {
+		return models.Node{}, fmt.Errorf("fingerprint %s does not match the pending host key %s", fingerprint, pendingFingerprint)
+	}
+
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return models.Node{}, fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return models.Node{}, fmt.Errorf("invalid node UUID: %w", err)
+	}
+
+	if _, err := c.store.ApproveNodeHostKey(ctx, repo.ApproveNodeHostKeyParams{
+		Uuid:           uuidID,
+		PendingHostKey: node.PendingHostKey,
+		Uuid_2:         namespaceUUID,
+	}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Node{}, errors.New("pending host key has changed, review the host key again")
+		}
+		return models.Node{}, fmt.Errorf("could not approve host key: %w", err)
+	}
+
+	return c.GetNodeByID(ctx, id, namespaceID)
+}
+
+// ResetNodeHostKey removes the trusted and pending host keys of the node
+func (c *Core) ResetNodeHostKey(ctx context.Context, id string, namespaceID string) (models.Node, error) {
+	if err := c.setNodeHostKey(ctx, id, "", namespaceID); err != nil {
+		return models.Node{}, err
+	}
+	return c.GetNodeByID(ctx, id, namespaceID)
+}
+
+// ImportKnownHosts trusts the host keys found in a known_hosts file for the nodes in the namespace.
+// The nodes whose host key was updated are returned
+func (c *Core) ImportKnownHosts(ctx context.Context, knownHosts []byte, namespaceID string) ([]models.Node, error) {
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	nodes, err := c.store.GetNodesByNamespace(ctx, namespaceUUID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get nodes: %w", err)
+	}
+
+	updated := make([]models.Node, 0)
+	for _, n := range nodes {
+		// Docker nodes are not reached over SSH and have no host key
+		if string(n.ConnectionType) == models.ConnectionTypeDocker {
+			continue
+		}
+
+		hostKey, err := remoteclient.LookupKnownHosts(knownHosts, n.Hostname, int(n.Port))
+		if err != nil {
+			return nil, err
+		}
+		if hostKey == "" || hostKey == n.HostKey {
+			continue
+		}
+
+		if err := c.setNodeHostKey(ctx, n.Uuid.String(), hostKey, namespaceID); err != nil {
+			return nil, fmt.Errorf("could not update host key of node %s: %w", n.Name, err)
+		}
+
+		node, err := c.GetNodeByID(ctx, n.Uuid.String(), namespaceID)
+		if err != nil {
+			return nil, err
+		}
+		updated = append(updated, node)
+	}
+
+	return updated, nil
+}
+
+func (c *Core) setNodeHostKey(ctx context.Context, id string, hostKey string, namespaceID string) error {
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid node UUID: %w", err)
+	}
+
+	_, err = c.store.SetNodeHostKey(ctx, repo.SetNodeHostKeyParams{
+		Uuid:    uuidID,
+		HostKey: hostKey,
+		Uuid_2:  namespaceUUID,
+	})
+	return err
+}
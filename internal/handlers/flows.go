@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"time"
@@ -165,8 +167,16 @@ func (h *Handler) HandleFlowTrigger(c echo.Context) error {
 		})
 	}
 
+	var triggerReq FlowTriggerReq
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &triggerReq); err != nil {
+		return wrapError(ErrInvalidInput, "invalid request", err, nil)
+	}
+	if err := h.validate.Struct(triggerReq); err != nil {
+		return wrapError(ErrValidationFailed, fmt.Sprintf("request validation failed: %s", formatValidationErrors(err)), err, nil)
+	}
+
 	// Add to queue
-	execID, err := h.co.QueueFlowExecution(c.Request().Context(), f, req, user.ID, namespace)
+	execID, err := h.co.QueueFlowExecution(c.Request().Context(), f, req, triggerReq.Priority, user.ID, namespace)
 	if err != nil {
 		return wrapError(ErrOperationFailed, fmt.Sprintf("could not trigger flow: %v", err), err, nil)
 	}
@@ -368,6 +378,35 @@ func (h *Handler) HandleGetExecutionSummary(c echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+// HandleDownloadExecutionArtifact streams an artifact stored by an action of the execution
+func (h *Handler) HandleDownloadExecutionArtifact(c echo.Context) error {
+	namespace, ok := c.Get("namespace").(string)
+	if !ok {
+		return wrapError(ErrRequiredFieldMissing, "could not get namespace", nil, nil)
+	}
+
+	var req ExecutionArtifactGetReq
+	if err := c.Bind(&req); err != nil {
+		return wrapError(ErrInvalidInput, "could not decode request", err, nil)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return wrapError(ErrValidationFailed, fmt.Sprintf("request validation failed: %s", formatValidationErrors(err)), err, nil)
+	}
+
+	artifact, r, err := h.co.GetExecutionArtifact(c.Request().Context(), req.ExecID, req.ArtifactID, namespace)
+	if err != nil {
+		return wrapError(ErrResourceNotFound, "artifact not found", err, nil)
+	}
+	defer r.Close()
+
+	// Artifacts are always downloaded, they are never rendered by the browser
+	c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(artifact.Path)}))
+	c.Response().Header().Set(echo.HeaderContentLength, strconv.FormatInt(artifact.SizeBytes, 10))
+	c.Response().Header().Set(echo.HeaderXContentTypeOptions, "nosniff")
+	return c.Stream(http.StatusOK, echo.MIMEOctetStream, r)
+}
+
 func (h *Handler) HandleExecutionsPagination(c echo.Context) error {
 	namespace, ok := c.Get("namespace").(string)
 	if !ok {
@@ -537,6 +576,9 @@ func (h *Handler) HandleCreateFlow(c echo.Context) error {
 			Description: req.Meta.Description,
 			Schedules:   req.Meta.Schedules,
 			Namespace:   namespace,
+			Idempotent:  req.Meta.Idempotent,
+			Locks:       req.Meta.Locks,
+			LockTimeout: req.Meta.LockTimeout,
 		},
 		Inputs:  convertFlowInputsReqToInputs(req.Inputs),
 		Actions: convertFlowActionsReqToActions(req.Actions),
@@ -576,6 +618,9 @@ func (h *Handler) HandleUpdateFlow(c echo.Context) error {
 	updatedMeta := f.Meta
 	updatedMeta.Schedules = req.Schedules
 	updatedMeta.AllowOverlap = req.AllowOverlap
+	updatedMeta.Idempotent = req.Idempotent
+	updatedMeta.Locks = req.Locks
+	updatedMeta.LockTimeout = req.LockTimeout
 	updatedMeta.Description = req.Description
 
 	flow := models.Flow{
@@ -628,6 +673,9 @@ func (h *Handler) HandleGetFlowConfig(c echo.Context) error {
 			Description:  f.Meta.Description,
 			Schedules:    f.Meta.Schedules,
 			AllowOverlap: f.Meta.AllowOverlap,
+			Idempotent:   f.Meta.Idempotent,
+			Locks:        f.Meta.Locks,
+			LockTimeout:  f.Meta.LockTimeout,
 		},
 		Inputs:  convertFlowInputsToInputsReq(f.Inputs),
 		Actions: convertFlowActionsToActionsReq(f.Actions),
@@ -59,6 +59,11 @@ func NewHandler(logger *slog.Logger, db *sql.DB, co *core.Core, cfg config.Confi
 	validate := validator.New()
 	validate.RegisterValidation("alphanum_underscore", models.AlphanumericUnderscore)
 	validate.RegisterValidation("alphanum_whitespace", models.AlphanumericSpace)
+	validate.RegisterValidation("resource_name", models.ResourceName)
+	validate.RegisterValidation("executor", models.RegisteredExecutor)
+	validate.RegisterValidation("container_name", models.ContainerName)
+	validate.RegisterValidation("priority", models.Priority)
+	validate.RegisterStructValidation(validateNodeReq, NodeReq{})
 
 	sessMgr := simplesessions.New(simplesessions.Options{
 		EnableAutoCreate: false,
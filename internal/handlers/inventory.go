@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cvhariharan/flowctl/internal/core/models"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) HandleImportNodes(c echo.Context) error {
+	namespace, ok := c.Get("namespace").(string)
+	if !ok {
+		return wrapError(ErrRequiredFieldMissing, "could not get namespace", nil, nil)
+	}
+
+	var req ImportNodesReq
+	if err := c.Bind(&req); err != nil {
+		return wrapError(ErrInvalidInput, "could not decode request", err, nil)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return wrapError(ErrValidationFailed, fmt.Sprintf("request validation failed: %s", formatValidationErrors(err)), err, nil)
+	}
+
+	report, err := h.co.ImportNodes(c.Request().Context(), req.Format, []byte(req.Content), inventoryDefaults(req.Defaults), namespace)
+	if err != nil {
+		return wrapError(ErrOperationFailed, "could not import nodes", err, nil)
+	}
+
+	return c.JSON(http.StatusOK, coreInventoryReportToResp(report))
+}
+
+func (h *Handler) HandleCreateInventorySource(c echo.Context) error {
+	namespace, ok := c.Get("namespace").(string)
+	if !ok {
+		return wrapError(ErrRequiredFieldMissing, "could not get namespace", nil, nil)
+	}
+
+	var req InventorySourceReq
+	if err := c.Bind(&req); err != nil {
+		return wrapError(ErrInvalidInput, "could not decode request", err, nil)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return wrapError(ErrValidationFailed, fmt.Sprintf("request validation failed: %s", formatValidationErrors(err)), err, nil)
+	}
+
+	created, err := h.co.CreateInventorySource(c.Request().Context(), inventorySource(req), namespace)
+	if err != nil {
+		return wrapError(ErrOperationFailed, "could not create inventory source", err, nil)
+	}
+
+	return c.JSON(http.StatusCreated, coreInventorySourceToResp(created))
+}
+
+func (h *Handler) HandleGetInventorySource(c echo.Context) error {
+	namespace, ok := c.Get("namespace").(string)
+	if !ok {
+		return wrapError(ErrRequiredFieldMissing, "could not get namespace", nil, nil)
+	}
+
+	sourceID := c.Param("sourceID")
+	if sourceID == "" {
+		return wrapError(ErrRequiredFieldMissing, "inventory source ID cannot be empty", nil, nil)
+	}
+
+	source, err := h.co.GetInventorySourceByID(c.Request().Context(), sourceID, namespace)
+	if err != nil {
+		return wrapError(ErrResourceNotFound, "inventory source not found", err, nil)
+	}
+
+	return c.JSON(http.StatusOK, coreInventorySourceToResp(source))
+}
+
+func (h *Handler) HandleListInventorySources(c echo.Context) error {
+	namespace, ok := c.Get("namespace").(string)
+	if !ok {
+		return wrapError(ErrRequiredFieldMissing, "could not get namespace", nil, nil)
+	}
+
+	sources, err := h.co.ListInventorySources(c.Request().Context(), namespace)
+	if err != nil {
+		return wrapError(ErrOperationFailed, "could not list inventory sources", err, nil)
+	}
+
+	return c.JSON(http.StatusOK, coreInventorySourcesToResp(sources))
+}
+
+func (h *Handler) HandleUpdateInventorySource(c echo.Context) error {
+	namespace, ok := c.Get("namespace").(string)
+	if !ok {
+		return wrapError(ErrRequiredFieldMissing, "could not get namespace", nil, nil)
+	}
+
+	sourceID := c.Param("sourceID")
+	if sourceID == "" {
+		return wrapError(ErrRequiredFieldMissing, "inventory source ID cannot be empty", nil, nil)
+	}
+
+	var req InventorySourceReq
+	if err := c.Bind(&req); err != nil {
+		return wrapError(ErrInvalidInput, "could not decode request", err, nil)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return wrapError(ErrValidationFailed, fmt.Sprintf("request validation failed: %s", formatValidationErrors(err)), err, nil)
+	}
+
+	updated, err := h.co.UpdateInventorySource(c.Request().Context(), sourceID, inventorySource(req), namespace)
+	if err != nil {
+		return wrapError(ErrOperationFailed, "could not update inventory source", err, nil)
+	}
+
+	return c.JSON(http.StatusOK, coreInventorySourceToResp(updated))
+}
+
+func (h *Handler) HandleDeleteInventorySource(c echo.Context) error {
+	namespace, ok := c.Get("namespace").(string)
+	if !ok {
+		return wrapError(ErrRequiredFieldMissing, "could not get namespace", nil, nil)
+	}
+
+	sourceID := c.Param("sourceID")
+	if sourceID == "" {
+		return wrapError(ErrRequiredFieldMissing, "inventory source ID cannot be empty", nil, nil)
+	}
+
+	if err := h.co.DeleteInventorySource(c.Request().Context(), sourceID, namespace); err != nil {
+		return wrapError(ErrOperationFailed, "could not delete inventory source", err, nil)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// HandleSyncInventorySource syncs the nodes of the source without waiting for its sync interval
+func (h *Handler) HandleSyncInventorySource(c echo.Context) error {
+	namespace, ok := c.Get("namespace").(string)
+	if !ok {
+		return wrapError(ErrRequiredFieldMissing, "could not get namespace", nil, nil)
+	}
+
+	sourceID := c.Param("sourceID")
+	if sourceID == "" {
+		return wrapError(ErrRequiredFieldMissing, "inventory source ID cannot be empty", nil, nil)
+	}
+
+	source, err := h.co.SyncInventorySource(c.Request().Context(), sourceID, namespace)
+	if err != nil {
+		return wrapError(ErrOperationFailed, "could not sync inventory source", err, nil)
+	}
+
+	return c.JSON(http.StatusOK, coreInventorySourceToResp(source))
+}
+
+func inventoryDefaults(d InventoryDefaults) models.InventoryDefaults {
+	return models.InventoryDefaults{
+		Port:           d.Port,
+		Username:       d.Username,
+		ConnectionType: d.ConnectionType,
+		CredentialID:   d.CredentialID,
+	}
+}
+
+func inventorySource(req InventorySourceReq) models.InventorySource {
+	return models.InventorySource{
+		Name:         req.Name,
+		Type:         req.Type,
+		Path:         req.Path,
+		Format:       req.Format,
+		SyncInterval: time.Duration(req.SyncInterval) * time.Second,
+		Defaults:     inventoryDefaults(req.Defaults),
+	}
+}
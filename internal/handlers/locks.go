@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) HandleListResourceLocks(c echo.Context) error {
+	namespace, ok := c.Get("namespace").(string)
+	if !ok {
+		return wrapError(ErrRequiredFieldMissing, "could not get namespace", nil, nil)
+	}
+
+	locks, err := h.co.ListResourceLocks(c.Request().Context(), namespace)
+	if err != nil {
+		return wrapError(ErrOperationFailed, "could not list resource locks", err, nil)
+	}
+
+	return c.JSON(http.StatusOK, coreResourceLocksToResp(locks))
+}
@@ -19,7 +19,8 @@ func (h *Handler) HandleCreateNamespace(c echo.Context) error {
 	}
 
 	namespace := &models.Namespace{
-		Name: req.Name,
+		Name:                  req.Name,
+		ArtifactRetentionDays: req.ArtifactRetentionDays,
 	}
 
 	created, err := h.co.CreateNamespace(c.Request().Context(), namespace)
@@ -95,7 +96,8 @@ func (h *Handler) HandleUpdateNamespace(c echo.Context) error {
 	}
 
 	namespace := &models.Namespace{
-		Name: req.Name,
+		Name:                  req.Name,
+		ArtifactRetentionDays: req.ArtifactRetentionDays,
 	}
 
 	updated, err := h.co.UpdateNamespace(c.Request().Context(), namespaceID, *namespace)
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/cvhariharan/flowctl/internal/core"
 	"github.com/cvhariharan/flowctl/internal/core/models"
+	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 )
 
@@ -36,10 +39,18 @@ func (h *Handler) HandleCreateNode(c echo.Context) error {
 		OSFamily:       "linux",
 		ConnectionType: req.ConnectionType,
 		Tags:           req.Tags,
+		MaxConcurrency: req.MaxConcurrency,
 		Auth: models.NodeAuth{
 			Method:       models.AuthMethod(req.Auth.Method),
 			CredentialID: req.Auth.CredentialID,
 		},
+		Become: models.NodeBecome{
+			Enabled:      req.Become.Enabled,
+			User:         req.Become.User,
+			Method:       req.Become.Method,
+			CredentialID: req.Become.CredentialID,
+		},
+		JumpHostID: req.JumpHostID,
 	}
 
 	created, err := h.co.CreateNode(c.Request().Context(), node, namespace)
@@ -133,10 +144,18 @@ func (h *Handler) HandleUpdateNode(c echo.Context) error {
 		OSFamily:       "linux",
 		ConnectionType: req.ConnectionType,
 		Tags:           req.Tags,
+		MaxConcurrency: req.MaxConcurrency,
 		Auth: models.NodeAuth{
 			Method:       models.AuthMethod(req.Auth.Method),
 			CredentialID: req.Auth.CredentialID,
 		},
+		Become: models.NodeBecome{
+			Enabled:      req.Become.Enabled,
+			User:         req.Become.User,
+			Method:       req.Become.Method,
+			CredentialID: req.Become.CredentialID,
+		},
+		JumpHostID: req.JumpHostID,
 	}
 
 	updated, err := h.co.UpdateNode(c.Request().Context(), nodeID, node, namespace)
@@ -159,6 +178,9 @@ func (h *Handler) HandleDeleteNode(c echo.Context) error {
 	}
 
 	err := h.co.DeleteNode(c.Request().Context(), nodeID, namespace)
+	if errors.Is(err, core.ErrJumpHostInUse) {
+		return wrapError(ErrInvalidInput, err.Error(), err, nil)
+	}
 	if err != nil {
 		return wrapError(ErrOperationFailed, "could not delete node", err, nil)
 	}
@@ -178,8 +200,134 @@ func (h *Handler) HandleGetNodeStats(c echo.Context) error {
 	}
 
 	return c.JSON(http.StatusOK, NodeStatsResp{
-		TotalHosts: stats.TotalHosts,
-		SSHHosts:   stats.SSHHosts,
-		QSSHHosts:  stats.QSSHHosts,
+		TotalHosts:       stats.TotalHosts,
+		SSHHosts:         stats.SSHHosts,
+		QSSHHosts:        stats.QSSHHosts,
+		DockerHosts:      stats.DockerHosts,
+		UnreachableHosts: stats.UnreachableHosts,
 	})
 }
+
+func (h *Handler) HandleScanNodeHostKey(c echo.Context) error {
+	namespace, ok := c.Get("namespace").(string)
+	if !ok {
+		return wrapError(ErrRequiredFieldMissing, "could not get namespace", nil, nil)
+	}
+
+	nodeID := c.Param("nodeID")
+	if nodeID == "" {
+		return wrapError(ErrRequiredFieldMissing, "node ID cannot be empty", nil, nil)
+	}
+
+	node, err := h.co.ScanNodeHostKey(c.Request().Context(), nodeID, namespace)
+	if err != nil {
+		return wrapError(ErrOperationFailed, "could not read host key", err, nil)
+	}
+
+	return c.JSON(http.StatusOK, coreNodeToNodeResp(node))
+}
+
+func (h *Handler) HandleApproveNodeHostKey(c echo.Context) error {
+	namespace, ok := c.Get("namespace").(string)
+	if !ok {
+		return wrapError(ErrRequiredFieldMissing, "could not get namespace", nil, nil)
+	}
+
+	nodeID := c.Param("nodeID")
+	if nodeID == "" {
+		return wrapError(ErrRequiredFieldMissing, "node ID cannot be empty", nil, nil)
+	}
+
+	var req ApproveHostKeyReq
+	if err := c.Bind(&req); err != nil {
+		return wrapError(ErrInvalidInput, "could not decode request", err, nil)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return wrapError(ErrValidationFailed, fmt.Sprintf("request validation failed: %s", formatValidationErrors(err)), err, nil)
+	}
+
+	node, err := h.co.ApproveNodeHostKey(c.Request().Context(), nodeID, req.Fingerprint, namespace)
+	if err != nil {
+		return wrapError(ErrOperationFailed, "could not approve host key", err, nil)
+	}
+
+	return c.JSON(http.StatusOK, coreNodeToNodeResp(node))
+}
+
+func (h *Handler) HandleResetNodeHostKey(c echo.Context) error {
+	namespace, ok := c.Get("namespace").(string)
+	if !ok {
+		return wrapError(ErrRequiredFieldMissing, "could not get namespace", nil, nil)
+	}
+
+	nodeID := c.Param("nodeID")
+	if nodeID == "" {
+		return wrapError(ErrRequiredFieldMissing, "node ID cannot be empty", nil, nil)
+	}
+
+	node, err := h.co.ResetNodeHostKey(c.Request().Context(), nodeID, namespace)
+	if err != nil {
+		return wrapError(ErrOperationFailed, "could not reset host key", err, nil)
+	}
+
+	return c.JSON(http.StatusOK, coreNodeToNodeResp(node))
+}
+
+func (h *Handler) HandleImportKnownHosts(c echo.Context) error {
+	namespace, ok := c.Get("namespace").(string)
+	if !ok {
+		return wrapError(ErrRequiredFieldMissing, "could not get namespace", nil, nil)
+	}
+
+	var req ImportKnownHostsReq
+	if err := c.Bind(&req); err != nil {
+		return wrapError(ErrInvalidInput, "could not decode request", err, nil)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return wrapError(ErrValidationFailed, fmt.Sprintf("request validation failed: %s", formatValidationErrors(err)), err, nil)
+	}
+
+	nodes, err := h.co.ImportKnownHosts(c.Request().Context(), []byte(req.KnownHosts), namespace)
+	if err != nil {
+		return wrapError(ErrOperationFailed, "could not import known_hosts", err, nil)
+	}
+
+	return c.JSON(http.StatusOK, ImportKnownHostsResp{
+		Nodes: coreNodeArrayToNodeRespArray(nodes),
+	})
+}
+
+// validateNodeReq checks the fields of a node which depend on its connection type.
+// Docker nodes are containers reached through the Docker daemon of the server, they do not
+// have a port or credential and commands run as the default user of the container without a username
+func validateNodeReq(sl validator.StructLevel) {
+	req := sl.Current().Interface().(NodeReq)
+
+	type check struct {
+		value any
+		field string
+		tag   string
+	}
+
+	checks := []check{
+		{req.Hostname, "Hostname", "hostname|ip"},
+		{req.Port, "Port", "required"},
+		{req.Username, "Username", "required,min=2"},
+		{req.Auth.Method, "Method", "required"},
+		{req.Auth.CredentialID, "CredentialID", "required"},
+	}
+	if req.ConnectionType == models.ConnectionTypeDocker {
+		checks = []check{
+			{req.Hostname, "Hostname", "container_name"},
+			{req.JumpHostID, "JumpHostID", "isdefault"},
+		}
+	}
+
+	for _, c := range checks {
+		if err := sl.Validator().Var(c.value, c.tag); err != nil {
+			sl.ReportError(c.value, c.field, c.field, c.tag, "")
+		}
+	}
+}
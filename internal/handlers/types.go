@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"strconv"
 	"strings"
 
 	"github.com/cvhariharan/flowctl/internal/core/models"
+	"github.com/cvhariharan/flowctl/sdk/remoteclient"
 	"github.com/gosimple/slug"
 )
 
@@ -23,6 +25,12 @@ type AuthReq struct {
 	Password string `json:"password"`
 }
 
+// FlowTriggerReq contains the trigger options passed as query params.
+// Flow inputs are sent separately as form values
+type FlowTriggerReq struct {
+	Priority int `query:"priority" validate:"priority"`
+}
+
 type FlowTriggerResp struct {
 	ExecID string `json:"exec_id"`
 }
@@ -172,32 +180,84 @@ type ApprovalsPaginateResponse struct {
 }
 
 // Node related types
+// NodeAuth is required for ssh and qssh nodes, see validateNodeReq
 type NodeAuth struct {
-	Method       string `json:"method" validate:"required,oneof=private_key password"`
-	CredentialID string `json:"credential_id" validate:"required,uuid4"`
+	Method       string `json:"method" validate:"omitempty,oneof=private_key password ssh_ca"`
+	CredentialID string `json:"credential_id" validate:"omitempty,uuid4"`
+}
+
+type NodeBecome struct {
+	Enabled      bool   `json:"enabled"`
+	User         string `json:"user" validate:"omitempty,max=150"`
+	Method       string `json:"method" validate:"omitempty,oneof=sudo su doas"`
+	CredentialID string `json:"credential_id" validate:"omitempty,uuid4"`
 }
 
 type NodeReq struct {
-	Name           string   `json:"name" validate:"required,min=1,max=50,alphanum_underscore"`
-	Hostname       string   `json:"hostname" validate:"required,hostname|ip"`
-	Port           int      `json:"port" validate:"required,min=1,max=65535"`
-	Username       string   `json:"username" validate:"required,min=2,max=50"`
-	ConnectionType string   `json:"connection_type" validate:"required,oneof=ssh qssh"`
-	Tags           []string `json:"tags" validate:"omitempty,dive,alphanum_underscore"`
-	Auth           NodeAuth `json:"auth" validate:"required"`
+	Name string `json:"name" validate:"required,min=1,max=50,alphanum_underscore"`
+	// Hostname is the name of the container for docker nodes
+	Hostname       string     `json:"hostname" validate:"required,max=255"`
+	Port           int        `json:"port" validate:"omitempty,min=1,max=65535"`
+	Username       string     `json:"username" validate:"omitempty,max=50"`
+	ConnectionType string     `json:"connection_type" validate:"required,oneof=ssh qssh docker"`
+	Tags           []string   `json:"tags" validate:"omitempty,dive,alphanum_underscore"`
+	MaxConcurrency int        `json:"max_concurrency" validate:"min=0"`
+	Auth           NodeAuth   `json:"auth" validate:"required"`
+	Become         NodeBecome `json:"become"`
+	// JumpHostID is the node through which the node is reached
+	JumpHostID string `json:"jump_host_id" validate:"omitempty,uuid"`
 	// OSFamily       string   `json:"os_family" validate:"required,oneof=linux windows"`
 }
 
 type NodeResp struct {
-	ID             string   `json:"id"`
-	Name           string   `json:"name"`
-	Hostname       string   `json:"hostname"`
-	Port           int      `json:"port"`
-	Username       string   `json:"username"`
-	OSFamily       string   `json:"os_family"`
-	ConnectionType string   `json:"connection_type"`
-	Tags           []string `json:"tags"`
-	Auth           NodeAuth `json:"auth"`
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Hostname       string     `json:"hostname"`
+	Port           int        `json:"port"`
+	Username       string     `json:"username"`
+	OSFamily       string     `json:"os_family"`
+	ConnectionType string     `json:"connection_type"`
+	Tags           []string   `json:"tags"`
+	MaxConcurrency int        `json:"max_concurrency"`
+	Auth           NodeAuth   `json:"auth"`
+	Become         NodeBecome `json:"become"`
+	// HostKey is the trusted SSH host key, it is null until a host key is approved or imported
+	HostKey        *NodeHostKey `json:"host_key"`
+	PendingHostKey *NodeHostKey `json:"pending_host_key"`
+	JumpHostID     string       `json:"jump_host_id"`
+	// Facts are gathered by the health check, null until the node has been checked
+	Facts *NodeFactsResp `json:"facts"`
+}
+
+type NodeFactsResp struct {
+	Reachable     bool   `json:"reachable"`
+	Error         string `json:"error"`
+	LatencyMs     int    `json:"latency_ms"`
+	OS            string `json:"os"`
+	Kernel        string `json:"kernel"`
+	Arch          string `json:"arch"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	DiskFreeBytes int64  `json:"disk_free_bytes"`
+	CheckedAt     string `json:"checked_at"`
+	GatheredAt    string `json:"gathered_at"`
+}
+
+type NodeHostKey struct {
+	Type        string `json:"type"`
+	Fingerprint string `json:"fingerprint"`
+	Key         string `json:"key"`
+}
+
+type ApproveHostKeyReq struct {
+	Fingerprint string `json:"fingerprint" validate:"required"`
+}
+
+type ImportKnownHostsReq struct {
+	KnownHosts string `json:"known_hosts" validate:"required"`
+}
+
+type ImportKnownHostsResp struct {
+	Nodes []NodeResp `json:"nodes"`
 }
 
 type NodesPaginateResponse struct {
@@ -207,9 +267,11 @@ type NodesPaginateResponse struct {
 }
 
 type NodeStatsResp struct {
-	TotalHosts int64 `json:"total_hosts"`
-	SSHHosts   int64 `json:"ssh_hosts"`
-	QSSHHosts  int64 `json:"qssh_hosts"`
+	TotalHosts       int64 `json:"total_hosts"`
+	SSHHosts         int64 `json:"ssh_hosts"`
+	QSSHHosts        int64 `json:"qssh_hosts"`
+	DockerHosts      int64 `json:"docker_hosts"`
+	UnreachableHosts int64 `json:"unreachable_hosts"`
 }
 
 func coreNodeToNodeResp(n models.Node) NodeResp {
@@ -222,10 +284,57 @@ func coreNodeToNodeResp(n models.Node) NodeResp {
 		OSFamily:       n.OSFamily,
 		ConnectionType: n.ConnectionType,
 		Tags:           n.Tags,
+		MaxConcurrency: n.MaxConcurrency,
 		Auth: NodeAuth{
 			Method:       string(n.Auth.Method),
 			CredentialID: n.Auth.CredentialID,
 		},
+		Become: NodeBecome{
+			Enabled:      n.Become.Enabled,
+			User:         n.Become.User,
+			Method:       n.Become.Method,
+			CredentialID: n.Become.CredentialID,
+		},
+		HostKey:        toNodeHostKey(n.HostKey),
+		PendingHostKey: toNodeHostKey(n.PendingHostKey),
+		JumpHostID:     n.JumpHostID,
+		Facts:          toNodeFactsResp(n.Facts),
+	}
+}
+
+func toNodeFactsResp(f *models.NodeFacts) *NodeFactsResp {
+	if f == nil {
+		return nil
+	}
+	resp := &NodeFactsResp{
+		Reachable:     f.Reachable,
+		Error:         f.Error,
+		LatencyMs:     f.LatencyMs,
+		OS:            f.OS,
+		Kernel:        f.Kernel,
+		Arch:          f.Arch,
+		UptimeSeconds: f.UptimeSeconds,
+		DiskFreeBytes: f.DiskFreeBytes,
+		CheckedAt:     f.CheckedAt.UTC().Format(TimeFormat),
+	}
+	if !f.GatheredAt.IsZero() {
+		resp.GatheredAt = f.GatheredAt.UTC().Format(TimeFormat)
+	}
+	return resp
+}
+
+func toNodeHostKey(key string) *NodeHostKey {
+	if key == "" {
+		return nil
+	}
+	keyType, fingerprint, err := remoteclient.HostKeyFingerprint(key)
+	if err != nil {
+		return nil
+	}
+	return &NodeHostKey{
+		Type:        keyType,
+		Fingerprint: fingerprint,
+		Key:         key,
 	}
 }
 
@@ -237,10 +346,107 @@ func coreNodeArrayToNodeRespArray(nodes []models.Node) []NodeResp {
 	return resp
 }
 
+// Inventory related types
+type InventoryDefaults struct {
+	Port           int    `json:"port" validate:"omitempty,min=1,max=65535"`
+	Username       string `json:"username" validate:"omitempty,max=50"`
+	ConnectionType string `json:"connection_type" validate:"omitempty,oneof=ssh qssh docker"`
+	CredentialID   string `json:"credential_id" validate:"omitempty,uuid"`
+}
+
+type ImportNodesReq struct {
+	Format   string            `json:"format" validate:"required,oneof=csv ini yaml json"`
+	Content  string            `json:"content" validate:"required"`
+	Defaults InventoryDefaults `json:"defaults"`
+}
+
+type InventoryReportResp struct {
+	Added   []string `json:"added"`
+	Updated []string `json:"updated"`
+	Removed []string `json:"removed"`
+	Errors  []string `json:"errors"`
+}
+
+type InventorySourceReq struct {
+	Name   string `json:"name" validate:"required,min=1,max=150,resource_name"`
+	Type   string `json:"type" validate:"required,oneof=file script"`
+	Path   string `json:"path" validate:"required,max=1024"`
+	Format string `json:"format" validate:"required,oneof=csv ini yaml json"`
+	// SyncInterval is in seconds
+	SyncInterval int               `json:"sync_interval" validate:"required,min=60"`
+	Defaults     InventoryDefaults `json:"defaults"`
+}
+
+type InventorySourceResp struct {
+	ID           string              `json:"id"`
+	Name         string              `json:"name"`
+	Type         string              `json:"type"`
+	Path         string              `json:"path"`
+	Format       string              `json:"format"`
+	SyncInterval int                 `json:"sync_interval"`
+	Defaults     InventoryDefaults   `json:"defaults"`
+	NextSyncAt   string              `json:"next_sync_at"`
+	LastSyncedAt string              `json:"last_synced_at"`
+	LastError    string              `json:"last_error"`
+	LastReport   InventoryReportResp `json:"last_report"`
+}
+
+type InventorySourcesResp struct {
+	Sources []InventorySourceResp `json:"sources"`
+}
+
+func coreInventoryReportToResp(r models.InventoryReport) InventoryReportResp {
+	return InventoryReportResp{
+		Added:   nonNil(r.Added),
+		Updated: nonNil(r.Updated),
+		Removed: nonNil(r.Removed),
+		Errors:  nonNil(r.Errors),
+	}
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func coreInventorySourceToResp(s models.InventorySource) InventorySourceResp {
+	resp := InventorySourceResp{
+		ID:           s.ID,
+		Name:         s.Name,
+		Type:         s.Type,
+		Path:         s.Path,
+		Format:       s.Format,
+		SyncInterval: int(s.SyncInterval.Seconds()),
+		Defaults: InventoryDefaults{
+			Port:           s.Defaults.Port,
+			Username:       s.Defaults.Username,
+			ConnectionType: s.Defaults.ConnectionType,
+			CredentialID:   s.Defaults.CredentialID,
+		},
+		NextSyncAt: s.NextSyncAt.UTC().Format(TimeFormat),
+		LastError:  s.LastError,
+		LastReport: coreInventoryReportToResp(s.LastReport),
+	}
+	if s.LastSyncedAt != nil {
+		resp.LastSyncedAt = s.LastSyncedAt.UTC().Format(TimeFormat)
+	}
+	return resp
+}
+
+func coreInventorySourcesToResp(sources []models.InventorySource) InventorySourcesResp {
+	resp := make([]InventorySourceResp, len(sources))
+	for i, s := range sources {
+		resp[i] = coreInventorySourceToResp(s)
+	}
+	return InventorySourcesResp{Sources: resp}
+}
+
 // Credential related types
 type CredentialReq struct {
 	Name    string `json:"name" validate:"required,min=2,max=255,alphanum_whitespace"`
-	KeyType string `json:"key_type" validate:"required,oneof=private_key password"`
+	KeyType string `json:"key_type" validate:"required,oneof=private_key password kubeconfig registry ssh_ca"`
 	KeyData string `json:"key_data" validate:"required"`
 }
 
@@ -286,11 +492,14 @@ func coreCredentialArrayToCredentialRespArray(creds []models.Credential) []Crede
 // Namespace related types
 type NamespaceReq struct {
 	Name string `json:"name" validate:"required,min=1,max=150,alphanum_underscore"`
+	// ArtifactRetentionDays is unset to use the retention from the server config, 0 keeps artifacts forever
+	ArtifactRetentionDays *int32 `json:"artifact_retention_days" validate:"omitempty,min=0,max=36500"`
 }
 
 type NamespaceResp struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID                    string `json:"id"`
+	Name                  string `json:"name"`
+	ArtifactRetentionDays *int32 `json:"artifact_retention_days"`
 }
 
 type NamespacesPaginateResponse struct {
@@ -301,8 +510,9 @@ type NamespacesPaginateResponse struct {
 
 func coreNamespaceToNamespaceResp(n models.Namespace) NamespaceResp {
 	return NamespaceResp{
-		ID:   n.ID,
-		Name: n.Name,
+		ID:                    n.ID,
+		Name:                  n.Name,
+		ArtifactRetentionDays: n.ArtifactRetentionDays,
 	}
 }
 
@@ -365,6 +575,9 @@ type FlowMeta struct {
 	Schedules    []string `json:"schedules"`
 	Namespace    string   `json:"namespace"`
 	AllowOverlap bool     `json:"allow_overlap"`
+	Idempotent   bool     `json:"idempotent"`
+	Locks        []string `json:"locks"`
+	LockTimeout  string   `json:"lock_timeout"`
 }
 
 func coreFlowMetatoFlowMeta(m models.Metadata) FlowMeta {
@@ -375,6 +588,9 @@ func coreFlowMetatoFlowMeta(m models.Metadata) FlowMeta {
 		Schedules:    m.Schedules,
 		Namespace:    m.Namespace,
 		AllowOverlap: m.AllowOverlap,
+		Idempotent:   m.Idempotent,
+		Locks:        m.Locks,
+		LockTimeout:  m.LockTimeout,
 	}
 }
 
@@ -384,6 +600,7 @@ type FlowAction struct {
 	Executor string   `json:"executor"`
 	Approval bool     `json:"approval"`
 	On       []string `json:"on"`
+	Locks    []string `json:"locks"`
 }
 
 func coreFlowActiontoFlowAction(a models.Action) FlowAction {
@@ -393,6 +610,7 @@ func coreFlowActiontoFlowAction(a models.Action) FlowAction {
 		Executor: a.Executor,
 		Approval: a.Approval,
 		On:       a.On,
+		Locks:    a.Locks,
 	}
 }
 
@@ -543,9 +761,63 @@ type ExecutionSummary struct {
 	CreatedAt       string          `json:"started_at"`
 	CompletedAt     string          `json:"completed_at"`
 	Duration        string          `json:"duration"`
+	// SSHCertificates are only returned for a single execution
+	SSHCertificates []SSHCertificateResp `json:"ssh_certificates,omitempty"`
+	// Artifacts are only returned for a single execution
+	Artifacts []ExecutionArtifactResp `json:"artifacts,omitempty"`
+}
+
+// ExecutionArtifactResp is a file stored by an action, it is downloaded from the artifacts endpoint of the execution
+type ExecutionArtifactResp struct {
+	ID        string `json:"id"`
+	ActionID  string `json:"action_id"`
+	NodeName  string `json:"node_name"`
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"size_bytes"`
+	Checksum  string `json:"checksum"`
+	CreatedAt string `json:"created_at"`
+}
+
+// SSHCertificateResp is a certificate issued to connect to a node, the serial is a string as it does not fit in a JSON number
+type SSHCertificateResp struct {
+	ActionID    string   `json:"action_id"`
+	NodeName    string   `json:"node_name"`
+	Serial      string   `json:"serial"`
+	KeyID       string   `json:"key_id"`
+	Principals  []string `json:"principals"`
+	ValidAfter  string   `json:"valid_after"`
+	ValidBefore string   `json:"valid_before"`
+	IssuedAt    string   `json:"issued_at"`
 }
 
 func coreExecutionSummaryToExecutionSummary(e models.ExecutionSummary) ExecutionSummary {
+	var certs []SSHCertificateResp
+	for _, cert := range e.SSHCertificates {
+		certs = append(certs, SSHCertificateResp{
+			ActionID:    cert.ActionID,
+			NodeName:    cert.NodeName,
+			Serial:      strconv.FormatUint(cert.Serial, 10),
+			KeyID:       cert.KeyID,
+			Principals:  cert.Principals,
+			ValidAfter:  cert.ValidAfter.UTC().Format(TimeFormat),
+			ValidBefore: cert.ValidBefore.UTC().Format(TimeFormat),
+			IssuedAt:    cert.CreatedAt.UTC().Format(TimeFormat),
+		})
+	}
+
+	var artifacts []ExecutionArtifactResp
+	for _, a := range e.Artifacts {
+		artifacts = append(artifacts, ExecutionArtifactResp{
+			ID:        a.ID,
+			ActionID:  a.ActionID,
+			NodeName:  a.NodeName,
+			Path:      a.Path,
+			SizeBytes: a.SizeBytes,
+			Checksum:  a.Checksum,
+			CreatedAt: a.CreatedAt.UTC().Format(TimeFormat),
+		})
+	}
+
 	return ExecutionSummary{
 		ID:              e.ExecID,
 		FlowName:        e.FlowName,
@@ -558,6 +830,8 @@ func coreExecutionSummaryToExecutionSummary(e models.ExecutionSummary) Execution
 		CreatedAt:       e.CreatedAt.Format(TimeFormat),
 		CompletedAt:     e.CompletedAt.Format(TimeFormat),
 		Duration:        e.Duration(),
+		SSHCertificates: certs,
+		Artifacts:       artifacts,
 	}
 }
 
@@ -572,6 +846,9 @@ type FlowMetaReq struct {
 	Description  string   `json:"description" validate:"max=255"`
 	Schedules    []string `json:"schedules" validate:"omitempty,dive,cron"`
 	AllowOverlap bool     `json:"allow_overlap"`
+	Idempotent   bool     `json:"idempotent"`
+	Locks        []string `json:"locks" validate:"omitempty,dive,resource_name"`
+	LockTimeout  string   `json:"lock_timeout"`
 }
 
 type FlowInputReq struct {
@@ -586,13 +863,16 @@ type FlowInputReq struct {
 }
 
 type FlowActionReq struct {
-	Name      string           `json:"name" validate:"required,alphanum_whitespace,min=1,max=150"`
-	Executor  string           `json:"executor" validate:"required,oneof=script docker"`
-	With      map[string]any   `json:"with" validate:"required"`
-	Approval  bool             `json:"approval"`
-	Variables []map[string]any `json:"variables"`
-	Condition string           `json:"condition"`
-	On        []string         `json:"on"`
+	Name        string           `json:"name" validate:"required,alphanum_whitespace,min=1,max=150"`
+	Executor    string           `json:"executor" validate:"required,executor"`
+	With        map[string]any   `json:"with" validate:"required"`
+	Approval    bool             `json:"approval"`
+	Variables   []map[string]any `json:"variables"`
+	Condition   string           `json:"condition"`
+	Artifacts   []string         `json:"artifacts" validate:"omitempty,dive,required"`
+	On          []string         `json:"on"`
+	Locks       []string         `json:"locks" validate:"omitempty,dive,resource_name"`
+	LockTimeout string           `json:"lock_timeout"`
 }
 
 type FlowCreateResp struct {
@@ -607,9 +887,17 @@ type ExecutionGetReq struct {
 	ExecID string `param:"execID" validate:"required,uuid4"`
 }
 
+type ExecutionArtifactGetReq struct {
+	ExecID     string `param:"execID" validate:"required,uuid4"`
+	ArtifactID string `param:"artifactID" validate:"required,uuid4"`
+}
+
 type FlowUpdateReq struct {
 	Schedules    []string        `json:"schedules" validate:"omitempty,dive,cron"`
 	AllowOverlap bool            `json:"allow_overlap"`
+	Idempotent   bool            `json:"idempotent"`
+	Locks        []string        `json:"locks" validate:"omitempty,dive,resource_name"`
+	LockTimeout  string          `json:"lock_timeout"`
 	Description  string          `json:"description" validate:"max=255"`
 	Inputs       []FlowInputReq  `json:"inputs" validate:"required,dive"`
 	Actions      []FlowActionReq `json:"actions" validate:"required,dive"`
@@ -643,13 +931,17 @@ func convertFlowActionsReqToActions(actionsReq []FlowActionReq) []models.Action
 		}
 
 		actions[i] = models.Action{
-			ID:        GenerateSlug(action.Name),
-			Name:      action.Name,
-			Executor:  action.Executor,
-			With:      action.With,
-			Approval:  action.Approval,
-			Variables: variables,
-			On:        action.On,
+			ID:          GenerateSlug(action.Name),
+			Name:        action.Name,
+			Executor:    action.Executor,
+			With:        action.With,
+			Approval:    action.Approval,
+			Variables:   variables,
+			On:          action.On,
+			Locks:       action.Locks,
+			LockTimeout: action.LockTimeout,
+			If:          action.Condition,
+			Artifacts:   action.Artifacts,
 		}
 	}
 	return actions
@@ -683,12 +975,16 @@ func convertFlowActionsToActionsReq(actions []models.Action) []FlowActionReq {
 		}
 
 		actionsReq[i] = FlowActionReq{
-			Name:      action.Name,
-			Executor:  action.Executor,
-			With:      action.With,
-			Approval:  action.Approval,
-			Variables: variables,
-			On:        action.On,
+			Name:        action.Name,
+			Executor:    action.Executor,
+			With:        action.With,
+			Approval:    action.Approval,
+			Variables:   variables,
+			Condition:   action.If,
+			Artifacts:   action.Artifacts,
+			On:          action.On,
+			Locks:       action.Locks,
+			LockTimeout: action.LockTimeout,
 		}
 	}
 	return actionsReq
@@ -736,3 +1032,55 @@ type FlowCancellationResp struct {
 	Message string `json:"message"`
 	ExecID  string `json:"execID"`
 }
+
+type ResourceLockEntryResp struct {
+	ExecID     string `json:"exec_id"`
+	ActionID   string `json:"action_id,omitempty"`
+	FlowID     string `json:"flow_id"`
+	FlowName   string `json:"flow_name"`
+	CreatedAt  string `json:"created_at"`
+	AcquiredAt string `json:"acquired_at,omitempty"`
+}
+
+type ResourceLockResp struct {
+	Name    string                  `json:"name"`
+	Holder  *ResourceLockEntryResp  `json:"holder"`
+	Waiters []ResourceLockEntryResp `json:"waiters"`
+}
+
+type ResourceLocksResp struct {
+	Locks []ResourceLockResp `json:"locks"`
+}
+
+func coreResourceLockEntryToResp(e models.ResourceLockEntry) ResourceLockEntryResp {
+	resp := ResourceLockEntryResp{
+		ExecID:    e.ExecID,
+		ActionID:  e.ActionID,
+		FlowID:    e.FlowID,
+		FlowName:  e.FlowName,
+		CreatedAt: e.CreatedAt.Format(TimeFormat),
+	}
+	if e.AcquiredAt != nil {
+		resp.AcquiredAt = e.AcquiredAt.Format(TimeFormat)
+	}
+	return resp
+}
+
+func coreResourceLocksToResp(locks []models.ResourceLock) ResourceLocksResp {
+	resp := ResourceLocksResp{Locks: make([]ResourceLockResp, 0, len(locks))}
+	for _, l := range locks {
+		lock := ResourceLockResp{
+			Name:    l.Name,
+			Waiters: make([]ResourceLockEntryResp, 0, len(l.Waiters)),
+		}
+		if l.Holder != nil {
+			holder := coreResourceLockEntryToResp(*l.Holder)
+			lock.Holder = &holder
+		}
+		for _, w := range l.Waiters {
+			lock.Waiters = append(lock.Waiters, coreResourceLockEntryToResp(w))
+		}
+		resp.Locks = append(resp.Locks, lock)
+	}
+	return resp
+}
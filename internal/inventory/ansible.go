@@ -0,0 +1,474 @@
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ansibleInventory is the group structure shared by the Ansible inventory formats
+type ansibleInventory struct {
+	groups   map[string]*ansibleGroup
+	hosts    []string
+	hostVars map[string]map[string]string
+}
+
+type ansibleGroup struct {
+	hosts    []string
+	vars     map[string]string
+	children []string
+}
+
+func newAnsibleInventory() *ansibleInventory {
+	return &ansibleInventory{
+		groups:   make(map[string]*ansibleGroup),
+		hostVars: make(map[string]map[string]string),
+	}
+}
+
+func (inv *ansibleInventory) group(name string) *ansibleGroup {
+	g, ok := inv.groups[name]
+	if !ok {
+		g = &ansibleGroup{vars: make(map[string]string)}
+		inv.groups[name] = g
+	}
+	return g
+}
+
+// addHost adds a host to a group, vars are merged with the variables already set for the host
+func (inv *ansibleInventory) addHost(group string, host string, vars map[string]string) {
+	if _, ok := inv.hostVars[host]; !ok {
+		inv.hosts = append(inv.hosts, host)
+		inv.hostVars[host] = make(map[string]string)
+	}
+	for k, v := range vars {
+		inv.hostVars[host][k] = v
+	}
+
+	g := inv.group(group)
+	for _, h := range g.hosts {
+		if h == host {
+			return
+		}
+	}
+	g.hosts = append(g.hosts, host)
+}
+
+// resolve returns the hosts of the inventory. Like Ansible, variables of parent groups are
+// overridden by child groups, groups at the same depth are applied in order of their names
+// and host variables take precedence over all group variables
+func (inv *ansibleInventory) resolve() ([]Host, error) {
+	parents := make(map[string][]string)
+	for name, g := range inv.groups {
+		for _, child := range g.children {
+			inv.group(child)
+			parents[child] = append(parents[child], name)
+		}
+	}
+
+	depths := make(map[string]int)
+	var depth func(name string, visiting map[string]bool) (int, error)
+	depth = func(name string, visiting map[string]bool) (int, error) {
+		if d, ok := depths[name]; ok {
+			return d, nil
+		}
+		if visiting[name] {
+			return 0, fmt.Errorf("group %s is its own child", name)
+		}
+		visiting[name] = true
+		defer delete(visiting, name)
+
+		d := 0
+		if name != "all" {
+			d = 1
+		}
+		for _, p := range parents[name] {
+			pd, err := depth(p, visiting)
+			if err != nil {
+				return 0, err
+			}
+			if pd+1 > d {
+				d = pd + 1
+			}
+		}
+		depths[name] = d
+		return d, nil
+	}
+	for name := range inv.groups {
+		if _, err := depth(name, make(map[string]bool)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Groups containing each host directly
+	hostGroups := make(map[string][]string)
+	for name, g := range inv.groups {
+		for _, h := range g.hosts {
+			hostGroups[h] = append(hostGroups[h], name)
+		}
+	}
+
+	hosts := make([]Host, 0, len(inv.hosts))
+	for _, name := range inv.hosts {
+		// Collect the groups of the host and their ancestors
+		groups := map[string]bool{"all": true}
+		queue := append([]string{}, hostGroups[name]...)
+		for len(queue) > 0 {
+			g := queue[0]
+			queue = queue[1:]
+			if groups[g] {
+				continue
+			}
+			groups[g] = true
+			queue = append(queue, parents[g]...)
+		}
+
+		ordered := make([]string, 0, len(groups))
+		for g := range groups {
+			ordered = append(ordered, g)
+		}
+		sort.Slice(ordered, func(i, j int) bool {
+			if depths[ordered[i]] != depths[ordered[j]] {
+				return depths[ordered[i]] < depths[ordered[j]]
+			}
+			return ordered[i] < ordered[j]
+		})
+
+		vars := make(map[string]string)
+		for _, g := range ordered {
+			if group, ok := inv.groups[g]; ok {
+				for k, v := range group.vars {
+					vars[k] = v
+				}
+			}
+		}
+		for k, v := range inv.hostVars[name] {
+			vars[k] = v
+		}
+
+		h, err := hostFromVars(name, vars, ordered)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, h)
+	}
+
+	return hosts, nil
+}
+
+// ParseINI parses an Ansible inventory in the INI format
+func ParseINI(data []byte) ([]Host, error) {
+	inv := newAnsibleInventory()
+
+	group, kind := "ungrouped", "hosts"
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			group, kind = line[1:len(line)-1], "hosts"
+			if name, suffix, ok := strings.Cut(group, ":"); ok {
+				if suffix != "vars" && suffix != "children" {
+					return nil, fmt.Errorf("line %d: unknown section type %q", lineNo, suffix)
+				}
+				group, kind = name, suffix
+			}
+			if group == "" {
+				return nil, fmt.Errorf("line %d: empty group name", lineNo)
+			}
+			inv.group(group)
+			continue
+		}
+
+		fields, err := splitFields(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		switch kind {
+		case "vars":
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, fmt.Errorf("line %d: expected key=value", lineNo)
+			}
+			inv.group(group).vars[strings.TrimSpace(key)] = unquote(strings.TrimSpace(value))
+		case "children":
+			g := inv.group(group)
+			g.children = append(g.children, fields[0])
+		default:
+			vars := make(map[string]string)
+			for _, f := range fields[1:] {
+				key, value, ok := strings.Cut(f, "=")
+				if !ok {
+					return nil, fmt.Errorf("line %d: expected key=value, got %q", lineNo, f)
+				}
+				vars[key] = value
+			}
+
+			pattern := fields[0]
+			// A port can be set with host:port, IPv6 addresses are not split
+			if host, port, ok := strings.Cut(pattern, ":"); ok && !strings.Contains(port, ":") && !strings.Contains(pattern, "[") {
+				pattern = host
+				vars["ansible_port"] = port
+			}
+
+			names, err := expandHostPattern(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			for _, name := range names {
+				inv.addHost(group, name, vars)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read inventory: %w", err)
+	}
+
+	return inv.resolve()
+}
+
+// splitFields splits an inventory line on whitespace. Values can be quoted to include spaces
+func splitFields(line string) ([]string, error) {
+	var fields []string
+	var current strings.Builder
+	var quote rune
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && current.Len() == 0:
+			// The rest of the line is a comment
+			return fields, nil
+		case r == ' ' || r == '\t':
+			if current.Len() > 0 {
+				fields = append(fields, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if current.Len() > 0 {
+		fields = append(fields, current.String())
+	}
+	return fields, nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// expandHostPattern expands the ranges in a host pattern, such as web[01:20].example.com
+// or db-[a:c].example.com. A range can have a stride, [1:10:2]
+func expandHostPattern(pattern string) ([]string, error) {
+	start := strings.Index(pattern, "[")
+	if start < 0 {
+		return []string{pattern}, nil
+	}
+	end := strings.Index(pattern[start:], "]")
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated range in host pattern %s", pattern)
+	}
+	end += start
+
+	parts := strings.Split(pattern[start+1:end], ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid range in host pattern %s", pattern)
+	}
+	stride := 1
+	if len(parts) == 3 {
+		s, err := strconv.Atoi(parts[2])
+		if err != nil || s < 1 {
+			return nil, fmt.Errorf("invalid range stride in host pattern %s", pattern)
+		}
+		stride = s
+	}
+
+	var values []string
+	from, errFrom := strconv.Atoi(parts[0])
+	to, errTo := strconv.Atoi(parts[1])
+	switch {
+	case errFrom == nil && errTo == nil:
+		if to < from {
+			return nil, fmt.Errorf("invalid range in host pattern %s", pattern)
+		}
+		// Leading zeros of the start are kept for all values
+		format := fmt.Sprintf("%%0%dd", len(parts[0]))
+		for i := from; i <= to; i += stride {
+			values = append(values, fmt.Sprintf(format, i))
+		}
+	case len(parts[0]) == 1 && len(parts[1]) == 1 && parts[0] <= parts[1]:
+		for c := parts[0][0]; c <= parts[1][0]; c += byte(stride) {
+			values = append(values, string(c))
+		}
+	default:
+		return nil, fmt.Errorf("invalid range in host pattern %s", pattern)
+	}
+
+	var hosts []string
+	for _, v := range values {
+		rest, err := expandHostPattern(pattern[end+1:])
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rest {
+			hosts = append(hosts, pattern[:start]+v+r)
+		}
+	}
+	return hosts, nil
+}
+
+type yamlGroup struct {
+	Hosts    map[string]map[string]any `yaml:"hosts"`
+	Vars     map[string]any            `yaml:"vars"`
+	Children map[string]*yamlGroup     `yaml:"children"`
+}
+
+// ParseYAML parses an Ansible inventory in the YAML format
+func ParseYAML(data []byte) ([]Host, error) {
+	var root map[string]*yamlGroup
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("could not parse YAML inventory: %w", err)
+	}
+
+	inv := newAnsibleInventory()
+	var add func(name string, g *yamlGroup) error
+	add = func(name string, g *yamlGroup) error {
+		group := inv.group(name)
+		if g == nil {
+			return nil
+		}
+
+		for k, v := range g.Vars {
+			group.vars[k] = fmt.Sprint(v)
+		}
+
+		// Hosts are added in order of their names as YAML maps are not ordered
+		names := make([]string, 0, len(g.Hosts))
+		for h := range g.Hosts {
+			names = append(names, h)
+		}
+		sort.Strings(names)
+		for _, pattern := range names {
+			vars := make(map[string]string)
+			for k, v := range g.Hosts[pattern] {
+				vars[k] = fmt.Sprint(v)
+			}
+			hosts, err := expandHostPattern(pattern)
+			if err != nil {
+				return err
+			}
+			for _, h := range hosts {
+				inv.addHost(name, h, vars)
+			}
+		}
+
+		children := make([]string, 0, len(g.Children))
+		for c := range g.Children {
+			children = append(children, c)
+		}
+		sort.Strings(children)
+		for _, c := range children {
+			group.children = append(group.children, c)
+			if err := add(c, g.Children[c]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	names := make([]string, 0, len(root))
+	for name := range root {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := add(name, root[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	return inv.resolve()
+}
+
+// ParseJSON parses the output of an Ansible dynamic inventory script run with --list
+func ParseJSON(data []byte) ([]Host, error) {
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("could not parse JSON inventory: %w", err)
+	}
+
+	var meta struct {
+		HostVars map[string]map[string]any `json:"hostvars"`
+	}
+	if raw, ok := root["_meta"]; ok {
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return nil, fmt.Errorf("could not parse _meta: %w", err)
+		}
+	}
+
+	inv := newAnsibleInventory()
+	names := make([]string, 0, len(root))
+	for name := range root {
+		if name != "_meta" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var g struct {
+			Hosts    []string       `json:"hosts"`
+			Vars     map[string]any `json:"vars"`
+			Children []string       `json:"children"`
+		}
+		// A group can also be a list of hosts
+		if err := json.Unmarshal(root[name], &g.Hosts); err != nil {
+			if err := json.Unmarshal(root[name], &g); err != nil {
+				return nil, fmt.Errorf("could not parse group %s: %w", name, err)
+			}
+		}
+
+		group := inv.group(name)
+		for k, v := range g.Vars {
+			group.vars[k] = fmt.Sprint(v)
+		}
+		group.children = append(group.children, g.Children...)
+		for _, h := range g.Hosts {
+			inv.addHost(name, h, nil)
+		}
+	}
+
+	for host, hv := range meta.HostVars {
+		if _, ok := inv.hostVars[host]; !ok {
+			continue
+		}
+		for k, v := range hv {
+			inv.hostVars[host][k] = fmt.Sprint(v)
+		}
+	}
+
+	return inv.resolve()
+}
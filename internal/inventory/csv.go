@@ -0,0 +1,96 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseCSV parses a CSV file with a header row. The hostname column is required, the other
+// supported columns are name, port, username, connection_type, credential and tags.
+// Multiple tags are separated with semicolons. Nodes are named after the hostname when there is no name
+func ParseCSV(data []byte) ([]Host, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	header, err := r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("inventory is empty")
+		}
+		return nil, fmt.Errorf("could not read header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, col := range header {
+		col = strings.ToLower(strings.TrimSpace(col))
+		switch col {
+		case "name", "hostname", "port", "username", "connection_type", "credential", "tags":
+		default:
+			return nil, fmt.Errorf("unknown column %q", col)
+		}
+		columns[col] = i
+	}
+	if _, ok := columns["hostname"]; !ok {
+		return nil, errors.New("hostname column is required")
+	}
+
+	var hosts []Host
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read inventory: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+
+		field := func(col string) string {
+			if i, ok := columns[col]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		h := Host{
+			Hostname:   field("hostname"),
+			Username:   field("username"),
+			Credential: field("credential"),
+		}
+
+		h.Name = NodeName(h.Hostname)
+		if name := field("name"); name != "" {
+			h.Name = name
+		}
+
+		if port := field("port"); port != "" {
+			p, err := strconv.Atoi(port)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid port %q", line, port)
+			}
+			h.Port = p
+		}
+
+		if h.ConnectionType, err = connectionType(field("connection_type")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		var groups []string
+		for _, t := range strings.Split(field("tags"), ";") {
+			if t = strings.TrimSpace(t); t != "" {
+				groups = append(groups, t)
+			}
+		}
+		h.Tags = tags(groups)
+
+		hosts = append(hosts, h)
+	}
+
+	return hosts, nil
+}
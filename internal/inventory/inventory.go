@@ -0,0 +1,169 @@
+// Package inventory parses lists of nodes from CSV files and Ansible inventories
+package inventory
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatINI  = "ini"
+	FormatYAML = "yaml"
+	// FormatJSON is the output of an Ansible dynamic inventory script run with --list
+	FormatJSON = "json"
+)
+
+// maxNameLength is the maximum length of node names
+const maxNameLength = 50
+
+// Host is a node found in an inventory. Empty fields use the defaults of the import
+type Host struct {
+	Name           string
+	Hostname       string
+	Port           int
+	Username       string
+	ConnectionType string
+	// Credential is the name of the credential used by the node
+	Credential string
+	// Tags are the groups of the host
+	Tags []string
+}
+
+var (
+	invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+	nameRegex        = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+	hostnameRegex    = regexp.MustCompile(`^[a-zA-Z0-9._:\-\[\]]+$`)
+)
+
+// Parse reads the hosts of an inventory in the given format
+func Parse(format string, data []byte) ([]Host, error) {
+	var hosts []Host
+	var err error
+	switch format {
+	case FormatCSV:
+		hosts, err = ParseCSV(data)
+	case FormatINI:
+		hosts, err = ParseINI(data)
+	case FormatYAML:
+		hosts, err = ParseYAML(data)
+	case FormatJSON:
+		hosts, err = ParseJSON(data)
+	default:
+		return nil, fmt.Errorf("unsupported inventory format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return hosts, validate(hosts)
+}
+
+// validate checks that the hosts can be stored as nodes and that their names are unique
+func validate(hosts []Host) error {
+	seen := make(map[string]bool)
+	for _, h := range hosts {
+		if !nameRegex.MatchString(h.Name) || len(h.Name) > maxNameLength {
+			return fmt.Errorf("host %s does not have a valid name %q", h.Hostname, h.Name)
+		}
+		if seen[h.Name] {
+			return fmt.Errorf("duplicate node name %s", h.Name)
+		}
+		seen[h.Name] = true
+
+		if !hostnameRegex.MatchString(h.Hostname) {
+			return fmt.Errorf("invalid hostname %q for node %s", h.Hostname, h.Name)
+		}
+		if h.Port < 0 || h.Port > 65535 {
+			return fmt.Errorf("invalid port %d for node %s", h.Port, h.Name)
+		}
+	}
+	return nil
+}
+
+// NodeName converts an inventory hostname to a node name. Characters which are not
+// allowed in node names are replaced with underscores
+func NodeName(name string) string {
+	name = invalidNameChars.ReplaceAllString(strings.TrimSpace(name), "_")
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+	return name
+}
+
+// tags converts group names to node tags, the implicit groups all and ungrouped are not included
+func tags(groups []string) []string {
+	set := make(map[string]bool)
+	for _, g := range groups {
+		if g == "all" || g == "ungrouped" || g == "" {
+			continue
+		}
+		set[NodeName(g)] = true
+	}
+
+	result := make([]string, 0, len(set))
+	for t := range set {
+		result = append(result, t)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// connectionType maps the ansible_connection variable to a connection type
+func connectionType(conn string) (string, error) {
+	switch conn {
+	case "":
+		return "", nil
+	case "ssh", "paramiko", "smart":
+		return "ssh", nil
+	case "qssh":
+		return "qssh", nil
+	case "docker", "community.docker.docker":
+		return "docker", nil
+	default:
+		return "", fmt.Errorf("unsupported connection %q", conn)
+	}
+}
+
+// hostFromVars creates a host from the Ansible variables of an inventory host
+func hostFromVars(name string, vars map[string]string, groups []string) (Host, error) {
+	h := Host{
+		Name:       NodeName(name),
+		Hostname:   name,
+		Username:   firstOf(vars, "ansible_user", "ansible_ssh_user"),
+		Credential: vars["flowctl_credential"],
+		Tags:       tags(groups),
+	}
+
+	if hostname := firstOf(vars, "ansible_host", "ansible_ssh_host"); hostname != "" {
+		h.Hostname = hostname
+	}
+
+	if port := firstOf(vars, "ansible_port", "ansible_ssh_port"); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return Host{}, fmt.Errorf("invalid port %q for host %s", port, name)
+		}
+		h.Port = p
+	}
+
+	ct, err := connectionType(vars["ansible_connection"])
+	if err != nil {
+		return Host{}, fmt.Errorf("host %s: %w", name, err)
+	}
+	h.ConnectionType = ct
+
+	return h, nil
+}
+
+func firstOf(vars map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v := vars[k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+)
+
+func hostsByName(t *testing.T, hosts []Host) map[string]Host {
+	t.Helper()
+	m := make(map[string]Host)
+	for _, h := range hosts {
+		m[h.Name] = h
+	}
+	return m
+}
+
+func TestParseCSV(t *testing.T) {
+	data := []byte(`hostname,name,port,username,tags,connection_type
+10.0.0.1,web1,2222,deploy,web;prod,ssh
+db.example.com,,,,db,
+# comment
+`)
+	hosts, err := Parse(FormatCSV, data)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	want := []Host{
+		{Name: "web1", Hostname: "10.0.0.1", Port: 2222, Username: "deploy", ConnectionType: "ssh", Tags: []string{"prod", "web"}},
+		{Name: "db_example_com", Hostname: "db.example.com", Tags: []string{"db"}},
+	}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("unexpected hosts:\n got %+v\nwant %+v", hosts, want)
+	}
+
+	if _, err := Parse(FormatCSV, []byte("name,port\nweb1,22\n")); err == nil {
+		t.Error("expected an error without a hostname column")
+	}
+}
+
+func TestParseINI(t *testing.T) {
+	data := []byte(`
+standalone.example.com
+
+[web]
+web[01:03].example.com ansible_user=deploy
+web-extra ansible_host=10.0.0.9 ansible_port=2200
+
+[db]
+db1 ansible_host=10.0.1.1 ansible_connection=docker
+db2:5432
+
+[prod:children]
+web
+db
+
+[prod:vars]
+ansible_user=admin
+ansible_port=2222
+
+[all:vars]
+ansible_port=22
+`)
+	hosts, err := Parse(FormatINI, data)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if len(hosts) != 7 {
+		t.Fatalf("expected 7 hosts, got %d: %+v", len(hosts), hosts)
+	}
+	byName := hostsByName(t, hosts)
+
+	// Host variables override the variables of the group
+	web := byName["web02_example_com"]
+	if web.Hostname != "web02.example.com" || web.Username != "deploy" || web.Port != 2222 {
+		t.Errorf("unexpected host web02: %+v", web)
+	}
+	if !reflect.DeepEqual(web.Tags, []string{"prod", "web"}) {
+		t.Errorf("expected tags from groups and parent groups, got %v", web.Tags)
+	}
+
+	extra := byName["web_extra"]
+	if extra.Hostname != "10.0.0.9" || extra.Port != 2200 || extra.Username != "admin" {
+		t.Errorf("unexpected host web-extra: %+v", extra)
+	}
+
+	if db := byName["db1"]; db.ConnectionType != "docker" || db.Hostname != "10.0.1.1" {
+		t.Errorf("unexpected host db1: %+v", db)
+	}
+	if db := byName["db2"]; db.Port != 5432 {
+		t.Errorf("expected port from host:port, got %+v", db)
+	}
+
+	standalone := byName["standalone_example_com"]
+	if standalone.Port != 22 || len(standalone.Tags) != 0 {
+		t.Errorf("unexpected ungrouped host: %+v", standalone)
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	data := []byte(`
+all:
+  vars:
+    ansible_user: root
+  hosts:
+    bastion.example.com:
+  children:
+    web:
+      vars:
+        ansible_port: 2222
+      hosts:
+        web[1:2].example.com:
+        web3.example.com:
+          ansible_host: 10.0.0.3
+          ansible_user: deploy
+`)
+	hosts, err := Parse(FormatYAML, data)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	byName := hostsByName(t, hosts)
+	if len(byName) != 4 {
+		t.Fatalf("expected 4 hosts, got %+v", hosts)
+	}
+
+	if b := byName["bastion_example_com"]; b.Username != "root" || b.Port != 0 {
+		t.Errorf("unexpected host bastion: %+v", b)
+	}
+	if w := byName["web1_example_com"]; w.Username != "root" || w.Port != 2222 || !reflect.DeepEqual(w.Tags, []string{"web"}) {
+		t.Errorf("unexpected host web1: %+v", w)
+	}
+	if w := byName["web3_example_com"]; w.Hostname != "10.0.0.3" || w.Username != "deploy" {
+		t.Errorf("unexpected host web3: %+v", w)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	data := []byte(`{
+  "_meta": {"hostvars": {"app1": {"ansible_host": "10.0.0.1", "ansible_port": 2200}}},
+  "app": {"hosts": ["app1", "app2"], "vars": {"ansible_user": "app"}},
+  "legacy": ["app2"],
+  "prod": {"children": ["app"]}
+}`)
+	hosts, err := Parse(FormatJSON, data)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	byName := hostsByName(t, hosts)
+
+	if a := byName["app1"]; a.Hostname != "10.0.0.1" || a.Port != 2200 || a.Username != "app" {
+		t.Errorf("unexpected host app1: %+v", a)
+	}
+	if a := byName["app2"]; !reflect.DeepEqual(a.Tags, []string{"app", "legacy", "prod"}) {
+		t.Errorf("unexpected tags for app2: %v", a.Tags)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+	}{
+		{"duplicate names", FormatCSV, "hostname,name\na.example.com,a\nb.example.com,a\n"},
+		{"invalid name", FormatCSV, "hostname,name\na.example.com,web-1\n"},
+		{"invalid port", FormatINI, "host1 ansible_port=ssh\n"},
+		{"unsupported connection", FormatINI, "host1 ansible_connection=winrm\n"},
+		{"invalid range", FormatINI, "web[10:1]\n"},
+		{"cyclic groups", FormatINI, "[a:children]\nb\n[b:children]\na\n[b]\nhost1\n"},
+		{"unknown format", "toml", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.format, []byte(tt.data)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: artifacts.sql
+
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const addExecutionArtifact = `-- name: AddExecutionArtifact :exec
+INSERT INTO execution_artifacts (exec_id, action_id, node_name, path, size_bytes, checksum, storage_key, namespace_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, (SELECT id FROM namespaces WHERE namespaces.uuid = $8))
+ON CONFLICT ON CONSTRAINT unique_execution_artifact
+DO UPDATE SET size_bytes = EXCLUDED.size_bytes, checksum = EXCLUDED.checksum, storage_key = EXCLUDED.storage_key, created_at = NOW()
+`
+
+type AddExecutionArtifactParams struct {
+	ExecID     string    `db:"exec_id" json:"exec_id"`
+	ActionID   string    `db:"action_id" json:"action_id"`
+	NodeName   string    `db:"node_name" json:"node_name"`
+	Path       string    `db:"path" json:"path"`
+	SizeBytes  int64     `db:"size_bytes" json:"size_bytes"`
+	Checksum   string    `db:"checksum" json:"checksum"`
+	StorageKey string    `db:"storage_key" json:"storage_key"`
+	Uuid       uuid.UUID `db:"uuid" json:"uuid"`
+}
+
+// Artifacts stored again for the same action, for example when an execution is retried, replace the previous record
+func (q *Queries) AddExecutionArtifact(ctx context.Context, arg AddExecutionArtifactParams) error {
+	_, err := q.db.ExecContext(ctx, addExecutionArtifact,
+		arg.ExecID,
+		arg.ActionID,
+		arg.NodeName,
+		arg.Path,
+		arg.SizeBytes,
+		arg.Checksum,
+		arg.StorageKey,
+		arg.Uuid,
+	)
+	return err
+}
+
+const deleteExecutionArtifact = `-- name: DeleteExecutionArtifact :exec
+DELETE FROM execution_artifacts WHERE id = $1
+`
+
+func (q *Queries) DeleteExecutionArtifact(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteExecutionArtifact, id)
+	return err
+}
+
+const getExecutionArtifactByUUID = `-- name: GetExecutionArtifactByUUID :one
+SELECT a.id, a.uuid, a.exec_id, a.action_id, a.node_name, a.path, a.size_bytes, a.checksum, a.storage_key, a.namespace_id, a.created_at FROM execution_artifacts a
+JOIN namespaces ns ON a.namespace_id = ns.id
+WHERE a.uuid = $1 AND a.exec_id = $2 AND ns.uuid = $3
+`
+
+type GetExecutionArtifactByUUIDParams struct {
+	Uuid   uuid.UUID `db:"uuid" json:"uuid"`
+	ExecID string    `db:"exec_id" json:"exec_id"`
+	Uuid_2 uuid.UUID `db:"uuid_2" json:"uuid_2"`
+}
+
+func (q *Queries) GetExecutionArtifactByUUID(ctx context.Context, arg GetExecutionArtifactByUUIDParams) (ExecutionArtifact, error) {
+	row := q.db.QueryRowContext(ctx, getExecutionArtifactByUUID, arg.Uuid, arg.ExecID, arg.Uuid_2)
+	var i ExecutionArtifact
+	err := row.Scan(
+		&i.ID,
+		&i.Uuid,
+		&i.ExecID,
+		&i.ActionID,
+		&i.NodeName,
+		&i.Path,
+		&i.SizeBytes,
+		&i.Checksum,
+		&i.StorageKey,
+		&i.NamespaceID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getExecutionArtifacts = `-- name: GetExecutionArtifacts :many
+SELECT a.id, a.uuid, a.exec_id, a.action_id, a.node_name, a.path, a.size_bytes, a.checksum, a.storage_key, a.namespace_id, a.created_at FROM execution_artifacts a
+JOIN namespaces ns ON a.namespace_id = ns.id
+WHERE a.exec_id = $1 AND ns.uuid = $2
+ORDER BY a.created_at, a.id
+`
+
+type GetExecutionArtifactsParams struct {
+	ExecID string    `db:"exec_id" json:"exec_id"`
+	Uuid   uuid.UUID `db:"uuid" json:"uuid"`
+}
+
+func (q *Queries) GetExecutionArtifacts(ctx context.Context, arg GetExecutionArtifactsParams) ([]ExecutionArtifact, error) {
+	rows, err := q.db.QueryContext(ctx, getExecutionArtifacts, arg.ExecID, arg.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExecutionArtifact
+	for rows.Next() {
+		var i ExecutionArtifact
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uuid,
+			&i.ExecID,
+			&i.ActionID,
+			&i.NodeName,
+			&i.Path,
+			&i.SizeBytes,
+			&i.Checksum,
+			&i.StorageKey,
+			&i.NamespaceID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getExpiredArtifacts = `-- name: GetExpiredArtifacts :many
+SELECT a.id, a.storage_key FROM execution_artifacts a
+JOIN namespaces ns ON a.namespace_id = ns.id
+WHERE COALESCE(ns.artifact_retention_days * 86400, $1::bigint) > 0
+AND a.created_at < NOW() - (COALESCE(ns.artifact_retention_days * 86400, $1::bigint) * INTERVAL '1 second')
+ORDER BY a.id
+LIMIT $2
+`
+
+type GetExpiredArtifactsParams struct {
+	DefaultRetentionSeconds int64 `db:"default_retention_seconds" json:"default_retention_seconds"`
+	BatchSize               int32 `db:"batch_size" json:"batch_size"`
+}
+
+type GetExpiredArtifactsRow struct {
+	ID         int32  `db:"id" json:"id"`
+	StorageKey string `db:"storage_key" json:"storage_key"`
+}
+
+// The retention of the namespace is used when set, otherwise default_retention_seconds. A retention of 0 keeps artifacts forever
+func (q *Queries) GetExpiredArtifacts(ctx context.Context, arg GetExpiredArtifactsParams) ([]GetExpiredArtifactsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getExpiredArtifacts, arg.DefaultRetentionSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetExpiredArtifactsRow
+	for rows.Next() {
+		var i GetExpiredArtifactsRow
+		if err := rows.Scan(&i.ID, &i.StorageKey); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -32,10 +32,11 @@ INSERT INTO execution_log (
     input,
     trigger_type,
     triggered_by,
-    namespace_id
+    namespace_id,
+    priority
 ) VALUES (
-    $1, $2, (SELECT version FROM next_version), $3, $6, (SELECT id FROM user_lookup), (SELECT id FROM namespace_lookup)
-) RETURNING id, exec_id, flow_id, version, input, error, current_action_id, status, trigger_type, triggered_by, namespace_id, created_at, updated_at
+    $1, $2, (SELECT version FROM next_version), $3, $6, (SELECT id FROM user_lookup), (SELECT id FROM namespace_lookup), $7
+) RETURNING id, exec_id, flow_id, version, input, error, current_action_id, status, trigger_type, triggered_by, namespace_id, created_at, updated_at, priority
 `
 
 type AddExecutionLogParams struct {
@@ -45,6 +46,7 @@ type AddExecutionLogParams struct {
 	Uuid        uuid.UUID       `db:"uuid" json:"uuid"`
 	Uuid_2      uuid.UUID       `db:"uuid_2" json:"uuid_2"`
 	TriggerType TriggerType     `db:"trigger_type" json:"trigger_type"`
+	Priority    int32           `db:"priority" json:"priority"`
 }
 
 func (q *Queries) AddExecutionLog(ctx context.Context, arg AddExecutionLogParams) (ExecutionLog, error) {
@@ -55,6 +57,7 @@ func (q *Queries) AddExecutionLog(ctx context.Context, arg AddExecutionLogParams
 		arg.Uuid,
 		arg.Uuid_2,
 		arg.TriggerType,
+		arg.Priority,
 	)
 	var i ExecutionLog
 	err := row.Scan(
@@ -71,10 +74,43 @@ func (q *Queries) AddExecutionLog(ctx context.Context, arg AddExecutionLogParams
 		&i.NamespaceID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Priority,
 	)
 	return i, err
 }
 
+const addExecutionSSHCertificate = `-- name: AddExecutionSSHCertificate :exec
+INSERT INTO execution_ssh_certificates (exec_id, action_id, node_name, serial, key_id, principals, valid_after, valid_before, namespace_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, (SELECT id FROM namespaces WHERE namespaces.uuid = $9))
+`
+
+type AddExecutionSSHCertificateParams struct {
+	ExecID      string    `db:"exec_id" json:"exec_id"`
+	ActionID    string    `db:"action_id" json:"action_id"`
+	NodeName    string    `db:"node_name" json:"node_name"`
+	Serial      int64     `db:"serial" json:"serial"`
+	KeyID       string    `db:"key_id" json:"key_id"`
+	Principals  []string  `db:"principals" json:"principals"`
+	ValidAfter  time.Time `db:"valid_after" json:"valid_after"`
+	ValidBefore time.Time `db:"valid_before" json:"valid_before"`
+	Uuid        uuid.UUID `db:"uuid" json:"uuid"`
+}
+
+func (q *Queries) AddExecutionSSHCertificate(ctx context.Context, arg AddExecutionSSHCertificateParams) error {
+	_, err := q.db.ExecContext(ctx, addExecutionSSHCertificate,
+		arg.ExecID,
+		arg.ActionID,
+		arg.NodeName,
+		arg.Serial,
+		arg.KeyID,
+		pq.Array(arg.Principals),
+		arg.ValidAfter,
+		arg.ValidBefore,
+		arg.Uuid,
+	)
+	return err
+}
+
 const executionExistsForFlow = `-- name: ExecutionExistsForFlow :one
 WITH namespace_lookup AS (
     SELECT id FROM namespaces WHERE namespaces.uuid = $2
@@ -86,7 +122,7 @@ latest_versions AS (
     WHERE f.namespace_id = (SELECT id FROM namespace_lookup)
     GROUP BY exec_id
 )
-SELECT exists (SELECT id, el.exec_id, flow_id, version, input, error, current_action_id, status, trigger_type, triggered_by, namespace_id, created_at, updated_at, lv.exec_id, max_version FROM execution_log el INNER JOIN latest_versions lv on el.exec_id = lv.exec_id
+SELECT exists (SELECT id, el.exec_id, flow_id, version, input, error, current_action_id, status, trigger_type, triggered_by, namespace_id, created_at, updated_at, priority, lv.exec_id, max_version FROM execution_log el INNER JOIN latest_versions lv on el.exec_id = lv.exec_id
 WHERE flow_id = (SELECT id FROM flows WHERE flows.slug = $1 AND flows.is_active = TRUE) AND
 namespace_id = (SELECT id FROM namespace_lookup) AND
 (status = 'running' or status = 'pending_approval' or status = 'pending') AND
@@ -118,7 +154,7 @@ latest_versions AS (
     GROUP BY exec_id
 ),
 filtered AS (
-    SELECT el.id, el.exec_id, el.flow_id, el.version, el.input, el.error, el.current_action_id, el.status, el.trigger_type, el.triggered_by, el.namespace_id, el.created_at, el.updated_at, u.name, u.username, u.uuid as triggered_by_uuid,
+    SELECT el.id, el.exec_id, el.flow_id, el.version, el.input, el.error, el.current_action_id, el.status, el.trigger_type, el.triggered_by, el.namespace_id, el.created_at, el.updated_at, el.priority, u.name, u.username, u.uuid as triggered_by_uuid,
            CONCAT(u.name, ' <', u.username, '>')::TEXT as triggered_by_name,
            f.name as flow_name,
            f.slug as flow_slug
@@ -133,7 +169,7 @@ total AS (
     SELECT COUNT(*) AS total_count FROM filtered
 ),
 paged AS (
-    SELECT id, exec_id, flow_id, version, input, error, current_action_id, status, trigger_type, triggered_by, namespace_id, created_at, updated_at, name, username, triggered_by_uuid, triggered_by_name, flow_name, flow_slug FROM filtered
+    SELECT id, exec_id, flow_id, version, input, error, current_action_id, status, trigger_type, triggered_by, namespace_id, created_at, updated_at, priority, name, username, triggered_by_uuid, triggered_by_name, flow_name, flow_slug FROM filtered
     ORDER BY created_at DESC
     LIMIT $2 OFFSET $3
 ),
@@ -141,7 +177,7 @@ page_count AS (
     SELECT CEIL(total.total_count::numeric / $2::numeric)::bigint AS page_count FROM total
 )
 SELECT
-    p.id, p.exec_id, p.flow_id, p.version, p.input, p.error, p.current_action_id, p.status, p.trigger_type, p.triggered_by, p.namespace_id, p.created_at, p.updated_at, p.name, p.username, p.triggered_by_uuid, p.triggered_by_name, p.flow_name, p.flow_slug,
+    p.id, p.exec_id, p.flow_id, p.version, p.input, p.error, p.current_action_id, p.status, p.trigger_type, p.triggered_by, p.namespace_id, p.created_at, p.updated_at, p.priority, p.name, p.username, p.triggered_by_uuid, p.triggered_by_name, p.flow_name, p.flow_slug,
     pc.page_count,
     t.total_count
 FROM paged p, page_count pc, total t
@@ -167,6 +203,7 @@ type GetAllExecutionsPaginatedRow struct {
 	NamespaceID     int32           `db:"namespace_id" json:"namespace_id"`
 	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
+	Priority        int32           `db:"priority" json:"priority"`
 	Name            string          `db:"name" json:"name"`
 	Username        string          `db:"username" json:"username"`
 	TriggeredByUuid uuid.UUID       `db:"triggered_by_uuid" json:"triggered_by_uuid"`
@@ -200,6 +237,7 @@ func (q *Queries) GetAllExecutionsPaginated(ctx context.Context, arg GetAllExecu
 			&i.NamespaceID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Priority,
 			&i.Name,
 			&i.Username,
 			&i.TriggeredByUuid,
@@ -231,7 +269,7 @@ WITH namespace_lookup AS (
     WHERE exec_id = $1 AND namespace_id = (SELECT id FROM namespace_lookup)
 )
 SELECT
-    el.id, el.exec_id, el.flow_id, el.version, el.input, el.error, el.current_action_id, el.status, el.trigger_type, el.triggered_by, el.namespace_id, el.created_at, el.updated_at,
+    el.id, el.exec_id, el.flow_id, el.version, el.input, el.error, el.current_action_id, el.status, el.trigger_type, el.triggered_by, el.namespace_id, el.created_at, el.updated_at, el.priority,
     u.name,
     u.username,
     u.uuid AS triggered_by_uuid,
@@ -270,6 +308,7 @@ type GetExecutionByExecIDRow struct {
 	NamespaceID     int32           `db:"namespace_id" json:"namespace_id"`
 	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
+	Priority        int32           `db:"priority" json:"priority"`
 	Name            string          `db:"name" json:"name"`
 	Username        string          `db:"username" json:"username"`
 	TriggeredByUuid uuid.UUID       `db:"triggered_by_uuid" json:"triggered_by_uuid"`
@@ -295,6 +334,7 @@ func (q *Queries) GetExecutionByExecID(ctx context.Context, arg GetExecutionByEx
 		&i.NamespaceID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Priority,
 		&i.Name,
 		&i.Username,
 		&i.TriggeredByUuid,
@@ -315,7 +355,7 @@ WITH namespace_lookup AS (
     WHERE el2.exec_id = $1 AND f2.namespace_id = (SELECT id FROM namespace_lookup) AND f2.is_active = TRUE
 )
 SELECT
-    el.id, el.exec_id, el.flow_id, el.version, el.input, el.error, el.current_action_id, el.status, el.trigger_type, el.triggered_by, el.namespace_id, el.created_at, el.updated_at,
+    el.id, el.exec_id, el.flow_id, el.version, el.input, el.error, el.current_action_id, el.status, el.trigger_type, el.triggered_by, el.namespace_id, el.created_at, el.updated_at, el.priority,
     u.name,
     u.username,
     u.uuid AS triggered_by_uuid,
@@ -354,6 +394,7 @@ type GetExecutionByExecIDWithNamespaceRow struct {
 	NamespaceID     int32           `db:"namespace_id" json:"namespace_id"`
 	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
+	Priority        int32           `db:"priority" json:"priority"`
 	Name            string          `db:"name" json:"name"`
 	Username        string          `db:"username" json:"username"`
 	TriggeredByUuid uuid.UUID       `db:"triggered_by_uuid" json:"triggered_by_uuid"`
@@ -379,6 +420,7 @@ func (q *Queries) GetExecutionByExecIDWithNamespace(ctx context.Context, arg Get
 		&i.NamespaceID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Priority,
 		&i.Name,
 		&i.Username,
 		&i.TriggeredByUuid,
@@ -393,7 +435,7 @@ const getExecutionByID = `-- name: GetExecutionByID :one
 WITH namespace_lookup AS (
     SELECT id FROM namespaces WHERE namespaces.uuid = $2
 )
-SELECT el.id, el.exec_id, el.flow_id, el.version, el.input, el.error, el.current_action_id, el.status, el.trigger_type, el.triggered_by, el.namespace_id, el.created_at, el.updated_at, u.name, u.username, u.uuid as triggered_by_uuid,
+SELECT el.id, el.exec_id, el.flow_id, el.version, el.input, el.error, el.current_action_id, el.status, el.trigger_type, el.triggered_by, el.namespace_id, el.created_at, el.updated_at, el.priority, u.name, u.username, u.uuid as triggered_by_uuid,
        CONCAT(u.name, ' <', u.username, '>')::TEXT as triggered_by_name,
        f.name as flow_name,
        f.slug as flow_slug
@@ -422,6 +464,7 @@ type GetExecutionByIDRow struct {
 	NamespaceID     int32           `db:"namespace_id" json:"namespace_id"`
 	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
+	Priority        int32           `db:"priority" json:"priority"`
 	Name            string          `db:"name" json:"name"`
 	Username        string          `db:"username" json:"username"`
 	TriggeredByUuid uuid.UUID       `db:"triggered_by_uuid" json:"triggered_by_uuid"`
@@ -447,6 +490,7 @@ func (q *Queries) GetExecutionByID(ctx context.Context, arg GetExecutionByIDPara
 		&i.NamespaceID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Priority,
 		&i.Name,
 		&i.Username,
 		&i.TriggeredByUuid,
@@ -457,13 +501,60 @@ func (q *Queries) GetExecutionByID(ctx context.Context, arg GetExecutionByIDPara
 	return i, err
 }
 
+const getExecutionSSHCertificates = `-- name: GetExecutionSSHCertificates :many
+SELECT c.id, c.exec_id, c.action_id, c.node_name, c.serial, c.key_id, c.principals, c.valid_after, c.valid_before, c.namespace_id, c.created_at FROM execution_ssh_certificates c
+JOIN namespaces ns ON c.namespace_id = ns.id
+WHERE c.exec_id = $1 AND ns.uuid = $2
+ORDER BY c.created_at, c.id
+`
+
+type GetExecutionSSHCertificatesParams struct {
+	ExecID string    `db:"exec_id" json:"exec_id"`
+	Uuid   uuid.UUID `db:"uuid" json:"uuid"`
+}
+
+func (q *Queries) GetExecutionSSHCertificates(ctx context.Context, arg GetExecutionSSHCertificatesParams) ([]ExecutionSshCertificate, error) {
+	rows, err := q.db.QueryContext(ctx, getExecutionSSHCertificates, arg.ExecID, arg.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExecutionSshCertificate
+	for rows.Next() {
+		var i ExecutionSshCertificate
+		if err := rows.Scan(
+			&i.ID,
+			&i.ExecID,
+			&i.ActionID,
+			&i.NodeName,
+			&i.Serial,
+			&i.KeyID,
+			pq.Array(&i.Principals),
+			&i.ValidAfter,
+			&i.ValidBefore,
+			&i.NamespaceID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getExecutionsByFlow = `-- name: GetExecutionsByFlow :many
 WITH user_lookup AS (
     SELECT id FROM users WHERE users.uuid = $2
 ), namespace_lookup AS (
     SELECT id FROM namespaces WHERE namespaces.uuid = $3
 )
-SELECT el.id, el.exec_id, el.flow_id, el.version, el.input, el.error, el.current_action_id, el.status, el.trigger_type, el.triggered_by, el.namespace_id, el.created_at, el.updated_at, u.name, u.username, u.uuid as triggered_by_uuid,
+SELECT el.id, el.exec_id, el.flow_id, el.version, el.input, el.error, el.current_action_id, el.status, el.trigger_type, el.triggered_by, el.namespace_id, el.created_at, el.updated_at, el.priority, u.name, u.username, u.uuid as triggered_by_uuid,
        CONCAT(u.name, ' <', u.username, '>')::TEXT as triggered_by_name,
        f.name as flow_name,
        f.slug as flow_slug
@@ -496,6 +587,7 @@ type GetExecutionsByFlowRow struct {
 	NamespaceID     int32           `db:"namespace_id" json:"namespace_id"`
 	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
+	Priority        int32           `db:"priority" json:"priority"`
 	Name            string          `db:"name" json:"name"`
 	Username        string          `db:"username" json:"username"`
 	TriggeredByUuid uuid.UUID       `db:"triggered_by_uuid" json:"triggered_by_uuid"`
@@ -527,6 +619,7 @@ func (q *Queries) GetExecutionsByFlow(ctx context.Context, arg GetExecutionsByFl
 			&i.NamespaceID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Priority,
 			&i.Name,
 			&i.Username,
 			&i.TriggeredByUuid,
@@ -561,7 +654,7 @@ latest_versions AS (
     GROUP BY exec_id
 ),
 filtered AS (
-    SELECT el.id, el.exec_id, el.flow_id, el.version, el.input, el.error, el.current_action_id, el.status, el.trigger_type, el.triggered_by, el.namespace_id, el.created_at, el.updated_at, u.name, u.username, u.uuid as triggered_by_uuid,
+    SELECT el.id, el.exec_id, el.flow_id, el.version, el.input, el.error, el.current_action_id, el.status, el.trigger_type, el.triggered_by, el.namespace_id, el.created_at, el.updated_at, el.priority, u.name, u.username, u.uuid as triggered_by_uuid,
            CONCAT(u.name, ' <', u.username, '>')::TEXT as triggered_by_name,
            f.name as flow_name,
            f.slug as flow_slug
@@ -577,7 +670,7 @@ total AS (
     SELECT COUNT(*) AS total_count FROM filtered
 ),
 paged AS (
-    SELECT id, exec_id, flow_id, version, input, error, current_action_id, status, trigger_type, triggered_by, namespace_id, created_at, updated_at, name, username, triggered_by_uuid, triggered_by_name, flow_name, flow_slug FROM filtered
+    SELECT id, exec_id, flow_id, version, input, error, current_action_id, status, trigger_type, triggered_by, namespace_id, created_at, updated_at, priority, name, username, triggered_by_uuid, triggered_by_name, flow_name, flow_slug FROM filtered
     ORDER BY created_at DESC
     LIMIT $3 OFFSET $4
 ),
@@ -585,7 +678,7 @@ page_count AS (
     SELECT CEIL(total.total_count::numeric / $3::numeric)::bigint AS page_count FROM total
 )
 SELECT
-    p.id, p.exec_id, p.flow_id, p.version, p.input, p.error, p.current_action_id, p.status, p.trigger_type, p.triggered_by, p.namespace_id, p.created_at, p.updated_at, p.name, p.username, p.triggered_by_uuid, p.triggered_by_name, p.flow_name, p.flow_slug,
+    p.id, p.exec_id, p.flow_id, p.version, p.input, p.error, p.current_action_id, p.status, p.trigger_type, p.triggered_by, p.namespace_id, p.created_at, p.updated_at, p.priority, p.name, p.username, p.triggered_by_uuid, p.triggered_by_name, p.flow_name, p.flow_slug,
     pc.page_count,
     t.total_count
 FROM paged p, page_count pc, total t
@@ -612,6 +705,7 @@ type GetExecutionsByFlowPaginatedRow struct {
 	NamespaceID     int32           `db:"namespace_id" json:"namespace_id"`
 	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
+	Priority        int32           `db:"priority" json:"priority"`
 	Name            string          `db:"name" json:"name"`
 	Username        string          `db:"username" json:"username"`
 	TriggeredByUuid uuid.UUID       `db:"triggered_by_uuid" json:"triggered_by_uuid"`
@@ -650,6 +744,7 @@ func (q *Queries) GetExecutionsByFlowPaginated(ctx context.Context, arg GetExecu
 			&i.NamespaceID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Priority,
 			&i.Name,
 			&i.Username,
 			&i.TriggeredByUuid,
@@ -792,7 +887,7 @@ latest_versions AS (
     GROUP BY exec_id
 ),
 filtered AS (
-    SELECT el.id, el.exec_id, el.flow_id, el.version, el.input, el.error, el.current_action_id, el.status, el.trigger_type, el.triggered_by, el.namespace_id, el.created_at, el.updated_at, u.name, u.username, u.uuid as triggered_by_uuid,
+    SELECT el.id, el.exec_id, el.flow_id, el.version, el.input, el.error, el.current_action_id, el.status, el.trigger_type, el.triggered_by, el.namespace_id, el.created_at, el.updated_at, el.priority, u.name, u.username, u.uuid as triggered_by_uuid,
            CONCAT(u.name, ' <', u.username, '>')::TEXT as triggered_by_name,
            f.name as flow_name,
            f.slug as flow_slug
@@ -815,7 +910,7 @@ total AS (
     SELECT COUNT(*) AS total_count FROM filtered
 ),
 paged AS (
-    SELECT id, exec_id, flow_id, version, input, error, current_action_id, status, trigger_type, triggered_by, namespace_id, created_at, updated_at, name, username, triggered_by_uuid, triggered_by_name, flow_name, flow_slug FROM filtered
+    SELECT id, exec_id, flow_id, version, input, error, current_action_id, status, trigger_type, triggered_by, namespace_id, created_at, updated_at, priority, name, username, triggered_by_uuid, triggered_by_name, flow_name, flow_slug FROM filtered
     ORDER BY created_at DESC
     LIMIT $3 OFFSET $4
 ),
@@ -823,7 +918,7 @@ page_count AS (
     SELECT CEIL(total.total_count::numeric / $3::numeric)::bigint AS page_count FROM total
 )
 SELECT
-    p.id, p.exec_id, p.flow_id, p.version, p.input, p.error, p.current_action_id, p.status, p.trigger_type, p.triggered_by, p.namespace_id, p.created_at, p.updated_at, p.name, p.username, p.triggered_by_uuid, p.triggered_by_name, p.flow_name, p.flow_slug,
+    p.id, p.exec_id, p.flow_id, p.version, p.input, p.error, p.current_action_id, p.status, p.trigger_type, p.triggered_by, p.namespace_id, p.created_at, p.updated_at, p.priority, p.name, p.username, p.triggered_by_uuid, p.triggered_by_name, p.flow_name, p.flow_slug,
     pc.page_count,
     t.total_count
 FROM paged p, page_count pc, total t
@@ -850,6 +945,7 @@ type SearchExecutionsPaginatedRow struct {
 	NamespaceID     int32           `db:"namespace_id" json:"namespace_id"`
 	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
+	Priority        int32           `db:"priority" json:"priority"`
 	Name            string          `db:"name" json:"name"`
 	Username        string          `db:"username" json:"username"`
 	TriggeredByUuid uuid.UUID       `db:"triggered_by_uuid" json:"triggered_by_uuid"`
@@ -888,6 +984,7 @@ func (q *Queries) SearchExecutionsPaginated(ctx context.Context, arg SearchExecu
 			&i.NamespaceID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Priority,
 			&i.Name,
 			&i.Username,
 			&i.TriggeredByUuid,
@@ -922,7 +1019,7 @@ UPDATE execution_log SET current_action_id=$1, updated_at=NOW()
 WHERE execution_log.exec_id = $2
   AND version = (SELECT version FROM latest_version)
   AND namespace_id = (SELECT id FROM namespace_lookup)
-RETURNING id, exec_id, flow_id, version, input, error, current_action_id, status, trigger_type, triggered_by, namespace_id, created_at, updated_at
+RETURNING id, exec_id, flow_id, version, input, error, current_action_id, status, trigger_type, triggered_by, namespace_id, created_at, updated_at, priority
 `
 
 type UpdateExecutionActionIDParams struct {
@@ -948,6 +1045,7 @@ func (q *Queries) UpdateExecutionActionID(ctx context.Context, arg UpdateExecuti
 		&i.NamespaceID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Priority,
 	)
 	return i, err
 }
@@ -964,7 +1062,7 @@ UPDATE execution_log SET status=$1, error=$2, updated_at=NOW()
 WHERE execution_log.exec_id = $3
   AND version = (SELECT version FROM latest_version)
   AND namespace_id = (SELECT id FROM namespace_lookup)
-RETURNING id, exec_id, flow_id, version, input, error, current_action_id, status, trigger_type, triggered_by, namespace_id, created_at, updated_at
+RETURNING id, exec_id, flow_id, version, input, error, current_action_id, status, trigger_type, triggered_by, namespace_id, created_at, updated_at, priority
 `
 
 type UpdateExecutionStatusParams struct {
@@ -996,6 +1094,7 @@ func (q *Queries) UpdateExecutionStatus(ctx context.Context, arg UpdateExecution
 		&i.NamespaceID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Priority,
 	)
 	return i, err
 }
@@ -0,0 +1,69 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: instances.sql
+
+package repo
+
+import (
+	"context"
+)
+
+const deleteSchedulerInstance = `-- name: DeleteSchedulerInstance :exec
+DELETE FROM scheduler_instances WHERE id = $1
+`
+
+func (q *Queries) DeleteSchedulerInstance(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteSchedulerInstance, id)
+	return err
+}
+
+const deleteStaleSchedulerInstances = `-- name: DeleteStaleSchedulerInstances :many
+DELETE FROM scheduler_instances
+WHERE last_heartbeat < NOW() - ($1::int * INTERVAL '1 second')
+RETURNING id, hostname, started_at, last_heartbeat
+`
+
+func (q *Queries) DeleteStaleSchedulerInstances(ctx context.Context, staleAfterSeconds int32) ([]SchedulerInstance, error) {
+	rows, err := q.db.QueryContext(ctx, deleteStaleSchedulerInstances, staleAfterSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SchedulerInstance
+	for rows.Next() {
+		var i SchedulerInstance
+		if err := rows.Scan(
+			&i.ID,
+			&i.Hostname,
+			&i.StartedAt,
+			&i.LastHeartbeat,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertSchedulerInstance = `-- name: UpsertSchedulerInstance :exec
+INSERT INTO scheduler_instances (id, hostname)
+VALUES ($1, $2)
+ON CONFLICT (id) DO UPDATE SET last_heartbeat = NOW()
+`
+
+type UpsertSchedulerInstanceParams struct {
+	ID       string `db:"id" json:"id"`
+	Hostname string `db:"hostname" json:"hostname"`
+}
+
+func (q *Queries) UpsertSchedulerInstance(ctx context.Context, arg UpsertSchedulerInstanceParams) error {
+	_, err := q.db.ExecContext(ctx, upsertSchedulerInstance, arg.ID, arg.Hostname)
+	return err
+}
@@ -0,0 +1,359 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: inventory_sources.sql
+
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const claimDueInventorySources = `-- name: ClaimDueInventorySources :many
+UPDATE inventory_sources s
+SET next_sync_at = NOW() + make_interval(secs => s.sync_interval)
+FROM namespaces ns
+WHERE s.namespace_id = ns.id AND s.id IN (
+    SELECT id FROM inventory_sources
+    WHERE next_sync_at <= NOW()
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING s.uuid, ns.uuid AS namespace_uuid
+`
+
+type ClaimDueInventorySourcesRow struct {
+	Uuid          uuid.UUID `db:"uuid" json:"uuid"`
+	NamespaceUuid uuid.UUID `db:"namespace_uuid" json:"namespace_uuid"`
+}
+
+// Due sources are claimed by moving their next sync forward so that only one instance syncs them
+func (q *Queries) ClaimDueInventorySources(ctx context.Context) ([]ClaimDueInventorySourcesRow, error) {
+	rows, err := q.db.QueryContext(ctx, claimDueInventorySources)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimDueInventorySourcesRow
+	for rows.Next() {
+		var i ClaimDueInventorySourcesRow
+		if err := rows.Scan(&i.Uuid, &i.NamespaceUuid); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createInventorySource = `-- name: CreateInventorySource :one
+INSERT INTO inventory_sources (name, source_type, path, format, sync_interval, port, username, connection_type, credential_id, namespace_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, (SELECT id FROM namespaces WHERE namespaces.uuid = $10))
+RETURNING id, uuid, name, source_type, path, format, sync_interval, port, username, connection_type, credential_id, next_sync_at, last_synced_at, last_error, last_report, namespace_id, created_at, updated_at
+`
+
+type CreateInventorySourceParams struct {
+	Name           string              `db:"name" json:"name"`
+	SourceType     InventorySourceType `db:"source_type" json:"source_type"`
+	Path           string              `db:"path" json:"path"`
+	Format         string              `db:"format" json:"format"`
+	SyncInterval   int32               `db:"sync_interval" json:"sync_interval"`
+	Port           int32               `db:"port" json:"port"`
+	Username       string              `db:"username" json:"username"`
+	ConnectionType ConnectionType      `db:"connection_type" json:"connection_type"`
+	CredentialID   sql.NullInt32       `db:"credential_id" json:"credential_id"`
+	Uuid           uuid.UUID           `db:"uuid" json:"uuid"`
+}
+
+func (q *Queries) CreateInventorySource(ctx context.Context, arg CreateInventorySourceParams) (InventorySource, error) {
+	row := q.db.QueryRowContext(ctx, createInventorySource,
+		arg.Name,
+		arg.SourceType,
+		arg.Path,
+		arg.Format,
+		arg.SyncInterval,
+		arg.Port,
+		arg.Username,
+		arg.ConnectionType,
+		arg.CredentialID,
+		arg.Uuid,
+	)
+	var i InventorySource
+	err := row.Scan(
+		&i.ID,
+		&i.Uuid,
+		&i.Name,
+		&i.SourceType,
+		&i.Path,
+		&i.Format,
+		&i.SyncInterval,
+		&i.Port,
+		&i.Username,
+		&i.ConnectionType,
+		&i.CredentialID,
+		&i.NextSyncAt,
+		&i.LastSyncedAt,
+		&i.LastError,
+		&i.LastReport,
+		&i.NamespaceID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteInventorySource = `-- name: DeleteInventorySource :exec
+DELETE FROM inventory_sources WHERE inventory_sources.uuid = $1 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $2)
+`
+
+type DeleteInventorySourceParams struct {
+	Uuid   uuid.UUID `db:"uuid" json:"uuid"`
+	Uuid_2 uuid.UUID `db:"uuid_2" json:"uuid_2"`
+}
+
+func (q *Queries) DeleteInventorySource(ctx context.Context, arg DeleteInventorySourceParams) error {
+	_, err := q.db.ExecContext(ctx, deleteInventorySource, arg.Uuid, arg.Uuid_2)
+	return err
+}
+
+const getInventorySourceByUUID = `-- name: GetInventorySourceByUUID :one
+SELECT s.id, s.uuid, s.name, s.source_type, s.path, s.format, s.sync_interval, s.port, s.username, s.connection_type, s.credential_id, s.next_sync_at, s.last_synced_at, s.last_error, s.last_report, s.namespace_id, s.created_at, s.updated_at, ns.uuid AS namespace_uuid, c.uuid AS credential_uuid FROM inventory_sources s
+JOIN namespaces ns ON s.namespace_id = ns.id
+LEFT JOIN credentials c ON s.credential_id = c.id
+WHERE s.uuid = $1 AND ns.uuid = $2
+`
+
+type GetInventorySourceByUUIDParams struct {
+	Uuid   uuid.UUID `db:"uuid" json:"uuid"`
+	Uuid_2 uuid.UUID `db:"uuid_2" json:"uuid_2"`
+}
+
+type GetInventorySourceByUUIDRow struct {
+	ID             int32               `db:"id" json:"id"`
+	Uuid           uuid.UUID           `db:"uuid" json:"uuid"`
+	Name           string              `db:"name" json:"name"`
+	SourceType     InventorySourceType `db:"source_type" json:"source_type"`
+	Path           string              `db:"path" json:"path"`
+	Format         string              `db:"format" json:"format"`
+	SyncInterval   int32               `db:"sync_interval" json:"sync_interval"`
+	Port           int32               `db:"port" json:"port"`
+	Username       string              `db:"username" json:"username"`
+	ConnectionType ConnectionType      `db:"connection_type" json:"connection_type"`
+	CredentialID   sql.NullInt32       `db:"credential_id" json:"credential_id"`
+	NextSyncAt     time.Time           `db:"next_sync_at" json:"next_sync_at"`
+	LastSyncedAt   sql.NullTime        `db:"last_synced_at" json:"last_synced_at"`
+	LastError      string              `db:"last_error" json:"last_error"`
+	LastReport     json.RawMessage     `db:"last_report" json:"last_report"`
+	NamespaceID    int32               `db:"namespace_id" json:"namespace_id"`
+	CreatedAt      time.Time           `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time           `db:"updated_at" json:"updated_at"`
+	NamespaceUuid  uuid.UUID           `db:"namespace_uuid" json:"namespace_uuid"`
+	CredentialUuid uuid.NullUUID       `db:"credential_uuid" json:"credential_uuid"`
+}
+
+func (q *Queries) GetInventorySourceByUUID(ctx context.Context, arg GetInventorySourceByUUIDParams) (GetInventorySourceByUUIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getInventorySourceByUUID, arg.Uuid, arg.Uuid_2)
+	var i GetInventorySourceByUUIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.Uuid,
+		&i.Name,
+		&i.SourceType,
+		&i.Path,
+		&i.Format,
+		&i.SyncInterval,
+		&i.Port,
+		&i.Username,
+		&i.ConnectionType,
+		&i.CredentialID,
+		&i.NextSyncAt,
+		&i.LastSyncedAt,
+		&i.LastError,
+		&i.LastReport,
+		&i.NamespaceID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.NamespaceUuid,
+		&i.CredentialUuid,
+	)
+	return i, err
+}
+
+const listInventorySources = `-- name: ListInventorySources :many
+SELECT s.id, s.uuid, s.name, s.source_type, s.path, s.format, s.sync_interval, s.port, s.username, s.connection_type, s.credential_id, s.next_sync_at, s.last_synced_at, s.last_error, s.last_report, s.namespace_id, s.created_at, s.updated_at, ns.uuid AS namespace_uuid, c.uuid AS credential_uuid FROM inventory_sources s
+JOIN namespaces ns ON s.namespace_id = ns.id
+LEFT JOIN credentials c ON s.credential_id = c.id
+WHERE ns.uuid = $1
+ORDER BY s.name
+`
+
+type ListInventorySourcesRow struct {
+	ID             int32               `db:"id" json:"id"`
+	Uuid           uuid.UUID           `db:"uuid" json:"uuid"`
+	Name           string              `db:"name" json:"name"`
+	SourceType     InventorySourceType `db:"source_type" json:"source_type"`
+	Path           string              `db:"path" json:"path"`
+	Format         string              `db:"format" json:"format"`
+	SyncInterval   int32               `db:"sync_interval" json:"sync_interval"`
+	Port           int32               `db:"port" json:"port"`
+	Username       string              `db:"username" json:"username"`
+	ConnectionType ConnectionType      `db:"connection_type" json:"connection_type"`
+	CredentialID   sql.NullInt32       `db:"credential_id" json:"credential_id"`
+	NextSyncAt     time.Time           `db:"next_sync_at" json:"next_sync_at"`
+	LastSyncedAt   sql.NullTime        `db:"last_synced_at" json:"last_synced_at"`
+	LastError      string              `db:"last_error" json:"last_error"`
+	LastReport     json.RawMessage     `db:"last_report" json:"last_report"`
+	NamespaceID    int32               `db:"namespace_id" json:"namespace_id"`
+	CreatedAt      time.Time           `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time           `db:"updated_at" json:"updated_at"`
+	NamespaceUuid  uuid.UUID           `db:"namespace_uuid" json:"namespace_uuid"`
+	CredentialUuid uuid.NullUUID       `db:"credential_uuid" json:"credential_uuid"`
+}
+
+func (q *Queries) ListInventorySources(ctx context.Context, argUuid uuid.UUID) ([]ListInventorySourcesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listInventorySources, argUuid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListInventorySourcesRow
+	for rows.Next() {
+		var i ListInventorySourcesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uuid,
+			&i.Name,
+			&i.SourceType,
+			&i.Path,
+			&i.Format,
+			&i.SyncInterval,
+			&i.Port,
+			&i.Username,
+			&i.ConnectionType,
+			&i.CredentialID,
+			&i.NextSyncAt,
+			&i.LastSyncedAt,
+			&i.LastError,
+			&i.LastReport,
+			&i.NamespaceID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.NamespaceUuid,
+			&i.CredentialUuid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setInventorySourceResult = `-- name: SetInventorySourceResult :exec
+UPDATE inventory_sources
+SET last_synced_at = NOW(), last_error = $2, last_report = $3
+WHERE id = $1
+`
+
+type SetInventorySourceResultParams struct {
+	ID         int32           `db:"id" json:"id"`
+	LastError  string          `db:"last_error" json:"last_error"`
+	LastReport json.RawMessage `db:"last_report" json:"last_report"`
+}
+
+func (q *Queries) SetInventorySourceResult(ctx context.Context, arg SetInventorySourceResultParams) error {
+	_, err := q.db.ExecContext(ctx, setInventorySourceResult, arg.ID, arg.LastError, arg.LastReport)
+	return err
+}
+
+const setNodeInventorySource = `-- name: SetNodeInventorySource :exec
+UPDATE nodes
+SET inventory_source_id = $2, updated_at = NOW()
+WHERE nodes.uuid = $1
+`
+
+type SetNodeInventorySourceParams struct {
+	Uuid              uuid.UUID     `db:"uuid" json:"uuid"`
+	InventorySourceID sql.NullInt32 `db:"inventory_source_id" json:"inventory_source_id"`
+}
+
+func (q *Queries) SetNodeInventorySource(ctx context.Context, arg SetNodeInventorySourceParams) error {
+	_, err := q.db.ExecContext(ctx, setNodeInventorySource, arg.Uuid, arg.InventorySourceID)
+	return err
+}
+
+const updateInventorySource = `-- name: UpdateInventorySource :one
+UPDATE inventory_sources
+SET name = $2, source_type = $3, path = $4, format = $5, sync_interval = $6, port = $7, username = $8, connection_type = $9, credential_id = $10,
+    -- A changed interval applies from the last sync
+    next_sync_at = COALESCE(last_synced_at, NOW()) + make_interval(secs => $6),
+    updated_at = NOW()
+WHERE inventory_sources.uuid = $1 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $11)
+RETURNING id, uuid, name, source_type, path, format, sync_interval, port, username, connection_type, credential_id, next_sync_at, last_synced_at, last_error, last_report, namespace_id, created_at, updated_at
+`
+
+type UpdateInventorySourceParams struct {
+	Uuid           uuid.UUID           `db:"uuid" json:"uuid"`
+	Name           string              `db:"name" json:"name"`
+	SourceType     InventorySourceType `db:"source_type" json:"source_type"`
+	Path           string              `db:"path" json:"path"`
+	Format         string              `db:"format" json:"format"`
+	SyncInterval   int32               `db:"sync_interval" json:"sync_interval"`
+	Port           int32               `db:"port" json:"port"`
+	Username       string              `db:"username" json:"username"`
+	ConnectionType ConnectionType      `db:"connection_type" json:"connection_type"`
+	CredentialID   sql.NullInt32       `db:"credential_id" json:"credential_id"`
+	Uuid_2         uuid.UUID           `db:"uuid_2" json:"uuid_2"`
+}
+
+func (q *Queries) UpdateInventorySource(ctx context.Context, arg UpdateInventorySourceParams) (InventorySource, error) {
+	row := q.db.QueryRowContext(ctx, updateInventorySource,
+		arg.Uuid,
+		arg.Name,
+		arg.SourceType,
+		arg.Path,
+		arg.Format,
+		arg.SyncInterval,
+		arg.Port,
+		arg.Username,
+		arg.ConnectionType,
+		arg.CredentialID,
+		arg.Uuid_2,
+	)
+	var i InventorySource
+	err := row.Scan(
+		&i.ID,
+		&i.Uuid,
+		&i.Name,
+		&i.SourceType,
+		&i.Path,
+		&i.Format,
+		&i.SyncInterval,
+		&i.Port,
+		&i.Username,
+		&i.ConnectionType,
+		&i.CredentialID,
+		&i.NextSyncAt,
+		&i.LastSyncedAt,
+		&i.LastError,
+		&i.LastReport,
+		&i.NamespaceID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
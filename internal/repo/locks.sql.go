@@ -0,0 +1,197 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: locks.sql
+
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const acquireResourceLock = `-- name: AcquireResourceLock :execrows
+UPDATE resource_locks rl SET status = 'held', acquired_at = NOW()
+WHERE rl.id = $1 AND rl.status = 'waiting'
+  AND NOT EXISTS (
+    SELECT 1 FROM resource_locks o
+    WHERE o.name = rl.name
+      AND o.namespace_id = rl.namespace_id
+      AND (o.status = 'held' OR (o.status = 'waiting' AND o.id < rl.id))
+  )
+`
+
+// Promotes a waiter to holder only if nobody holds the lock and no older waiter exists
+func (q *Queries) AcquireResourceLock(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, acquireResourceLock, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const addResourceLockWaiter = `-- name: AddResourceLockWaiter :one
+INSERT INTO resource_locks (name, exec_id, action_id, flow_id, status, namespace_id)
+VALUES ($1, $2, $3, $4, 'waiting', (SELECT id FROM namespaces WHERE namespaces.uuid = $5))
+RETURNING id, name, exec_id, action_id, flow_id, status, namespace_id, created_at, acquired_at
+`
+
+type AddResourceLockWaiterParams struct {
+	Name     string         `db:"name" json:"name"`
+	ExecID   string         `db:"exec_id" json:"exec_id"`
+	ActionID sql.NullString `db:"action_id" json:"action_id"`
+	FlowID   int32          `db:"flow_id" json:"flow_id"`
+	Uuid     uuid.UUID      `db:"uuid" json:"uuid"`
+}
+
+func (q *Queries) AddResourceLockWaiter(ctx context.Context, arg AddResourceLockWaiterParams) (ResourceLock, error) {
+	row := q.db.QueryRowContext(ctx, addResourceLockWaiter,
+		arg.Name,
+		arg.ExecID,
+		arg.ActionID,
+		arg.FlowID,
+		arg.Uuid,
+	)
+	var i ResourceLock
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExecID,
+		&i.ActionID,
+		&i.FlowID,
+		&i.Status,
+		&i.NamespaceID,
+		&i.CreatedAt,
+		&i.AcquiredAt,
+	)
+	return i, err
+}
+
+const deleteResourceLock = `-- name: DeleteResourceLock :exec
+DELETE FROM resource_locks WHERE id = $1
+`
+
+func (q *Queries) DeleteResourceLock(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteResourceLock, id)
+	return err
+}
+
+const getResourceLockHolder = `-- name: GetResourceLockHolder :one
+SELECT rl.id, rl.name, rl.exec_id, rl.action_id, rl.flow_id, rl.status, rl.namespace_id, rl.created_at, rl.acquired_at FROM resource_locks rl
+JOIN namespaces ns ON rl.namespace_id = ns.id
+WHERE rl.name = $1 AND ns.uuid = $2 AND rl.status = 'held'
+`
+
+type GetResourceLockHolderParams struct {
+	Name string    `db:"name" json:"name"`
+	Uuid uuid.UUID `db:"uuid" json:"uuid"`
+}
+
+func (q *Queries) GetResourceLockHolder(ctx context.Context, arg GetResourceLockHolderParams) (ResourceLock, error) {
+	row := q.db.QueryRowContext(ctx, getResourceLockHolder, arg.Name, arg.Uuid)
+	var i ResourceLock
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExecID,
+		&i.ActionID,
+		&i.FlowID,
+		&i.Status,
+		&i.NamespaceID,
+		&i.CreatedAt,
+		&i.AcquiredAt,
+	)
+	return i, err
+}
+
+const listResourceLocks = `-- name: ListResourceLocks :many
+SELECT
+    rl.id, rl.name, rl.exec_id, rl.action_id, rl.flow_id, rl.status, rl.namespace_id, rl.created_at, rl.acquired_at,
+    f.slug AS flow_slug,
+    f.name AS flow_name
+FROM resource_locks rl
+JOIN namespaces ns ON rl.namespace_id = ns.id
+JOIN flows f ON rl.flow_id = f.id
+WHERE ns.uuid = $1
+ORDER BY rl.name, rl.status, rl.id
+`
+
+type ListResourceLocksRow struct {
+	ID          int32              `db:"id" json:"id"`
+	Name        string             `db:"name" json:"name"`
+	ExecID      string             `db:"exec_id" json:"exec_id"`
+	ActionID    sql.NullString     `db:"action_id" json:"action_id"`
+	FlowID      int32              `db:"flow_id" json:"flow_id"`
+	Status      ResourceLockStatus `db:"status" json:"status"`
+	NamespaceID int32              `db:"namespace_id" json:"namespace_id"`
+	CreatedAt   time.Time          `db:"created_at" json:"created_at"`
+	AcquiredAt  sql.NullTime       `db:"acquired_at" json:"acquired_at"`
+	FlowSlug    string             `db:"flow_slug" json:"flow_slug"`
+	FlowName    string             `db:"flow_name" json:"flow_name"`
+}
+
+func (q *Queries) ListResourceLocks(ctx context.Context, argUuid uuid.UUID) ([]ListResourceLocksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listResourceLocks, argUuid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListResourceLocksRow
+	for rows.Next() {
+		var i ListResourceLocksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ExecID,
+			&i.ActionID,
+			&i.FlowID,
+			&i.Status,
+			&i.NamespaceID,
+			&i.CreatedAt,
+			&i.AcquiredAt,
+			&i.FlowSlug,
+			&i.FlowName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const releaseAllResourceLocks = `-- name: ReleaseAllResourceLocks :exec
+DELETE FROM resource_locks WHERE exec_id = $1
+`
+
+func (q *Queries) ReleaseAllResourceLocks(ctx context.Context, execID string) error {
+	_, err := q.db.ExecContext(ctx, releaseAllResourceLocks, execID)
+	return err
+}
+
+const releaseResourceLocks = `-- name: ReleaseResourceLocks :exec
+DELETE FROM resource_locks
+WHERE exec_id = $1
+  AND name = ANY($2::text[])
+  AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $3)
+`
+
+type ReleaseResourceLocksParams struct {
+	ExecID  string    `db:"exec_id" json:"exec_id"`
+	Column2 []string  `db:"column_2" json:"column_2"`
+	Uuid    uuid.UUID `db:"uuid" json:"uuid"`
+}
+
+func (q *Queries) ReleaseResourceLocks(ctx context.Context, arg ReleaseResourceLocksParams) error {
+	_, err := q.db.ExecContext(ctx, releaseResourceLocks, arg.ExecID, pq.Array(arg.Column2), arg.Uuid)
+	return err
+}
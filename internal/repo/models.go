@@ -62,6 +62,7 @@ type AuthenticationMethod string
 const (
 	AuthenticationMethodPrivateKey AuthenticationMethod = "private_key"
 	AuthenticationMethodPassword   AuthenticationMethod = "password"
+	AuthenticationMethodSshCa      AuthenticationMethod = "ssh_ca"
 )
 
 func (e *AuthenticationMethod) Scan(src interface{}) error {
@@ -102,8 +103,9 @@ func (ns NullAuthenticationMethod) Value() (driver.Value, error) {
 type ConnectionType string
 
 const (
-	ConnectionTypeSsh  ConnectionType = "ssh"
-	ConnectionTypeQssh ConnectionType = "qssh"
+	ConnectionTypeSsh    ConnectionType = "ssh"
+	ConnectionTypeQssh   ConnectionType = "qssh"
+	ConnectionTypeDocker ConnectionType = "docker"
 )
 
 func (e *ConnectionType) Scan(src interface{}) error {
@@ -187,6 +189,90 @@ func (ns NullExecutionStatus) Value() (driver.Value, error) {
 	return string(ns.ExecutionStatus), nil
 }
 
+type InventorySourceType string
+
+const (
+	InventorySourceTypeFile   InventorySourceType = "file"
+	InventorySourceTypeScript InventorySourceType = "script"
+)
+
+func (e *InventorySourceType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = InventorySourceType(s)
+	case string:
+		*e = InventorySourceType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for InventorySourceType: %T", src)
+	}
+	return nil
+}
+
+type NullInventorySourceType struct {
+	InventorySourceType InventorySourceType `json:"inventory_source_type"`
+	Valid               bool                `json:"valid"` // Valid is true if InventorySourceType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullInventorySourceType) Scan(value interface{}) error {
+	if value == nil {
+		ns.InventorySourceType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.InventorySourceType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullInventorySourceType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.InventorySourceType), nil
+}
+
+type ResourceLockStatus string
+
+const (
+	ResourceLockStatusHeld    ResourceLockStatus = "held"
+	ResourceLockStatusWaiting ResourceLockStatus = "waiting"
+)
+
+func (e *ResourceLockStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ResourceLockStatus(s)
+	case string:
+		*e = ResourceLockStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ResourceLockStatus: %T", src)
+	}
+	return nil
+}
+
+type NullResourceLockStatus struct {
+	ResourceLockStatus ResourceLockStatus `json:"resource_lock_status"`
+	Valid              bool               `json:"valid"` // Valid is true if ResourceLockStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullResourceLockStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.ResourceLockStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ResourceLockStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullResourceLockStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ResourceLockStatus), nil
+}
+
 type TriggerType string
 
 const (
@@ -349,6 +435,20 @@ type Credential struct {
 	UpdatedAt    time.Time    `db:"updated_at" json:"updated_at"`
 }
 
+type ExecutionArtifact struct {
+	ID          int32     `db:"id" json:"id"`
+	Uuid        uuid.UUID `db:"uuid" json:"uuid"`
+	ExecID      string    `db:"exec_id" json:"exec_id"`
+	ActionID    string    `db:"action_id" json:"action_id"`
+	NodeName    string    `db:"node_name" json:"node_name"`
+	Path        string    `db:"path" json:"path"`
+	SizeBytes   int64     `db:"size_bytes" json:"size_bytes"`
+	Checksum    string    `db:"checksum" json:"checksum"`
+	StorageKey  string    `db:"storage_key" json:"storage_key"`
+	NamespaceID int32     `db:"namespace_id" json:"namespace_id"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
 type ExecutionLog struct {
 	ID              int32           `db:"id" json:"id"`
 	ExecID          string          `db:"exec_id" json:"exec_id"`
@@ -363,6 +463,21 @@ type ExecutionLog struct {
 	NamespaceID     int32           `db:"namespace_id" json:"namespace_id"`
 	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
+	Priority        int32           `db:"priority" json:"priority"`
+}
+
+type ExecutionSshCertificate struct {
+	ID          int32     `db:"id" json:"id"`
+	ExecID      string    `db:"exec_id" json:"exec_id"`
+	ActionID    string    `db:"action_id" json:"action_id"`
+	NodeName    string    `db:"node_name" json:"node_name"`
+	Serial      int64     `db:"serial" json:"serial"`
+	KeyID       string    `db:"key_id" json:"key_id"`
+	Principals  []string  `db:"principals" json:"principals"`
+	ValidAfter  time.Time `db:"valid_after" json:"valid_after"`
+	ValidBefore time.Time `db:"valid_before" json:"valid_before"`
+	NamespaceID int32     `db:"namespace_id" json:"namespace_id"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
 }
 
 type Flow struct {
@@ -417,12 +532,34 @@ type GroupView struct {
 	Users       interface{}    `db:"users" json:"users"`
 }
 
+type InventorySource struct {
+	ID             int32               `db:"id" json:"id"`
+	Uuid           uuid.UUID           `db:"uuid" json:"uuid"`
+	Name           string              `db:"name" json:"name"`
+	SourceType     InventorySourceType `db:"source_type" json:"source_type"`
+	Path           string              `db:"path" json:"path"`
+	Format         string              `db:"format" json:"format"`
+	SyncInterval   int32               `db:"sync_interval" json:"sync_interval"`
+	Port           int32               `db:"port" json:"port"`
+	Username       string              `db:"username" json:"username"`
+	ConnectionType ConnectionType      `db:"connection_type" json:"connection_type"`
+	CredentialID   sql.NullInt32       `db:"credential_id" json:"credential_id"`
+	NextSyncAt     time.Time           `db:"next_sync_at" json:"next_sync_at"`
+	LastSyncedAt   sql.NullTime        `db:"last_synced_at" json:"last_synced_at"`
+	LastError      string              `db:"last_error" json:"last_error"`
+	LastReport     json.RawMessage     `db:"last_report" json:"last_report"`
+	NamespaceID    int32               `db:"namespace_id" json:"namespace_id"`
+	CreatedAt      time.Time           `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time           `db:"updated_at" json:"updated_at"`
+}
+
 type Namespace struct {
-	ID        int32     `db:"id" json:"id"`
-	Uuid      uuid.UUID `db:"uuid" json:"uuid"`
-	Name      string    `db:"name" json:"name"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	ID                    int32         `db:"id" json:"id"`
+	Uuid                  uuid.UUID     `db:"uuid" json:"uuid"`
+	Name                  string        `db:"name" json:"name"`
+	CreatedAt             time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt             time.Time     `db:"updated_at" json:"updated_at"`
+	ArtifactRetentionDays sql.NullInt32 `db:"artifact_retention_days" json:"artifact_retention_days"`
 }
 
 type NamespaceMember struct {
@@ -437,20 +574,63 @@ type NamespaceMember struct {
 }
 
 type Node struct {
-	ID             int32                `db:"id" json:"id"`
-	Uuid           uuid.UUID            `db:"uuid" json:"uuid"`
-	Name           string               `db:"name" json:"name"`
-	Hostname       string               `db:"hostname" json:"hostname"`
-	Port           int32                `db:"port" json:"port"`
-	Username       string               `db:"username" json:"username"`
-	OsFamily       string               `db:"os_family" json:"os_family"`
-	Tags           []string             `db:"tags" json:"tags"`
-	AuthMethod     AuthenticationMethod `db:"auth_method" json:"auth_method"`
-	ConnectionType ConnectionType       `db:"connection_type" json:"connection_type"`
-	CredentialID   sql.NullInt32        `db:"credential_id" json:"credential_id"`
-	NamespaceID    int32                `db:"namespace_id" json:"namespace_id"`
-	CreatedAt      time.Time            `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time            `db:"updated_at" json:"updated_at"`
+	ID                 int32                `db:"id" json:"id"`
+	Uuid               uuid.UUID            `db:"uuid" json:"uuid"`
+	Name               string               `db:"name" json:"name"`
+	Hostname           string               `db:"hostname" json:"hostname"`
+	Port               int32                `db:"port" json:"port"`
+	Username           string               `db:"username" json:"username"`
+	OsFamily           string               `db:"os_family" json:"os_family"`
+	Tags               []string             `db:"tags" json:"tags"`
+	AuthMethod         AuthenticationMethod `db:"auth_method" json:"auth_method"`
+	ConnectionType     ConnectionType       `db:"connection_type" json:"connection_type"`
+	CredentialID       sql.NullInt32        `db:"credential_id" json:"credential_id"`
+	NamespaceID        int32                `db:"namespace_id" json:"namespace_id"`
+	CreatedAt          time.Time            `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time            `db:"updated_at" json:"updated_at"`
+	MaxConcurrency     int32                `db:"max_concurrency" json:"max_concurrency"`
+	Become             bool                 `db:"become" json:"become"`
+	BecomeUser         string               `db:"become_user" json:"become_user"`
+	BecomeMethod       string               `db:"become_method" json:"become_method"`
+	BecomeCredentialID sql.NullInt32        `db:"become_credential_id" json:"become_credential_id"`
+	HostKey            string               `db:"host_key" json:"host_key"`
+	PendingHostKey     string               `db:"pending_host_key" json:"pending_host_key"`
+	JumpHostID         sql.NullInt32        `db:"jump_host_id" json:"jump_host_id"`
+	InventorySourceID  sql.NullInt32        `db:"inventory_source_id" json:"inventory_source_id"`
+}
+
+type NodeFact struct {
+	NodeID        int32        `db:"node_id" json:"node_id"`
+	Reachable     sql.NullBool `db:"reachable" json:"reachable"`
+	Error         string       `db:"error" json:"error"`
+	LatencyMs     int32        `db:"latency_ms" json:"latency_ms"`
+	Os            string       `db:"os" json:"os"`
+	Kernel        string       `db:"kernel" json:"kernel"`
+	Arch          string       `db:"arch" json:"arch"`
+	UptimeSeconds int64        `db:"uptime_seconds" json:"uptime_seconds"`
+	DiskFreeBytes int64        `db:"disk_free_bytes" json:"disk_free_bytes"`
+	CheckedAt     sql.NullTime `db:"checked_at" json:"checked_at"`
+	GatheredAt    sql.NullTime `db:"gathered_at" json:"gathered_at"`
+	NextCheckAt   time.Time    `db:"next_check_at" json:"next_check_at"`
+}
+
+type ResourceLock struct {
+	ID          int32              `db:"id" json:"id"`
+	Name        string             `db:"name" json:"name"`
+	ExecID      string             `db:"exec_id" json:"exec_id"`
+	ActionID    sql.NullString     `db:"action_id" json:"action_id"`
+	FlowID      int32              `db:"flow_id" json:"flow_id"`
+	Status      ResourceLockStatus `db:"status" json:"status"`
+	NamespaceID int32              `db:"namespace_id" json:"namespace_id"`
+	CreatedAt   time.Time          `db:"created_at" json:"created_at"`
+	AcquiredAt  sql.NullTime       `db:"acquired_at" json:"acquired_at"`
+}
+
+type SchedulerInstance struct {
+	ID            string    `db:"id" json:"id"`
+	Hostname      string    `db:"hostname" json:"hostname"`
+	StartedAt     time.Time `db:"started_at" json:"started_at"`
+	LastHeartbeat time.Time `db:"last_heartbeat" json:"last_heartbeat"`
 }
 
 type SchedulerTask struct {
@@ -7,6 +7,7 @@ package repo
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -81,13 +82,18 @@ func (q *Queries) AssignUserNamespaceRole(ctx context.Context, arg AssignUserNam
 }
 
 const createNamespace = `-- name: CreateNamespace :one
-INSERT INTO namespaces (name)
-VALUES ($1)
-RETURNING id, uuid, name, created_at, updated_at
+INSERT INTO namespaces (name, artifact_retention_days)
+VALUES ($1, $2)
+RETURNING id, uuid, name, created_at, updated_at, artifact_retention_days
 `
 
-func (q *Queries) CreateNamespace(ctx context.Context, name string) (Namespace, error) {
-	row := q.db.QueryRowContext(ctx, createNamespace, name)
+type CreateNamespaceParams struct {
+	Name                  string        `db:"name" json:"name"`
+	ArtifactRetentionDays sql.NullInt32 `db:"artifact_retention_days" json:"artifact_retention_days"`
+}
+
+func (q *Queries) CreateNamespace(ctx context.Context, arg CreateNamespaceParams) (Namespace, error) {
+	row := q.db.QueryRowContext(ctx, createNamespace, arg.Name, arg.ArtifactRetentionDays)
 	var i Namespace
 	err := row.Scan(
 		&i.ID,
@@ -95,6 +101,7 @@ func (q *Queries) CreateNamespace(ctx context.Context, name string) (Namespace,
 		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ArtifactRetentionDays,
 	)
 	return i, err
 }
@@ -172,7 +179,7 @@ func (q *Queries) GetAllNamespaceMembers(ctx context.Context) ([]GetAllNamespace
 }
 
 const getAllNamespaces = `-- name: GetAllNamespaces :many
-SELECT id, uuid, name, created_at, updated_at FROM namespaces ORDER BY name
+SELECT id, uuid, name, created_at, updated_at, artifact_retention_days FROM namespaces ORDER BY name
 `
 
 func (q *Queries) GetAllNamespaces(ctx context.Context) ([]Namespace, error) {
@@ -190,6 +197,7 @@ func (q *Queries) GetAllNamespaces(ctx context.Context) ([]Namespace, error) {
 			&i.Name,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ArtifactRetentionDays,
 		); err != nil {
 			return nil, err
 		}
@@ -205,7 +213,7 @@ func (q *Queries) GetAllNamespaces(ctx context.Context) ([]Namespace, error) {
 }
 
 const getNamespaceByName = `-- name: GetNamespaceByName :one
-SELECT id, uuid, name, created_at, updated_at FROM namespaces WHERE name = $1
+SELECT id, uuid, name, created_at, updated_at, artifact_retention_days FROM namespaces WHERE name = $1
 `
 
 func (q *Queries) GetNamespaceByName(ctx context.Context, name string) (Namespace, error) {
@@ -217,12 +225,13 @@ func (q *Queries) GetNamespaceByName(ctx context.Context, name string) (Namespac
 		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ArtifactRetentionDays,
 	)
 	return i, err
 }
 
 const getNamespaceByUUID = `-- name: GetNamespaceByUUID :one
-SELECT id, uuid, name, created_at, updated_at FROM namespaces WHERE uuid = $1
+SELECT id, uuid, name, created_at, updated_at, artifact_retention_days FROM namespaces WHERE uuid = $1
 `
 
 func (q *Queries) GetNamespaceByUUID(ctx context.Context, argUuid uuid.UUID) (Namespace, error) {
@@ -234,6 +243,7 @@ func (q *Queries) GetNamespaceByUUID(ctx context.Context, argUuid uuid.UUID) (Na
 		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ArtifactRetentionDays,
 	)
 	return i, err
 }
@@ -385,7 +395,7 @@ func (q *Queries) GetUserNamespacesWithRoles(ctx context.Context, argUuid uuid.U
 
 const listNamespaces = `-- name: ListNamespaces :many
 WITH filtered AS (
-    SELECT DISTINCT n.id, n.uuid, n.name, n.created_at, n.updated_at FROM namespaces n
+    SELECT DISTINCT n.id, n.uuid, n.name, n.created_at, n.updated_at, n.artifact_retention_days FROM namespaces n
     LEFT JOIN namespace_members nm ON n.id = nm.namespace_id
     LEFT JOIN users u ON nm.user_id = u.id
     LEFT JOIN groups g ON nm.group_id = g.id
@@ -400,14 +410,14 @@ total AS (
     SELECT COUNT(*) AS total_count FROM filtered
 ),
 paged AS (
-    SELECT id, uuid, name, created_at, updated_at FROM filtered
+    SELECT id, uuid, name, created_at, updated_at, artifact_retention_days FROM filtered
     LIMIT $2 OFFSET $3
 ),
 page_count AS (
     SELECT CEIL(total.total_count::numeric / $2::numeric)::bigint AS page_count FROM total
 )
 SELECT
-    p.id, p.uuid, p.name, p.created_at, p.updated_at,
+    p.id, p.uuid, p.name, p.created_at, p.updated_at, p.artifact_retention_days,
     pc.page_count,
     t.total_count
 FROM paged p, page_count pc, total t
@@ -421,13 +431,14 @@ type ListNamespacesParams struct {
 }
 
 type ListNamespacesRow struct {
-	ID         int32     `db:"id" json:"id"`
-	Uuid       uuid.UUID `db:"uuid" json:"uuid"`
-	Name       string    `db:"name" json:"name"`
-	CreatedAt  time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
-	PageCount  int64     `db:"page_count" json:"page_count"`
-	TotalCount int64     `db:"total_count" json:"total_count"`
+	ID                    int32         `db:"id" json:"id"`
+	Uuid                  uuid.UUID     `db:"uuid" json:"uuid"`
+	Name                  string        `db:"name" json:"name"`
+	CreatedAt             time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt             time.Time     `db:"updated_at" json:"updated_at"`
+	ArtifactRetentionDays sql.NullInt32 `db:"artifact_retention_days" json:"artifact_retention_days"`
+	PageCount             int64         `db:"page_count" json:"page_count"`
+	TotalCount            int64         `db:"total_count" json:"total_count"`
 }
 
 func (q *Queries) ListNamespaces(ctx context.Context, arg ListNamespacesParams) ([]ListNamespacesRow, error) {
@@ -450,6 +461,7 @@ func (q *Queries) ListNamespaces(ctx context.Context, arg ListNamespacesParams)
 			&i.Name,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ArtifactRetentionDays,
 			&i.PageCount,
 			&i.TotalCount,
 		); err != nil {
@@ -496,18 +508,19 @@ func (q *Queries) RemoveNamespaceMember(ctx context.Context, arg RemoveNamespace
 
 const updateNamespace = `-- name: UpdateNamespace :one
 UPDATE namespaces
-SET name = $2, updated_at = NOW()
+SET name = $2, artifact_retention_days = $3, updated_at = NOW()
 WHERE uuid = $1
-RETURNING id, uuid, name, created_at, updated_at
+RETURNING id, uuid, name, created_at, updated_at, artifact_retention_days
 `
 
 type UpdateNamespaceParams struct {
-	Uuid uuid.UUID `db:"uuid" json:"uuid"`
-	Name string    `db:"name" json:"name"`
+	Uuid                  uuid.UUID     `db:"uuid" json:"uuid"`
+	Name                  string        `db:"name" json:"name"`
+	ArtifactRetentionDays sql.NullInt32 `db:"artifact_retention_days" json:"artifact_retention_days"`
 }
 
 func (q *Queries) UpdateNamespace(ctx context.Context, arg UpdateNamespaceParams) (Namespace, error) {
-	row := q.db.QueryRowContext(ctx, updateNamespace, arg.Uuid, arg.Name)
+	row := q.db.QueryRowContext(ctx, updateNamespace, arg.Uuid, arg.Name, arg.ArtifactRetentionDays)
 	var i Namespace
 	err := row.Scan(
 		&i.ID,
@@ -515,6 +528,7 @@ func (q *Queries) UpdateNamespace(ctx context.Context, arg UpdateNamespaceParams
 		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ArtifactRetentionDays,
 	)
 	return i, err
 }
@@ -0,0 +1,193 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: node_facts.sql
+
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const claimNodesForHealthCheck = `-- name: ClaimNodesForHealthCheck :many
+WITH due AS (
+    SELECT n.id FROM nodes n
+    LEFT JOIN node_facts f ON f.node_id = n.id
+    WHERE f.node_id IS NULL OR f.next_check_at <= NOW()
+),
+claimed AS (
+    INSERT INTO node_facts (node_id, next_check_at)
+    SELECT id, NOW() + ($1::int * INTERVAL '1 second') FROM due
+    ON CONFLICT (node_id) DO UPDATE SET next_check_at = EXCLUDED.next_check_at
+    WHERE node_facts.next_check_at <= NOW()
+    RETURNING node_id
+)
+SELECT n.name, ns.uuid AS namespace_uuid FROM claimed c
+JOIN nodes n ON c.node_id = n.id
+JOIN namespaces ns ON n.namespace_id = ns.id
+ORDER BY ns.uuid, n.name
+`
+
+type ClaimNodesForHealthCheckRow struct {
+	Name          string    `db:"name" json:"name"`
+	NamespaceUuid uuid.UUID `db:"namespace_uuid" json:"namespace_uuid"`
+}
+
+// Due nodes are claimed by moving their next check forward so that only one instance checks them.
+// Nodes which have not been checked before are claimed by creating their facts
+func (q *Queries) ClaimNodesForHealthCheck(ctx context.Context, intervalSeconds int32) ([]ClaimNodesForHealthCheckRow, error) {
+	rows, err := q.db.QueryContext(ctx, claimNodesForHealthCheck, intervalSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimNodesForHealthCheckRow
+	for rows.Next() {
+		var i ClaimNodesForHealthCheckRow
+		if err := rows.Scan(&i.Name, &i.NamespaceUuid); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNodeFacts = `-- name: GetNodeFacts :one
+SELECT node_id, reachable, error, latency_ms, os, kernel, arch, uptime_seconds, disk_free_bytes, checked_at, gathered_at, next_check_at FROM node_facts WHERE node_id = $1
+`
+
+func (q *Queries) GetNodeFacts(ctx context.Context, nodeID int32) (NodeFact, error) {
+	row := q.db.QueryRowContext(ctx, getNodeFacts, nodeID)
+	var i NodeFact
+	err := row.Scan(
+		&i.NodeID,
+		&i.Reachable,
+		&i.Error,
+		&i.LatencyMs,
+		&i.Os,
+		&i.Kernel,
+		&i.Arch,
+		&i.UptimeSeconds,
+		&i.DiskFreeBytes,
+		&i.CheckedAt,
+		&i.GatheredAt,
+		&i.NextCheckAt,
+	)
+	return i, err
+}
+
+const getNodeFactsByUUIDs = `-- name: GetNodeFactsByUUIDs :many
+SELECT f.node_id, f.reachable, f.error, f.latency_ms, f.os, f.kernel, f.arch, f.uptime_seconds, f.disk_free_bytes, f.checked_at, f.gathered_at, f.next_check_at, n.uuid AS node_uuid FROM node_facts f
+JOIN nodes n ON f.node_id = n.id
+WHERE n.uuid = ANY($1::uuid[])
+`
+
+type GetNodeFactsByUUIDsRow struct {
+	NodeID        int32        `db:"node_id" json:"node_id"`
+	Reachable     sql.NullBool `db:"reachable" json:"reachable"`
+	Error         string       `db:"error" json:"error"`
+	LatencyMs     int32        `db:"latency_ms" json:"latency_ms"`
+	Os            string       `db:"os" json:"os"`
+	Kernel        string       `db:"kernel" json:"kernel"`
+	Arch          string       `db:"arch" json:"arch"`
+	UptimeSeconds int64        `db:"uptime_seconds" json:"uptime_seconds"`
+	DiskFreeBytes int64        `db:"disk_free_bytes" json:"disk_free_bytes"`
+	CheckedAt     sql.NullTime `db:"checked_at" json:"checked_at"`
+	GatheredAt    sql.NullTime `db:"gathered_at" json:"gathered_at"`
+	NextCheckAt   time.Time    `db:"next_check_at" json:"next_check_at"`
+	NodeUuid      uuid.UUID    `db:"node_uuid" json:"node_uuid"`
+}
+
+func (q *Queries) GetNodeFactsByUUIDs(ctx context.Context, dollar_1 []uuid.UUID) ([]GetNodeFactsByUUIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getNodeFactsByUUIDs, pq.Array(dollar_1))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNodeFactsByUUIDsRow
+	for rows.Next() {
+		var i GetNodeFactsByUUIDsRow
+		if err := rows.Scan(
+			&i.NodeID,
+			&i.Reachable,
+			&i.Error,
+			&i.LatencyMs,
+			&i.Os,
+			&i.Kernel,
+			&i.Arch,
+			&i.UptimeSeconds,
+			&i.DiskFreeBytes,
+			&i.CheckedAt,
+			&i.GatheredAt,
+			&i.NextCheckAt,
+			&i.NodeUuid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setNodeFacts = `-- name: SetNodeFacts :exec
+UPDATE node_facts
+SET reachable = TRUE, error = '', latency_ms = $2, os = $3, kernel = $4, arch = $5, uptime_seconds = $6, disk_free_bytes = $7,
+    checked_at = NOW(), gathered_at = NOW()
+WHERE node_id = (SELECT id FROM nodes WHERE nodes.uuid = $1)
+`
+
+type SetNodeFactsParams struct {
+	Uuid          uuid.UUID `db:"uuid" json:"uuid"`
+	LatencyMs     int32     `db:"latency_ms" json:"latency_ms"`
+	Os            string    `db:"os" json:"os"`
+	Kernel        string    `db:"kernel" json:"kernel"`
+	Arch          string    `db:"arch" json:"arch"`
+	UptimeSeconds int64     `db:"uptime_seconds" json:"uptime_seconds"`
+	DiskFreeBytes int64     `db:"disk_free_bytes" json:"disk_free_bytes"`
+}
+
+func (q *Queries) SetNodeFacts(ctx context.Context, arg SetNodeFactsParams) error {
+	_, err := q.db.ExecContext(ctx, setNodeFacts,
+		arg.Uuid,
+		arg.LatencyMs,
+		arg.Os,
+		arg.Kernel,
+		arg.Arch,
+		arg.UptimeSeconds,
+		arg.DiskFreeBytes,
+	)
+	return err
+}
+
+const setNodeUnreachable = `-- name: SetNodeUnreachable :exec
+UPDATE node_facts
+SET reachable = FALSE, error = $2, latency_ms = 0, checked_at = NOW()
+WHERE node_id = (SELECT id FROM nodes WHERE nodes.uuid = $1)
+`
+
+type SetNodeUnreachableParams struct {
+	Uuid  uuid.UUID `db:"uuid" json:"uuid"`
+	Error string    `db:"error" json:"error"`
+}
+
+func (q *Queries) SetNodeUnreachable(ctx context.Context, arg SetNodeUnreachableParams) error {
+	_, err := q.db.ExecContext(ctx, setNodeUnreachable, arg.Uuid, arg.Error)
+	return err
+}
@@ -14,23 +14,73 @@ import (
 	"github.com/lib/pq"
 )
 
+const approveNodeHostKey = `-- name: ApproveNodeHostKey :one
+UPDATE nodes
+SET host_key = pending_host_key, pending_host_key = '', updated_at = NOW()
+WHERE nodes.uuid = $1 AND pending_host_key = $2 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $3)
+RETURNING id, uuid, name, hostname, port, username, os_family, tags, auth_method, connection_type, credential_id, namespace_id, created_at, updated_at, max_concurrency, become, become_user, become_method, become_credential_id, host_key, pending_host_key, jump_host_id, inventory_source_id
+`
+
+type ApproveNodeHostKeyParams struct {
+	Uuid           uuid.UUID `db:"uuid" json:"uuid"`
+	PendingHostKey string    `db:"pending_host_key" json:"pending_host_key"`
+	Uuid_2         uuid.UUID `db:"uuid_2" json:"uuid_2"`
+}
+
+func (q *Queries) ApproveNodeHostKey(ctx context.Context, arg ApproveNodeHostKeyParams) (Node, error) {
+	row := q.db.QueryRowContext(ctx, approveNodeHostKey, arg.Uuid, arg.PendingHostKey, arg.Uuid_2)
+	var i Node
+	err := row.Scan(
+		&i.ID,
+		&i.Uuid,
+		&i.Name,
+		&i.Hostname,
+		&i.Port,
+		&i.Username,
+		&i.OsFamily,
+		pq.Array(&i.Tags),
+		&i.AuthMethod,
+		&i.ConnectionType,
+		&i.CredentialID,
+		&i.NamespaceID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MaxConcurrency,
+		&i.Become,
+		&i.BecomeUser,
+		&i.BecomeMethod,
+		&i.BecomeCredentialID,
+		&i.HostKey,
+		&i.PendingHostKey,
+		&i.JumpHostID,
+		&i.InventorySourceID,
+	)
+	return i, err
+}
+
 const createNode = `-- name: CreateNode :one
-INSERT INTO nodes (name, hostname, port, username, os_family, tags, auth_method, connection_type, credential_id, namespace_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, (SELECT id FROM namespaces WHERE namespaces.uuid = $10))
-RETURNING id, uuid, name, hostname, port, username, os_family, tags, auth_method, connection_type, credential_id, namespace_id, created_at, updated_at
+INSERT INTO nodes (name, hostname, port, username, os_family, tags, auth_method, connection_type, credential_id, max_concurrency, become, become_user, become_method, become_credential_id, jump_host_id, namespace_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, (SELECT id FROM namespaces WHERE namespaces.uuid = $16))
+RETURNING id, uuid, name, hostname, port, username, os_family, tags, auth_method, connection_type, credential_id, namespace_id, created_at, updated_at, max_concurrency, become, become_user, become_method, become_credential_id, host_key, pending_host_key, jump_host_id, inventory_source_id
 `
 
 type CreateNodeParams struct {
-	Name           string               `db:"name" json:"name"`
-	Hostname       string               `db:"hostname" json:"hostname"`
-	Port           int32                `db:"port" json:"port"`
-	Username       string               `db:"username" json:"username"`
-	OsFamily       string               `db:"os_family" json:"os_family"`
-	Tags           []string             `db:"tags" json:"tags"`
-	AuthMethod     AuthenticationMethod `db:"auth_method" json:"auth_method"`
-	ConnectionType ConnectionType       `db:"connection_type" json:"connection_type"`
-	CredentialID   sql.NullInt32        `db:"credential_id" json:"credential_id"`
-	Uuid           uuid.UUID            `db:"uuid" json:"uuid"`
+	Name               string               `db:"name" json:"name"`
+	Hostname           string               `db:"hostname" json:"hostname"`
+	Port               int32                `db:"port" json:"port"`
+	Username           string               `db:"username" json:"username"`
+	OsFamily           string               `db:"os_family" json:"os_family"`
+	Tags               []string             `db:"tags" json:"tags"`
+	AuthMethod         AuthenticationMethod `db:"auth_method" json:"auth_method"`
+	ConnectionType     ConnectionType       `db:"connection_type" json:"connection_type"`
+	CredentialID       sql.NullInt32        `db:"credential_id" json:"credential_id"`
+	MaxConcurrency     int32                `db:"max_concurrency" json:"max_concurrency"`
+	Become             bool                 `db:"become" json:"become"`
+	BecomeUser         string               `db:"become_user" json:"become_user"`
+	BecomeMethod       string               `db:"become_method" json:"become_method"`
+	BecomeCredentialID sql.NullInt32        `db:"become_credential_id" json:"become_credential_id"`
+	JumpHostID         sql.NullInt32        `db:"jump_host_id" json:"jump_host_id"`
+	Uuid               uuid.UUID            `db:"uuid" json:"uuid"`
 }
 
 func (q *Queries) CreateNode(ctx context.Context, arg CreateNodeParams) (Node, error) {
@@ -44,6 +94,12 @@ func (q *Queries) CreateNode(ctx context.Context, arg CreateNodeParams) (Node, e
 		arg.AuthMethod,
 		arg.ConnectionType,
 		arg.CredentialID,
+		arg.MaxConcurrency,
+		arg.Become,
+		arg.BecomeUser,
+		arg.BecomeMethod,
+		arg.BecomeCredentialID,
+		arg.JumpHostID,
 		arg.Uuid,
 	)
 	var i Node
@@ -62,6 +118,15 @@ func (q *Queries) CreateNode(ctx context.Context, arg CreateNodeParams) (Node, e
 		&i.NamespaceID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MaxConcurrency,
+		&i.Become,
+		&i.BecomeUser,
+		&i.BecomeMethod,
+		&i.BecomeCredentialID,
+		&i.HostKey,
+		&i.PendingHostKey,
+		&i.JumpHostID,
+		&i.InventorySourceID,
 	)
 	return i, err
 }
@@ -80,8 +145,79 @@ func (q *Queries) DeleteNode(ctx context.Context, arg DeleteNodeParams) error {
 	return err
 }
 
+const getJumpHostByID = `-- name: GetJumpHostByID :one
+SELECT n.id, n.uuid, n.name, n.hostname, n.port, n.username, n.os_family, n.tags, n.auth_method, n.connection_type, n.credential_id, n.namespace_id, n.created_at, n.updated_at, n.max_concurrency, n.become, n.become_user, n.become_method, n.become_credential_id, n.host_key, n.pending_host_key, n.jump_host_id, n.inventory_source_id, c.key_data AS credential_key_data FROM nodes n
+JOIN namespaces ns ON n.namespace_id = ns.id
+LEFT JOIN credentials c ON n.credential_id = c.id
+WHERE n.id = $1 AND ns.uuid = $2
+`
+
+type GetJumpHostByIDParams struct {
+	ID   int32     `db:"id" json:"id"`
+	Uuid uuid.UUID `db:"uuid" json:"uuid"`
+}
+
+type GetJumpHostByIDRow struct {
+	ID                 int32                `db:"id" json:"id"`
+	Uuid               uuid.UUID            `db:"uuid" json:"uuid"`
+	Name               string               `db:"name" json:"name"`
+	Hostname           string               `db:"hostname" json:"hostname"`
+	Port               int32                `db:"port" json:"port"`
+	Username           string               `db:"username" json:"username"`
+	OsFamily           string               `db:"os_family" json:"os_family"`
+	Tags               []string             `db:"tags" json:"tags"`
+	AuthMethod         AuthenticationMethod `db:"auth_method" json:"auth_method"`
+	ConnectionType     ConnectionType       `db:"connection_type" json:"connection_type"`
+	CredentialID       sql.NullInt32        `db:"credential_id" json:"credential_id"`
+	NamespaceID        int32                `db:"namespace_id" json:"namespace_id"`
+	CreatedAt          time.Time            `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time            `db:"updated_at" json:"updated_at"`
+	MaxConcurrency     int32                `db:"max_concurrency" json:"max_concurrency"`
+	Become             bool                 `db:"become" json:"become"`
+	BecomeUser         string               `db:"become_user" json:"become_user"`
+	BecomeMethod       string               `db:"become_method" json:"become_method"`
+	BecomeCredentialID sql.NullInt32        `db:"become_credential_id" json:"become_credential_id"`
+	HostKey            string               `db:"host_key" json:"host_key"`
+	PendingHostKey     string               `db:"pending_host_key" json:"pending_host_key"`
+	JumpHostID         sql.NullInt32        `db:"jump_host_id" json:"jump_host_id"`
+	InventorySourceID  sql.NullInt32        `db:"inventory_source_id" json:"inventory_source_id"`
+	CredentialKeyData  sql.NullString       `db:"credential_key_data" json:"credential_key_data"`
+}
+
+func (q *Queries) GetJumpHostByID(ctx context.Context, arg GetJumpHostByIDParams) (GetJumpHostByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getJumpHostByID, arg.ID, arg.Uuid)
+	var i GetJumpHostByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.Uuid,
+		&i.Name,
+		&i.Hostname,
+		&i.Port,
+		&i.Username,
+		&i.OsFamily,
+		pq.Array(&i.Tags),
+		&i.AuthMethod,
+		&i.ConnectionType,
+		&i.CredentialID,
+		&i.NamespaceID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MaxConcurrency,
+		&i.Become,
+		&i.BecomeUser,
+		&i.BecomeMethod,
+		&i.BecomeCredentialID,
+		&i.HostKey,
+		&i.PendingHostKey,
+		&i.JumpHostID,
+		&i.InventorySourceID,
+		&i.CredentialKeyData,
+	)
+	return i, err
+}
+
 const getNodeByName = `-- name: GetNodeByName :one
-SELECT n.id, n.uuid, n.name, n.hostname, n.port, n.username, n.os_family, n.tags, n.auth_method, n.connection_type, n.credential_id, n.namespace_id, n.created_at, n.updated_at, ns.uuid AS namespace_uuid FROM nodes n
+SELECT n.id, n.uuid, n.name, n.hostname, n.port, n.username, n.os_family, n.tags, n.auth_method, n.connection_type, n.credential_id, n.namespace_id, n.created_at, n.updated_at, n.max_concurrency, n.become, n.become_user, n.become_method, n.become_credential_id, n.host_key, n.pending_host_key, n.jump_host_id, n.inventory_source_id, ns.uuid AS namespace_uuid FROM nodes n
 JOIN namespaces ns ON n.namespace_id = ns.id
 WHERE n.name = $1 AND ns.uuid = $2
 `
@@ -92,21 +228,30 @@ type GetNodeByNameParams struct {
 }
 
 type GetNodeByNameRow struct {
-	ID             int32                `db:"id" json:"id"`
-	Uuid           uuid.UUID            `db:"uuid" json:"uuid"`
-	Name           string               `db:"name" json:"name"`
-	Hostname       string               `db:"hostname" json:"hostname"`
-	Port           int32                `db:"port" json:"port"`
-	Username       string               `db:"username" json:"username"`
-	OsFamily       string               `db:"os_family" json:"os_family"`
-	Tags           []string             `db:"tags" json:"tags"`
-	AuthMethod     AuthenticationMethod `db:"auth_method" json:"auth_method"`
-	ConnectionType ConnectionType       `db:"connection_type" json:"connection_type"`
-	CredentialID   sql.NullInt32        `db:"credential_id" json:"credential_id"`
-	NamespaceID    int32                `db:"namespace_id" json:"namespace_id"`
-	CreatedAt      time.Time            `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time            `db:"updated_at" json:"updated_at"`
-	NamespaceUuid  uuid.UUID            `db:"namespace_uuid" json:"namespace_uuid"`
+	ID                 int32                `db:"id" json:"id"`
+	Uuid               uuid.UUID            `db:"uuid" json:"uuid"`
+	Name               string               `db:"name" json:"name"`
+	Hostname           string               `db:"hostname" json:"hostname"`
+	Port               int32                `db:"port" json:"port"`
+	Username           string               `db:"username" json:"username"`
+	OsFamily           string               `db:"os_family" json:"os_family"`
+	Tags               []string             `db:"tags" json:"tags"`
+	AuthMethod         AuthenticationMethod `db:"auth_method" json:"auth_method"`
+	ConnectionType     ConnectionType       `db:"connection_type" json:"connection_type"`
+	CredentialID       sql.NullInt32        `db:"credential_id" json:"credential_id"`
+	NamespaceID        int32                `db:"namespace_id" json:"namespace_id"`
+	CreatedAt          time.Time            `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time            `db:"updated_at" json:"updated_at"`
+	MaxConcurrency     int32                `db:"max_concurrency" json:"max_concurrency"`
+	Become             bool                 `db:"become" json:"become"`
+	BecomeUser         string               `db:"become_user" json:"become_user"`
+	BecomeMethod       string               `db:"become_method" json:"become_method"`
+	BecomeCredentialID sql.NullInt32        `db:"become_credential_id" json:"become_credential_id"`
+	HostKey            string               `db:"host_key" json:"host_key"`
+	PendingHostKey     string               `db:"pending_host_key" json:"pending_host_key"`
+	JumpHostID         sql.NullInt32        `db:"jump_host_id" json:"jump_host_id"`
+	InventorySourceID  sql.NullInt32        `db:"inventory_source_id" json:"inventory_source_id"`
+	NamespaceUuid      uuid.UUID            `db:"namespace_uuid" json:"namespace_uuid"`
 }
 
 func (q *Queries) GetNodeByName(ctx context.Context, arg GetNodeByNameParams) (GetNodeByNameRow, error) {
@@ -127,14 +272,24 @@ func (q *Queries) GetNodeByName(ctx context.Context, arg GetNodeByNameParams) (G
 		&i.NamespaceID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MaxConcurrency,
+		&i.Become,
+		&i.BecomeUser,
+		&i.BecomeMethod,
+		&i.BecomeCredentialID,
+		&i.HostKey,
+		&i.PendingHostKey,
+		&i.JumpHostID,
+		&i.InventorySourceID,
 		&i.NamespaceUuid,
 	)
 	return i, err
 }
 
 const getNodeByUUID = `-- name: GetNodeByUUID :one
-SELECT n.id, n.uuid, n.name, n.hostname, n.port, n.username, n.os_family, n.tags, n.auth_method, n.connection_type, n.credential_id, n.namespace_id, n.created_at, n.updated_at, ns.uuid AS namespace_uuid FROM nodes n
+SELECT n.id, n.uuid, n.name, n.hostname, n.port, n.username, n.os_family, n.tags, n.auth_method, n.connection_type, n.credential_id, n.namespace_id, n.created_at, n.updated_at, n.max_concurrency, n.become, n.become_user, n.become_method, n.become_credential_id, n.host_key, n.pending_host_key, n.jump_host_id, n.inventory_source_id, ns.uuid AS namespace_uuid, jh.uuid AS jump_host_uuid FROM nodes n
 JOIN namespaces ns ON n.namespace_id = ns.id
+LEFT JOIN nodes jh ON n.jump_host_id = jh.id
 WHERE n.uuid = $1 AND ns.uuid = $2
 `
 
@@ -144,21 +299,31 @@ type GetNodeByUUIDParams struct {
 }
 
 type GetNodeByUUIDRow struct {
-	ID             int32                `db:"id" json:"id"`
-	Uuid           uuid.UUID            `db:"uuid" json:"uuid"`
-	Name           string               `db:"name" json:"name"`
-	Hostname       string               `db:"hostname" json:"hostname"`
-	Port           int32                `db:"port" json:"port"`
-	Username       string               `db:"username" json:"username"`
-	OsFamily       string               `db:"os_family" json:"os_family"`
-	Tags           []string             `db:"tags" json:"tags"`
-	AuthMethod     AuthenticationMethod `db:"auth_method" json:"auth_method"`
-	ConnectionType ConnectionType       `db:"connection_type" json:"connection_type"`
-	CredentialID   sql.NullInt32        `db:"credential_id" json:"credential_id"`
-	NamespaceID    int32                `db:"namespace_id" json:"namespace_id"`
-	CreatedAt      time.Time            `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time            `db:"updated_at" json:"updated_at"`
-	NamespaceUuid  uuid.UUID            `db:"namespace_uuid" json:"namespace_uuid"`
+	ID                 int32                `db:"id" json:"id"`
+	Uuid               uuid.UUID            `db:"uuid" json:"uuid"`
+	Name               string               `db:"name" json:"name"`
+	Hostname           string               `db:"hostname" json:"hostname"`
+	Port               int32                `db:"port" json:"port"`
+	Username           string               `db:"username" json:"username"`
+	OsFamily           string               `db:"os_family" json:"os_family"`
+	Tags               []string             `db:"tags" json:"tags"`
+	AuthMethod         AuthenticationMethod `db:"auth_method" json:"auth_method"`
+	ConnectionType     ConnectionType       `db:"connection_type" json:"connection_type"`
+	CredentialID       sql.NullInt32        `db:"credential_id" json:"credential_id"`
+	NamespaceID        int32                `db:"namespace_id" json:"namespace_id"`
+	CreatedAt          time.Time            `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time            `db:"updated_at" json:"updated_at"`
+	MaxConcurrency     int32                `db:"max_concurrency" json:"max_concurrency"`
+	Become             bool                 `db:"become" json:"become"`
+	BecomeUser         string               `db:"become_user" json:"become_user"`
+	BecomeMethod       string               `db:"become_method" json:"become_method"`
+	BecomeCredentialID sql.NullInt32        `db:"become_credential_id" json:"become_credential_id"`
+	HostKey            string               `db:"host_key" json:"host_key"`
+	PendingHostKey     string               `db:"pending_host_key" json:"pending_host_key"`
+	JumpHostID         sql.NullInt32        `db:"jump_host_id" json:"jump_host_id"`
+	InventorySourceID  sql.NullInt32        `db:"inventory_source_id" json:"inventory_source_id"`
+	NamespaceUuid      uuid.UUID            `db:"namespace_uuid" json:"namespace_uuid"`
+	JumpHostUuid       uuid.NullUUID        `db:"jump_host_uuid" json:"jump_host_uuid"`
 }
 
 func (q *Queries) GetNodeByUUID(ctx context.Context, arg GetNodeByUUIDParams) (GetNodeByUUIDRow, error) {
@@ -179,7 +344,17 @@ func (q *Queries) GetNodeByUUID(ctx context.Context, arg GetNodeByUUIDParams) (G
 		&i.NamespaceID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MaxConcurrency,
+		&i.Become,
+		&i.BecomeUser,
+		&i.BecomeMethod,
+		&i.BecomeCredentialID,
+		&i.HostKey,
+		&i.PendingHostKey,
+		&i.JumpHostID,
+		&i.InventorySourceID,
 		&i.NamespaceUuid,
+		&i.JumpHostUuid,
 	)
 	return i, err
 }
@@ -188,22 +363,33 @@ const getNodeStats = `-- name: GetNodeStats :one
 SELECT
     COUNT(*) AS total_hosts,
     COUNT(*) FILTER (WHERE connection_type = 'ssh') AS ssh_hosts,
-    COUNT(*) FILTER (WHERE connection_type = 'qssh') AS qssh_hosts
+    COUNT(*) FILTER (WHERE connection_type = 'qssh') AS qssh_hosts,
+    COUNT(*) FILTER (WHERE connection_type = 'docker') AS docker_hosts,
+    COUNT(*) FILTER (WHERE f.reachable = FALSE) AS unreachable_hosts
 FROM nodes n
 JOIN namespaces ns ON n.namespace_id = ns.id
+LEFT JOIN node_facts f ON f.node_id = n.id
 WHERE ns.uuid = $1
 `
 
 type GetNodeStatsRow struct {
-	TotalHosts int64 `db:"total_hosts" json:"total_hosts"`
-	SshHosts   int64 `db:"ssh_hosts" json:"ssh_hosts"`
-	QsshHosts  int64 `db:"qssh_hosts" json:"qssh_hosts"`
+	TotalHosts       int64 `db:"total_hosts" json:"total_hosts"`
+	SshHosts         int64 `db:"ssh_hosts" json:"ssh_hosts"`
+	QsshHosts        int64 `db:"qssh_hosts" json:"qssh_hosts"`
+	DockerHosts      int64 `db:"docker_hosts" json:"docker_hosts"`
+	UnreachableHosts int64 `db:"unreachable_hosts" json:"unreachable_hosts"`
 }
 
 func (q *Queries) GetNodeStats(ctx context.Context, argUuid uuid.UUID) (GetNodeStatsRow, error) {
 	row := q.db.QueryRowContext(ctx, getNodeStats, argUuid)
 	var i GetNodeStatsRow
-	err := row.Scan(&i.TotalHosts, &i.SshHosts, &i.QsshHosts)
+	err := row.Scan(
+		&i.TotalHosts,
+		&i.SshHosts,
+		&i.QsshHosts,
+		&i.DockerHosts,
+		&i.UnreachableHosts,
+	)
 	return i, err
 }
 
@@ -220,15 +406,19 @@ WITH updated_credentials AS (
     RETURNING id, uuid, name, key_type, key_data, namespace_id, last_accessed, created_at, updated_at
 )
 SELECT
-    n.id, n.uuid, n.name, n.hostname, n.port, n.username, n.os_family, n.tags, n.auth_method, n.connection_type, n.credential_id, n.namespace_id, n.created_at, n.updated_at,
+    n.id, n.uuid, n.name, n.hostname, n.port, n.username, n.os_family, n.tags, n.auth_method, n.connection_type, n.credential_id, n.namespace_id, n.created_at, n.updated_at, n.max_concurrency, n.become, n.become_user, n.become_method, n.become_credential_id, n.host_key, n.pending_host_key, n.jump_host_id, n.inventory_source_id,
     ns.uuid AS namespace_uuid,
     c.uuid AS credential_uuid,
     c.name AS credential_name,
     c.key_type AS credential_key_type,
-    c.key_data AS credential_key_data
+    c.key_data AS credential_key_data,
+    bc.uuid AS become_credential_uuid,
+    bc.key_type AS become_credential_key_type,
+    bc.key_data AS become_credential_key_data
 FROM nodes n
 JOIN namespaces ns ON n.namespace_id = ns.id
 LEFT JOIN credentials c ON n.credential_id = c.id
+LEFT JOIN credentials bc ON n.become_credential_id = bc.id
 WHERE n.name = ANY($1::text[]) AND ns.uuid = $2
 ORDER BY n.name
 `
@@ -239,25 +429,37 @@ type GetNodesByNamesParams struct {
 }
 
 type GetNodesByNamesRow struct {
-	ID                int32                `db:"id" json:"id"`
-	Uuid              uuid.UUID            `db:"uuid" json:"uuid"`
-	Name              string               `db:"name" json:"name"`
-	Hostname          string               `db:"hostname" json:"hostname"`
-	Port              int32                `db:"port" json:"port"`
-	Username          string               `db:"username" json:"username"`
-	OsFamily          string               `db:"os_family" json:"os_family"`
-	Tags              []string             `db:"tags" json:"tags"`
-	AuthMethod        AuthenticationMethod `db:"auth_method" json:"auth_method"`
-	ConnectionType    ConnectionType       `db:"connection_type" json:"connection_type"`
-	CredentialID      sql.NullInt32        `db:"credential_id" json:"credential_id"`
-	NamespaceID       int32                `db:"namespace_id" json:"namespace_id"`
-	CreatedAt         time.Time            `db:"created_at" json:"created_at"`
-	UpdatedAt         time.Time            `db:"updated_at" json:"updated_at"`
-	NamespaceUuid     uuid.UUID            `db:"namespace_uuid" json:"namespace_uuid"`
-	CredentialUuid    uuid.NullUUID        `db:"credential_uuid" json:"credential_uuid"`
-	CredentialName    sql.NullString       `db:"credential_name" json:"credential_name"`
-	CredentialKeyType sql.NullString       `db:"credential_key_type" json:"credential_key_type"`
-	CredentialKeyData sql.NullString       `db:"credential_key_data" json:"credential_key_data"`
+	ID                      int32                `db:"id" json:"id"`
+	Uuid                    uuid.UUID            `db:"uuid" json:"uuid"`
+	Name                    string               `db:"name" json:"name"`
+	Hostname                string               `db:"hostname" json:"hostname"`
+	Port                    int32                `db:"port" json:"port"`
+	Username                string               `db:"username" json:"username"`
+	OsFamily                string               `db:"os_family" json:"os_family"`
+	Tags                    []string             `db:"tags" json:"tags"`
+	AuthMethod              AuthenticationMethod `db:"auth_method" json:"auth_method"`
+	ConnectionType          ConnectionType       `db:"connection_type" json:"connection_type"`
+	CredentialID            sql.NullInt32        `db:"credential_id" json:"credential_id"`
+	NamespaceID             int32                `db:"namespace_id" json:"namespace_id"`
+	CreatedAt               time.Time            `db:"created_at" json:"created_at"`
+	UpdatedAt               time.Time            `db:"updated_at" json:"updated_at"`
+	MaxConcurrency          int32                `db:"max_concurrency" json:"max_concurrency"`
+	Become                  bool                 `db:"become" json:"become"`
+	BecomeUser              string               `db:"become_user" json:"become_user"`
+	BecomeMethod            string               `db:"become_method" json:"become_method"`
+	BecomeCredentialID      sql.NullInt32        `db:"become_credential_id" json:"become_credential_id"`
+	HostKey                 string               `db:"host_key" json:"host_key"`
+	PendingHostKey          string               `db:"pending_host_key" json:"pending_host_key"`
+	JumpHostID              sql.NullInt32        `db:"jump_host_id" json:"jump_host_id"`
+	InventorySourceID       sql.NullInt32        `db:"inventory_source_id" json:"inventory_source_id"`
+	NamespaceUuid           uuid.UUID            `db:"namespace_uuid" json:"namespace_uuid"`
+	CredentialUuid          uuid.NullUUID        `db:"credential_uuid" json:"credential_uuid"`
+	CredentialName          sql.NullString       `db:"credential_name" json:"credential_name"`
+	CredentialKeyType       sql.NullString       `db:"credential_key_type" json:"credential_key_type"`
+	CredentialKeyData       sql.NullString       `db:"credential_key_data" json:"credential_key_data"`
+	BecomeCredentialUuid    uuid.NullUUID        `db:"become_credential_uuid" json:"become_credential_uuid"`
+	BecomeCredentialKeyType sql.NullString       `db:"become_credential_key_type" json:"become_credential_key_type"`
+	BecomeCredentialKeyData sql.NullString       `db:"become_credential_key_data" json:"become_credential_key_data"`
 }
 
 func (q *Queries) GetNodesByNames(ctx context.Context, arg GetNodesByNamesParams) ([]GetNodesByNamesRow, error) {
@@ -284,11 +486,77 @@ func (q *Queries) GetNodesByNames(ctx context.Context, arg GetNodesByNamesParams
 			&i.NamespaceID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.MaxConcurrency,
+			&i.Become,
+			&i.BecomeUser,
+			&i.BecomeMethod,
+			&i.BecomeCredentialID,
+			&i.HostKey,
+			&i.PendingHostKey,
+			&i.JumpHostID,
+			&i.InventorySourceID,
 			&i.NamespaceUuid,
 			&i.CredentialUuid,
 			&i.CredentialName,
 			&i.CredentialKeyType,
 			&i.CredentialKeyData,
+			&i.BecomeCredentialUuid,
+			&i.BecomeCredentialKeyType,
+			&i.BecomeCredentialKeyData,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNodesByNamespace = `-- name: GetNodesByNamespace :many
+SELECT n.id, n.uuid, n.name, n.hostname, n.port, n.username, n.os_family, n.tags, n.auth_method, n.connection_type, n.credential_id, n.namespace_id, n.created_at, n.updated_at, n.max_concurrency, n.become, n.become_user, n.become_method, n.become_credential_id, n.host_key, n.pending_host_key, n.jump_host_id, n.inventory_source_id FROM nodes n
+JOIN namespaces ns ON n.namespace_id = ns.id
+WHERE ns.uuid = $1
+ORDER BY n.name
+`
+
+func (q *Queries) GetNodesByNamespace(ctx context.Context, argUuid uuid.UUID) ([]Node, error) {
+	rows, err := q.db.QueryContext(ctx, getNodesByNamespace, argUuid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Node
+	for rows.Next() {
+		var i Node
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uuid,
+			&i.Name,
+			&i.Hostname,
+			&i.Port,
+			&i.Username,
+			&i.OsFamily,
+			pq.Array(&i.Tags),
+			&i.AuthMethod,
+			&i.ConnectionType,
+			&i.CredentialID,
+			&i.NamespaceID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MaxConcurrency,
+			&i.Become,
+			&i.BecomeUser,
+			&i.BecomeMethod,
+			&i.BecomeCredentialID,
+			&i.HostKey,
+			&i.PendingHostKey,
+			&i.JumpHostID,
+			&i.InventorySourceID,
 		); err != nil {
 			return nil, err
 		}
@@ -305,7 +573,7 @@ func (q *Queries) GetNodesByNames(ctx context.Context, arg GetNodesByNamesParams
 
 const searchNodes = `-- name: SearchNodes :many
 WITH filtered AS (
-    SELECT n.id, n.uuid, n.name, n.hostname, n.port, n.username, n.os_family, n.tags, n.auth_method, n.connection_type, n.credential_id, n.namespace_id, n.created_at, n.updated_at, ns.uuid AS namespace_uuid FROM nodes n
+    SELECT n.id, n.uuid, n.name, n.hostname, n.port, n.username, n.os_family, n.tags, n.auth_method, n.connection_type, n.credential_id, n.namespace_id, n.created_at, n.updated_at, n.max_concurrency, n.become, n.become_user, n.become_method, n.become_credential_id, n.host_key, n.pending_host_key, n.jump_host_id, n.inventory_source_id, ns.uuid AS namespace_uuid FROM nodes n
     JOIN namespaces ns ON n.namespace_id = ns.id
     WHERE ns.uuid = $1 AND (
         $4 = '' OR
@@ -317,14 +585,14 @@ total AS (
     SELECT COUNT(*) AS total_count FROM filtered
 ),
 paged AS (
-    SELECT id, uuid, name, hostname, port, username, os_family, tags, auth_method, connection_type, credential_id, namespace_id, created_at, updated_at, namespace_uuid FROM filtered
+    SELECT id, uuid, name, hostname, port, username, os_family, tags, auth_method, connection_type, credential_id, namespace_id, created_at, updated_at, max_concurrency, become, become_user, become_method, become_credential_id, host_key, pending_host_key, jump_host_id, inventory_source_id, namespace_uuid FROM filtered
     LIMIT $2 OFFSET $3
 ),
 page_count AS (
     SELECT CEIL(total.total_count::numeric / $2::numeric)::bigint AS page_count FROM total
 )
 SELECT
-    p.id, p.uuid, p.name, p.hostname, p.port, p.username, p.os_family, p.tags, p.auth_method, p.connection_type, p.credential_id, p.namespace_id, p.created_at, p.updated_at, p.namespace_uuid,
+    p.id, p.uuid, p.name, p.hostname, p.port, p.username, p.os_family, p.tags, p.auth_method, p.connection_type, p.credential_id, p.namespace_id, p.created_at, p.updated_at, p.max_concurrency, p.become, p.become_user, p.become_method, p.become_credential_id, p.host_key, p.pending_host_key, p.jump_host_id, p.inventory_source_id, p.namespace_uuid,
     pc.page_count,
     t.total_count
 FROM paged p, page_count pc, total t
@@ -338,23 +606,32 @@ type SearchNodesParams struct {
 }
 
 type SearchNodesRow struct {
-	ID             int32                `db:"id" json:"id"`
-	Uuid           uuid.UUID            `db:"uuid" json:"uuid"`
-	Name           string               `db:"name" json:"name"`
-	Hostname       string               `db:"hostname" json:"hostname"`
-	Port           int32                `db:"port" json:"port"`
-	Username       string               `db:"username" json:"username"`
-	OsFamily       string               `db:"os_family" json:"os_family"`
-	Tags           []string             `db:"tags" json:"tags"`
-	AuthMethod     AuthenticationMethod `db:"auth_method" json:"auth_method"`
-	ConnectionType ConnectionType       `db:"connection_type" json:"connection_type"`
-	CredentialID   sql.NullInt32        `db:"credential_id" json:"credential_id"`
-	NamespaceID    int32                `db:"namespace_id" json:"namespace_id"`
-	CreatedAt      time.Time            `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time            `db:"updated_at" json:"updated_at"`
-	NamespaceUuid  uuid.UUID            `db:"namespace_uuid" json:"namespace_uuid"`
-	PageCount      int64                `db:"page_count" json:"page_count"`
-	TotalCount     int64                `db:"total_count" json:"total_count"`
+	ID                 int32                `db:"id" json:"id"`
+	Uuid               uuid.UUID            `db:"uuid" json:"uuid"`
+	Name               string               `db:"name" json:"name"`
+	Hostname           string               `db:"hostname" json:"hostname"`
+	Port               int32                `db:"port" json:"port"`
+	Username           string               `db:"username" json:"username"`
+	OsFamily           string               `db:"os_family" json:"os_family"`
+	Tags               []string             `db:"tags" json:"tags"`
+	AuthMethod         AuthenticationMethod `db:"auth_method" json:"auth_method"`
+	ConnectionType     ConnectionType       `db:"connection_type" json:"connection_type"`
+	CredentialID       sql.NullInt32        `db:"credential_id" json:"credential_id"`
+	NamespaceID        int32                `db:"namespace_id" json:"namespace_id"`
+	CreatedAt          time.Time            `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time            `db:"updated_at" json:"updated_at"`
+	MaxConcurrency     int32                `db:"max_concurrency" json:"max_concurrency"`
+	Become             bool                 `db:"become" json:"become"`
+	BecomeUser         string               `db:"become_user" json:"become_user"`
+	BecomeMethod       string               `db:"become_method" json:"become_method"`
+	BecomeCredentialID sql.NullInt32        `db:"become_credential_id" json:"become_credential_id"`
+	HostKey            string               `db:"host_key" json:"host_key"`
+	PendingHostKey     string               `db:"pending_host_key" json:"pending_host_key"`
+	JumpHostID         sql.NullInt32        `db:"jump_host_id" json:"jump_host_id"`
+	InventorySourceID  sql.NullInt32        `db:"inventory_source_id" json:"inventory_source_id"`
+	NamespaceUuid      uuid.UUID            `db:"namespace_uuid" json:"namespace_uuid"`
+	PageCount          int64                `db:"page_count" json:"page_count"`
+	TotalCount         int64                `db:"total_count" json:"total_count"`
 }
 
 func (q *Queries) SearchNodes(ctx context.Context, arg SearchNodesParams) ([]SearchNodesRow, error) {
@@ -386,6 +663,15 @@ func (q *Queries) SearchNodes(ctx context.Context, arg SearchNodesParams) ([]Sea
 			&i.NamespaceID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.MaxConcurrency,
+			&i.Become,
+			&i.BecomeUser,
+			&i.BecomeMethod,
+			&i.BecomeCredentialID,
+			&i.HostKey,
+			&i.PendingHostKey,
+			&i.JumpHostID,
+			&i.InventorySourceID,
 			&i.NamespaceUuid,
 			&i.PageCount,
 			&i.TotalCount,
@@ -403,25 +689,96 @@ func (q *Queries) SearchNodes(ctx context.Context, arg SearchNodesParams) ([]Sea
 	return items, nil
 }
 
+const setNodeHostKey = `-- name: SetNodeHostKey :one
+UPDATE nodes
+SET host_key = $2, pending_host_key = '', updated_at = NOW()
+WHERE nodes.uuid = $1 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $3)
+RETURNING id, uuid, name, hostname, port, username, os_family, tags, auth_method, connection_type, credential_id, namespace_id, created_at, updated_at, max_concurrency, become, become_user, become_method, become_credential_id, host_key, pending_host_key, jump_host_id, inventory_source_id
+`
+
+type SetNodeHostKeyParams struct {
+	Uuid    uuid.UUID `db:"uuid" json:"uuid"`
+	HostKey string    `db:"host_key" json:"host_key"`
+	Uuid_2  uuid.UUID `db:"uuid_2" json:"uuid_2"`
+}
+
+func (q *Queries) SetNodeHostKey(ctx context.Context, arg SetNodeHostKeyParams) (Node, error) {
+	row := q.db.QueryRowContext(ctx, setNodeHostKey, arg.Uuid, arg.HostKey, arg.Uuid_2)
+	var i Node
+	err := row.Scan(
+		&i.ID,
+		&i.Uuid,
+		&i.Name,
+		&i.Hostname,
+		&i.Port,
+		&i.Username,
+		&i.OsFamily,
+		pq.Array(&i.Tags),
+		&i.AuthMethod,
+		&i.ConnectionType,
+		&i.CredentialID,
+		&i.NamespaceID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MaxConcurrency,
+		&i.Become,
+		&i.BecomeUser,
+		&i.BecomeMethod,
+		&i.BecomeCredentialID,
+		&i.HostKey,
+		&i.PendingHostKey,
+		&i.JumpHostID,
+		&i.InventorySourceID,
+	)
+	return i, err
+}
+
+const setNodePendingHostKey = `-- name: SetNodePendingHostKey :exec
+UPDATE nodes
+SET pending_host_key = $2, updated_at = NOW()
+WHERE nodes.uuid = $1 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $3)
+`
+
+type SetNodePendingHostKeyParams struct {
+	Uuid           uuid.UUID `db:"uuid" json:"uuid"`
+	PendingHostKey string    `db:"pending_host_key" json:"pending_host_key"`
+	Uuid_2         uuid.UUID `db:"uuid_2" json:"uuid_2"`
+}
+
+func (q *Queries) SetNodePendingHostKey(ctx context.Context, arg SetNodePendingHostKeyParams) error {
+	_, err := q.db.ExecContext(ctx, setNodePendingHostKey, arg.Uuid, arg.PendingHostKey, arg.Uuid_2)
+	return err
+}
+
 const updateNode = `-- name: UpdateNode :one
 UPDATE nodes
-SET name = $2, hostname = $3, port = $4, username = $5, os_family = $6, tags = $7, auth_method = $8, connection_type = $9, credential_id = $10, updated_at = NOW()
-WHERE nodes.uuid = $1 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $11)
-RETURNING id, uuid, name, hostname, port, username, os_family, tags, auth_method, connection_type, credential_id, namespace_id, created_at, updated_at
+SET name = $2, hostname = $3, port = $4, username = $5, os_family = $6, tags = $7, auth_method = $8, connection_type = $9, credential_id = $10, max_concurrency = $11, become = $12, become_user = $13, become_method = $14, become_credential_id = $15, jump_host_id = $16,
+    -- The trusted host key belongs to the previous address
+    host_key = CASE WHEN nodes.hostname = $3 AND nodes.port = $4 THEN nodes.host_key ELSE '' END,
+    pending_host_key = CASE WHEN nodes.hostname = $3 AND nodes.port = $4 THEN nodes.pending_host_key ELSE '' END,
+    updated_at = NOW()
+WHERE nodes.uuid = $1 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $17)
+RETURNING id, uuid, name, hostname, port, username, os_family, tags, auth_method, connection_type, credential_id, namespace_id, created_at, updated_at, max_concurrency, become, become_user, become_method, become_credential_id, host_key, pending_host_key, jump_host_id, inventory_source_id
 `
 
 type UpdateNodeParams struct {
-	Uuid           uuid.UUID            `db:"uuid" json:"uuid"`
-	Name           string               `db:"name" json:"name"`
-	Hostname       string               `db:"hostname" json:"hostname"`
-	Port           int32                `db:"port" json:"port"`
-	Username       string               `db:"username" json:"username"`
-	OsFamily       string               `db:"os_family" json:"os_family"`
-	Tags           []string             `db:"tags" json:"tags"`
-	AuthMethod     AuthenticationMethod `db:"auth_method" json:"auth_method"`
-	ConnectionType ConnectionType       `db:"connection_type" json:"connection_type"`
-	CredentialID   sql.NullInt32        `db:"credential_id" json:"credential_id"`
-	Uuid_2         uuid.UUID            `db:"uuid_2" json:"uuid_2"`
+	Uuid               uuid.UUID            `db:"uuid" json:"uuid"`
+	Name               string               `db:"name" json:"name"`
+	Hostname           string               `db:"hostname" json:"hostname"`
+	Port               int32                `db:"port" json:"port"`
+	Username           string               `db:"username" json:"username"`
+	OsFamily           string               `db:"os_family" json:"os_family"`
+	Tags               []string             `db:"tags" json:"tags"`
+	AuthMethod         AuthenticationMethod `db:"auth_method" json:"auth_method"`
+	ConnectionType     ConnectionType       `db:"connection_type" json:"connection_type"`
+	CredentialID       sql.NullInt32        `db:"credential_id" json:"credential_id"`
+	MaxConcurrency     int32                `db:"max_concurrency" json:"max_concurrency"`
+	Become             bool                 `db:"become" json:"become"`
+	BecomeUser         string               `db:"become_user" json:"become_user"`
+	BecomeMethod       string               `db:"become_method" json:"become_method"`
+	BecomeCredentialID sql.NullInt32        `db:"become_credential_id" json:"become_credential_id"`
+	JumpHostID         sql.NullInt32        `db:"jump_host_id" json:"jump_host_id"`
+	Uuid_2             uuid.UUID            `db:"uuid_2" json:"uuid_2"`
 }
 
 func (q *Queries) UpdateNode(ctx context.Context, arg UpdateNodeParams) (Node, error) {
@@ -436,6 +793,12 @@ func (q *Queries) UpdateNode(ctx context.Context, arg UpdateNodeParams) (Node, e
 		arg.AuthMethod,
 		arg.ConnectionType,
 		arg.CredentialID,
+		arg.MaxConcurrency,
+		arg.Become,
+		arg.BecomeUser,
+		arg.BecomeMethod,
+		arg.BecomeCredentialID,
+		arg.JumpHostID,
 		arg.Uuid_2,
 	)
 	var i Node
@@ -454,6 +817,15 @@ func (q *Queries) UpdateNode(ctx context.Context, arg UpdateNodeParams) (Node, e
 		&i.NamespaceID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MaxConcurrency,
+		&i.Become,
+		&i.BecomeUser,
+		&i.BecomeMethod,
+		&i.BecomeCredentialID,
+		&i.HostKey,
+		&i.PendingHostKey,
+		&i.JumpHostID,
+		&i.InventorySourceID,
 	)
 	return i, err
 }
@@ -13,29 +13,47 @@ import (
 
 type Querier interface {
 	AccessCredential(ctx context.Context, arg AccessCredentialParams) (Credential, error)
+	// Promotes a waiter to holder only if nobody holds the lock and no older waiter exists
+	AcquireResourceLock(ctx context.Context, id int32) (int64, error)
 	AddApprovalRequest(ctx context.Context, arg AddApprovalRequestParams) (AddApprovalRequestRow, error)
+	// Artifacts stored again for the same action, for example when an execution is retried, replace the previous record
+	AddExecutionArtifact(ctx context.Context, arg AddExecutionArtifactParams) error
 	AddExecutionLog(ctx context.Context, arg AddExecutionLogParams) (ExecutionLog, error)
+	AddExecutionSSHCertificate(ctx context.Context, arg AddExecutionSSHCertificateParams) error
 	AddGroupToUserByUUID(ctx context.Context, arg AddGroupToUserByUUIDParams) error
+	AddResourceLockWaiter(ctx context.Context, arg AddResourceLockWaiterParams) (ResourceLock, error)
+	ApproveNodeHostKey(ctx context.Context, arg ApproveNodeHostKeyParams) (Node, error)
 	ApproveRequestByUUID(ctx context.Context, arg ApproveRequestByUUIDParams) (ApproveRequestByUUIDRow, error)
 	AssignGroupNamespaceRole(ctx context.Context, arg AssignGroupNamespaceRoleParams) (NamespaceMember, error)
 	AssignUserNamespaceRole(ctx context.Context, arg AssignUserNamespaceRoleParams) (NamespaceMember, error)
 	CancelTasksByExecID(ctx context.Context, execID string) error
+	// Due sources are claimed by moving their next sync forward so that only one instance syncs them
+	ClaimDueInventorySources(ctx context.Context) ([]ClaimDueInventorySourcesRow, error)
+	// Due nodes are claimed by moving their next check forward so that only one instance checks them.
+	// Nodes which have not been checked before are claimed by creating their facts
+	ClaimNodesForHealthCheck(ctx context.Context, intervalSeconds int32) ([]ClaimNodesForHealthCheckRow, error)
 	CreateCredential(ctx context.Context, arg CreateCredentialParams) (Credential, error)
 	CreateFlow(ctx context.Context, arg CreateFlowParams) (Flow, error)
 	CreateFlowSecret(ctx context.Context, arg CreateFlowSecretParams) (FlowSecret, error)
 	CreateGroup(ctx context.Context, arg CreateGroupParams) (Group, error)
-	CreateNamespace(ctx context.Context, name string) (Namespace, error)
+	CreateInventorySource(ctx context.Context, arg CreateInventorySourceParams) (InventorySource, error)
+	CreateNamespace(ctx context.Context, arg CreateNamespaceParams) (Namespace, error)
 	CreateNode(ctx context.Context, arg CreateNodeParams) (Node, error)
 	// Immediate task operations
 	CreateSchedulerTask(ctx context.Context, arg CreateSchedulerTaskParams) (SchedulerTask, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteAllFlows(ctx context.Context) error
 	DeleteCredential(ctx context.Context, arg DeleteCredentialParams) error
+	DeleteExecutionArtifact(ctx context.Context, id int32) error
 	DeleteFlow(ctx context.Context, arg DeleteFlowParams) error
 	DeleteFlowSecret(ctx context.Context, arg DeleteFlowSecretParams) error
 	DeleteGroupByUUID(ctx context.Context, argUuid uuid.UUID) error
+	DeleteInventorySource(ctx context.Context, arg DeleteInventorySourceParams) error
 	DeleteNamespace(ctx context.Context, argUuid uuid.UUID) error
 	DeleteNode(ctx context.Context, arg DeleteNodeParams) error
+	DeleteResourceLock(ctx context.Context, id int32) error
+	DeleteSchedulerInstance(ctx context.Context, id string) error
+	DeleteStaleSchedulerInstances(ctx context.Context, staleAfterSeconds int32) ([]SchedulerInstance, error)
 	DeleteUserByUUID(ctx context.Context, argUuid uuid.UUID) error
 	ExecutionExistsForFlow(ctx context.Context, arg ExecutionExistsForFlowParams) (bool, error)
 	GetAllExecutionsPaginated(ctx context.Context, arg GetAllExecutionsPaginatedParams) ([]GetAllExecutionsPaginatedRow, error)
@@ -50,12 +68,18 @@ type Querier interface {
 	GetApprovalWithInputsByUUID(ctx context.Context, arg GetApprovalWithInputsByUUIDParams) (GetApprovalWithInputsByUUIDRow, error)
 	GetApprovalsPaginated(ctx context.Context, arg GetApprovalsPaginatedParams) ([]GetApprovalsPaginatedRow, error)
 	GetCredentialByID(ctx context.Context, arg GetCredentialByIDParams) (GetCredentialByIDRow, error)
+	GetCredentialByName(ctx context.Context, arg GetCredentialByNameParams) (GetCredentialByNameRow, error)
 	GetCredentialByUUID(ctx context.Context, arg GetCredentialByUUIDParams) (GetCredentialByUUIDRow, error)
+	GetExecutionArtifactByUUID(ctx context.Context, arg GetExecutionArtifactByUUIDParams) (ExecutionArtifact, error)
+	GetExecutionArtifacts(ctx context.Context, arg GetExecutionArtifactsParams) ([]ExecutionArtifact, error)
 	GetExecutionByExecID(ctx context.Context, arg GetExecutionByExecIDParams) (GetExecutionByExecIDRow, error)
 	GetExecutionByExecIDWithNamespace(ctx context.Context, arg GetExecutionByExecIDWithNamespaceParams) (GetExecutionByExecIDWithNamespaceRow, error)
 	GetExecutionByID(ctx context.Context, arg GetExecutionByIDParams) (GetExecutionByIDRow, error)
+	GetExecutionSSHCertificates(ctx context.Context, arg GetExecutionSSHCertificatesParams) ([]ExecutionSshCertificate, error)
 	GetExecutionsByFlow(ctx context.Context, arg GetExecutionsByFlowParams) ([]GetExecutionsByFlowRow, error)
 	GetExecutionsByFlowPaginated(ctx context.Context, arg GetExecutionsByFlowPaginatedParams) ([]GetExecutionsByFlowPaginatedRow, error)
+	// The retention of the namespace is used when set, otherwise default_retention_seconds. A retention of 0 keeps artifacts forever
+	GetExpiredArtifacts(ctx context.Context, arg GetExpiredArtifactsParams) ([]GetExpiredArtifactsRow, error)
 	GetFlowBySlug(ctx context.Context, arg GetFlowBySlugParams) (Flow, error)
 	GetFlowFromExecID(ctx context.Context, arg GetFlowFromExecIDParams) (Flow, error)
 	GetFlowFromExecIDWithNamespace(ctx context.Context, arg GetFlowFromExecIDWithNamespaceParams) (Flow, error)
@@ -66,14 +90,20 @@ type Querier interface {
 	GetGroupByUUID(ctx context.Context, argUuid uuid.UUID) (Group, error)
 	GetGroupByUUIDWithUsers(ctx context.Context, argUuid uuid.UUID) (GroupView, error)
 	GetInputForExecByUUID(ctx context.Context, arg GetInputForExecByUUIDParams) (json.RawMessage, error)
+	GetInventorySourceByUUID(ctx context.Context, arg GetInventorySourceByUUIDParams) (GetInventorySourceByUUIDRow, error)
+	GetJumpHostByID(ctx context.Context, arg GetJumpHostByIDParams) (GetJumpHostByIDRow, error)
 	GetNamespaceByName(ctx context.Context, name string) (Namespace, error)
 	GetNamespaceByUUID(ctx context.Context, argUuid uuid.UUID) (Namespace, error)
 	GetNamespaceMembers(ctx context.Context, argUuid uuid.UUID) ([]GetNamespaceMembersRow, error)
 	GetNodeByName(ctx context.Context, arg GetNodeByNameParams) (GetNodeByNameRow, error)
 	GetNodeByUUID(ctx context.Context, arg GetNodeByUUIDParams) (GetNodeByUUIDRow, error)
+	GetNodeFacts(ctx context.Context, nodeID int32) (NodeFact, error)
+	GetNodeFactsByUUIDs(ctx context.Context, dollar_1 []uuid.UUID) ([]GetNodeFactsByUUIDsRow, error)
 	GetNodeStats(ctx context.Context, argUuid uuid.UUID) (GetNodeStatsRow, error)
 	GetNodesByNames(ctx context.Context, arg GetNodesByNamesParams) ([]GetNodesByNamesRow, error)
+	GetNodesByNamespace(ctx context.Context, argUuid uuid.UUID) ([]Node, error)
 	GetPendingTasks(ctx context.Context, limit int32) ([]SchedulerTask, error)
+	GetResourceLockHolder(ctx context.Context, arg GetResourceLockHolderParams) (ResourceLock, error)
 	GetScheduledFlows(ctx context.Context) ([]GetScheduledFlowsRow, error)
 	GetUserByID(ctx context.Context, id int32) (User, error)
 	GetUserByUUID(ctx context.Context, argUuid uuid.UUID) (User, error)
@@ -86,10 +116,14 @@ type Querier interface {
 	ListFlowSecrets(ctx context.Context, arg ListFlowSecretsParams) ([]ListFlowSecretsRow, error)
 	ListFlows(ctx context.Context, arg ListFlowsParams) ([]ListFlowsRow, error)
 	ListFlowsPaginated(ctx context.Context, arg ListFlowsPaginatedParams) ([]ListFlowsPaginatedRow, error)
+	ListInventorySources(ctx context.Context, argUuid uuid.UUID) ([]ListInventorySourcesRow, error)
 	ListNamespaces(ctx context.Context, arg ListNamespacesParams) ([]ListNamespacesRow, error)
+	ListResourceLocks(ctx context.Context, argUuid uuid.UUID) ([]ListResourceLocksRow, error)
 	MarkAllFlowsInactiveForNamespace(ctx context.Context, argUuid uuid.UUID) error
 	MarkFlowActive(ctx context.Context, arg MarkFlowActiveParams) error
 	RejectRequestByUUID(ctx context.Context, arg RejectRequestByUUIDParams) (RejectRequestByUUIDRow, error)
+	ReleaseAllResourceLocks(ctx context.Context, execID string) error
+	ReleaseResourceLocks(ctx context.Context, arg ReleaseResourceLocksParams) error
 	RemoveAllGroupsForUserByUUID(ctx context.Context, userUuid uuid.UUID) error
 	RemoveNamespaceMember(ctx context.Context, arg RemoveNamespaceMemberParams) (NamespaceMember, error)
 	SearchCredentials(ctx context.Context, arg SearchCredentialsParams) ([]SearchCredentialsRow, error)
@@ -98,6 +132,12 @@ type Querier interface {
 	SearchGroup(ctx context.Context, arg SearchGroupParams) ([]SearchGroupRow, error)
 	SearchNodes(ctx context.Context, arg SearchNodesParams) ([]SearchNodesRow, error)
 	SearchUsersWithGroups(ctx context.Context, arg SearchUsersWithGroupsParams) ([]SearchUsersWithGroupsRow, error)
+	SetInventorySourceResult(ctx context.Context, arg SetInventorySourceResultParams) error
+	SetNodeFacts(ctx context.Context, arg SetNodeFactsParams) error
+	SetNodeHostKey(ctx context.Context, arg SetNodeHostKeyParams) (Node, error)
+	SetNodeInventorySource(ctx context.Context, arg SetNodeInventorySourceParams) error
+	SetNodePendingHostKey(ctx context.Context, arg SetNodePendingHostKeyParams) error
+	SetNodeUnreachable(ctx context.Context, arg SetNodeUnreachableParams) error
 	UpdateApprovalStatusByUUID(ctx context.Context, arg UpdateApprovalStatusByUUIDParams) (UpdateApprovalStatusByUUIDRow, error)
 	UpdateCredential(ctx context.Context, arg UpdateCredentialParams) (Credential, error)
 	UpdateExecutionActionID(ctx context.Context, arg UpdateExecutionActionIDParams) (ExecutionLog, error)
@@ -105,12 +145,14 @@ type Querier interface {
 	UpdateFlow(ctx context.Context, arg UpdateFlowParams) (Flow, error)
 	UpdateFlowSecret(ctx context.Context, arg UpdateFlowSecretParams) (FlowSecret, error)
 	UpdateGroupByUUID(ctx context.Context, arg UpdateGroupByUUIDParams) (Group, error)
+	UpdateInventorySource(ctx context.Context, arg UpdateInventorySourceParams) (InventorySource, error)
 	UpdateNamespace(ctx context.Context, arg UpdateNamespaceParams) (Namespace, error)
 	UpdateNamespaceMember(ctx context.Context, arg UpdateNamespaceMemberParams) (NamespaceMember, error)
 	UpdateNode(ctx context.Context, arg UpdateNodeParams) (Node, error)
 	UpdateTaskStatus(ctx context.Context, arg UpdateTaskStatusParams) error
 	UpdateUserByUUID(ctx context.Context, arg UpdateUserByUUIDParams) (User, error)
 	UpdateUserPasswordByUsername(ctx context.Context, arg UpdateUserPasswordByUsernameParams) (User, error)
+	UpsertSchedulerInstance(ctx context.Context, arg UpsertSchedulerInstanceParams) error
 }
 
 var _ Querier = (*Queries)(nil)
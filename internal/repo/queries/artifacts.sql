@@ -0,0 +1,29 @@
+-- name: AddExecutionArtifact :exec
+-- Artifacts stored again for the same action, for example when an execution is retried, replace the previous record
+INSERT INTO execution_artifacts (exec_id, action_id, node_name, path, size_bytes, checksum, storage_key, namespace_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, (SELECT id FROM namespaces WHERE namespaces.uuid = $8))
+ON CONFLICT ON CONSTRAINT unique_execution_artifact
+DO UPDATE SET size_bytes = EXCLUDED.size_bytes, checksum = EXCLUDED.checksum, storage_key = EXCLUDED.storage_key, created_at = NOW();
+
+-- name: GetExecutionArtifacts :many
+SELECT a.* FROM execution_artifacts a
+JOIN namespaces ns ON a.namespace_id = ns.id
+WHERE a.exec_id = $1 AND ns.uuid = $2
+ORDER BY a.created_at, a.id;
+
+-- name: GetExecutionArtifactByUUID :one
+SELECT a.* FROM execution_artifacts a
+JOIN namespaces ns ON a.namespace_id = ns.id
+WHERE a.uuid = $1 AND a.exec_id = $2 AND ns.uuid = $3;
+
+-- name: GetExpiredArtifacts :many
+-- The retention of the namespace is used when set, otherwise default_retention_seconds. A retention of 0 keeps artifacts forever
+SELECT a.id, a.storage_key FROM execution_artifacts a
+JOIN namespaces ns ON a.namespace_id = ns.id
+WHERE COALESCE(ns.artifact_retention_days * 86400, sqlc.arg(default_retention_seconds)::bigint) > 0
+AND a.created_at < NOW() - (COALESCE(ns.artifact_retention_days * 86400, sqlc.arg(default_retention_seconds)::bigint) * INTERVAL '1 second')
+ORDER BY a.id
+LIMIT sqlc.arg(batch_size);
+
+-- name: DeleteExecutionArtifact :exec
+DELETE FROM execution_artifacts WHERE id = $1;
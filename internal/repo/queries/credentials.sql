@@ -53,3 +53,8 @@ RETURNING *;
 
 -- name: DeleteCredential :exec
 DELETE FROM credentials WHERE credentials.uuid = $1 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $2);
+
+-- name: GetCredentialByName :one
+SELECT c.*, ns.uuid AS namespace_uuid FROM credentials c
+JOIN namespaces ns ON c.namespace_id = ns.id
+WHERE c.name = $1 AND ns.uuid = $2;
@@ -15,9 +15,10 @@ INSERT INTO execution_log (
     input,
     trigger_type,
     triggered_by,
-    namespace_id
+    namespace_id,
+    priority
 ) VALUES (
-    $1, $2, (SELECT version FROM next_version), $3, $6, (SELECT id FROM user_lookup), (SELECT id FROM namespace_lookup)
+    $1, $2, (SELECT version FROM next_version), $3, $6, (SELECT id FROM user_lookup), (SELECT id FROM namespace_lookup), $7
 ) RETURNING *;
 
 -- name: UpdateExecutionStatus :one
@@ -331,3 +332,13 @@ WHERE flow_id = (SELECT id FROM flows WHERE flows.slug = $1 AND flows.is_active
 namespace_id = (SELECT id FROM namespace_lookup) AND
 (status = 'running' or status = 'pending_approval' or status = 'pending') AND
 version = lv.max_version);
+
+-- name: AddExecutionSSHCertificate :exec
+INSERT INTO execution_ssh_certificates (exec_id, action_id, node_name, serial, key_id, principals, valid_after, valid_before, namespace_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, (SELECT id FROM namespaces WHERE namespaces.uuid = $9));
+
+-- name: GetExecutionSSHCertificates :many
+SELECT c.* FROM execution_ssh_certificates c
+JOIN namespaces ns ON c.namespace_id = ns.id
+WHERE c.exec_id = $1 AND ns.uuid = $2
+ORDER BY c.created_at, c.id;
@@ -0,0 +1,12 @@
+-- name: UpsertSchedulerInstance :exec
+INSERT INTO scheduler_instances (id, hostname)
+VALUES ($1, $2)
+ON CONFLICT (id) DO UPDATE SET last_heartbeat = NOW();
+
+-- name: DeleteSchedulerInstance :exec
+DELETE FROM scheduler_instances WHERE id = $1;
+
+-- name: DeleteStaleSchedulerInstances :many
+DELETE FROM scheduler_instances
+WHERE last_heartbeat < NOW() - (sqlc.arg(stale_after_seconds)::int * INTERVAL '1 second')
+RETURNING *;
@@ -0,0 +1,51 @@
+-- name: CreateInventorySource :one
+INSERT INTO inventory_sources (name, source_type, path, format, sync_interval, port, username, connection_type, credential_id, namespace_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, (SELECT id FROM namespaces WHERE namespaces.uuid = $10))
+RETURNING *;
+
+-- name: GetInventorySourceByUUID :one
+SELECT s.*, ns.uuid AS namespace_uuid, c.uuid AS credential_uuid FROM inventory_sources s
+JOIN namespaces ns ON s.namespace_id = ns.id
+LEFT JOIN credentials c ON s.credential_id = c.id
+WHERE s.uuid = $1 AND ns.uuid = $2;
+
+-- name: ListInventorySources :many
+SELECT s.*, ns.uuid AS namespace_uuid, c.uuid AS credential_uuid FROM inventory_sources s
+JOIN namespaces ns ON s.namespace_id = ns.id
+LEFT JOIN credentials c ON s.credential_id = c.id
+WHERE ns.uuid = $1
+ORDER BY s.name;
+
+-- name: UpdateInventorySource :one
+UPDATE inventory_sources
+SET name = $2, source_type = $3, path = $4, format = $5, sync_interval = $6, port = $7, username = $8, connection_type = $9, credential_id = $10,
+    -- A changed interval applies from the last sync
+    next_sync_at = COALESCE(last_synced_at, NOW()) + make_interval(secs => $6),
+    updated_at = NOW()
+WHERE inventory_sources.uuid = $1 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $11)
+RETURNING *;
+
+-- name: DeleteInventorySource :exec
+DELETE FROM inventory_sources WHERE inventory_sources.uuid = $1 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $2);
+
+-- name: ClaimDueInventorySources :many
+-- Due sources are claimed by moving their next sync forward so that only one instance syncs them
+UPDATE inventory_sources s
+SET next_sync_at = NOW() + make_interval(secs => s.sync_interval)
+FROM namespaces ns
+WHERE s.namespace_id = ns.id AND s.id IN (
+    SELECT id FROM inventory_sources
+    WHERE next_sync_at <= NOW()
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING s.uuid, ns.uuid AS namespace_uuid;
+
+-- name: SetInventorySourceResult :exec
+UPDATE inventory_sources
+SET last_synced_at = NOW(), last_error = $2, last_report = $3
+WHERE id = $1;
+
+-- name: SetNodeInventorySource :exec
+UPDATE nodes
+SET inventory_source_id = $2, updated_at = NOW()
+WHERE nodes.uuid = $1;
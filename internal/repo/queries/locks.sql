@@ -0,0 +1,43 @@
+-- name: AddResourceLockWaiter :one
+INSERT INTO resource_locks (name, exec_id, action_id, flow_id, status, namespace_id)
+VALUES ($1, $2, $3, $4, 'waiting', (SELECT id FROM namespaces WHERE namespaces.uuid = $5))
+RETURNING *;
+
+-- name: AcquireResourceLock :execrows
+-- Promotes a waiter to holder only if nobody holds the lock and no older waiter exists
+UPDATE resource_locks rl SET status = 'held', acquired_at = NOW()
+WHERE rl.id = $1 AND rl.status = 'waiting'
+  AND NOT EXISTS (
+    SELECT 1 FROM resource_locks o
+    WHERE o.name = rl.name
+      AND o.namespace_id = rl.namespace_id
+      AND (o.status = 'held' OR (o.status = 'waiting' AND o.id < rl.id))
+  );
+
+-- name: GetResourceLockHolder :one
+SELECT rl.* FROM resource_locks rl
+JOIN namespaces ns ON rl.namespace_id = ns.id
+WHERE rl.name = $1 AND ns.uuid = $2 AND rl.status = 'held';
+
+-- name: DeleteResourceLock :exec
+DELETE FROM resource_locks WHERE id = $1;
+
+-- name: ReleaseResourceLocks :exec
+DELETE FROM resource_locks
+WHERE exec_id = $1
+  AND name = ANY($2::text[])
+  AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $3);
+
+-- name: ListResourceLocks :many
+SELECT
+    rl.*,
+    f.slug AS flow_slug,
+    f.name AS flow_name
+FROM resource_locks rl
+JOIN namespaces ns ON rl.namespace_id = ns.id
+JOIN flows f ON rl.flow_id = f.id
+WHERE ns.uuid = $1
+ORDER BY rl.name, rl.status, rl.id;
+
+-- name: ReleaseAllResourceLocks :exec
+DELETE FROM resource_locks WHERE exec_id = $1;
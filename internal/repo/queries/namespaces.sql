@@ -1,6 +1,6 @@
 -- name: CreateNamespace :one
-INSERT INTO namespaces (name)
-VALUES ($1)
+INSERT INTO namespaces (name, artifact_retention_days)
+VALUES ($1, $2)
 RETURNING *;
 
 -- name: GetNamespaceByUUID :one
@@ -37,7 +37,7 @@ FROM paged p, page_count pc, total t;
 
 -- name: UpdateNamespace :one
 UPDATE namespaces
-SET name = $2, updated_at = NOW()
+SET name = $2, artifact_retention_days = $3, updated_at = NOW()
 WHERE uuid = $1
 RETURNING *;
 
@@ -0,0 +1,38 @@
+-- name: ClaimNodesForHealthCheck :many
+-- Due nodes are claimed by moving their next check forward so that only one instance checks them.
+-- Nodes which have not been checked before are claimed by creating their facts
+WITH due AS (
+    SELECT n.id FROM nodes n
+    LEFT JOIN node_facts f ON f.node_id = n.id
+    WHERE f.node_id IS NULL OR f.next_check_at <= NOW()
+),
+claimed AS (
+    INSERT INTO node_facts (node_id, next_check_at)
+    SELECT id, NOW() + (sqlc.arg(interval_seconds)::int * INTERVAL '1 second') FROM due
+    ON CONFLICT (node_id) DO UPDATE SET next_check_at = EXCLUDED.next_check_at
+    WHERE node_facts.next_check_at <= NOW()
+    RETURNING node_id
+)
+SELECT n.name, ns.uuid AS namespace_uuid FROM claimed c
+JOIN nodes n ON c.node_id = n.id
+JOIN namespaces ns ON n.namespace_id = ns.id
+ORDER BY ns.uuid, n.name;
+
+-- name: SetNodeFacts :exec
+UPDATE node_facts
+SET reachable = TRUE, error = '', latency_ms = $2, os = $3, kernel = $4, arch = $5, uptime_seconds = $6, disk_free_bytes = $7,
+    checked_at = NOW(), gathered_at = NOW()
+WHERE node_id = (SELECT id FROM nodes WHERE nodes.uuid = $1);
+
+-- name: SetNodeUnreachable :exec
+UPDATE node_facts
+SET reachable = FALSE, error = $2, latency_ms = 0, checked_at = NOW()
+WHERE node_id = (SELECT id FROM nodes WHERE nodes.uuid = $1);
+
+-- name: GetNodeFacts :one
+SELECT * FROM node_facts WHERE node_id = $1;
+
+-- name: GetNodeFactsByUUIDs :many
+SELECT f.*, n.uuid AS node_uuid FROM node_facts f
+JOIN nodes n ON f.node_id = n.id
+WHERE n.uuid = ANY($1::uuid[]);
@@ -1,13 +1,20 @@
 -- name: CreateNode :one
-INSERT INTO nodes (name, hostname, port, username, os_family, tags, auth_method, connection_type, credential_id, namespace_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, (SELECT id FROM namespaces WHERE namespaces.uuid = $10))
+INSERT INTO nodes (name, hostname, port, username, os_family, tags, auth_method, connection_type, credential_id, max_concurrency, become, become_user, become_method, become_credential_id, jump_host_id, namespace_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, (SELECT id FROM namespaces WHERE namespaces.uuid = $16))
 RETURNING *;
 
 -- name: GetNodeByUUID :one
-SELECT n.*, ns.uuid AS namespace_uuid FROM nodes n
+SELECT n.*, ns.uuid AS namespace_uuid, jh.uuid AS jump_host_uuid FROM nodes n
 JOIN namespaces ns ON n.namespace_id = ns.id
+LEFT JOIN nodes jh ON n.jump_host_id = jh.id
 WHERE n.uuid = $1 AND ns.uuid = $2;
 
+-- name: GetJumpHostByID :one
+SELECT n.*, c.key_data AS credential_key_data FROM nodes n
+JOIN namespaces ns ON n.namespace_id = ns.id
+LEFT JOIN credentials c ON n.credential_id = c.id
+WHERE n.id = $1 AND ns.uuid = $2;
+
 -- name: SearchNodes :many
 WITH filtered AS (
     SELECT n.*, ns.uuid AS namespace_uuid FROM nodes n
@@ -36,10 +43,37 @@ FROM paged p, page_count pc, total t;
 
 -- name: UpdateNode :one
 UPDATE nodes
-SET name = $2, hostname = $3, port = $4, username = $5, os_family = $6, tags = $7, auth_method = $8, connection_type = $9, credential_id = $10, updated_at = NOW()
-WHERE nodes.uuid = $1 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $11)
+SET name = $2, hostname = $3, port = $4, username = $5, os_family = $6, tags = $7, auth_method = $8, connection_type = $9, credential_id = $10, max_concurrency = $11, become = $12, become_user = $13, become_method = $14, become_credential_id = $15, jump_host_id = $16,
+    -- The trusted host key belongs to the previous address
+    host_key = CASE WHEN nodes.hostname = $3 AND nodes.port = $4 THEN nodes.host_key ELSE '' END,
+    pending_host_key = CASE WHEN nodes.hostname = $3 AND nodes.port = $4 THEN nodes.pending_host_key ELSE '' END,
+    updated_at = NOW()
+WHERE nodes.uuid = $1 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $17)
 RETURNING *;
 
+-- name: SetNodeHostKey :one
+UPDATE nodes
+SET host_key = $2, pending_host_key = '', updated_at = NOW()
+WHERE nodes.uuid = $1 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $3)
+RETURNING *;
+
+-- name: SetNodePendingHostKey :exec
+UPDATE nodes
+SET pending_host_key = $2, updated_at = NOW()
+WHERE nodes.uuid = $1 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $3);
+
+-- name: ApproveNodeHostKey :one
+UPDATE nodes
+SET host_key = pending_host_key, pending_host_key = '', updated_at = NOW()
+WHERE nodes.uuid = $1 AND pending_host_key = $2 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $3)
+RETURNING *;
+
+-- name: GetNodesByNamespace :many
+SELECT n.* FROM nodes n
+JOIN namespaces ns ON n.namespace_id = ns.id
+WHERE ns.uuid = $1
+ORDER BY n.name;
+
 -- name: DeleteNode :exec
 DELETE FROM nodes WHERE nodes.uuid = $1 AND namespace_id = (SELECT id FROM namespaces WHERE namespaces.uuid = $2);
 
@@ -66,10 +100,14 @@ SELECT
     c.uuid AS credential_uuid,
     c.name AS credential_name,
     c.key_type AS credential_key_type,
-    c.key_data AS credential_key_data
+    c.key_data AS credential_key_data,
+    bc.uuid AS become_credential_uuid,
+    bc.key_type AS become_credential_key_type,
+    bc.key_data AS become_credential_key_data
 FROM nodes n
 JOIN namespaces ns ON n.namespace_id = ns.id
 LEFT JOIN credentials c ON n.credential_id = c.id
+LEFT JOIN credentials bc ON n.become_credential_id = bc.id
 WHERE n.name = ANY($1::text[]) AND ns.uuid = $2
 ORDER BY n.name;
 
@@ -77,7 +115,10 @@ ORDER BY n.name;
 SELECT
     COUNT(*) AS total_hosts,
     COUNT(*) FILTER (WHERE connection_type = 'ssh') AS ssh_hosts,
-    COUNT(*) FILTER (WHERE connection_type = 'qssh') AS qssh_hosts
+    COUNT(*) FILTER (WHERE connection_type = 'qssh') AS qssh_hosts,
+    COUNT(*) FILTER (WHERE connection_type = 'docker') AS docker_hosts,
+    COUNT(*) FILTER (WHERE f.reachable = FALSE) AS unreachable_hosts
 FROM nodes n
 JOIN namespaces ns ON n.namespace_id = ns.id
+LEFT JOIN node_facts f ON f.node_id = n.id
 WHERE ns.uuid = $1;
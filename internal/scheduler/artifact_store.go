@@ -0,0 +1,159 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/cvhariharan/flowctl/internal/repo"
+	"github.com/cvhariharan/flowctl/sdk/executor"
+	"github.com/google/uuid"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+// expiredArtifactsBatch is the number of expired artifacts read from the database at a time
+const expiredArtifactsBatch = 100
+
+// NamespaceArtifactsPrefix returns the prefix of the keys of the artifacts stored for a namespace.
+// Artifacts are stored under <namespace>/<execution>/<action>/<node>/<path>
+func NamespaceArtifactsPrefix(namespaceID string) string {
+	return namespaceID + "/"
+}
+
+func artifactKey(namespaceID, execID, actionID, nodeName, path string) string {
+	return NamespaceArtifactsPrefix(namespaceID) + strings.Join([]string{execID, actionID, nodeName, path}, "/")
+}
+
+// artifactNodeName is the directory of the artifact store where the artifacts of a node are pulled to
+func artifactNodeName(driver executor.NodeDriver, node Node) string {
+	if driver.IsRemote() {
+		return node.Name
+	}
+	return "local"
+}
+
+// matchArtifact reports whether a slash separated path matches any of the artifact patterns of an action
+func matchArtifact(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if ok, err := doublestar.Match(pattern, path); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// storeArtifacts uploads the files pulled from a node after the action which match the artifact patterns of
+// the action to the artifact bucket. Patterns are matched against paths relative to the artifacts directory
+// of the node, files pulled by earlier actions are not stored again
+func (s *Scheduler) storeArtifacts(ctx context.Context, driver executor.NodeDriver, artifactDir string, execID string, action Action, node Node, namespaceID string, files map[string]string) error {
+	if s.artifactBucket == nil || len(action.Artifacts) == 0 {
+		return nil
+	}
+
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		return fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	nodeName := artifactNodeName(driver, node)
+	nodeDir := filepath.Join(artifactDir, nodeName)
+
+	var stored int
+	for _, file := range slices.Sorted(maps.Keys(files)) {
+		if !matchArtifact(action.Artifacts, file) {
+			continue
+		}
+
+		key := artifactKey(namespaceID, execID, action.ID, nodeName, file)
+		size, err := s.uploadArtifact(ctx, filepath.Join(nodeDir, filepath.FromSlash(file)), key)
+		if err != nil {
+			return fmt.Errorf("failed to upload artifact %s: %w", file, err)
+		}
+
+		if err := s.store.AddExecutionArtifact(ctx, repo.AddExecutionArtifactParams{
+			ExecID:     execID,
+			ActionID:   action.ID,
+			NodeName:   nodeName,
+			Path:       file,
+			SizeBytes:  size,
+			Checksum:   files[file],
+			StorageKey: key,
+			Uuid:       namespaceUUID,
+		}); err != nil {
+			return fmt.Errorf("failed to record artifact %s: %w", file, err)
+		}
+		stored++
+	}
+	s.logger.Debug("stored artifacts", "execID", execID, "actionID", action.ID, "node", nodeName, "stored", stored)
+
+	return nil
+}
+
+// uploadArtifact copies a file to the artifact bucket and returns its size
+func (s *Scheduler) uploadArtifact(ctx context.Context, localPath string, key string) (int64, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	// Artifacts are always downloaded as attachments, the content type is not detected
+	if err := s.artifactBucket.Upload(ctx, key, f, &blob.WriterOptions{ContentType: "application/octet-stream"}); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// cleanupArtifacts deletes expired artifacts in the background.
+// A cleanup is skipped while the previous one is still running
+func (s *Scheduler) cleanupArtifacts(ctx context.Context) {
+	if s.artifactBucket == nil || !s.artifactCleaning.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer s.artifactCleaning.Store(false)
+		if err := s.deleteExpiredArtifacts(ctx); err != nil {
+			s.logger.Error("error deleting expired artifacts", "error", err)
+		}
+	}()
+}
+
+// deleteExpiredArtifacts deletes the artifacts which are older than the retention of their namespace.
+// Records are removed after their files so that no files are left behind if deleting fails
+func (s *Scheduler) deleteExpiredArtifacts(ctx context.Context) error {
+	for {
+		expired, err := s.store.GetExpiredArtifacts(ctx, repo.GetExpiredArtifactsParams{
+			DefaultRetentionSeconds: int64(s.retentionTime / time.Second),
+			BatchSize:               expiredArtifactsBatch,
+		})
+		if err != nil {
+			return fmt.Errorf("could not get expired artifacts: %w", err)
+		}
+
+		for _, a := range expired {
+			if err := s.artifactBucket.Delete(ctx, a.StorageKey); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+				return fmt.Errorf("could not delete artifact %s: %w", a.StorageKey, err)
+			}
+			if err := s.store.DeleteExecutionArtifact(ctx, a.ID); err != nil {
+				return fmt.Errorf("could not delete artifact record %s: %w", a.StorageKey, err)
+			}
+		}
+
+		if len(expired) < expiredArtifactsBatch {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+)
+
+// artifactTracker records the checksums of the artifacts on the nodes of an execution so that
+// only files which changed are transferred between the artifact store and the nodes
+type artifactTracker struct {
+	mu    sync.Mutex
+	nodes map[string]*nodeArtifacts
+}
+
+// nodeArtifacts are the checksums of the files in the artifacts directory of a node, keyed by
+// their slash separated path relative to the directory
+type nodeArtifacts struct {
+	mu     sync.Mutex
+	loaded bool // Set once the files on the node have been read
+	files  map[string]string
+}
+
+func newArtifactTracker() *artifactTracker {
+	return &artifactTracker{nodes: make(map[string]*nodeArtifacts)}
+}
+
+// node returns the artifacts of a node, the local node is tracked with an empty ID.
+// Nothing is tracked across calls on a nil tracker
+func (t *artifactTracker) node(nodeID string) *nodeArtifacts {
+	if t == nil {
+		return &nodeArtifacts{files: make(map[string]string)}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n, ok := t.nodes[nodeID]
+	if !ok {
+		n = &nodeArtifacts{files: make(map[string]string)}
+		t.nodes[nodeID] = n
+	}
+	return n
+}
+
+// load reads the files on the node the first time it is used in the execution. Files which match the
+// artifact store are not pushed again, for example when an execution resumes after an approval
+func (n *nodeArtifacts) load(ctx context.Context, driver executor.NodeDriver, remoteDir string, local map[string]string) error {
+	if n.loaded {
+		return nil
+	}
+
+	remote, err := checksums(ctx, driver, remoteDir)
+	if err != nil {
+		return err
+	}
+	for file, sum := range remote {
+		if local[file] == sum {
+			n.files[file] = sum
+		}
+	}
+	n.loaded = true
+	return nil
+}
+
+func (s *Scheduler) newExecArtifacts(execID string) {
+	s.artifactsMu.Lock()
+	s.artifacts[execID] = newArtifactTracker()
+	s.artifactsMu.Unlock()
+}
+
+func (s *Scheduler) closeExecArtifacts(execID string) {
+	s.artifactsMu.Lock()
+	delete(s.artifacts, execID)
+	s.artifactsMu.Unlock()
+}
+
+func (s *Scheduler) execArtifacts(execID string) *artifactTracker {
+	s.artifactsMu.Lock()
+	defer s.artifactsMu.Unlock()
+	return s.artifacts[execID]
+}
+
+// pushArtifactsWithDriver pushes the files in the directories of the artifact store to the remote artifacts
+// directory, including files in subdirectories. Files which are already on the node are skipped and
+// pushed files are verified with their checksums
+func (s *Scheduler) pushArtifactsWithDriver(ctx context.Context, driver executor.NodeDriver, artifactDir string, execID string, nodeID string) error {
+	remoteArtifactsDir := driver.Join(driver.TempDir(), fmt.Sprintf("artifacts-%s", execID))
+	s.logger.Debug("remote artifacts directory", "pushdir", remoteArtifactsDir)
+
+	local, err := executor.LocalChecksums(artifactDir)
+	if err != nil {
+		return err
+	}
+
+	state := s.execArtifacts(execID).node(nodeID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if err := state.load(ctx, driver, remoteArtifactsDir, local); err != nil {
+		return err
+	}
+
+	var pushed []string
+	for _, file := range slices.Sorted(maps.Keys(local)) {
+		// Top level files of the artifact store are not pushed, only the directories of each node
+		if !strings.Contains(file, "/") || state.files[file] == local[file] {
+			continue
+		}
+
+		localPath := filepath.Join(artifactDir, filepath.FromSlash(file))
+		remotePath := driver.Join(remoteArtifactsDir, file)
+		s.logger.Debug("pushing artifact file", "localPath", localPath, "remotePath", remotePath)
+		if err := driver.Upload(ctx, localPath, remotePath); err != nil {
+			return fmt.Errorf("failed to push artifact %s: %w", file, err)
+		}
+		pushed = append(pushed, file)
+	}
+	s.logger.Debug("pushed artifacts", "pushed", len(pushed), "total", len(local))
+
+	if len(pushed) == 0 {
+		return nil
+	}
+
+	remote, err := checksums(ctx, driver, remoteArtifactsDir)
+	if err != nil {
+		return fmt.Errorf("failed to verify pushed artifacts: %w", err)
+	}
+	for _, file := range pushed {
+		if remote[file] == local[file] {
+			state.files[file] = remote[file]
+			continue
+		}
+
+		// The file may have been replaced in the artifact store by a node which finished in the meantime
+		current, err := executor.FileChecksum(filepath.Join(artifactDir, filepath.FromSlash(file)))
+		if err != nil || current != remote[file] {
+			return fmt.Errorf("checksum mismatch for pushed artifact %s", file)
+		}
+		state.files[file] = remote[file]
+	}
+
+	return nil
+}
+
+// pullArtifactsWithDriver downloads the files in the remote artifacts directory which changed since they were
+// pushed or pulled, including files in subdirectories. Pulled files are verified with their checksums and
+// returned with them, paths are relative to the directory of the node in the artifact store
+func (s *Scheduler) pullArtifactsWithDriver(ctx context.Context, driver executor.NodeDriver, artifactDir string, execID string, node Node) (map[string]string, error) {
+	remoteArtifactsDir := driver.Join(driver.TempDir(), fmt.Sprintf("artifacts-%s", execID))
+	s.logger.Debug("remote artifacts directory", "pulldir", remoteArtifactsDir)
+
+	remote, err := checksums(ctx, driver, remoteArtifactsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+
+	// Remote execution then store in nodeName subdirectory, local execution in local subdirectory
+	nodeDir := filepath.Join(artifactDir, artifactNodeName(driver, node))
+
+	state := s.execArtifacts(execID).node(node.ID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	pulled := make(map[string]string)
+	for _, file := range slices.Sorted(maps.Keys(remote)) {
+		if state.files[file] == remote[file] {
+			continue
+		}
+		if !filepath.IsLocal(filepath.FromSlash(file)) {
+			return nil, fmt.Errorf("invalid artifact path %q on node %s", file, node.Name)
+		}
+
+		localPath := filepath.Join(nodeDir, filepath.FromSlash(file))
+		if err := downloadArtifact(ctx, driver, driver.Join(remoteArtifactsDir, file), localPath, remote[file]); err != nil {
+			return nil, fmt.Errorf("failed to pull artifact %s from node %s: %w", file, node.Name, err)
+		}
+		state.files[file] = remote[file]
+		pulled[file] = remote[file]
+	}
+
+	return pulled, nil
+}
+
+// downloadArtifact downloads a file to a temporary file which replaces localPath once its checksum is verified
+func downloadArtifact(ctx context.Context, driver executor.NodeDriver, remotePath string, localPath string, checksum string) error {
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// The temporary file is kept out of the artifact store so that it is not pushed by other nodes
+	tmp, err := os.CreateTemp("", "artifact-*")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := driver.Download(ctx, remotePath, tmp.Name()); err != nil {
+		return err
+	}
+
+	sum, err := executor.FileChecksum(tmp.Name())
+	if err != nil {
+		return err
+	}
+	if sum != checksum {
+		return fmt.Errorf("checksum mismatch, expected %s got %s", checksum, sum)
+	}
+
+	return os.Rename(tmp.Name(), localPath)
+}
+
+// checksums returns the checksums of the files under a directory on the node
+func checksums(ctx context.Context, driver executor.NodeDriver, dir string) (map[string]string, error) {
+	cd, ok := driver.(executor.ChecksumDriver)
+	if !ok {
+		return nil, fmt.Errorf("node driver does not support checksums")
+	}
+	return cd.Checksums(ctx, dir)
+}
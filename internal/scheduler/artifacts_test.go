@@ -0,0 +1,222 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cvhariharan/flowctl/internal/repo"
+	"github.com/cvhariharan/flowctl/sdk/executor"
+	"github.com/google/uuid"
+	"gocloud.dev/blob/memblob"
+)
+
+// countingDriver records the files transferred by a node driver
+type countingDriver struct {
+	executor.NodeDriver
+	uploads   []string
+	downloads []string
+}
+
+func (d *countingDriver) Upload(ctx context.Context, localPath, remotePath string) error {
+	d.uploads = append(d.uploads, localPath)
+	return d.NodeDriver.Upload(ctx, localPath, remotePath)
+}
+
+func (d *countingDriver) Download(ctx context.Context, remotePath, localPath string) error {
+	d.downloads = append(d.downloads, remotePath)
+	return d.NodeDriver.Download(ctx, remotePath, localPath)
+}
+
+func (d *countingDriver) Checksums(ctx context.Context, dirPath string) (map[string]string, error) {
+	return d.NodeDriver.(executor.ChecksumDriver).Checksums(ctx, dirPath)
+}
+
+func (d *countingDriver) reset() {
+	d.uploads = nil
+	d.downloads = nil
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArtifactTransfer(t *testing.T) {
+	ctx := context.Background()
+	execID := uuid.NewString()
+
+	local, err := executor.NewLocalLinux()
+	if err != nil {
+		t.Fatal(err)
+	}
+	driver := &countingDriver{NodeDriver: local}
+	remoteDir := driver.Join(driver.TempDir(), "artifacts-"+execID)
+	t.Cleanup(func() {
+		os.RemoveAll(remoteDir)
+		os.RemoveAll(local.GetWorkingDirectory())
+	})
+
+	artifactDir := t.TempDir()
+	writeFile(t, filepath.Join(artifactDir, "local", "run.sh"), "echo hello")
+	writeFile(t, filepath.Join(artifactDir, "local", "app", "config.yml"), "port: 80")
+	writeFile(t, filepath.Join(artifactDir, "ignored.txt"), "top level files are not pushed")
+
+	s := &Scheduler{
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		artifacts: make(map[string]*artifactTracker),
+	}
+	s.newExecArtifacts(execID)
+
+	// Files in subdirectories are pushed
+	if err := s.pushArtifactsWithDriver(ctx, driver, artifactDir, execID, ""); err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+	if len(driver.uploads) != 2 {
+		t.Fatalf("expected 2 uploads, got %v", driver.uploads)
+	}
+	if _, err := os.Stat(filepath.Join(remoteDir, "local", "app", "config.yml")); err != nil {
+		t.Fatalf("nested artifact was not pushed: %v", err)
+	}
+
+	// Unchanged files are not pushed again
+	driver.reset()
+	if err := s.pushArtifactsWithDriver(ctx, driver, artifactDir, execID, ""); err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+	if len(driver.uploads) != 0 {
+		t.Errorf("expected no uploads, got %v", driver.uploads)
+	}
+
+	// Only files created or changed on the node are pulled
+	writeFile(t, filepath.Join(remoteDir, "out", "report.txt"), "report")
+	driver.reset()
+	pulled, err := s.pullArtifactsWithDriver(ctx, driver, artifactDir, execID, Node{})
+	if err != nil {
+		t.Fatalf("pull failed: %v", err)
+	}
+	if _, ok := pulled["out/report.txt"]; !ok || len(pulled) != 1 {
+		t.Errorf("expected only out/report.txt to be pulled, got %v", pulled)
+	}
+	if want := []string{filepath.Join(remoteDir, "out", "report.txt")}; !reflect.DeepEqual(driver.downloads, want) {
+		t.Errorf("expected downloads %v, got %v", want, driver.downloads)
+	}
+	if b, err := os.ReadFile(filepath.Join(artifactDir, "local", "out", "report.txt")); err != nil || string(b) != "report" {
+		t.Errorf("unexpected pulled artifact %q: %v", b, err)
+	}
+
+	// Changed files are pushed again
+	writeFile(t, filepath.Join(artifactDir, "local", "run.sh"), "echo changed")
+	driver.reset()
+	if err := s.pushArtifactsWithDriver(ctx, driver, artifactDir, execID, ""); err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+	want := []string{
+		filepath.Join(artifactDir, "local", "out", "report.txt"),
+		filepath.Join(artifactDir, "local", "run.sh"),
+	}
+	if !reflect.DeepEqual(driver.uploads, want) {
+		t.Errorf("expected uploads %v, got %v", want, driver.uploads)
+	}
+
+	// Files already on the node are not pushed when the execution resumes
+	s.closeExecArtifacts(execID)
+	s.newExecArtifacts(execID)
+	driver.reset()
+	if err := s.pushArtifactsWithDriver(ctx, driver, artifactDir, execID, ""); err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+	if len(driver.uploads) != 0 {
+		t.Errorf("expected no uploads after resuming, got %v", driver.uploads)
+	}
+}
+
+func TestMatchArtifact(t *testing.T) {
+	patterns := []string{"dist/*.tar.gz", "reports/**", "*.log"}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"dist/app.tar.gz", true},
+		{"dist/nested/app.tar.gz", false},
+		{"reports/index.html", true},
+		{"reports/coverage/unit.xml", true},
+		{"build.log", true},
+		{"logs/build.log", false},
+		{"run.sh", false},
+	}
+	for _, tt := range tests {
+		if got := matchArtifact(patterns, tt.path); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if key := artifactKey("ns", "exec", "build", "web1", "dist/app.tar.gz"); key != "ns/exec/build/web1/dist/app.tar.gz" {
+		t.Errorf("unexpected artifact key %s", key)
+	}
+}
+
+// artifactRecorder records the artifacts added to the store
+type artifactRecorder struct {
+	repo.Store
+	paths []string
+}
+
+func (a *artifactRecorder) AddExecutionArtifact(ctx context.Context, arg repo.AddExecutionArtifactParams) error {
+	a.paths = append(a.paths, arg.Path)
+	return nil
+}
+
+func TestStoreArtifacts(t *testing.T) {
+	ctx := context.Background()
+
+	local, err := executor.NewLocalLinux()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(local.GetWorkingDirectory()) })
+
+	bucket := memblob.OpenBucket(nil)
+	t.Cleanup(func() { bucket.Close() })
+
+	store := &artifactRecorder{}
+	s := &Scheduler{
+		store:          store,
+		artifactBucket: bucket,
+		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	// dist/old.tar.gz was pulled after an earlier action and is still in the artifact store
+	artifactDir := t.TempDir()
+	writeFile(t, filepath.Join(artifactDir, "local", "dist", "old.tar.gz"), "old")
+	writeFile(t, filepath.Join(artifactDir, "local", "dist", "app.tar.gz"), "app")
+	writeFile(t, filepath.Join(artifactDir, "local", "run.sh"), "echo hello")
+
+	pulled := map[string]string{"dist/app.tar.gz": "sum", "run.sh": "sum"}
+	action := Action{ID: "build", Artifacts: []string{"dist/*.tar.gz"}}
+	namespaceID := uuid.NewString()
+	if err := s.storeArtifacts(ctx, local, artifactDir, "exec", action, Node{}, namespaceID, pulled); err != nil {
+		t.Fatalf("storeArtifacts() error = %v", err)
+	}
+
+	if want := []string{"dist/app.tar.gz"}; !reflect.DeepEqual(store.paths, want) {
+		t.Errorf("stored %v, want %v", store.paths, want)
+	}
+	if ok, err := bucket.Exists(ctx, artifactKey(namespaceID, "exec", "build", "local", "dist/old.tar.gz")); err != nil || ok {
+		t.Errorf("artifact of an earlier action was uploaded again")
+	}
+	b, err := bucket.ReadAll(ctx, artifactKey(namespaceID, "exec", "build", "local", "dist/app.tar.gz"))
+	if err != nil || string(b) != "app" {
+		t.Errorf("unexpected stored artifact %q: %v", b, err)
+	}
+}
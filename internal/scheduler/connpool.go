@@ -0,0 +1,254 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/cvhariharan/flowctl/sdk/remoteclient"
+)
+
+const (
+	// connKeepAliveInterval is how often idle connections in a pool are checked
+	connKeepAliveInterval = 30 * time.Second
+	// connKeepAliveTimeout bounds a keepalive so that an unresponsive node is treated as disconnected
+	connKeepAliveTimeout = 10 * time.Second
+	// connMaxIdle is how long a connection is kept open without being used by an action
+	connMaxIdle = 5 * time.Minute
+)
+
+var errConnPoolClosed = errors.New("connection pool is closed")
+
+// connPool reuses connections to nodes across the actions of an execution so that
+// each action does not go through a new handshake. Idle connections are kept alive
+// and closed after connMaxIdle. A connection which dropped is replaced on the next use
+type connPool struct {
+	mu     sync.Mutex
+	conns  map[string]*pooledConn
+	closed bool
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+type pooledConn struct {
+	mu     sync.Mutex // Serializes dialing and health checks of the connection
+	client remoteclient.RemoteClient
+
+	// Guarded by connPool.mu
+	inUse    int
+	lastUsed time.Time
+}
+
+func newConnPool() *connPool {
+	p := &connPool{
+		conns:  make(map[string]*pooledConn),
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+	go p.keepAliveLoop(connKeepAliveInterval)
+	return p
+}
+
+// get returns a connection to the node identified by key, dialing a new one if there is
+// no connection or the existing one no longer responds. Closing the returned client
+// releases it back to the pool. A nil pool dials a connection which is not shared
+func (p *connPool) get(key string, dial func() (remoteclient.RemoteClient, error)) (remoteclient.RemoteClient, error) {
+	if p == nil {
+		return dial()
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, errConnPoolClosed
+	}
+	pc, ok := p.conns[key]
+	if !ok {
+		pc = &pooledConn{}
+		p.conns[key] = pc
+	}
+	pc.inUse++
+	p.mu.Unlock()
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	// The connection could have dropped since it was last used
+	if pc.client != nil {
+		if err := keepAlive(pc.client); err != nil {
+			pc.client.Close()
+			pc.client = nil
+		}
+	}
+
+	if pc.client == nil {
+		client, err := dial()
+		if err != nil {
+			p.release(pc)
+			return nil, err
+		}
+		pc.client = client
+	}
+
+	return &pooledClient{RemoteClient: pc.client, release: func() { p.release(pc) }}, nil
+}
+
+func (p *connPool) release(pc *pooledConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pc.inUse--
+	pc.lastUsed = time.Now()
+}
+
+// keepAliveLoop periodically closes connections idle for longer than connMaxIdle
+// and checks that the remaining idle connections are still open
+func (p *connPool) keepAliveLoop(interval time.Duration) {
+	defer close(p.doneCh)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case <-ticker.C:
+			p.checkIdle(connMaxIdle)
+		}
+	}
+}
+
+func (p *connPool) checkIdle(maxIdle time.Duration) {
+	var expired, idle []*pooledConn
+
+	p.mu.Lock()
+	for key, pc := range p.conns {
+		if pc.inUse > 0 {
+			continue
+		}
+		if time.Since(pc.lastUsed) > maxIdle {
+			// Removing the connection from the map prevents it from being handed out again
+			delete(p.conns, key)
+			expired = append(expired, pc)
+			continue
+		}
+		idle = append(idle, pc)
+	}
+	p.mu.Unlock()
+
+	for _, pc := range expired {
+		pc.mu.Lock()
+		if pc.client != nil {
+			pc.client.Close()
+			pc.client = nil
+		}
+		pc.mu.Unlock()
+	}
+
+	for _, pc := range idle {
+		pc.mu.Lock()
+		if pc.client != nil {
+			if err := keepAlive(pc.client); err != nil {
+				pc.client.Close()
+				pc.client = nil
+			}
+		}
+		pc.mu.Unlock()
+	}
+}
+
+// close stops the keepalives and closes all connections in the pool
+func (p *connPool) close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	conns := p.conns
+	p.conns = make(map[string]*pooledConn)
+	p.mu.Unlock()
+
+	close(p.stopCh)
+	<-p.doneCh
+
+	for _, pc := range conns {
+		pc.mu.Lock()
+		if pc.client != nil {
+			pc.client.Close()
+			pc.client = nil
+		}
+		pc.mu.Unlock()
+	}
+}
+
+// keepAlive checks that the connection of the client is still open.
+// Clients which do not support keepalives are assumed to be connected
+func keepAlive(client remoteclient.RemoteClient) error {
+	ka, ok := client.(remoteclient.KeepAliveClient)
+	if !ok {
+		return nil
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ka.KeepAlive()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(connKeepAliveTimeout):
+		return errors.New("keepalive timed out")
+	}
+}
+
+// pooledClient is a client borrowed from a connPool. Close returns it to the pool
+// instead of closing the connection
+type pooledClient struct {
+	remoteclient.RemoteClient
+	once    sync.Once
+	release func()
+}
+
+func (c *pooledClient) RunCommandWithOptions(ctx context.Context, command string, opts remoteclient.RunOptions, stdout, stderr io.Writer) error {
+	ic, ok := c.RemoteClient.(remoteclient.InteractiveClient)
+	if !ok {
+		return errors.New("remote client does not support interactive commands")
+	}
+	return ic.RunCommandWithOptions(ctx, command, opts, stdout, stderr)
+}
+
+func (c *pooledClient) Close() error {
+	c.once.Do(c.release)
+	return nil
+}
+
+// newExecConnPool creates the connection pool used by the actions of an execution
+func (s *Scheduler) newExecConnPool(execID string) *connPool {
+	pool := newConnPool()
+	s.connPoolsMu.Lock()
+	s.connPools[execID] = pool
+	s.connPoolsMu.Unlock()
+	return pool
+}
+
+// closeExecConnPool closes the connections opened during an execution
+func (s *Scheduler) closeExecConnPool(execID string) {
+	s.connPoolsMu.Lock()
+	pool := s.connPools[execID]
+	delete(s.connPools, execID)
+	s.connPoolsMu.Unlock()
+
+	if pool != nil {
+		pool.close()
+	}
+}
+
+func (s *Scheduler) execConnPool(execID string) *connPool {
+	s.connPoolsMu.Lock()
+	defer s.connPoolsMu.Unlock()
+	return s.connPools[execID]
+}
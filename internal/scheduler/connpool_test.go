@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/cvhariharan/flowctl/sdk/remoteclient"
+)
+
+// fakeConn is a remote client whose connection can be dropped
+type fakeConn struct {
+	dropped bool
+	closed  bool
+}
+
+func (f *fakeConn) RunCommand(ctx context.Context, command string, stdout, stderr io.Writer) error {
+	return nil
+}
+
+func (f *fakeConn) Download(ctx context.Context, remotePath, localPath string) error { return nil }
+
+func (f *fakeConn) Upload(ctx context.Context, localPath, remotePath string) error { return nil }
+
+func (f *fakeConn) Dial(network, address string) (net.Conn, error) { return nil, nil }
+
+func (f *fakeConn) KeepAlive() error {
+	if f.dropped {
+		return errors.New("connection lost")
+	}
+	return nil
+}
+
+func (f *fakeConn) Close() error {
+	f.closed = true
+	return nil
+}
+
+// fakeDialer counts the connections made to a node
+type fakeDialer struct {
+	conns []*fakeConn
+}
+
+func (d *fakeDialer) dial() (remoteclient.RemoteClient, error) {
+	c := &fakeConn{}
+	d.conns = append(d.conns, c)
+	return c, nil
+}
+
+func TestConnPoolReuse(t *testing.T) {
+	pool := newConnPool()
+	defer pool.close()
+
+	d := &fakeDialer{}
+	for i := 0; i < 3; i++ {
+		client, err := pool.get("node", d.dial)
+		if err != nil {
+			t.Fatalf("get returned error: %v", err)
+		}
+		client.Close()
+	}
+	if len(d.conns) != 1 {
+		t.Fatalf("expected one connection to be reused by all actions, got %d", len(d.conns))
+	}
+	if d.conns[0].closed {
+		t.Error("expected releasing a client to keep the connection open")
+	}
+
+	// A connection which dropped between actions is replaced
+	d.conns[0].dropped = true
+	client, err := pool.get("node", d.dial)
+	if err != nil {
+		t.Fatalf("get returned error: %v", err)
+	}
+	client.Close()
+	if len(d.conns) != 2 || !d.conns[0].closed {
+		t.Errorf("expected the dropped connection to be closed and redialed, got %d connections", len(d.conns))
+	}
+
+	pool.close()
+	if !d.conns[1].closed {
+		t.Error("expected closing the pool to close its connections")
+	}
+	if _, err := pool.get("node", d.dial); !errors.Is(err, errConnPoolClosed) {
+		t.Errorf("expected errConnPoolClosed from a closed pool, got %v", err)
+	}
+}
+
+func TestConnPoolCheckIdle(t *testing.T) {
+	pool := newConnPool()
+	defer pool.close()
+
+	idle, busy, dropped := &fakeDialer{}, &fakeDialer{}, &fakeDialer{}
+
+	client, err := pool.get("idle", idle.dial)
+	if err != nil {
+		t.Fatalf("get returned error: %v", err)
+	}
+	client.Close()
+
+	inUse, err := pool.get("busy", busy.dial)
+	if err != nil {
+		t.Fatalf("get returned error: %v", err)
+	}
+	defer inUse.Close()
+
+	client, err = pool.get("dropped", dropped.dial)
+	if err != nil {
+		t.Fatalf("get returned error: %v", err)
+	}
+	client.Close()
+	dropped.conns[0].dropped = true
+
+	// Connections are not expired yet but the dropped one fails its keepalive
+	pool.checkIdle(connMaxIdle)
+	if idle.conns[0].closed || busy.conns[0].closed {
+		t.Error("expected healthy connections to stay open")
+	}
+	if !dropped.conns[0].closed {
+		t.Error("expected the dropped connection to be closed")
+	}
+
+	// Every idle connection has expired, the one in use is kept
+	pool.checkIdle(0)
+	if !idle.conns[0].closed {
+		t.Error("expected the idle connection to be closed")
+	}
+	if busy.conns[0].closed {
+		t.Error("expected the connection in use to stay open")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +19,7 @@ import (
 	"github.com/cvhariharan/flowctl/internal/repo"
 	"github.com/cvhariharan/flowctl/internal/streamlogger"
 	"github.com/cvhariharan/flowctl/sdk/executor"
+	"github.com/cvhariharan/flowctl/sdk/remoteclient"
 	"github.com/expr-lang/expr"
 	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
@@ -58,6 +60,32 @@ func (s *Scheduler) executeFlow(ctx context.Context, payload FlowExecutionPayloa
 	}
 	defer streamLogger.Close()
 
+	// Acquire flow level locks, these are held until the execution finishes or pauses for approval
+	flowLocks, err := s.acquireLocks(ctx, lockRequest{
+		execID:      payload.ExecID,
+		flowID:      payload.Workflow.Meta.DBID,
+		namespaceID: payload.NamespaceID,
+		names:       payload.Workflow.Meta.Locks,
+		timeout:     payload.Workflow.Meta.LockTimeout,
+	}, streamLogger)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			streamLogger.Checkpoint("", "", "execution cancelled", streamlogger.CancelledMessageType)
+			return ErrExecutionCancelled
+		}
+		streamLogger.Checkpoint("", "", err.Error(), streamlogger.ErrMessageType)
+		return err
+	}
+	defer s.releaseLocks(payload.ExecID, payload.NamespaceID, flowLocks)
+
+	// Connections to nodes are reused by all the actions of the execution
+	s.newExecConnPool(payload.ExecID)
+	defer s.closeExecConnPool(payload.ExecID)
+
+	// Checksums of the artifacts pushed to and pulled from each node
+	s.newExecArtifacts(payload.ExecID)
+	defer s.closeExecArtifacts(payload.ExecID)
+
 	// Get flow-specific secrets
 	flowSecrets := s.getFlowSecrets(ctx, payload.Workflow.Meta.ID, payload.NamespaceID, payload.ExecID)
 
@@ -67,7 +95,7 @@ func (s *Scheduler) executeFlow(ctx context.Context, payload FlowExecutionPayloa
 	for i := payload.StartingActionIdx; i < len(payload.Workflow.Actions); i++ {
 		action := payload.Workflow.Actions[i]
 
-		res, err := s.executeSingleAction(ctx, action, payload.Workflow.Meta.SrcDir, payload.Input, streamLogger, artifactDir, flowSecrets, outputs, payload.ExecID, payload.NamespaceID)
+		res, err := s.executeSingleAction(ctx, action, payload.Workflow.Meta, payload.FlowDirectory, flowLocks, payload.Input, streamLogger, artifactDir, flowSecrets, outputs, payload.ExecID, payload.NamespaceID)
 		if err != nil {
 			return err
 		}
@@ -142,7 +170,7 @@ func (s *Scheduler) copyFlowFilesToArtifacts(flowDir string, artifactDir string)
 }
 
 // executeSingleAction executes a single action within a flow, handling approval and error checkpointing
-func (s *Scheduler) executeSingleAction(ctx context.Context, action Action, srcDir string, input map[string]any, streamLogger streamlogger.Logger, artifactDir string, secrets map[string]string, outputs map[string]any, execID string, namespaceID string) (map[string]string, error) {
+func (s *Scheduler) executeSingleAction(ctx context.Context, action Action, meta Metadata, flowDir string, flowLocks []string, input map[string]any, streamLogger streamlogger.Logger, artifactDir string, secrets map[string]string, outputs map[string]any, execID string, namespaceID string) (map[string]any, error) {
 	// Check for context cancellation
 	if ctx.Err() != nil {
 		if err := streamLogger.Checkpoint("", "", "execution cancelled", streamlogger.CancelledMessageType); err != nil {
@@ -156,8 +184,33 @@ func (s *Scheduler) executeSingleAction(ctx context.Context, action Action, srcD
 		return nil, err
 	}
 
+	// Acquire action level locks that are not already held by the flow
+	var actionLocks []string
+	for _, name := range action.Locks {
+		if !slices.Contains(flowLocks, name) {
+			actionLocks = append(actionLocks, name)
+		}
+	}
+	held, err := s.acquireLocks(ctx, lockRequest{
+		execID:      execID,
+		actionID:    action.ID,
+		flowID:      meta.DBID,
+		namespaceID: namespaceID,
+		names:       actionLocks,
+		timeout:     action.LockTimeout,
+	}, streamLogger)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			streamLogger.Checkpoint(action.ID, "", "execution cancelled", streamlogger.CancelledMessageType)
+			return nil, ErrExecutionCancelled
+		}
+		streamLogger.Checkpoint(action.ID, "", err.Error(), streamlogger.ErrMessageType)
+		return nil, err
+	}
+	defer s.releaseLocks(execID, namespaceID, held)
+
 	// Run the action
-	res, err := s.runAction(ctx, execID, action, srcDir, input, streamLogger, artifactDir, secrets, outputs)
+	res, err := s.runAction(ctx, execID, action, flowDir, input, streamLogger, artifactDir, secrets, outputs, namespaceID)
 	if err != nil {
 		// Check if the error is due to context cancellation
 		if errors.Is(err, context.Canceled) {
@@ -170,16 +223,17 @@ func (s *Scheduler) executeSingleAction(ctx context.Context, action Action, srcD
 		return nil, err
 	}
 
-	// Checkpoint successful result
-	if err := streamLogger.Checkpoint(action.ID, "", res, streamlogger.ResultMessageType); err != nil {
+	// Checkpoint successful result, values which are not strings are logged as JSON
+	if err := streamLogger.Checkpoint(action.ID, "", executor.StringOutputs(res), streamlogger.ResultMessageType); err != nil {
 		return nil, err
 	}
 
 	return res, nil
 }
 
-// processActionResults processes action results and updates the outputs map
-func processActionResults(results map[string]string, outputs map[string]interface{}) {
+// processActionResults processes action results and updates the outputs map.
+// Values keep their type so that lists and maps can be used directly in expressions
+func processActionResults(results map[string]any, outputs map[string]interface{}) {
 	for k, v := range results {
 		parts := strings.SplitN(k, "@", 2)
 		// node suffixed output
@@ -198,7 +252,7 @@ func processActionResults(results map[string]string, outputs map[string]interfac
 }
 
 // executeOnNode executes an action on a single node and returns the results
-func (s *Scheduler) executeOnNode(ctx context.Context, execID string, node Node, action Action, streamLogger streamlogger.Logger, inputVars map[string]interface{}, withConfig []byte, artifactDir string) ExecResults {
+func (s *Scheduler) executeOnNode(ctx context.Context, execID string, node Node, action Action, streamLogger streamlogger.Logger, inputVars map[string]interface{}, withConfig []byte, artifactDir string, flowDir string, namespaceID string) ExecResults {
 	nodeLogger := streamlogger.NewNodeContextLogger(streamLogger, action.ID, node.Name)
 
 	// Create a separate executor instance for each node
@@ -208,36 +262,59 @@ func (s *Scheduler) executeOnNode(ctx context.Context, execID string, node Node,
 		nodeExecutorID = action.ID
 	}
 
-	// Check if node is accessible
-	// Ignore local node
-	if node.Name != "" {
-		if err := node.CheckConnectivity(); err != nil {
-			s.logger.Debug("node connectivity", "error", err)
+	// Queue work on the node if it is already running the maximum number of actions
+	if !s.nodeLimiter.tryAcquire(node.ID, node.MaxConcurrency) {
+		start := time.Now()
+		nodeLogger.Checkpoint("", "", []byte(fmt.Sprintf("node %s is at its concurrency limit of %d, waiting for a free slot\n", node.Name, node.MaxConcurrency)), streamlogger.LogMessageType)
+		if err := s.nodeLimiter.acquire(ctx, node.ID, node.MaxConcurrency); err != nil {
 			return ExecResults{
 				result: nil,
-				err:    fmt.Errorf("failed to connect to node %s", node.Name),
+				err:    err,
 			}
 		}
+		nodeLogger.Checkpoint("", "", []byte(fmt.Sprintf("acquired slot on node %s after waiting %s\n", node.Name, time.Since(start).Round(time.Millisecond))), streamlogger.LogMessageType)
 	}
+	defer s.nodeLimiter.release(node.ID, node.MaxConcurrency)
 
-	// Convert task node to executor node
-	execNode := executor.Node{
-		Hostname:       node.Hostname,
-		Port:           node.Port,
-		Username:       node.Username,
-		ConnectionType: node.ConnectionType,
-		OSFamily:       node.OSFamily,
-		Auth: executor.NodeAuth{
-			Method: string(node.Auth.Method),
-			Key:    node.Auth.Key,
-		},
-	}
-
-	driver, err := executor.NewNodeDriver(ctx, execNode)
+	become, err := s.resolveBecome(ctx, action, node, namespaceID)
 	if err != nil {
 		return ExecResults{
 			result: nil,
-			err:    fmt.Errorf("failed to create node driver: %w", err),
+			err:    err,
+		}
+	}
+
+	var driver executor.NodeDriver
+	// Ignore local node
+	if node.Name == "" {
+		driver, err = executor.NewNodeDriver(ctx, executor.Node{Become: become})
+		if err != nil {
+			return ExecResults{
+				result: nil,
+				err:    fmt.Errorf("failed to create node driver: %w", err),
+			}
+		}
+	} else {
+		client, err := s.execConnPool(execID).get(node.ID, func() (remoteclient.RemoteClient, error) {
+			return s.connectNode(ctx, execID, action.ID, node, namespaceID)
+		})
+		if err != nil {
+			return ExecResults{
+				result: nil,
+				err:    err,
+			}
+		}
+
+		driver, err = executor.NewNodeDriverWithClient(client, executor.Node{
+			OSFamily: node.OSFamily,
+			Become:   become,
+		})
+		if err != nil {
+			client.Close()
+			return ExecResults{
+				result: nil,
+				err:    fmt.Errorf("failed to create node driver: %w", err),
+			}
 		}
 	}
 	defer driver.Close()
@@ -258,7 +335,7 @@ func (s *Scheduler) executeOnNode(ctx context.Context, execID string, node Node,
 	}
 
 	// Push existing artifacts to this node's executor before execution
-	if err := s.pushArtifactsWithDriver(ctx, driver, artifactDir, execID); err != nil {
+	if err := s.pushArtifactsWithDriver(ctx, driver, artifactDir, execID, node.ID); err != nil {
 		return ExecResults{
 			result: nil,
 			err:    fmt.Errorf("failed to push artifacts to node %s: %w", node.Name, err),
@@ -266,17 +343,21 @@ func (s *Scheduler) executeOnNode(ctx context.Context, execID string, node Node,
 	}
 
 	res, err := exec.Execute(ctx, executor.ExecutionContext{
-		ExecID:     execID,
-		Inputs:     inputVars,
-		WithConfig: withConfig,
-		Stdout:     nodeLogger,
-		Stderr:     nodeLogger,
+		ExecID:        execID,
+		Inputs:        inputVars,
+		WithConfig:    withConfig,
+		Stdout:        nodeLogger,
+		Stderr:        nodeLogger,
+		FlowDirectory: flowDir,
+		GetCredential: s.credentialGetter(namespaceID),
 	})
 
-	// Pull all artifacts from this node after execution
+	// Pull all artifacts from this node after execution and store the artifacts declared by the action
 	if err == nil {
-		if pullErr := s.pullArtifactsWithDriver(ctx, driver, artifactDir, execID, node.Name); pullErr != nil {
+		if pulled, pullErr := s.pullArtifactsWithDriver(ctx, driver, artifactDir, execID, node); pullErr != nil {
 			err = fmt.Errorf("execution succeeded but failed to pull artifacts: %w", pullErr)
+		} else if storeErr := s.storeArtifacts(ctx, driver, artifactDir, execID, action, node, namespaceID, pulled); storeErr != nil {
+			err = fmt.Errorf("execution succeeded but failed to store artifacts: %w", storeErr)
 		}
 	}
 
@@ -289,9 +370,166 @@ func (s *Scheduler) executeOnNode(ctx context.Context, execID string, node Node,
 	}
 }
 
+// connectNode opens a new connection to a remote node. Certificates are issued for the
+// connection when the node authenticates with a certificate authority
+func (s *Scheduler) connectNode(ctx context.Context, execID string, actionID string, node Node, namespaceID string) (remoteclient.RemoteClient, error) {
+	node, err := s.issueCertificates(ctx, execID, actionID, node, namespaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if node is accessible
+	if err := node.CheckConnectivity(); err != nil {
+		s.logger.Debug("node connectivity", "error", err)
+		// Report the hop that failed when the node is reached through jump hosts
+		if node.JumpHost != nil {
+			var hkErr *remoteclient.HostKeyError
+			if errors.As(err, &hkErr) {
+				err = s.handleUntrustedHostKey(ctx, failedHop(node, err), namespaceID, hkErr)
+			}
+			return nil, fmt.Errorf("failed to connect to node %s: %w", node.Name, err)
+		}
+		return nil, fmt.Errorf("failed to connect to node %s", node.Name)
+	}
+
+	client, err := executor.Connect(executor.Node{
+		Hostname:       node.Hostname,
+		Port:           node.Port,
+		Username:       node.Username,
+		ConnectionType: node.ConnectionType,
+		OSFamily:       node.OSFamily,
+		Auth: executor.NodeAuth{
+			Method:      string(node.Auth.Method),
+			Key:         node.Auth.Key,
+			Certificate: node.Auth.Certificate,
+		},
+		HostKey:  node.HostKey,
+		JumpHost: node.remoteJumpHost(),
+	})
+	if err != nil {
+		var hkErr *remoteclient.HostKeyError
+		if errors.As(err, &hkErr) {
+			hop := failedHop(node, err)
+			err := s.handleUntrustedHostKey(ctx, hop, namespaceID, hkErr)
+			if hop.ID != node.ID {
+				err = fmt.Errorf("failed to connect to node %s through jump host: %w", node.Name, err)
+			}
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create node driver: %w", err)
+	}
+	return client, nil
+}
+
+// resolveBecome merges the privilege escalation settings of the action with the defaults of the node.
+// nil is returned when the action runs as the login user of the node
+func (s *Scheduler) resolveBecome(ctx context.Context, action Action, node Node, namespaceID string) (*executor.Become, error) {
+	enabled := node.Become.Enabled
+	if action.Become != nil {
+		enabled = *action.Become
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	become := &executor.Become{
+		User:     node.Become.User,
+		Method:   node.Become.Method,
+		Password: node.Become.Password,
+	}
+	if action.BecomeUser != "" {
+		become.User = action.BecomeUser
+	}
+	if action.BecomeMethod != "" {
+		become.Method = action.BecomeMethod
+	}
+	if action.BecomeCredential != "" {
+		if s.becomeProvider == nil {
+			return nil, fmt.Errorf("could not get become credential %s: credentials are not available", action.BecomeCredential)
+		}
+		password, err := s.becomeProvider(ctx, action.BecomeCredential, namespaceID)
+		if err != nil {
+			return nil, fmt.Errorf("could not get become credential %s: %w", action.BecomeCredential, err)
+		}
+		become.Password = password
+	}
+
+	return become, nil
+}
+
+// issueCertificates signs a short-lived certificate for the node and each of its jump hosts which authenticate
+// using an SSH CA. The certificates are recorded on the execution before they are used, certificates issued
+// for health checks are not recorded as they do not belong to an execution
+func (s *Scheduler) issueCertificates(ctx context.Context, execID string, actionID string, node Node, namespaceID string) (Node, error) {
+	if node.JumpHost != nil {
+		jumpHost, err := s.issueCertificates(ctx, execID, actionID, *node.JumpHost, namespaceID)
+		if err != nil {
+			return Node{}, err
+		}
+		node.JumpHost = &jumpHost
+	}
+
+	if node.Auth.Method != AuthMethodSSHCA {
+		return node, nil
+	}
+
+	keyID := fmt.Sprintf("flowctl:%s:%s:%s", execID, actionID, node.Name)
+	cert, err := remoteclient.SignUserCertificate(node.Auth.Key, keyID, []string{node.Username}, remoteclient.CertificateValidity)
+	if err != nil {
+		return Node{}, fmt.Errorf("could not sign certificate for node %s: %w", node.Name, err)
+	}
+
+	if s.certRecorder != nil && execID != healthCheckExecID {
+		if err := s.certRecorder(ctx, namespaceID, SSHCertificate{
+			ExecID:      execID,
+			ActionID:    actionID,
+			NodeName:    node.Name,
+			Serial:      cert.Serial,
+			KeyID:       cert.KeyID,
+			Principals:  cert.Principals,
+			ValidAfter:  cert.ValidAfter,
+			ValidBefore: cert.ValidBefore,
+		}); err != nil {
+			return Node{}, fmt.Errorf("could not record certificate for node %s: %w", node.Name, err)
+		}
+	}
+
+	node.Auth = NodeAuth{
+		CredentialID: node.Auth.CredentialID,
+		Method:       AuthMethodCertificate,
+		Key:          cert.PrivateKey,
+		Certificate:  cert.Certificate,
+	}
+	return node, nil
+}
+
+// handleUntrustedHostKey records the host key presented by the node so that it can be approved from the node API
+func (s *Scheduler) handleUntrustedHostKey(ctx context.Context, node Node, namespaceID string, hkErr *remoteclient.HostKeyError) error {
+	if s.hostKeyRecorder != nil {
+		if err := s.hostKeyRecorder(ctx, node.ID, namespaceID, hkErr.Key); err != nil {
+			s.logger.Error("failed to record host key", "node", node.Name, "error", err)
+		}
+	}
+
+	if hkErr.Mismatch() {
+		return fmt.Errorf("refusing to connect to node %s: %w", node.Name, hkErr)
+	}
+	return fmt.Errorf("host key %s of node %s is not trusted, approve it from the node settings or import a known_hosts file", hkErr.Fingerprint, node.Name)
+}
+
+// credentialGetter returns a function used by executors to read credentials from the namespace of the execution
+func (s *Scheduler) credentialGetter(namespaceID string) func(ctx context.Context, name string, keyType string) (executor.Credential, error) {
+	return func(ctx context.Context, name string, keyType string) (executor.Credential, error) {
+		if s.credProvider == nil {
+			return executor.Credential{}, fmt.Errorf("credentials are not available")
+		}
+		return s.credProvider(ctx, name, keyType, namespaceID)
+	}
+}
+
 // prefixResultKeys adds node name suffix to result keys for node-specific outputs
-func prefixResultKeys(results map[string]string, nodeName string) map[string]string {
-	prefixedRes := make(map[string]string)
+func prefixResultKeys(results map[string]any, nodeName string) map[string]any {
+	prefixedRes := make(map[string]any)
 	for key, value := range results {
 		// Format key as valid environment variable (replace special chars with _)
 		prefixedKey := regexp.MustCompile(`[^a-zA-Z0-9_]+`).ReplaceAllString(key, "_")
@@ -338,8 +576,28 @@ func (s *Scheduler) interpolateVariables(action Action, input map[string]interfa
 	return inputVars, nil
 }
 
+// evaluateCondition runs the if expression of an action, the expression must return a boolean
+func evaluateCondition(condition string, env map[string]any) (bool, error) {
+	program, err := expr.Compile(condition, expr.Env(env), expr.AsBool())
+	if err != nil {
+		return false, fmt.Errorf("failed to compile expression: %w", err)
+	}
+
+	output, err := expr.Run(program, env)
+	if err != nil {
+		return false, fmt.Errorf("failed to run expression: %w", err)
+	}
+
+	// The type of values from maps is only known when the expression is run
+	result, ok := output.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression returned %T, expected bool", output)
+	}
+	return result, nil
+}
+
 // runAction executes a single action
-func (s *Scheduler) runAction(ctx context.Context, execID string, action Action, srcdir string, input map[string]interface{}, streamLogger streamlogger.Logger, artifactDir string, secrets map[string]string, outputs map[string]interface{}) (map[string]string, error) {
+func (s *Scheduler) runAction(ctx context.Context, execID string, action Action, flowDir string, input map[string]interface{}, streamLogger streamlogger.Logger, artifactDir string, secrets map[string]string, outputs map[string]interface{}, namespaceID string) (map[string]any, error) {
 	streamLogger.SetActionID(action.ID)
 
 	jobCtx, cancel := context.WithTimeout(ctx, time.Hour)
@@ -360,14 +618,49 @@ func (s *Scheduler) runAction(ctx context.Context, execID string, action Action,
 		action.On = append(action.On, Node{})
 	}
 
-	var wg sync.WaitGroup
-	resChan := make(chan ExecResults, len(action.On))
+	// Facts are used by conditions and to fail fast on nodes which failed their last health check
+	facts, err := s.nodeFacts(ctx, action.On)
+	if err != nil {
+		return nil, err
+	}
 
+	var nodes []Node
 	for _, node := range action.On {
+		nodeFacts, ok := facts[node.ID]
+		if action.If != "" {
+			run, err := evaluateCondition(action.If, map[string]any{
+				"inputs":  input,
+				"secrets": secrets,
+				"outputs": outputs,
+				"node":    nodeEnv(node, nodeFacts),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate condition of action %s: %w", action.ID, err)
+			}
+			if !run {
+				msg := fmt.Sprintf("skipping action on node %s, condition %q is false\n", node.Name, action.If)
+				if node.Name == "" {
+					msg = fmt.Sprintf("skipping action, condition %q is false\n", action.If)
+				}
+				streamlogger.NewNodeContextLogger(streamLogger, action.ID, node.Name).Checkpoint("", "", []byte(msg), streamlogger.LogMessageType)
+				continue
+			}
+		}
+
+		if err := s.checkReachable(node, nodeFacts, ok); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	var wg sync.WaitGroup
+	resChan := make(chan ExecResults, len(nodes))
+
+	for _, node := range nodes {
 		wg.Add(1)
 		go func(node Node) {
 			defer wg.Done()
-			result := s.executeOnNode(jobCtx, execID, node, action, streamLogger, inputVars, withConfig, artifactDir)
+			result := s.executeOnNode(jobCtx, execID, node, action, streamLogger, inputVars, withConfig, artifactDir, flowDir, namespaceID)
 			resChan <- result
 		}(node)
 	}
@@ -376,7 +669,7 @@ func (s *Scheduler) runAction(ctx context.Context, execID string, action Action,
 	close(resChan)
 
 	// Merge all results into a single map
-	mergedResults := make(map[string]string)
+	mergedResults := make(map[string]any)
 	for res := range resChan {
 		if res.err != nil {
 			// Check if any executor returned a context cancellation error
@@ -391,80 +684,6 @@ func (s *Scheduler) runAction(ctx context.Context, execID string, action Action,
 	return mergedResults, nil
 }
 
-// pushArtifactsWithDriver pushes files from the local artifact directory to the remote artifacts directory
-// Only pushes direct child files of top-level directories (one level deep)
-func (s *Scheduler) pushArtifactsWithDriver(ctx context.Context, driver executor.NodeDriver, artifactDir string, execID string) error {
-	remoteArtifactsDir := driver.Join(driver.TempDir(), fmt.Sprintf("artifacts-%s", execID))
-	s.logger.Debug("remote artifacts directory", "pushdir", remoteArtifactsDir)
-
-	// Read top-level entries in artifact directory
-	entries, err := os.ReadDir(artifactDir)
-	if err != nil {
-		return err
-	}
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			dirPath := filepath.Join(artifactDir, entry.Name())
-			s.logger.Debug("processing top-level directory", "pushdirentry", dirPath)
-
-			childEntries, err := os.ReadDir(dirPath)
-			if err != nil {
-				return err
-			}
-
-			for _, child := range childEntries {
-				if !child.IsDir() {
-					info, _ :=  child.Info()
-					s.logger.Debug("file size", "filesize", info.Size())
-					localPath := filepath.Join(dirPath, child.Name())
-					remotePath := driver.Join(remoteArtifactsDir, entry.Name(), child.Name())
-					s.logger.Debug("pushing artifact file", "localPath", localPath, "remotePath", remotePath)
-					if err := driver.Upload(ctx, localPath, remotePath); err != nil {
-						return fmt.Errorf("failed to push artifact %s: %w", localPath, err)
-					}
-				}
-			}
-		}
-	}
-
-	return nil
-}
-
-// pullArtifactsWithDriver downloads all files from the remote artifacts directory to the local artifact directory
-func (s *Scheduler) pullArtifactsWithDriver(ctx context.Context, driver executor.NodeDriver, artifactDir string, execID string, nodeName string) error {
-	remoteArtifactsDir := driver.Join(driver.TempDir(), fmt.Sprintf("artifacts-%s", execID))
-	s.logger.Debug("remote artifacts directory", "pulldir", remoteArtifactsDir)
-	files, err := driver.ListFiles(ctx, remoteArtifactsDir)
-	if err != nil {
-		// If the directory doesn't exist, there are no artifacts to pull
-		s.logger.Debug("no artifacts to pull", "remoteDir", remoteArtifactsDir, "error", err)
-		return nil
-	}
-
-	for _, file := range files {
-		remotePath := driver.Join(remoteArtifactsDir, file)
-
-		var localPath string
-		if driver.IsRemote() {
-			// Remote execution then store in nodeName subdirectory
-			localPath = filepath.Join(artifactDir, nodeName, file)
-		} else {
-			// Local execution then store in local subdirectory
-			localPath = filepath.Join(artifactDir, "local", file)
-		}
-
-		if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
-			return fmt.Errorf("failed to create directory for artifact %s: %w", file, err)
-		}
-
-		if err := driver.Download(ctx, remotePath, localPath); err != nil {
-			return fmt.Errorf("failed to pull artifact %s from node %s: %w", file, nodeName, err)
-		}
-	}
-	return nil
-}
-
 func (s *Scheduler) checkApproval(ctx context.Context, execID string, action Action, namespaceID string) error {
 	// use parent exec ID if available for approval requests
 	eID := execID
@@ -0,0 +1,274 @@
+package scheduler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cvhariharan/flowctl/internal/repo"
+	"github.com/google/uuid"
+)
+
+const (
+	// healthCheckConcurrency bounds the number of nodes an instance checks at the same time
+	healthCheckConcurrency = 10
+	healthCheckTimeout     = 30 * time.Second
+
+	// Health checks use these IDs in place of an execution and action in the key ID of the
+	// certificates issued for the check. These certificates are not recorded
+	healthCheckExecID   = "health-check"
+	healthCheckActionID = "facts"
+)
+
+// factsScript prints the facts of a POSIX node as key=value lines. Facts which
+// cannot be read on the node are left empty
+const factsScript = `if [ -r /etc/os-release ]; then . /etc/os-release; echo "os=${PRETTY_NAME:-$NAME}"; else echo "os=$(uname -s)"; fi
+echo "kernel=$(uname -r)"
+echo "arch=$(uname -m)"
+[ -r /proc/uptime ] && echo "uptime=$(cut -d. -f1 /proc/uptime)"
+echo "disk_free=$(df -Pk / 2>/dev/null | awk 'NR==2 {print $4}')"
+exit 0`
+
+// NodeFacts are the facts recorded by the last health check of a node
+type NodeFacts struct {
+	Reachable bool
+	Error     string
+	Latency   time.Duration
+	OS        string
+	Kernel    string
+	Arch      string
+	Uptime    time.Duration
+	DiskFree  int64
+	CheckedAt time.Time
+}
+
+// checkNodeHealth gathers the facts of nodes which are due for a health check in the background.
+// A check is skipped while the previous one is still running
+func (s *Scheduler) checkNodeHealth(ctx context.Context) {
+	if s.nodeLoader == nil || s.healthInterval <= 0 || !s.healthChecking.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer s.healthChecking.Store(false)
+		if err := s.gatherNodeFacts(ctx); err != nil {
+			s.logger.Error("error checking node health", "error", err)
+		}
+	}()
+}
+
+// gatherNodeFacts claims the nodes which are due for a health check and records their facts
+func (s *Scheduler) gatherNodeFacts(ctx context.Context) error {
+	claimed, err := s.store.ClaimNodesForHealthCheck(ctx, int32(s.healthInterval/time.Second))
+	if err != nil {
+		return fmt.Errorf("could not claim nodes for health check: %w", err)
+	}
+
+	byNamespace := make(map[string][]string)
+	for _, c := range claimed {
+		namespaceID := c.NamespaceUuid.String()
+		byNamespace[namespaceID] = append(byNamespace[namespaceID], c.Name)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, healthCheckConcurrency)
+	for namespaceID, names := range byNamespace {
+		nodes, err := s.nodeLoader(ctx, names, namespaceID)
+		if err != nil {
+			s.logger.Error("could not load nodes for health check", "namespace", namespaceID, "error", err)
+			continue
+		}
+
+		for _, node := range nodes {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(node Node, namespaceID string) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				s.recordNodeFacts(ctx, node, namespaceID)
+			}(node, namespaceID)
+		}
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// recordNodeFacts checks a node and records its facts. The facts of the last successful
+// check are kept when the node is unreachable
+func (s *Scheduler) recordNodeFacts(ctx context.Context, node Node, namespaceID string) {
+	nodeUUID, err := uuid.Parse(node.ID)
+	if err != nil {
+		s.logger.Error("invalid node ID", "node", node.Name, "error", err)
+		return
+	}
+
+	facts, err := s.gatherFacts(ctx, node, namespaceID)
+	if err != nil {
+		s.logger.Debug("node health check failed", "node", node.Name, "error", err)
+		if err := s.store.SetNodeUnreachable(ctx, repo.SetNodeUnreachableParams{
+			Uuid:  nodeUUID,
+			Error: err.Error(),
+		}); err != nil {
+			s.logger.Error("could not record node health", "node", node.Name, "error", err)
+		}
+		return
+	}
+
+	if err := s.store.SetNodeFacts(ctx, repo.SetNodeFactsParams{
+		Uuid:          nodeUUID,
+		LatencyMs:     int32(facts.Latency.Milliseconds()),
+		Os:            facts.OS,
+		Kernel:        facts.Kernel,
+		Arch:          facts.Arch,
+		UptimeSeconds: int64(facts.Uptime / time.Second),
+		DiskFreeBytes: facts.DiskFree,
+	}); err != nil {
+		s.logger.Error("could not record node facts", "node", node.Name, "error", err)
+	}
+}
+
+// gatherFacts connects to the node, measures the round trip of a command and reads the facts of the node.
+// An error is returned only if the node cannot be reached
+func (s *Scheduler) gatherFacts(ctx context.Context, node Node, namespaceID string) (NodeFacts, error) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	client, err := s.connectNode(ctx, healthCheckExecID, healthCheckActionID, node, namespaceID)
+	if err != nil {
+		return NodeFacts{}, err
+	}
+	defer client.Close()
+
+	start := time.Now()
+	if err := client.RunCommand(ctx, "exit 0", io.Discard, io.Discard); err != nil {
+		return NodeFacts{}, fmt.Errorf("failed to run command on node %s: %w", node.Name, err)
+	}
+	facts := NodeFacts{
+		Reachable: true,
+		Latency:   time.Since(start),
+	}
+
+	// The facts script requires a POSIX shell
+	if node.OSFamily == "windows" {
+		return facts, nil
+	}
+
+	var stdout bytes.Buffer
+	if err := client.RunCommand(ctx, factsScript, &stdout, io.Discard); err != nil {
+		s.logger.Warn("could not gather node facts", "node", node.Name, "error", err)
+		return facts, nil
+	}
+	parseFacts(stdout.String(), &facts)
+
+	return facts, nil
+}
+
+// parseFacts reads the output of factsScript into facts, unknown keys and invalid values are ignored
+func parseFacts(output string, facts *NodeFacts) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		switch key {
+		case "os":
+			facts.OS = value
+		case "kernel":
+			facts.Kernel = value
+		case "arch":
+			facts.Arch = value
+		case "uptime":
+			if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+				facts.Uptime = time.Duration(secs) * time.Second
+			}
+		case "disk_free":
+			if kb, err := strconv.ParseInt(value, 10, 64); err == nil {
+				facts.DiskFree = kb * 1024
+			}
+		}
+	}
+}
+
+// nodeFacts returns the recorded facts of the nodes keyed by node ID.
+// Nodes which have not been checked yet are not included
+func (s *Scheduler) nodeFacts(ctx context.Context, nodes []Node) (map[string]NodeFacts, error) {
+	facts := make(map[string]NodeFacts)
+
+	var ids []uuid.UUID
+	for _, node := range nodes {
+		if id, err := uuid.Parse(node.ID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 || s.store == nil {
+		return facts, nil
+	}
+
+	rows, err := s.store.GetNodeFactsByUUIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("could not get node facts: %w", err)
+	}
+
+	for _, r := range rows {
+		if !r.CheckedAt.Valid {
+			continue
+		}
+		facts[r.NodeUuid.String()] = NodeFacts{
+			Reachable: r.Reachable.Bool,
+			Error:     r.Error,
+			Latency:   time.Duration(r.LatencyMs) * time.Millisecond,
+			OS:        r.Os,
+			Kernel:    r.Kernel,
+			Arch:      r.Arch,
+			Uptime:    time.Duration(r.UptimeSeconds) * time.Second,
+			DiskFree:  r.DiskFreeBytes,
+			CheckedAt: r.CheckedAt.Time,
+		}
+	}
+
+	return facts, nil
+}
+
+// checkReachable returns an error if the last health check of the node failed. Checks older
+// than two intervals are ignored as health checks may have stopped running
+func (s *Scheduler) checkReachable(node Node, facts NodeFacts, ok bool) error {
+	if !ok || facts.Reachable || s.healthInterval <= 0 {
+		return nil
+	}
+
+	since := time.Since(facts.CheckedAt)
+	if since > 2*s.healthInterval {
+		return nil
+	}
+	return fmt.Errorf("node %s is unreachable, health check %s ago failed: %s", node.Name, since.Round(time.Second), facts.Error)
+}
+
+// nodeEnv returns the node variables available to action conditions
+func nodeEnv(node Node, facts NodeFacts) map[string]any {
+	return map[string]any{
+		"name":     node.Name,
+		"hostname": node.Hostname,
+		"tags":     node.Tags,
+		"facts": map[string]any{
+			"reachable":       facts.Reachable,
+			"latency_ms":      facts.Latency.Milliseconds(),
+			"os":              facts.OS,
+			"kernel":          facts.Kernel,
+			"arch":            facts.Arch,
+			"uptime_seconds":  int64(facts.Uptime / time.Second),
+			"disk_free_bytes": facts.DiskFree,
+		},
+	}
+}
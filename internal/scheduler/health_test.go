@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestParseFacts(t *testing.T) {
+	output := "os=Ubuntu 24.04 LTS\nkernel=6.8.0-45-generic\narch=x86_64\nuptime=3600\ndisk_free=2048\nunknown=1\n"
+
+	var facts NodeFacts
+	parseFacts(output, &facts)
+
+	want := NodeFacts{
+		OS:       "Ubuntu 24.04 LTS",
+		Kernel:   "6.8.0-45-generic",
+		Arch:     "x86_64",
+		Uptime:   time.Hour,
+		DiskFree: 2048 * 1024,
+	}
+	if facts != want {
+		t.Errorf("unexpected facts:\n got %+v\nwant %+v", facts, want)
+	}
+
+	// Facts which cannot be read are left empty
+	facts = NodeFacts{}
+	parseFacts("os=Darwin\nkernel=23.0.0\narch=arm64\ndisk_free=\n", &facts)
+	if facts.OS != "Darwin" || facts.Uptime != 0 || facts.DiskFree != 0 {
+		t.Errorf("unexpected facts: %+v", facts)
+	}
+}
+
+func TestEvaluateCondition(t *testing.T) {
+	node := Node{Name: "web1", Hostname: "10.0.0.1", Tags: []string{"web", "prod"}}
+	facts := NodeFacts{Reachable: true, OS: "Debian GNU/Linux 12", DiskFree: 10 << 30}
+
+	env := map[string]any{
+		"inputs":  map[string]any{"env": "prod"},
+		"secrets": map[string]string{},
+		"outputs": map[string]any{},
+		"node":    nodeEnv(node, facts),
+	}
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{`node.facts.reachable`, true},
+		{`"web" in node.tags && inputs.env == "prod"`, true},
+		{`node.facts.disk_free_bytes < 1024 * 1024 * 1024`, false},
+		{`node.facts.os startsWith "Debian"`, true},
+		{`node.name == "db1"`, false},
+	}
+	for _, tt := range tests {
+		got, err := evaluateCondition(tt.condition, env)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.condition, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.condition, got, tt.want)
+		}
+	}
+
+	if _, err := evaluateCondition(`node.name`, env); err == nil {
+		t.Error("expected an error for a condition which does not return a boolean")
+	}
+}
+
+func TestCheckReachable(t *testing.T) {
+	s := &Scheduler{healthInterval: 5 * time.Minute}
+	node := Node{Name: "web1"}
+
+	failed := NodeFacts{Error: "connection refused", CheckedAt: time.Now().Add(-time.Minute)}
+	err := s.checkReachable(node, failed, true)
+	if err == nil || !strings.Contains(err.Error(), "node web1 is unreachable") || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("expected an unreachable error, got %v", err)
+	}
+
+	if err := s.checkReachable(node, NodeFacts{}, false); err != nil {
+		t.Errorf("nodes which have not been checked should be tried, got %v", err)
+	}
+
+	stale := NodeFacts{Error: "connection refused", CheckedAt: time.Now().Add(-time.Hour)}
+	if err := s.checkReachable(node, stale, true); err != nil {
+		t.Errorf("stale checks should be ignored, got %v", err)
+	}
+
+	s.healthInterval = 0
+	if err := s.checkReachable(node, failed, true); err != nil {
+		t.Errorf("checks should be ignored when health checks are disabled, got %v", err)
+	}
+}
+
+func TestIssueCertificatesRecording(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var recorded []SSHCertificate
+	s := &Scheduler{
+		certRecorder: func(ctx context.Context, namespaceID string, cert SSHCertificate) error {
+			recorded = append(recorded, cert)
+			return nil
+		},
+	}
+
+	node := Node{Name: "web1", Username: "deploy", Auth: NodeAuth{Method: AuthMethodSSHCA, Key: string(pem.EncodeToMemory(block))}}
+	for _, execID := range []string{healthCheckExecID, "exec"} {
+		issued, err := s.issueCertificates(context.Background(), execID, healthCheckActionID, node, "")
+		if err != nil {
+			t.Fatalf("issueCertificates(%s) error = %v", execID, err)
+		}
+		if issued.Auth.Method != AuthMethodCertificate {
+			t.Errorf("issueCertificates(%s) did not issue a certificate", execID)
+		}
+	}
+
+	// Only the certificate issued for the execution is recorded
+	if len(recorded) != 1 || recorded[0].ExecID != "exec" {
+		t.Errorf("recorded %v, want only the certificate of the execution", recorded)
+	}
+}
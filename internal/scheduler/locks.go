@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/cvhariharan/flowctl/internal/repo"
+	"github.com/cvhariharan/flowctl/internal/streamlogger"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// lockPollInterval is how often a waiter checks whether it can acquire the lock
+var lockPollInterval = time.Second
+
+var (
+	ErrLockHeld    = errors.New("resource lock is held by another execution")
+	ErrLockTimeout = errors.New("timed out waiting for resource lock")
+)
+
+// lockRequest describes a set of named locks to be acquired for an execution or one of its actions
+type lockRequest struct {
+	execID      string
+	actionID    string
+	flowID      int32
+	namespaceID string
+	names       []string
+	timeout     time.Duration
+}
+
+// acquireLocks acquires all the named locks in the request. Locks are acquired in sorted order
+// to avoid deadlocks between executions requesting overlapping sets of locks.
+// If the timeout is zero, the call fails fast when any lock is held by another execution, otherwise it waits
+// for the lock to be released. Requests queued behind older waiters of a free lock wait for their turn either way.
+// On failure, any lock acquired as part of this request is released.
+func (s *Scheduler) acquireLocks(ctx context.Context, req lockRequest, streamLogger streamlogger.Logger) ([]string, error) {
+	if len(req.names) == 0 {
+		return nil, nil
+	}
+
+	namespaceUUID, err := uuid.Parse(req.namespaceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	names := slices.Clone(req.names)
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	var deadline time.Time
+	if req.timeout > 0 {
+		deadline = time.Now().Add(req.timeout)
+	}
+
+	acquired := make([]string, 0, len(names))
+	for _, name := range names {
+		if err := s.acquireLock(ctx, req, namespaceUUID, name, deadline, streamLogger); err != nil {
+			s.releaseLocks(req.execID, req.namespaceID, acquired)
+			return nil, err
+		}
+		acquired = append(acquired, name)
+	}
+
+	return acquired, nil
+}
+
+// acquireLock queues the execution as a waiter for the lock and promotes it to holder once
+// the lock is free and no older waiter exists.
+func (s *Scheduler) acquireLock(ctx context.Context, req lockRequest, namespaceUUID uuid.UUID, name string, deadline time.Time, streamLogger streamlogger.Logger) error {
+	waiter, err := s.store.AddResourceLockWaiter(ctx, repo.AddResourceLockWaiterParams{
+		Name:     name,
+		ExecID:   req.execID,
+		ActionID: sql.NullString{String: req.actionID, Valid: req.actionID != ""},
+		FlowID:   req.flowID,
+		Uuid:     namespaceUUID,
+	})
+	if err != nil {
+		return fmt.Errorf("could not request lock %q: %w", name, err)
+	}
+
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	logged := false
+	for {
+		ok, err := s.tryAcquireLock(ctx, waiter.ID)
+		if err != nil {
+			s.removeLockWaiter(waiter.ID)
+			return fmt.Errorf("could not acquire lock %q: %w", name, err)
+		}
+		if ok {
+			return nil
+		}
+
+		holder, held := s.lockHolder(ctx, name, namespaceUUID)
+		if held && deadline.IsZero() {
+			s.removeLockWaiter(waiter.ID)
+			return fmt.Errorf("%w: lock %q%s", ErrLockHeld, name, holder)
+		}
+
+		if !logged {
+			msg := fmt.Sprintf("waiting for lock %q%s\n", name, holder)
+			if err := streamLogger.Checkpoint(req.actionID, "", []byte(msg), streamlogger.LogMessageType); err != nil {
+				s.logger.Error("failed to send lock wait message", "execID", req.execID, "error", err)
+			}
+			logged = true
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			s.removeLockWaiter(waiter.ID)
+			return fmt.Errorf("%w %q%s", ErrLockTimeout, name, holder)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.removeLockWaiter(waiter.ID)
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// tryAcquireLock attempts to promote the waiter to the lock holder
+func (s *Scheduler) tryAcquireLock(ctx context.Context, waiterID int32) (bool, error) {
+	rows, err := s.store.AcquireResourceLock(ctx, waiterID)
+	if err != nil {
+		// Concurrent promotions are guarded by a unique index on held locks
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return false, nil
+		}
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// lockHolder returns a description of the current lock holder to be used in messages and whether the
+// lock is held. A lock which is not held has older waiters queued for it
+func (s *Scheduler) lockHolder(ctx context.Context, name string, namespaceUUID uuid.UUID) (string, bool) {
+	holder, err := s.store.GetResourceLockHolder(ctx, repo.GetResourceLockHolderParams{
+		Name: name,
+		Uuid: namespaceUUID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ", queued behind other executions", false
+	}
+	if err != nil {
+		return "", true
+	}
+	return fmt.Sprintf(" held by execution %s", holder.ExecID), true
+}
+
+// removeLockWaiter removes a waiter entry. A background context is used so that cleanup
+// happens even when the execution is cancelled.
+func (s *Scheduler) removeLockWaiter(id int32) {
+	if err := s.store.DeleteResourceLock(context.Background(), id); err != nil {
+		s.logger.Error("failed to remove lock waiter", "id", id, "error", err)
+	}
+}
+
+// releaseLocks releases the named locks held by an execution
+func (s *Scheduler) releaseLocks(execID string, namespaceID string, names []string) {
+	if len(names) == 0 {
+		return
+	}
+
+	namespaceUUID, err := uuid.Parse(namespaceID)
+	if err != nil {
+		s.logger.Error("invalid namespace UUID while releasing locks", "execID", execID, "error", err)
+		return
+	}
+
+	if err := s.store.ReleaseResourceLocks(context.Background(), repo.ReleaseResourceLocksParams{
+		ExecID:  execID,
+		Column2: names,
+		Uuid:    namespaceUUID,
+	}); err != nil {
+		s.logger.Error("failed to release locks", "execID", execID, "locks", names, "error", err)
+	}
+}
@@ -0,0 +1,263 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cvhariharan/flowctl/internal/repo"
+	"github.com/cvhariharan/flowctl/internal/streamlogger"
+	"github.com/google/uuid"
+)
+
+// lockStore keeps the resource locks of a single namespace in memory
+type lockStore struct {
+	repo.Store
+	mu     sync.Mutex
+	nextID int32
+	locks  []repo.ResourceLock
+}
+
+func (l *lockStore) AddResourceLockWaiter(ctx context.Context, arg repo.AddResourceLockWaiterParams) (repo.ResourceLock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nextID++
+	lock := repo.ResourceLock{ID: l.nextID, Name: arg.Name, ExecID: arg.ExecID, Status: repo.ResourceLockStatusWaiting}
+	l.locks = append(l.locks, lock)
+	return lock, nil
+}
+
+func (l *lockStore) AcquireResourceLock(ctx context.Context, id int32) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	idx := -1
+	for i, lock := range l.locks {
+		if lock.ID == id && lock.Status == repo.ResourceLockStatusWaiting {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return 0, nil
+	}
+	for _, o := range l.locks {
+		if o.Name == l.locks[idx].Name && (o.Status == repo.ResourceLockStatusHeld || o.ID < id) {
+			return 0, nil
+		}
+	}
+	l.locks[idx].Status = repo.ResourceLockStatusHeld
+	return 1, nil
+}
+
+func (l *lockStore) GetResourceLockHolder(ctx context.Context, arg repo.GetResourceLockHolderParams) (repo.ResourceLock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, lock := range l.locks {
+		if lock.Name == arg.Name && lock.Status == repo.ResourceLockStatusHeld {
+			return lock, nil
+		}
+	}
+	return repo.ResourceLock{}, sql.ErrNoRows
+}
+
+func (l *lockStore) DeleteResourceLock(ctx context.Context, id int32) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.locks = deleteLocks(l.locks, func(lock repo.ResourceLock) bool { return lock.ID == id })
+	return nil
+}
+
+func (l *lockStore) ReleaseResourceLocks(ctx context.Context, arg repo.ReleaseResourceLocksParams) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.locks = deleteLocks(l.locks, func(lock repo.ResourceLock) bool {
+		return lock.ExecID == arg.ExecID && slices.Contains(arg.Column2, lock.Name)
+	})
+	return nil
+}
+
+func deleteLocks(locks []repo.ResourceLock, del func(repo.ResourceLock) bool) []repo.ResourceLock {
+	var kept []repo.ResourceLock
+	for _, lock := range locks {
+		if !del(lock) {
+			kept = append(kept, lock)
+		}
+	}
+	return kept
+}
+
+// hold adds a lock entry for an execution with the given status
+func (l *lockStore) hold(name, execID string, status repo.ResourceLockStatus) int32 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nextID++
+	l.locks = append(l.locks, repo.ResourceLock{ID: l.nextID, Name: name, ExecID: execID, Status: status})
+	return l.nextID
+}
+
+func (l *lockStore) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.locks)
+}
+
+// lockLogger records the messages sent to an execution's log
+type lockLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (l *lockLogger) Write(p []byte) (int, error) { return len(p), nil }
+func (l *lockLogger) GetID() string               { return "" }
+func (l *lockLogger) SetActionID(id string)       {}
+func (l *lockLogger) Close() error                { return nil }
+
+func (l *lockLogger) Checkpoint(id string, nodeID string, val interface{}, mtype streamlogger.MessageType) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, fmt.Sprintf("%s", val))
+	return nil
+}
+
+func (l *lockLogger) messages() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.msgs, "")
+}
+
+func newLockScheduler(t *testing.T) (*Scheduler, *lockStore) {
+	interval := lockPollInterval
+	lockPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { lockPollInterval = interval })
+
+	store := &lockStore{}
+	return &Scheduler{
+		store:  store,
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, store
+}
+
+func lockReq(execID string, timeout time.Duration, names ...string) lockRequest {
+	return lockRequest{execID: execID, namespaceID: uuid.NewString(), names: names, timeout: timeout}
+}
+
+func TestAcquireLocksFailFast(t *testing.T) {
+	s, store := newLockScheduler(t)
+	store.hold("db", "other", repo.ResourceLockStatusHeld)
+
+	_, err := s.acquireLocks(context.Background(), lockReq("exec", 0, "db", "cache"), &lockLogger{})
+	if !errors.Is(err, ErrLockHeld) {
+		t.Fatalf("acquireLocks() error = %v, want %v", err, ErrLockHeld)
+	}
+	if !strings.Contains(err.Error(), "held by execution other") {
+		t.Errorf("error does not name the holder: %v", err)
+	}
+
+	// The cache lock acquired before db is released and the waiter is removed
+	if n := store.count(); n != 1 {
+		t.Errorf("expected only the lock of the other execution to remain, got %d entries", n)
+	}
+}
+
+func TestAcquireLocksFailFastQueued(t *testing.T) {
+	s, store := newLockScheduler(t)
+	older := store.hold("db", "other", repo.ResourceLockStatusWaiting)
+
+	logger := &lockLogger{}
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := s.acquireLocks(context.Background(), lockReq("exec", 0, "db"), logger)
+		errCh <- err
+	}()
+
+	// The lock is free, the request waits for the older waiter instead of failing
+	time.Sleep(5 * lockPollInterval)
+	select {
+	case err := <-errCh:
+		t.Fatalf("acquireLocks() returned %v while queued behind a waiter", err)
+	default:
+	}
+	if msgs := logger.messages(); !strings.Contains(msgs, "queued behind other executions") || strings.Contains(msgs, "held by") {
+		t.Errorf("unexpected wait message %q", msgs)
+	}
+
+	store.DeleteResourceLock(context.Background(), older)
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("acquireLocks() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("lock was not acquired after the older waiter left")
+	}
+}
+
+func TestAcquireLocksTimeout(t *testing.T) {
+	s, store := newLockScheduler(t)
+	store.hold("db", "other", repo.ResourceLockStatusHeld)
+
+	logger := &lockLogger{}
+	start := time.Now()
+	_, err := s.acquireLocks(context.Background(), lockReq("exec", 50*time.Millisecond, "db"), logger)
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("acquireLocks() error = %v, want %v", err, ErrLockTimeout)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("gave up after %s, before the timeout", elapsed)
+	}
+	if !strings.Contains(logger.messages(), `waiting for lock "db" held by execution other`) {
+		t.Errorf("unexpected wait message %q", logger.messages())
+	}
+	if n := store.count(); n != 1 {
+		t.Errorf("expected the waiter to be removed, got %d entries", n)
+	}
+}
+
+func TestAcquireLocksOrdering(t *testing.T) {
+	s, store := newLockScheduler(t)
+	store.hold("db", "first", repo.ResourceLockStatusHeld)
+
+	acquired := make(chan string, 2)
+	for i, execID := range []string{"second", "third"} {
+		go func() {
+			if _, err := s.acquireLocks(context.Background(), lockReq(execID, time.Minute, "db"), &lockLogger{}); err != nil {
+				t.Errorf("acquireLocks(%s) error = %v", execID, err)
+				return
+			}
+			acquired <- execID
+		}()
+		// Wait for the waiter to be queued so that the executions queue in order
+		for store.count() != i+2 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	for _, holder := range []string{"first", "second", "third"} {
+		if holder != "first" {
+			select {
+			case got := <-acquired:
+				if got != holder {
+					t.Fatalf("%s acquired the lock, want %s", got, holder)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("%s did not acquire the lock", holder)
+			}
+		}
+
+		// No other waiter acquires the lock while it is held
+		time.Sleep(5 * lockPollInterval)
+		select {
+		case got := <-acquired:
+			t.Fatalf("%s acquired the lock held by %s", got, holder)
+		default:
+		}
+		s.releaseLocks(holder, uuid.NewString(), []string{"db"})
+	}
+}
@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+)
+
+// nodeLimiter bounds the number of actions running concurrently on a node
+// across all executions handled by the scheduler. Work beyond the limit is queued
+// and admitted in FIFO order as running work completes.
+type nodeLimiter struct {
+	mu      sync.Mutex
+	running map[string]int
+	waiters map[string][]chan struct{}
+}
+
+func newNodeLimiter() *nodeLimiter {
+	return &nodeLimiter{
+		running: make(map[string]int),
+		waiters: make(map[string][]chan struct{}),
+	}
+}
+
+// tryAcquire takes a slot on the node without waiting.
+// A limit of zero or less means the node has no concurrency limit
+func (l *nodeLimiter) tryAcquire(nodeID string, limit int) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.running[nodeID] < limit && len(l.waiters[nodeID]) == 0 {
+		l.running[nodeID]++
+		return true
+	}
+	return false
+}
+
+// acquire waits for a slot on the node until one is available or the context is done
+func (l *nodeLimiter) acquire(ctx context.Context, nodeID string, limit int) error {
+	if l.tryAcquire(nodeID, limit) {
+		return nil
+	}
+
+	ch := make(chan struct{})
+	l.mu.Lock()
+	l.waiters[nodeID] = append(l.waiters[nodeID], ch)
+	l.mu.Unlock()
+
+	// Admit waiters in case slots were freed before the waiter was queued
+	l.admit(nodeID, limit)
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		for i, w := range l.waiters[nodeID] {
+			if w == ch {
+				l.waiters[nodeID] = append(l.waiters[nodeID][:i], l.waiters[nodeID][i+1:]...)
+				l.mu.Unlock()
+				return ctx.Err()
+			}
+		}
+		l.mu.Unlock()
+
+		// The slot was granted while the context was being cancelled
+		l.release(nodeID, limit)
+		return ctx.Err()
+	}
+}
+
+// release frees a slot on the node and admits the next waiter, if any
+func (l *nodeLimiter) release(nodeID string, limit int) {
+	if limit <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	if l.running[nodeID] > 0 {
+		l.running[nodeID]--
+	}
+	if l.running[nodeID] == 0 && len(l.waiters[nodeID]) == 0 {
+		delete(l.running, nodeID)
+		delete(l.waiters, nodeID)
+	}
+	l.mu.Unlock()
+
+	l.admit(nodeID, limit)
+}
+
+func (l *nodeLimiter) admit(nodeID string, limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.running[nodeID] < limit && len(l.waiters[nodeID]) > 0 {
+		next := l.waiters[nodeID][0]
+		l.waiters[nodeID] = l.waiters[nodeID][1:]
+		l.running[nodeID]++
+		close(next)
+	}
+}
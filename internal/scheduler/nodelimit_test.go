@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitForWaiters blocks until n acquires are queued on the node
+func waitForWaiters(t *testing.T, l *nodeLimiter, nodeID string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		l.mu.Lock()
+		queued := len(l.waiters[nodeID])
+		l.mu.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%d acquires were not queued on %s", n, nodeID)
+}
+
+func TestNodeLimiterOrdering(t *testing.T) {
+	l := newNodeLimiter()
+	if !l.tryAcquire("node", 1) {
+		t.Fatal("tryAcquire() failed on an idle node")
+	}
+
+	admitted := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			if err := l.acquire(context.Background(), "node", 1); err != nil {
+				t.Error(err)
+				return
+			}
+			admitted <- i
+		}()
+		waitForWaiters(t, l, "node", i+1)
+	}
+
+	// Queued work is not overtaken by work which does not wait
+	if l.tryAcquire("node", 1) {
+		t.Fatal("tryAcquire() took a slot ahead of queued acquires")
+	}
+
+	for want := 0; want < 3; want++ {
+		l.release("node", 1)
+		select {
+		case got := <-admitted:
+			if got != want {
+				t.Fatalf("admitted acquire %d, want %d", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("acquire %d was not admitted after a release", want)
+		}
+	}
+
+	l.release("node", 1)
+	if len(l.running) != 0 || len(l.waiters) != 0 {
+		t.Errorf("limiter state was not cleaned up: running %v, waiters %v", l.running, l.waiters)
+	}
+}
+
+func TestNodeLimiterCancel(t *testing.T) {
+	l := newNodeLimiter()
+	if !l.tryAcquire("node", 1) {
+		t.Fatal("tryAcquire() failed on an idle node")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() { cancelled <- l.acquire(ctx, "node", 1) }()
+	waitForWaiters(t, l, "node", 1)
+
+	admitted := make(chan struct{})
+	go func() {
+		if err := l.acquire(context.Background(), "node", 1); err != nil {
+			t.Error(err)
+		}
+		close(admitted)
+	}()
+	waitForWaiters(t, l, "node", 2)
+
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("acquire() error = %v, want context.Canceled", err)
+	}
+	waitForWaiters(t, l, "node", 1)
+
+	// The cancelled acquire gave up its place, so the next one gets the slot
+	l.release("node", 1)
+	select {
+	case <-admitted:
+	case <-time.After(time.Second):
+		t.Fatal("acquire queued behind a cancelled acquire was not admitted")
+	}
+	if l.running["node"] != 1 {
+		t.Errorf("%d slots are taken, want 1", l.running["node"])
+	}
+}
+
+func TestNodeLimiterNoLimit(t *testing.T) {
+	l := newNodeLimiter()
+	for i := 0; i < 10; i++ {
+		if !l.tryAcquire("node", 0) {
+			t.Fatal("tryAcquire() failed without a limit")
+		}
+		if err := l.acquire(context.Background(), "node", -1); err != nil {
+			t.Fatalf("acquire() error = %v", err)
+		}
+	}
+	l.release("node", 0)
+
+	if len(l.running) != 0 || len(l.waiters) != 0 {
+		t.Errorf("unlimited nodes were tracked: running %v, waiters %v", l.running, l.waiters)
+	}
+}
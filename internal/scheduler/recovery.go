@@ -0,0 +1,186 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cvhariharan/flowctl/internal/repo"
+	"github.com/cvhariharan/flowctl/internal/scheduler/storage"
+	"github.com/cvhariharan/flowctl/internal/streamlogger"
+	"github.com/google/uuid"
+)
+
+// errLeaseLost is the cause of executions stopped because the instance could not renew its job leases
+var errLeaseLost = errors.New("job leases could not be renewed")
+
+// lease returns the lease used by this instance for the jobs it processes
+func (s *Scheduler) lease() storage.Lease {
+	return storage.Lease{
+		Owner: s.instanceID,
+		TTL:   s.leaseTTL,
+	}
+}
+
+// heartbeat records that this instance is alive and extends the leases of the jobs it is running.
+// The running executions are abandoned if the leases could not be renewed within the lease TTL
+func (s *Scheduler) heartbeat(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, heartbeatInterval)
+	defer cancel()
+
+	if err := s.renewLeases(ctx); err != nil {
+		if !s.lastRenewal.IsZero() && time.Since(s.lastRenewal) > s.leaseTTL {
+			s.abandonExecutions()
+		}
+		return err
+	}
+	s.lastRenewal = time.Now()
+
+	return nil
+}
+
+// renewLeases updates the heartbeat of this instance and the leases of its jobs
+func (s *Scheduler) renewLeases(ctx context.Context) error {
+	hostname, _ := os.Hostname()
+	if err := s.store.UpsertSchedulerInstance(ctx, repo.UpsertSchedulerInstanceParams{
+		ID:       s.instanceID,
+		Hostname: hostname,
+	}); err != nil {
+		return fmt.Errorf("could not update instance heartbeat: %w", err)
+	}
+
+	if err := s.jobStore.RenewLeases(ctx, s.lease()); err != nil {
+		return fmt.Errorf("could not renew job leases: %w", err)
+	}
+
+	return nil
+}
+
+// abandonExecutions stops the running executions once the instance could not renew its job leases in time.
+// Other instances can take over the jobs at that point, so the status of the executions is left for them to
+// recover. The instance continues with a new ID, the jobs of the previous ID are recovered once it is no longer live
+func (s *Scheduler) abandonExecutions() {
+	s.cancelMu.Lock()
+	for execID, cancel := range s.cancelFuncs {
+		cancel(errLeaseLost)
+		delete(s.cancelFuncs, execID)
+	}
+	s.cancelMu.Unlock()
+
+	previousID := s.instanceID
+	s.instanceID = uuid.NewString()
+	s.lastRenewal = time.Time{}
+	s.logger.Warn("could not renew job leases, running executions were stopped", "previousInstance", previousID, "instance", s.instanceID)
+}
+
+// recoverOrphanedJobs takes over jobs whose instance stopped sending heartbeats.
+// This runs on startup and periodically, so a peer instance can recover jobs from a crashed instance
+func (s *Scheduler) recoverOrphanedJobs(ctx context.Context) error {
+	stale, err := s.store.DeleteStaleSchedulerInstances(ctx, int32(instanceTTL.Seconds()))
+	if err != nil {
+		return fmt.Errorf("could not remove stale instances: %w", err)
+	}
+	for _, instance := range stale {
+		s.logger.Warn("scheduler instance stopped responding", "instance", instance.ID, "hostname", instance.Hostname, "lastHeartbeat", instance.LastHeartbeat)
+	}
+
+	jobs, err := s.jobStore.ClaimExpired(ctx, s.lease())
+	if err != nil {
+		return fmt.Errorf("could not claim expired jobs: %w", err)
+	}
+
+	for _, job := range jobs {
+		if err := s.recoverJob(ctx, job); err != nil {
+			s.logger.Error("could not recover orphaned job", "execID", job.ExecID, "jobID", job.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// recoverJob handles a job that was orphaned by a crashed instance.
+// Executions that never started are queued again. Running executions of idempotent flows are
+// re-queued from the action they were running, others are marked as errored.
+func (s *Scheduler) recoverJob(ctx context.Context, job storage.Job) error {
+	var payload FlowExecutionPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		s.jobStore.Delete(ctx, job.ID)
+		return fmt.Errorf("failed to unmarshal job payload: %w", err)
+	}
+
+	namespaceUUID, err := uuid.Parse(payload.NamespaceID)
+	if err != nil {
+		s.jobStore.Delete(ctx, job.ID)
+		return fmt.Errorf("invalid namespace UUID: %w", err)
+	}
+
+	exec, err := s.store.GetExecutionByExecID(ctx, repo.GetExecutionByExecIDParams{
+		ExecID: job.ExecID,
+		Uuid:   namespaceUUID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return s.jobStore.Delete(ctx, job.ID)
+		}
+		return fmt.Errorf("could not get execution %s: %w", job.ExecID, err)
+	}
+
+	// Locks held by the crashed instance for this execution are released
+	if err := s.store.ReleaseAllResourceLocks(ctx, job.ExecID); err != nil {
+		return fmt.Errorf("could not release locks for execution %s: %w", job.ExecID, err)
+	}
+
+	switch exec.Status {
+	case repo.ExecutionStatusRunning:
+	case repo.ExecutionStatusCompleted, repo.ExecutionStatusErrored, repo.ExecutionStatusCancelled:
+		// The instance crashed after the execution finished but before the job was removed
+		return s.jobStore.Delete(ctx, job.ID)
+	default:
+		// The execution had not started yet, it is safe to run it again
+		s.logger.Info("re-queueing orphaned job", "execID", job.ExecID, "previousOwner", job.LeaseOwner)
+		return s.jobStore.Requeue(ctx, job)
+	}
+
+	reason := fmt.Sprintf("execution orphaned: scheduler instance %s stopped responding while the execution was running", job.LeaseOwner)
+
+	streamLogger, err := s.logmanager.NewLogger(job.ExecID)
+	if err != nil {
+		return fmt.Errorf("could not create logger for execution %s: %w", job.ExecID, err)
+	}
+	defer streamLogger.Close()
+
+	if !payload.Workflow.Meta.Idempotent {
+		streamLogger.Checkpoint(exec.CurrentActionID.String, "", reason, streamlogger.ErrMessageType)
+		if err := s.setStatus(ctx, job.ExecID, repo.ExecutionStatusErrored, payload.NamespaceID, errors.New(reason)); err != nil {
+			return err
+		}
+		return s.jobStore.Delete(ctx, job.ID)
+	}
+
+	// Resume idempotent flows from the action that was running
+	for i, action := range payload.Workflow.Actions {
+		if exec.CurrentActionID.Valid && action.ID == exec.CurrentActionID.String {
+			payload.StartingActionIdx = i
+			break
+		}
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("could not marshal job payload: %w", err)
+	}
+	job.Payload = b
+
+	msg := fmt.Sprintf("%s, flow is idempotent so the execution is re-queued from action %q\n", reason, exec.CurrentActionID.String)
+	streamLogger.Checkpoint(exec.CurrentActionID.String, "", []byte(msg), streamlogger.LogMessageType)
+	s.logger.Info("re-queueing orphaned execution", "execID", job.ExecID, "actionID", exec.CurrentActionID.String, "previousOwner", job.LeaseOwner)
+
+	if err := s.setStatus(ctx, job.ExecID, repo.ExecutionStatusPending, payload.NamespaceID, nil); err != nil {
+		return err
+	}
+	return s.jobStore.Requeue(ctx, job)
+}
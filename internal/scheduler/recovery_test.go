@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/cvhariharan/flowctl/internal/repo"
+	"github.com/cvhariharan/flowctl/internal/scheduler/storage"
+	"github.com/cvhariharan/flowctl/internal/streamlogger"
+	"github.com/google/uuid"
+)
+
+// recoveryStore is a store holding a single execution
+type recoveryStore struct {
+	repo.Store
+	exec         repo.GetExecutionByExecIDRow
+	status       repo.ExecutionStatus
+	heartbeatErr error
+}
+
+func (r *recoveryStore) GetExecutionByExecID(ctx context.Context, arg repo.GetExecutionByExecIDParams) (repo.GetExecutionByExecIDRow, error) {
+	return r.exec, nil
+}
+
+func (r *recoveryStore) ReleaseAllResourceLocks(ctx context.Context, execID string) error {
+	return nil
+}
+
+func (r *recoveryStore) UpdateExecutionStatus(ctx context.Context, arg repo.UpdateExecutionStatusParams) (repo.ExecutionLog, error) {
+	r.status = arg.Status
+	return repo.ExecutionLog{}, nil
+}
+
+func (r *recoveryStore) UpsertSchedulerInstance(ctx context.Context, arg repo.UpsertSchedulerInstanceParams) error {
+	return r.heartbeatErr
+}
+
+// recoveryJobStore records the jobs requeued and deleted during recovery
+type recoveryJobStore struct {
+	*memoryStorage
+	requeued []storage.Job
+	deleted  []int64
+}
+
+func (r *recoveryJobStore) Requeue(ctx context.Context, job storage.Job) error {
+	r.requeued = append(r.requeued, job)
+	return nil
+}
+
+func (r *recoveryJobStore) Delete(ctx context.Context, jobID int64) error {
+	r.deleted = append(r.deleted, jobID)
+	return nil
+}
+
+func newRecoveryScheduler(t *testing.T, store *recoveryStore) (*Scheduler, *recoveryJobStore) {
+	jobStore := &recoveryJobStore{memoryStorage: newMemoryStorage()}
+	return &Scheduler{
+		store:       store,
+		jobStore:    jobStore,
+		logmanager:  streamlogger.NewFileLogManager(streamlogger.FileLogManagerCfg{LogDir: t.TempDir()}),
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		cancelFuncs: make(map[string]context.CancelCauseFunc),
+		instanceID:  uuid.NewString(),
+		leaseTTL:    jobLeaseTTL,
+	}, jobStore
+}
+
+func TestRecoverJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       repo.ExecutionStatus
+		idempotent   bool
+		wantRequeued bool
+		wantStatus   repo.ExecutionStatus
+		wantStartIdx int
+	}{
+		{name: "not started", status: repo.ExecutionStatusPending, wantRequeued: true},
+		{name: "finished", status: repo.ExecutionStatusCompleted},
+		{name: "running", status: repo.ExecutionStatusRunning, wantStatus: repo.ExecutionStatusErrored},
+		{name: "running idempotent", status: repo.ExecutionStatusRunning, idempotent: true, wantRequeued: true, wantStatus: repo.ExecutionStatusPending, wantStartIdx: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execID := uuid.NewString()
+			store := &recoveryStore{exec: repo.GetExecutionByExecIDRow{
+				ExecID:          execID,
+				Status:          tt.status,
+				CurrentActionID: sql.NullString{String: "deploy", Valid: true},
+			}}
+			s, jobStore := newRecoveryScheduler(t, store)
+
+			payload, err := json.Marshal(FlowExecutionPayload{
+				Workflow: Flow{
+					Meta:    Metadata{ID: "release", Idempotent: tt.idempotent},
+					Actions: []Action{{ID: "build"}, {ID: "deploy"}},
+				},
+				ExecID:      execID,
+				NamespaceID: uuid.NewString(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			job := storage.Job{ID: 1, ExecID: execID, Payload: payload, LeaseOwner: uuid.NewString()}
+
+			if err := s.recoverJob(context.Background(), job); err != nil {
+				t.Fatalf("recoverJob() error = %v", err)
+			}
+
+			if store.status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", store.status, tt.wantStatus)
+			}
+			if !tt.wantRequeued {
+				if len(jobStore.requeued) != 0 || len(jobStore.deleted) != 1 {
+					t.Fatalf("expected the job to be deleted, requeued %d, deleted %d", len(jobStore.requeued), len(jobStore.deleted))
+				}
+				return
+			}
+
+			if len(jobStore.requeued) != 1 || len(jobStore.deleted) != 0 {
+				t.Fatalf("expected the job to be requeued, requeued %d, deleted %d", len(jobStore.requeued), len(jobStore.deleted))
+			}
+			var requeued FlowExecutionPayload
+			if err := json.Unmarshal(jobStore.requeued[0].Payload, &requeued); err != nil {
+				t.Fatal(err)
+			}
+			if requeued.StartingActionIdx != tt.wantStartIdx {
+				t.Errorf("requeued from action %d, want %d", requeued.StartingActionIdx, tt.wantStartIdx)
+			}
+		})
+	}
+}
+
+func TestHeartbeatAbandonsExecutions(t *testing.T) {
+	tests := []struct {
+		name          string
+		heartbeatErr  error
+		lastRenewal   time.Duration
+		wantAbandoned bool
+	}{
+		{name: "renewed", lastRenewal: jobLeaseTTL * 2},
+		{name: "renewal failed within the lease", heartbeatErr: errors.New("connection refused"), lastRenewal: heartbeatInterval},
+		{name: "renewal failed for longer than the lease", heartbeatErr: errors.New("connection refused"), lastRenewal: jobLeaseTTL * 2, wantAbandoned: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newRecoveryScheduler(t, &recoveryStore{heartbeatErr: tt.heartbeatErr})
+			s.lastRenewal = time.Now().Add(-tt.lastRenewal)
+			instanceID := s.instanceID
+
+			execCtx, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+			s.cancelFuncs["exec"] = cancel
+
+			err := s.heartbeat(context.Background())
+			if (err != nil) != (tt.heartbeatErr != nil) {
+				t.Fatalf("heartbeat() error = %v", err)
+			}
+
+			abandoned := errors.Is(context.Cause(execCtx), errLeaseLost)
+			if abandoned != tt.wantAbandoned {
+				t.Errorf("execution abandoned = %v, want %v", abandoned, tt.wantAbandoned)
+			}
+			if (s.instanceID != instanceID) != tt.wantAbandoned {
+				t.Errorf("instance ID changed = %v, want %v", s.instanceID != instanceID, tt.wantAbandoned)
+			}
+		})
+	}
+}
@@ -9,12 +9,29 @@ import (
 	"log/slog"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cvhariharan/flowctl/internal/repo"
 	"github.com/cvhariharan/flowctl/internal/scheduler/storage"
 	"github.com/cvhariharan/flowctl/internal/streamlogger"
 	"github.com/google/uuid"
+	"gocloud.dev/blob"
+)
+
+const (
+	taskPollInterval         = 2 * time.Second
+	taskFallbackPollInterval = 30 * time.Second
+
+	// Instances send heartbeats and renew job leases every heartbeatInterval.
+	// Jobs with leases older than jobLeaseTTL are considered orphaned
+	heartbeatInterval = 10 * time.Second
+	jobLeaseTTL       = 30 * time.Second
+
+	// Instances without a heartbeat for instanceTTL are no longer live and their jobs are recovered.
+	// It is longer than jobLeaseTTL so that an instance which could not renew its leases stops its
+	// executions before they are taken over
+	instanceTTL = 2 * jobLeaseTTL
 )
 
 type TaskScheduler interface {
@@ -30,15 +47,37 @@ type Scheduler struct {
 	jobStore         storage.Storage // For job queue
 	secretsProvider  SecretsProviderFn
 	flowLoader       FlowLoaderFn
+	credProvider     CredentialProviderFn
+	becomeProvider   BecomeCredentialProviderFn
+	hostKeyRecorder  HostKeyRecorderFn
+	certRecorder     CertificateRecorderFn
+	inventorySyncer  InventorySyncFn
+	inventorySyncing atomic.Bool // Set while inventory sources are being synced
+	nodeLoader       NodeLoaderFn
+	healthInterval   time.Duration // Interval between health checks of a node, 0 disables health checks
+	healthChecking   atomic.Bool   // Set while nodes are being checked
+	artifactBucket   *blob.Bucket  // Artifacts declared by actions are stored here, nil disables storing artifacts
+	retentionTime    time.Duration // Default retention of stored artifacts, 0 keeps them forever
+	artifactCleaning atomic.Bool   // Set while expired artifacts are being deleted
 	logmanager       streamlogger.LogManager
-	cancelFuncs      map[string]context.CancelFunc
+	cancelFuncs      map[string]context.CancelCauseFunc
+	nodeLimiter      *nodeLimiter                         // Per node concurrency limits across executions
+	connPools        map[string]*connPool                 // Node connections reused across the actions of an execution
+	artifacts        map[string]*artifactTracker          // Checksums of the artifacts on the nodes of an execution
 	scheduledFlows   map[string]repo.GetScheduledFlowsRow // Cache of scheduled flows
 	cancelMu         sync.RWMutex                         // Lock for cancelFuncs
 	scheduledMu      sync.RWMutex                         // Lock for scheduledFlows
+	connPoolsMu      sync.Mutex                           // Lock for connPools
+	artifactsMu      sync.Mutex                           // Lock for artifacts
 	taskTicker       *time.Ticker
+	jobNotifications <-chan struct{}
 	periodicTicker   *time.Ticker
 	cronSyncTicker   *time.Ticker
 	cronSyncInterval time.Duration
+	heartbeatTicker  *time.Ticker
+	instanceID       string        // Identifies this instance in heartbeats and job leases
+	leaseTTL         time.Duration // Duration after which jobs of an unresponsive instance are recovered
+	lastRenewal      time.Time     // Last time the job leases of this instance were renewed
 	stopCh           chan struct{}
 	stopped          bool
 	workerCount      int
@@ -55,6 +94,9 @@ type SchedulerBuilder struct {
 	workerCount      int
 	logger           *slog.Logger
 	cronSyncInterval time.Duration
+	healthInterval   time.Duration
+	artifactBucket   *blob.Bucket
+	retentionTime    time.Duration
 }
 
 // NewSchedulerBuilder creates a new scheduler builder
@@ -104,6 +146,24 @@ func (b *SchedulerBuilder) WithCronSyncInterval(s time.Duration) *SchedulerBuild
 	return b
 }
 
+// WithNodeHealthInterval sets the interval at which nodes are checked and their facts are gathered
+func (b *SchedulerBuilder) WithNodeHealthInterval(i time.Duration) *SchedulerBuilder {
+	b.healthInterval = i
+	return b
+}
+
+// WithArtifactBucket sets the bucket where artifacts declared by actions are stored
+func (b *SchedulerBuilder) WithArtifactBucket(bucket *blob.Bucket) *SchedulerBuilder {
+	b.artifactBucket = bucket
+	return b
+}
+
+// WithArtifactRetention sets how long stored artifacts are kept in namespaces which do not set a retention
+func (b *SchedulerBuilder) WithArtifactRetention(r time.Duration) *SchedulerBuilder {
+	b.retentionTime = r
+	return b
+}
+
 // Build creates the scheduler instance
 func (b *SchedulerBuilder) Build() (*Scheduler, error) {
 	if b.workerCount == 0 {
@@ -127,7 +187,15 @@ func (b *SchedulerBuilder) Build() (*Scheduler, error) {
 		workerCount:      b.workerCount,
 		logger:           b.logger,
 		cronSyncInterval: b.cronSyncInterval,
-		cancelFuncs:      make(map[string]context.CancelFunc),
+		healthInterval:   b.healthInterval,
+		artifactBucket:   b.artifactBucket,
+		retentionTime:    b.retentionTime,
+		cancelFuncs:      make(map[string]context.CancelCauseFunc),
+		nodeLimiter:      newNodeLimiter(),
+		connPools:        make(map[string]*connPool),
+		artifacts:        make(map[string]*artifactTracker),
+		instanceID:       uuid.NewString(),
+		leaseTTL:         jobLeaseTTL,
 		scheduledFlows:   make(map[string]repo.GetScheduledFlowsRow),
 		stopCh:           make(chan struct{}),
 	}, nil
@@ -138,6 +206,37 @@ func (s *Scheduler) SetSecretsProvider(sp SecretsProviderFn) {
 	s.secretsProvider = sp
 }
 
+// SetCredentialProvider sets the provider used by executors to read credentials
+func (s *Scheduler) SetCredentialProvider(cp CredentialProviderFn) {
+	s.credProvider = cp
+}
+
+// SetBecomeCredentialProvider sets the provider used to read the passwords for privilege escalation on nodes.
+// Unlike the credential provider, it is not available to executors
+func (s *Scheduler) SetBecomeCredentialProvider(bp BecomeCredentialProviderFn) {
+	s.becomeProvider = bp
+}
+
+// SetHostKeyRecorder sets the function used to record host keys of nodes which are not trusted yet
+func (s *Scheduler) SetHostKeyRecorder(hr HostKeyRecorderFn) {
+	s.hostKeyRecorder = hr
+}
+
+// SetCertificateRecorder sets the function used to record the SSH certificates issued for executions
+func (s *Scheduler) SetCertificateRecorder(cr CertificateRecorderFn) {
+	s.certRecorder = cr
+}
+
+// SetInventorySyncer sets the function used to sync dynamic inventory sources, it is run every minute
+func (s *Scheduler) SetInventorySyncer(is InventorySyncFn) {
+	s.inventorySyncer = is
+}
+
+// SetNodeLoader sets the function used to load the nodes checked by the health check
+func (s *Scheduler) SetNodeLoader(nl NodeLoaderFn) {
+	s.nodeLoader = nl
+}
+
 // SetFlowLoader allows updating flow loader after build
 func (s *Scheduler) SetFlowLoader(fl FlowLoaderFn) {
 	s.flowLoader = fl
@@ -155,7 +254,17 @@ func (s *Scheduler) Start(ctx context.Context) error {
 		return err
 	}
 
-	s.taskTicker = time.NewTicker(2 * time.Second)
+	s.initTaskTicker()
+
+	if err := s.heartbeat(ctx); err != nil {
+		s.logger.Error("failed to send initial heartbeat", "error", err)
+	}
+	s.heartbeatTicker = time.NewTicker(heartbeatInterval)
+
+	// Recover jobs orphaned by instances that stopped before this one started
+	if err := s.recoverOrphanedJobs(ctx); err != nil {
+		s.logger.Error("failed to recover orphaned jobs", "error", err)
+	}
 
 	// Check periodic tasks every minute
 	s.periodicTicker = time.NewTicker(1 * time.Minute)
@@ -172,6 +281,17 @@ func (s *Scheduler) Start(ctx context.Context) error {
 	return nil
 }
 
+// initTaskTicker sets up job pickup. If the job store can notify about new jobs,
+// the queue is polled only as a fallback
+func (s *Scheduler) initTaskTicker() {
+	pollInterval := taskPollInterval
+	if n, ok := s.jobStore.(storage.Notifier); ok && n.Notifications() != nil {
+		s.jobNotifications = n.Notifications()
+		pollInterval = taskFallbackPollInterval
+	}
+	s.taskTicker = time.NewTicker(pollInterval)
+}
+
 // Stop shuts down the scheduler
 func (s *Scheduler) Stop(ctx context.Context) error {
 	if s.stopped {
@@ -190,9 +310,15 @@ func (s *Scheduler) Stop(ctx context.Context) error {
 	if s.cronSyncTicker != nil {
 		s.cronSyncTicker.Stop()
 	}
+	if s.heartbeatTicker != nil {
+		s.heartbeatTicker.Stop()
+		if err := s.store.DeleteSchedulerInstance(ctx, s.instanceID); err != nil {
+			s.logger.Error("failed to deregister scheduler instance", "error", err)
+		}
+	}
 
 	for _, cancel := range s.cancelFuncs {
-		cancel()
+		cancel(nil)
 	}
 
 	return nil
@@ -204,6 +330,8 @@ func (s *Scheduler) QueueTask(ctx context.Context, payload FlowExecutionPayload)
 	if err != nil {
 		return "", err
 	}
+	job.NamespaceID = payload.NamespaceID
+	job.Priority = payload.Priority
 
 	err = s.jobStore.Put(ctx, job)
 	if err != nil {
@@ -217,7 +345,7 @@ func (s *Scheduler) QueueTask(ctx context.Context, payload FlowExecutionPayload)
 func (s *Scheduler) CancelTask(ctx context.Context, execID string) error {
 	s.cancelMu.Lock()
 	if cancel, exists := s.cancelFuncs[execID]; exists {
-		cancel()
+		cancel(ErrExecutionCancelled)
 		delete(s.cancelFuncs, execID)
 	}
 	s.cancelMu.Unlock()
@@ -232,14 +360,28 @@ func (s *Scheduler) processLoop(ctx context.Context) {
 			if err := s.processPendingTasks(ctx); err != nil {
 				s.logger.Error("error processing pending tasks", "error", err)
 			}
+		case <-s.jobNotifications:
+			if err := s.processPendingTasks(ctx); err != nil {
+				s.logger.Error("error processing pending tasks", "error", err)
+			}
 		case <-s.periodicTicker.C:
 			if err := s.checkPeriodicTasks(ctx); err != nil {
 				s.logger.Error("error checking periodic tasks", "error", err)
 			}
+			s.syncInventories(ctx)
+			s.checkNodeHealth(ctx)
+			s.cleanupArtifacts(ctx)
 		case <-s.cronSyncTicker.C:
 			if err := s.syncScheduledFlows(ctx); err != nil {
 				s.logger.Error("error syncing scheduled flows", "error", err)
 			}
+		case <-s.heartbeatTicker.C:
+			if err := s.heartbeat(ctx); err != nil {
+				s.logger.Error("error sending heartbeat", "error", err)
+			}
+			if err := s.recoverOrphanedJobs(ctx); err != nil {
+				s.logger.Error("error recovering orphaned jobs", "error", err)
+			}
 		case <-s.stopCh:
 			return
 		case <-ctx.Done():
@@ -248,11 +390,26 @@ func (s *Scheduler) processLoop(ctx context.Context) {
 	}
 }
 
+// syncInventories syncs inventory sources in the background. Scripts can be slow,
+// a sync is skipped while the previous one is still running
+func (s *Scheduler) syncInventories(ctx context.Context) {
+	if s.inventorySyncer == nil || !s.inventorySyncing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer s.inventorySyncing.Store(false)
+		if err := s.inventorySyncer(ctx); err != nil {
+			s.logger.Error("error syncing inventory sources", "error", err)
+		}
+	}()
+}
+
 // processPendingTasks gets pending tasks and executes them
 func (s *Scheduler) processPendingTasks(ctx context.Context) error {
 	for i := 0; i < s.workerCount; i++ {
-		done := make(chan struct{})
-		job, err := s.jobStore.Get(ctx, done)
+		done := make(chan bool, 1)
+		job, err := s.jobStore.Get(ctx, s.lease(), done)
 		if err != nil {
 			if errors.Is(err, storage.ErrNoJobs) {
 				break
@@ -260,12 +417,14 @@ func (s *Scheduler) processPendingTasks(ctx context.Context) error {
 			return err
 		}
 
-		go func(done chan struct{}) {
-			defer close(done)
+		go func(done chan bool) {
 			s.logger.Debug("starting job execution", "execID", job.ExecID, "jobID", job.ID)
-			if err := s.executeJob(ctx, job); err != nil {
+			err := s.executeJob(ctx, job)
+			if err != nil {
 				s.logger.Error("error executing flow", "execID", job.ExecID, "error", err)
 			}
+			// Abandoned jobs are left in the queue for the instance recovering them
+			done <- !errors.Is(err, errLeaseLost)
 			s.logger.Debug("completed job execution", "execID", job.ExecID, "jobID", job.ID)
 		}(done)
 	}
@@ -280,7 +439,7 @@ func (s *Scheduler) executeJob(ctx context.Context, job storage.Job) error {
 		return fmt.Errorf("failed to unmarshal job payload: %w", err)
 	}
 
-	execCtx, cancel := context.WithCancel(ctx)
+	execCtx, cancel := context.WithCancelCause(ctx)
 
 	// Track cancellation function
 	s.cancelMu.Lock()
@@ -305,6 +464,10 @@ func (s *Scheduler) executeJob(ctx context.Context, job storage.Job) error {
 	}
 
 	if err := s.executeFlow(execCtx, payload); err != nil {
+		// The status is left as running so that the execution is recovered by the instance taking over the job
+		if errors.Is(context.Cause(execCtx), errLeaseLost) {
+			return errLeaseLost
+		}
 		if errors.Is(err, ErrPendingApproval) {
 			return s.setStatus(ctx, payload.ExecID, repo.ExecutionStatusPendingApproval, payload.NamespaceID, nil)
 		}
@@ -370,6 +533,7 @@ func (s *Scheduler) createExecutionLog(ctx context.Context, payload FlowExecutio
 		TriggerType: triggerType,
 		Uuid:        userUUID,
 		Uuid_2:      namespaceUUID,
+		Priority:    int32(payload.Priority),
 	})
 	if err != nil {
 		return fmt.Errorf("could not add execution log entry: %w", err)
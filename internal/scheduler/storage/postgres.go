@@ -3,18 +3,81 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+// JobNotifyChannel is the Postgres channel used to notify listeners about new jobs
+const JobNotifyChannel = "flowctl_job_queue"
+
+// namespaceLockClass is the first key of the advisory locks which serialize leasing jobs of a namespace
+const namespaceLockClass = 0x666c6f77
+
 // PostgresStorage implements the Storage interface using PostgreSQL
 type PostgresStorage struct {
-	db *sqlx.DB
+	db           *sqlx.DB
+	listener     *pq.Listener
+	notifyCh     chan struct{}
+	namespaceMax int
+	// namespaceLimits overrides namespaceMax for namespaces by name
+	namespaceLimits map[string]int
 }
 
 // NewPostgresStorage creates a new PostgreSQL storage backend
 func NewPostgresStorage(db *sqlx.DB) *PostgresStorage {
-	return &PostgresStorage{db: db}
+	return &PostgresStorage{
+		db:       db,
+		notifyCh: make(chan struct{}, 1),
+	}
+}
+
+// Listen subscribes to job notifications using LISTEN/NOTIFY on a dedicated connection.
+// Once listening, Notifications receives a value every time a job is added to the queue.
+func (p *PostgresStorage) Listen(connStr string) error {
+	listener := pq.NewListener(connStr, time.Second, time.Minute, nil)
+	if err := listener.Listen(JobNotifyChannel); err != nil {
+		listener.Close()
+		return fmt.Errorf("could not listen on %s: %w", JobNotifyChannel, err)
+	}
+	p.listener = listener
+
+	go func() {
+		// The channel is closed when the listener is closed.
+		// A nil notification is sent after a reconnect, notifications could have been missed so the queue is checked anyway
+		for range listener.Notify {
+			p.notify()
+		}
+	}()
+
+	return nil
+}
+
+// Notifications implements the Notifier interface
+func (p *PostgresStorage) Notifications() <-chan struct{} {
+	if p.listener == nil {
+		return nil
+	}
+	return p.notifyCh
+}
+
+// notify wakes up a waiting consumer without blocking.
+// Pending notifications are coalesced since a single wakeup drains the queue
+func (p *PostgresStorage) notify() {
+	select {
+	case p.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+// SetNamespaceMaxConcurrency sets the maximum number of jobs from a single namespace
+// that can run at the same time across all instances. Limits of individual namespaces,
+// keyed by namespace name, override the default. Zero means no limit.
+func (p *PostgresStorage) SetNamespaceMaxConcurrency(max int, limits map[string]int) {
+	p.namespaceMax = max
+	p.namespaceLimits = limits
 }
 
 // Initialize creates the job queue table
@@ -27,9 +90,16 @@ func (p *PostgresStorage) Initialize(ctx context.Context) error {
 			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 		);
 
+		ALTER TABLE job_queue ADD COLUMN IF NOT EXISTS namespace_id TEXT NOT NULL DEFAULT '';
+		ALTER TABLE job_queue ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE job_queue ADD COLUMN IF NOT EXISTS lease_owner TEXT;
+		ALTER TABLE job_queue ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP WITH TIME ZONE;
+
 		-- Index for efficient queue operations
 		CREATE INDEX IF NOT EXISTS idx_job_queue_pending ON job_queue(created_at);
+		CREATE INDEX IF NOT EXISTS idx_job_queue_priority ON job_queue(priority DESC, created_at);
 		CREATE INDEX IF NOT EXISTS idx_job_queue_exec_id ON job_queue(exec_id);
+		CREATE INDEX IF NOT EXISTS idx_job_queue_lease ON job_queue(lease_owner, lease_expires_at);
 	`
 
 	_, err := p.db.ExecContext(ctx, query)
@@ -39,54 +109,216 @@ func (p *PostgresStorage) Initialize(ctx context.Context) error {
 // Put adds a job to the queue
 func (p *PostgresStorage) Put(ctx context.Context, job Job) error {
 	query := `
-		INSERT INTO job_queue (exec_id, payload, created_at)
-		VALUES ($1, $2, $3)
+		INSERT INTO job_queue (exec_id, namespace_id, priority, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id
 	`
 
-	err := p.db.GetContext(ctx, &job.ID, query, job.ExecID, job.Payload, job.CreatedAt)
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.GetContext(ctx, &job.ID, query, job.ExecID, job.NamespaceID, job.Priority, job.Payload, job.CreatedAt); err != nil {
+		return err
+	}
+	if err := notifyJob(ctx, tx, job); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// notifyJob notifies the listeners of a queued job. The notification is only sent when the transaction commits,
+// so the job is queued only if the notification can be sent
+func notifyJob(ctx context.Context, tx *sqlx.Tx, job Job) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, JobNotifyChannel, job.ExecID)
 	return err
 }
 
-// Get retrieves and locks a job from the queue for processing
-// When the done channel is closed, the job is removed from the queue
-func (p *PostgresStorage) Get(ctx context.Context, done chan struct{}) (Job, error) {
+// Get leases a job from the queue for processing
+// When true is received on the done channel, the job is removed from the queue.
+// Namespaces with fewer running jobs are preferred, weighted by the priority of their pending jobs,
+// so that a single namespace cannot starve the others. Namespaces that have reached their
+// maximum concurrency are skipped.
+func (p *PostgresStorage) Get(ctx context.Context, lease Lease, done chan bool) (Job, error) {
 	tx, err := p.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return Job{}, err
 	}
+	defer tx.Rollback()
 
-	// Select and lock the oldest pending job
+	// Select and lock the next job based on its namespace's share and priority.
+	// Leased jobs are the ones currently running on any instance
 	selectQuery := `
-		SELECT id, exec_id, payload, created_at
-		FROM job_queue
-		ORDER BY created_at ASC
+		WITH limits AS (
+			SELECT n.uuid::text AS namespace_id, l.max_concurrent
+			FROM unnest($2::text[], $3::int[]) AS l(name, max_concurrent)
+			INNER JOIN namespaces n ON n.name = l.name
+		), running AS (
+			SELECT namespace_id, COUNT(*) AS running
+			FROM job_queue
+			WHERE lease_owner IS NOT NULL
+			GROUP BY namespace_id
+		)
+		SELECT q.id, q.exec_id, q.namespace_id, q.priority, q.payload, q.created_at,
+			COALESCE(l.max_concurrent, $1) AS max_concurrent
+		FROM job_queue q
+		LEFT JOIN running r ON r.namespace_id = q.namespace_id
+		LEFT JOIN limits l ON l.namespace_id = q.namespace_id
+		WHERE q.lease_owner IS NULL
+		  AND NOT (q.namespace_id = ANY($4::text[]))
+		  AND (COALESCE(l.max_concurrent, $1) = 0 OR COALESCE(r.running, 0) < COALESCE(l.max_concurrent, $1))
+		ORDER BY
+			COALESCE(r.running, 0)::float / (q.priority + 1) ASC,
+			q.priority DESC,
+			q.created_at ASC
 		LIMIT 1
-		FOR UPDATE SKIP LOCKED
+		FOR UPDATE OF q SKIP LOCKED
 	`
 
+	names := make([]string, 0, len(p.namespaceLimits))
+	limits := make([]int64, 0, len(p.namespaceLimits))
+	for name, max := range p.namespaceLimits {
+		names = append(names, name)
+		limits = append(limits, int64(max))
+	}
+
+	// Namespaces which reached their limit while this worker was selecting a job
+	skipped := []string{}
+
 	var job Job
-	err = tx.GetContext(ctx, &job, selectQuery)
-	if err != nil {
-		tx.Rollback()
-		if err == sql.ErrNoRows {
-			return Job{}, ErrNoJobs
+	for {
+		var candidate struct {
+			Job
+			MaxConcurrent int `db:"max_concurrent"`
+		}
+		if err := tx.GetContext(ctx, &candidate, selectQuery, p.namespaceMax, pq.Array(names), pq.Array(limits), pq.Array(skipped)); err != nil {
+			if err == sql.ErrNoRows {
+				return Job{}, ErrNoJobs
+			}
+			return Job{}, err
+		}
+
+		if candidate.MaxConcurrent == 0 {
+			job = candidate.Job
+			break
 		}
+
+		// The running jobs counted by the select are not locked, so workers leasing jobs of the same
+		// namespace at the same time could exceed the limit. Leasing is serialized per namespace and the
+		// running jobs are counted again once the lock is held. The lock is released when the transaction ends
+		ok, err := p.lockNamespace(ctx, tx, candidate.NamespaceID, candidate.MaxConcurrent)
+		if err != nil {
+			return Job{}, err
+		}
+		if !ok {
+			skipped = append(skipped, candidate.NamespaceID)
+			continue
+		}
+
+		job = candidate.Job
+		break
+	}
+
+	leaseQuery := `UPDATE job_queue SET lease_owner = $2, lease_expires_at = NOW() + make_interval(secs => $3) WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, leaseQuery, job.ID, lease.Owner, lease.TTL.Seconds()); err != nil {
+		return Job{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
 		return Job{}, err
 	}
+	job.LeaseOwner = lease.Owner
 
-	// Wait for job completion in background, then delete and commit
+	// Wait for job completion in background, then delete the job.
+	// A completed job is deleted even if its lease expired while it ran, it would otherwise stay leased and count
+	// towards the namespace limit, or be recovered after it completed. Jobs claimed by another instance are left
+	// to it and abandoned jobs are left for recovery
 	go func() {
-		<-done
+		if completed := <-done; !completed {
+			return
+		}
+
+		deleteQuery := `DELETE FROM job_queue WHERE id = $1 AND lease_owner = $2`
+		_, _ = p.db.ExecContext(context.Background(), deleteQuery, job.ID, lease.Owner)
 
-		deleteQuery := `DELETE FROM job_queue WHERE id = $1`
-		_, _ = tx.ExecContext(context.Background(), deleteQuery, job.ID)
-		_ = tx.Commit()
+		// A slot was freed, jobs skipped due to namespace limits can be picked up now
+		p.notify()
 	}()
 
 	return job, nil
 }
 
+// lockNamespace takes the leasing lock of a namespace and reports whether a job of the namespace can be leased.
+// It returns false if another worker is leasing a job of the namespace or if the namespace is at its limit
+func (p *PostgresStorage) lockNamespace(ctx context.Context, tx *sqlx.Tx, namespaceID string, max int) (bool, error) {
+	var locked bool
+	if err := tx.GetContext(ctx, &locked, `SELECT pg_try_advisory_xact_lock($1, hashtext($2))`, namespaceLockClass, namespaceID); err != nil {
+		return false, fmt.Errorf("could not lock namespace %s: %w", namespaceID, err)
+	}
+	if !locked {
+		return false, nil
+	}
+
+	var running int
+	if err := tx.GetContext(ctx, &running, `SELECT COUNT(*) FROM job_queue WHERE namespace_id = $1 AND lease_owner IS NOT NULL`, namespaceID); err != nil {
+		return false, fmt.Errorf("could not count running jobs of namespace %s: %w", namespaceID, err)
+	}
+	return running < max, nil
+}
+
+// RenewLeases extends the leases of all the jobs held by the owner
+func (p *PostgresStorage) RenewLeases(ctx context.Context, lease Lease) error {
+	query := `UPDATE job_queue SET lease_expires_at = NOW() + make_interval(secs => $2) WHERE lease_owner = $1`
+	_, err := p.db.ExecContext(ctx, query, lease.Owner, lease.TTL.Seconds())
+	return err
+}
+
+// ClaimExpired takes over jobs whose leases have expired and whose owner is no longer a live instance.
+// The returned jobs have their previous lease owner set
+func (p *PostgresStorage) ClaimExpired(ctx context.Context, lease Lease) ([]Job, error) {
+	query := `
+		WITH expired AS (
+			SELECT j.id, j.lease_owner
+			FROM job_queue j
+			WHERE j.lease_owner IS NOT NULL AND j.lease_expires_at < NOW()
+			  AND NOT EXISTS (SELECT 1 FROM scheduler_instances i WHERE i.id = j.lease_owner)
+			FOR UPDATE OF j SKIP LOCKED
+		)
+		UPDATE job_queue q
+		SET lease_owner = $1, lease_expires_at = NOW() + make_interval(secs => $2)
+		FROM expired e
+		WHERE q.id = e.id
+		RETURNING q.id, q.exec_id, q.namespace_id, q.priority, q.payload, q.created_at, e.lease_owner
+	`
+
+	var jobs []Job
+	if err := p.db.SelectContext(ctx, &jobs, query, lease.Owner, lease.TTL.Seconds()); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Requeue releases the lease on a job and puts it back in the queue with the job's payload
+func (p *PostgresStorage) Requeue(ctx context.Context, job Job) error {
+	query := `UPDATE job_queue SET payload = $2, lease_owner = NULL, lease_expires_at = NULL WHERE id = $1`
+
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query, job.ID, job.Payload); err != nil {
+		return err
+	}
+	if err := notifyJob(ctx, tx, job); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 // Delete removes a job from the queue
 func (p *PostgresStorage) Delete(ctx context.Context, jobID int64) error {
 	query := `DELETE FROM job_queue WHERE id = $1`
@@ -104,5 +336,8 @@ func (p *PostgresStorage) CancelByExecID(ctx context.Context, execID string) err
 // Close closes the storage backend
 func (p *PostgresStorage) Close() error {
 	// The database connection is managed externally, so we don't close it here
+	if p.listener != nil {
+		return p.listener.Close()
+	}
 	return nil
 }
@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+// newTestStorage connects to the database in FLOWCTL_TEST_DB_URL with the flowctl migrations applied.
+// The job queue is emptied, the database should not be used by a running instance
+func newTestStorage(t *testing.T) *PostgresStorage {
+	t.Helper()
+
+	dbURL := os.Getenv("FLOWCTL_TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("FLOWCTL_TEST_DB_URL not set")
+	}
+
+	db, err := sqlx.Connect("postgres", dbURL)
+	if err != nil {
+		t.Fatalf("could not connect to database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	p := NewPostgresStorage(db)
+	if err := p.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if _, err := db.Exec(`DELETE FROM job_queue`); err != nil {
+		t.Fatalf("could not empty the job queue: %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM job_queue`) })
+
+	return p
+}
+
+func putJob(t *testing.T, p *PostgresStorage, namespaceID string, priority int) {
+	t.Helper()
+
+	job, err := NewJob(uuid.NewString(), map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.NamespaceID = namespaceID
+	job.Priority = priority
+	if err := p.Put(context.Background(), job); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+}
+
+// leaseDone returns the done channel of a leased job, the job is completed when the test ends
+func leaseDone(t *testing.T) chan bool {
+	done := make(chan bool, 1)
+	t.Cleanup(func() { done <- true })
+	return done
+}
+
+func TestGetNamespaceMaxConcurrency(t *testing.T) {
+	p := newTestStorage(t)
+	p.SetNamespaceMaxConcurrency(2, nil)
+
+	namespaceID := uuid.NewString()
+	for i := 0; i < 8; i++ {
+		putJob(t, p, namespaceID, 0)
+	}
+
+	// Workers of different instances lease jobs at the same time
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		leased int
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := p.Get(context.Background(), Lease{Owner: uuid.NewString(), TTL: time.Minute}, leaseDone(t))
+			if errors.Is(err, ErrNoJobs) {
+				return
+			}
+			if err != nil {
+				t.Errorf("Get() error = %v", err)
+				return
+			}
+			mu.Lock()
+			leased++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if leased != 2 {
+		t.Errorf("leased %d jobs, want 2", leased)
+	}
+}
+
+func TestGetPriority(t *testing.T) {
+	p := newTestStorage(t)
+
+	namespaceID := uuid.NewString()
+	for _, priority := range []int{10, MaxPriority, MinPriority} {
+		putJob(t, p, namespaceID, priority)
+	}
+
+	lease := Lease{Owner: uuid.NewString(), TTL: time.Minute}
+	for _, want := range []int{MaxPriority, 10, MinPriority} {
+		job, err := p.Get(context.Background(), lease, leaseDone(t))
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if job.Priority != want {
+			t.Errorf("got job with priority %d, want %d", job.Priority, want)
+		}
+	}
+}
+
+func TestClaimExpiredLiveOwner(t *testing.T) {
+	p := newTestStorage(t)
+	ctx := context.Background()
+
+	putJob(t, p, uuid.NewString(), 0)
+
+	owner := Lease{Owner: uuid.NewString(), TTL: 100 * time.Millisecond}
+	if _, err := p.db.Exec(`INSERT INTO scheduler_instances (id) VALUES ($1)`, owner.Owner); err != nil {
+		t.Fatalf("could not register instance: %v", err)
+	}
+	t.Cleanup(func() { p.db.Exec(`DELETE FROM scheduler_instances WHERE id = $1`, owner.Owner) })
+
+	if _, err := p.Get(ctx, owner, leaseDone(t)); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	time.Sleep(2 * owner.TTL)
+
+	// The lease expired but the owner is still live
+	recovering := Lease{Owner: uuid.NewString(), TTL: time.Minute}
+	jobs, err := p.ClaimExpired(ctx, recovering)
+	if err != nil {
+		t.Fatalf("ClaimExpired() error = %v", err)
+	}
+	if len(jobs) != 0 {
+		t.Fatalf("claimed %d jobs of a live instance", len(jobs))
+	}
+
+	if _, err := p.db.Exec(`DELETE FROM scheduler_instances WHERE id = $1`, owner.Owner); err != nil {
+		t.Fatal(err)
+	}
+	jobs, err = p.ClaimExpired(ctx, recovering)
+	if err != nil {
+		t.Fatalf("ClaimExpired() error = %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].LeaseOwner != owner.Owner {
+		t.Fatalf("expected the job of the stopped instance to be claimed, got %v", jobs)
+	}
+}
+
+func TestPutNotifies(t *testing.T) {
+	p := newTestStorage(t)
+	if err := p.Listen(os.Getenv("FLOWCTL_TEST_DB_URL")); err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { p.listener.Close() })
+
+	putJob(t, p, uuid.NewString(), 0)
+
+	select {
+	case <-p.Notifications():
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification received for the queued job")
+	}
+}
+
+func TestGetDeletesJobWithExpiredLease(t *testing.T) {
+	p := newTestStorage(t)
+	ctx := context.Background()
+
+	namespaceID := uuid.NewString()
+	putJob(t, p, namespaceID, 0)
+
+	done := make(chan bool, 1)
+	owner := Lease{Owner: uuid.NewString(), TTL: 100 * time.Millisecond}
+	job, err := p.Get(ctx, owner, done)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	// The job completes after its lease expired
+	time.Sleep(2 * owner.TTL)
+	done <- true
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var count int
+		if err := p.db.Get(&count, `SELECT count(*) FROM job_queue WHERE id = $1`, job.ID); err != nil {
+			t.Fatal(err)
+		}
+		if count == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("completed job with an expired lease was not deleted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Nothing is left for recovery once the lease is renewed or claimed
+	if err := p.RenewLeases(ctx, owner); err != nil {
+		t.Fatal(err)
+	}
+	jobs, err := p.ClaimExpired(ctx, Lease{Owner: uuid.NewString(), TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("ClaimExpired() error = %v", err)
+	}
+	for _, j := range jobs {
+		if j.ID == job.ID {
+			t.Fatal("completed job was claimed for recovery")
+		}
+	}
+}
@@ -7,12 +7,27 @@ import (
 	"time"
 )
 
+const (
+	MinPriority = 0
+	MaxPriority = 100
+)
+
 // Job represents a job in the queue
 type Job struct {
-	ID        int64     `json:"id" db:"id"`
-	ExecID    string    `json:"exec_id" db:"exec_id"`
-	Payload   []byte    `json:"payload" db:"payload"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID          int64     `json:"id" db:"id"`
+	ExecID      string    `json:"exec_id" db:"exec_id"`
+	NamespaceID string    `json:"namespace_id" db:"namespace_id"`
+	Priority    int       `json:"priority" db:"priority"`
+	Payload     []byte    `json:"payload" db:"payload"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	LeaseOwner  string    `json:"lease_owner" db:"lease_owner"`
+}
+
+// Lease identifies the scheduler instance processing a job.
+// A job whose lease is not renewed within the TTL is considered orphaned
+type Lease struct {
+	Owner string
+	TTL   time.Duration
 }
 
 var (
@@ -27,10 +42,21 @@ type Storage interface {
 	// Put adds a job to the queue
 	Put(ctx context.Context, job Job) error
 
-	// Get retrieves and locks a job from the queue for processing
-	// The job remains locked until the done channel is closed
+	// Get retrieves and leases a job from the queue for processing
+	// Jobs are selected fairly across namespaces, weighted by their priority
+	// The job remains leased until a value is received on the done channel. true removes the job from
+	// the queue, false leaves it leased by the lease owner so that it is recovered once the owner is gone
 	// Returns ErrNoJobs if no jobs are available
-	Get(ctx context.Context, done chan struct{}) (Job, error)
+	Get(ctx context.Context, lease Lease, done chan bool) (Job, error)
+
+	// RenewLeases extends the leases of all the jobs held by the lease owner
+	RenewLeases(ctx context.Context, lease Lease) error
+
+	// ClaimExpired takes over the jobs whose leases have expired and whose owner is no longer live
+	ClaimExpired(ctx context.Context, lease Lease) ([]Job, error)
+
+	// Requeue releases the lease on a job and puts it back in the queue
+	Requeue(ctx context.Context, job Job) error
 
 	// Delete removes a job from the queue
 	Delete(ctx context.Context, jobID int64) error
@@ -42,6 +68,14 @@ type Storage interface {
 	Close() error
 }
 
+// Notifier is implemented by storage backends that can notify when new jobs are available.
+// Schedulers use it to pick up jobs as soon as they are queued instead of polling the queue.
+type Notifier interface {
+	// Notifications returns a channel that receives a value whenever jobs may be available.
+	// A nil channel is returned if notifications are not enabled
+	Notifications() <-chan struct{}
+}
+
 // Helper function to create a job
 func NewJob(execID string, payload any) (Job, error) {
 	payloadBytes, err := json.Marshal(payload)
@@ -6,8 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"time"
 
+	"github.com/cvhariharan/flowctl/sdk/executor"
+	"github.com/cvhariharan/flowctl/sdk/remoteclient"
 	"github.com/quic-go/quic-go"
 )
 
@@ -56,10 +59,14 @@ type AuthMethod string
 const (
 	AuthMethodPrivateKey AuthMethod = "private_key"
 	AuthMethodPassword   AuthMethod = "password"
+	// AuthMethodSSHCA nodes have the private key of an SSH CA, a certificate is signed for every connection
+	AuthMethodSSHCA AuthMethod = "ssh_ca"
+	// AuthMethodCertificate uses a signed certificate, it replaces AuthMethodSSHCA once the certificate is issued
+	AuthMethodCertificate AuthMethod = "certificate"
 )
 
 type ExecResults struct {
-	result map[string]string
+	result map[string]any
 	err    error
 }
 
@@ -72,19 +79,38 @@ type Node struct {
 	OSFamily       string
 	ConnectionType string
 	Tags           []string
+	MaxConcurrency int
 	Auth           NodeAuth
+	Become         NodeBecome
+	// HostKey is the trusted SSH host key of the node
+	HostKey string
+	// JumpHost is the node through which the node is reached, if any
+	JumpHost *Node
 }
 
 // CheckConnectivity can be used to check if a remote node is accessible at the given IP:Port
 // The default connection timeout is 5 seconds
+// Nodes with a jump host are checked by connecting to the node from the jump host,
+// a *remoteclient.JumpHostError is returned if a jump host in the chain is not accessible
 // Non-nil error is returned if the node is not accessible
 func (n *Node) CheckConnectivity() error {
-	address := fmt.Sprintf("%s:%d", n.Hostname, n.Port)
+	address := net.JoinHostPort(n.Hostname, strconv.Itoa(n.Port))
+
+	// Docker nodes are checked by the remote client, which inspects the container when connecting
+	if n.ConnectionType == "docker" {
+		return nil
+	}
+
+	if n.JumpHost != nil {
+		return n.checkConnectivityThroughJumpHost(address)
+	}
 
 	if n.ConnectionType == "qssh" {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
+		// No data is sent over the connection, the node is verified using its SSH host key
+		// when the remote client connects
 		tlsConfig := &tls.Config{
 			InsecureSkipVerify: true,
 		}
@@ -107,10 +133,102 @@ func (n *Node) CheckConnectivity() error {
 	return nil
 }
 
+// checkConnectivityThroughJumpHost connects to the jump host chain and opens a connection to the node from the last jump host
+func (n *Node) checkConnectivityThroughJumpHost(address string) error {
+	jumpHost := n.remoteJumpHost()
+	client, err := remoteclient.GetClient(jumpHost.ConnectionType, jumpHost.Config)
+	if err != nil {
+		return &remoteclient.JumpHostError{
+			Name:     jumpHost.Name,
+			Hostname: jumpHost.Config.Hostname,
+			Port:     jumpHost.Config.Port,
+			Err:      err,
+		}
+	}
+	defer client.Close()
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		conn, err := client.Dial("tcp", address)
+		resultCh <- result{conn, err}
+	}()
+
+	select {
+	case res := <-resultCh:
+		if res.err != nil {
+			return fmt.Errorf("failed to connect to %s through jump host %s: %w", address, n.JumpHost.Name, res.err)
+		}
+		res.conn.Close()
+		return nil
+	case <-time.After(5 * time.Second):
+		return fmt.Errorf("timed out connecting to %s through jump host %s", address, n.JumpHost.Name)
+	}
+}
+
+// remoteJumpHost returns the jump host chain of the node used by remote clients
+func (n *Node) remoteJumpHost() *remoteclient.JumpHost {
+	if n.JumpHost == nil {
+		return nil
+	}
+	j := n.JumpHost
+	return &remoteclient.JumpHost{
+		Name:           j.Name,
+		ConnectionType: j.ConnectionType,
+		Config: remoteclient.NodeConfig{
+			Hostname: j.Hostname,
+			Port:     j.Port,
+			Username: j.Username,
+			Auth: remoteclient.NodeAuth{
+				Method:      string(j.Auth.Method),
+				Key:         j.Auth.Key,
+				Certificate: j.Auth.Certificate,
+			},
+			HostKey:  j.HostKey,
+			JumpHost: j.remoteJumpHost(),
+		},
+	}
+}
+
+// failedHop returns the node in the jump host chain of node whose connection failed with err
+func failedHop(node Node, err error) Node {
+	var jhErr *remoteclient.JumpHostError
+	for node.JumpHost != nil && errors.As(err, &jhErr) {
+		node = *node.JumpHost
+		err = jhErr.Err
+	}
+	return node
+}
+
 type NodeAuth struct {
 	CredentialID string
 	Method       AuthMethod
 	Key          string
+	// Certificate is the SSH certificate of Key when using AuthMethodCertificate
+	Certificate string
+}
+
+// SSHCertificate is a short-lived certificate issued to connect to a node during an execution
+type SSHCertificate struct {
+	ExecID      string
+	ActionID    string
+	NodeName    string
+	Serial      uint64
+	KeyID       string
+	Principals  []string
+	ValidAfter  time.Time
+	ValidBefore time.Time
+}
+
+// NodeBecome contains the default privilege escalation settings of a node
+type NodeBecome struct {
+	Enabled  bool
+	User     string
+	Method   string
+	Password string
 }
 
 type Input struct {
@@ -124,23 +242,37 @@ type Input struct {
 }
 
 type Action struct {
-	ID        string         `yaml:"id" validate:"required,alphanum_underscore"`
-	Name      string         `yaml:"name" validate:"required"`
-	Executor  string         `yaml:"executor" validate:"required,oneof=script docker"`
-	With      map[string]any `yaml:"with" validate:"required"`
-	Approval  bool           `yaml:"approval"`
-	Variables []Variable     `yaml:"variables"`
-	On        []Node         `yaml:"on"`
+	ID          string         `yaml:"id" validate:"required,alphanum_underscore"`
+	Name        string         `yaml:"name" validate:"required"`
+	Executor    string         `yaml:"executor" validate:"required"`
+	With        map[string]any `yaml:"with" validate:"required"`
+	Approval    bool           `yaml:"approval"`
+	Variables   []Variable     `yaml:"variables"`
+	On          []Node         `yaml:"on"`
+	Locks       []string       `yaml:"locks"`
+	LockTimeout time.Duration  `yaml:"lock_timeout"`
+	// If is an expression evaluated for each node, the action is skipped on nodes where it is false
+	If string `yaml:"if"`
+	// Artifacts are glob patterns of the files in the artifacts directory that are kept after the execution
+	Artifacts []string `yaml:"artifacts"`
+	// Become overrides the privilege escalation settings of the nodes when set
+	Become           *bool  `yaml:"become"`
+	BecomeUser       string `yaml:"become_user"`
+	BecomeMethod     string `yaml:"become_method"`
+	BecomeCredential string `yaml:"become_credential"`
 }
 
 type Metadata struct {
-	ID          string   `yaml:"id" validate:"required,alphanum_underscore"`
-	DBID        int32    `yaml:"-"`
-	Name        string   `yaml:"name" validate:"required"`
-	Description string   `yaml:"description"`
-	Schedules   []string `yaml:"schedules"`
-	SrcDir      string   `yaml:"-"`
-	Namespace   string   `yaml:"namespace"`
+	ID          string        `yaml:"id" validate:"required,alphanum_underscore"`
+	DBID        int32         `yaml:"-"`
+	Name        string        `yaml:"name" validate:"required"`
+	Description string        `yaml:"description"`
+	Schedules   []string      `yaml:"schedules"`
+	SrcDir      string        `yaml:"-"`
+	Namespace   string        `yaml:"namespace"`
+	Idempotent  bool          `yaml:"idempotent"`
+	Locks       []string      `yaml:"locks"`
+	LockTimeout time.Duration `yaml:"lock_timeout"`
 }
 
 type Variable map[string]any
@@ -191,12 +323,19 @@ type FlowExecutionPayload struct {
 	TriggerType       TriggerType
 	UserUUID          string
 	FlowDirectory     string
+	Priority          int
 }
 
 // Hook function types for flow execution
 type HookFn func(ctx context.Context, execID string, action Action, namespaceID string) error
 type SecretsProviderFn func(ctx context.Context, flowID string, namespaceID string) (map[string]string, error)
 type FlowLoaderFn func(ctx context.Context, flowSlug string, namespaceUUID string) (Flow, error)
+type CredentialProviderFn func(ctx context.Context, name string, keyType string, namespaceID string) (executor.Credential, error)
+type BecomeCredentialProviderFn func(ctx context.Context, name string, namespaceID string) (string, error)
+type HostKeyRecorderFn func(ctx context.Context, nodeID string, namespaceID string, hostKey string) error
+type CertificateRecorderFn func(ctx context.Context, namespaceID string, cert SSHCertificate) error
+type InventorySyncFn func(ctx context.Context) error
+type NodeLoaderFn func(ctx context.Context, nodeNames []string, namespaceID string) ([]Node, error)
 
 // SchedulerDependencies contains dependencies needed by the scheduler
 type SchedulerDependencies struct {
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS resource_locks;
+DROP TYPE IF EXISTS resource_lock_status;
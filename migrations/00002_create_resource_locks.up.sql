@@ -0,0 +1,23 @@
+CREATE TYPE resource_lock_status AS ENUM (
+    'held',
+    'waiting'
+);
+
+CREATE TABLE IF NOT EXISTS resource_locks (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(150) NOT NULL,
+    exec_id VARCHAR(36) NOT NULL,
+    action_id TEXT,
+    flow_id INTEGER NOT NULL,
+    status resource_lock_status NOT NULL DEFAULT 'waiting',
+    namespace_id INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    acquired_at TIMESTAMP WITH TIME ZONE,
+    FOREIGN KEY (flow_id) REFERENCES flows(id) ON DELETE CASCADE,
+    FOREIGN KEY (namespace_id) REFERENCES namespaces(id) ON DELETE CASCADE
+);
+
+-- Only a single holder is allowed per lock name within a namespace
+CREATE UNIQUE INDEX idx_resource_locks_held ON resource_locks(name, namespace_id) WHERE status = 'held';
+CREATE INDEX idx_resource_locks_name_namespace ON resource_locks(name, namespace_id);
+CREATE INDEX idx_resource_locks_exec_id ON resource_locks(exec_id);
@@ -0,0 +1 @@
+ALTER TABLE nodes ADD COLUMN max_concurrency INTEGER NOT NULL DEFAULT 0 CHECK (max_concurrency >= 0);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS scheduler_instances (
+    id VARCHAR(36) PRIMARY KEY,
+    hostname TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_heartbeat TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_scheduler_instances_last_heartbeat ON scheduler_instances(last_heartbeat);
@@ -0,0 +1,4 @@
+ALTER TABLE nodes DROP COLUMN IF EXISTS become_credential_id;
+ALTER TABLE nodes DROP COLUMN IF EXISTS become_method;
+ALTER TABLE nodes DROP COLUMN IF EXISTS become_user;
+ALTER TABLE nodes DROP COLUMN IF EXISTS become;
@@ -0,0 +1,4 @@
+ALTER TABLE nodes ADD COLUMN become BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE nodes ADD COLUMN become_user VARCHAR(150) NOT NULL DEFAULT '';
+ALTER TABLE nodes ADD COLUMN become_method VARCHAR(10) NOT NULL DEFAULT 'sudo' CHECK (become_method IN ('sudo', 'su', 'doas'));
+ALTER TABLE nodes ADD COLUMN become_credential_id INTEGER REFERENCES credentials(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE nodes DROP COLUMN IF EXISTS pending_host_key;
+ALTER TABLE nodes DROP COLUMN IF EXISTS host_key;
@@ -0,0 +1,2 @@
+ALTER TABLE nodes ADD COLUMN host_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE nodes ADD COLUMN pending_host_key TEXT NOT NULL DEFAULT '';
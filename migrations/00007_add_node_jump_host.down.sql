@@ -0,0 +1 @@
+ALTER TABLE nodes DROP COLUMN IF EXISTS jump_host_id;
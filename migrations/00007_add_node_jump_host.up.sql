@@ -0,0 +1 @@
+ALTER TABLE nodes ADD COLUMN jump_host_id INTEGER REFERENCES nodes(id) ON DELETE RESTRICT;
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS execution_ssh_certificates;
+
+-- Enum values can not be removed, the type is recreated without ssh_ca
+UPDATE nodes SET auth_method = 'private_key' WHERE auth_method = 'ssh_ca';
+ALTER TABLE nodes ALTER COLUMN auth_method DROP DEFAULT;
+ALTER TYPE authentication_method RENAME TO authentication_method_old;
+CREATE TYPE authentication_method AS ENUM (
+    'private_key',
+    'password'
+);
+ALTER TABLE nodes ALTER COLUMN auth_method TYPE authentication_method USING auth_method::text::authentication_method;
+ALTER TABLE nodes ALTER COLUMN auth_method SET DEFAULT 'private_key';
+DROP TYPE authentication_method_old;
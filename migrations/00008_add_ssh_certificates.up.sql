@@ -0,0 +1,18 @@
+ALTER TYPE authentication_method ADD VALUE IF NOT EXISTS 'ssh_ca';
+
+CREATE TABLE IF NOT EXISTS execution_ssh_certificates (
+    id SERIAL PRIMARY KEY,
+    exec_id VARCHAR(36) NOT NULL,
+    action_id VARCHAR(50) NOT NULL,
+    node_name VARCHAR(150) NOT NULL,
+    serial BIGINT NOT NULL,
+    key_id TEXT NOT NULL,
+    principals TEXT[] NOT NULL,
+    valid_after TIMESTAMP WITH TIME ZONE NOT NULL,
+    valid_before TIMESTAMP WITH TIME ZONE NOT NULL,
+    namespace_id INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (namespace_id) REFERENCES namespaces(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_execution_ssh_certificates_exec_id ON execution_ssh_certificates(exec_id);
+CREATE INDEX idx_execution_ssh_certificates_serial ON execution_ssh_certificates(serial);
@@ -0,0 +1,11 @@
+-- Enum values can not be removed, the type is recreated without docker
+DELETE FROM nodes WHERE connection_type = 'docker';
+ALTER TABLE nodes ALTER COLUMN connection_type DROP DEFAULT;
+ALTER TYPE connection_type RENAME TO connection_type_old;
+CREATE TYPE connection_type AS ENUM (
+    'ssh',
+    'qssh'
+);
+ALTER TABLE nodes ALTER COLUMN connection_type TYPE connection_type USING connection_type::text::connection_type;
+ALTER TABLE nodes ALTER COLUMN connection_type SET DEFAULT 'ssh';
+DROP TYPE connection_type_old;
@@ -0,0 +1 @@
+ALTER TYPE connection_type ADD VALUE IF NOT EXISTS 'docker';
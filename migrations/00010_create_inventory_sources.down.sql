@@ -0,0 +1,3 @@
+ALTER TABLE nodes DROP COLUMN IF EXISTS inventory_source_id;
+DROP TABLE IF EXISTS inventory_sources;
+DROP TYPE IF EXISTS inventory_source_type;
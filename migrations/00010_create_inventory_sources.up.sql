@@ -0,0 +1,35 @@
+CREATE TYPE inventory_source_type AS ENUM (
+    'file',
+    'script'
+);
+
+CREATE TABLE IF NOT EXISTS inventory_sources (
+    id SERIAL PRIMARY KEY,
+    uuid UUID NOT NULL DEFAULT uuid_generate_v4(),
+    name VARCHAR(150) NOT NULL,
+    source_type inventory_source_type NOT NULL,
+    -- Path of the file or script relative to the inventory directory
+    path TEXT NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    -- Seconds between syncs
+    sync_interval INTEGER NOT NULL DEFAULT 3600,
+    -- Defaults for hosts which do not set them in the inventory
+    port INTEGER NOT NULL DEFAULT 22,
+    username VARCHAR(150) NOT NULL DEFAULT '',
+    connection_type connection_type NOT NULL DEFAULT 'ssh',
+    credential_id INTEGER REFERENCES credentials(id) ON DELETE SET NULL,
+    next_sync_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_synced_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT NOT NULL DEFAULT '',
+    last_report JSONB NOT NULL DEFAULT '{}',
+    namespace_id INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (namespace_id) REFERENCES namespaces(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_inventory_sources_uuid ON inventory_sources(uuid);
+CREATE UNIQUE INDEX idx_inventory_sources_name_namespace ON inventory_sources(name, namespace_id);
+CREATE INDEX idx_inventory_sources_next_sync_at ON inventory_sources(next_sync_at);
+
+-- Nodes created by a source are updated and removed when the source is synced
+ALTER TABLE nodes ADD COLUMN inventory_source_id INTEGER REFERENCES inventory_sources(id) ON DELETE SET NULL;
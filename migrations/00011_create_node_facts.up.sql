@@ -0,0 +1,18 @@
+-- Facts gathered from nodes by the periodic health check
+CREATE TABLE IF NOT EXISTS node_facts (
+    node_id INTEGER PRIMARY KEY REFERENCES nodes(id) ON DELETE CASCADE,
+    -- NULL until the node has been checked
+    reachable BOOLEAN,
+    error TEXT NOT NULL DEFAULT '',
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    -- The facts of the last successful check are kept while the node is unreachable
+    os TEXT NOT NULL DEFAULT '',
+    kernel TEXT NOT NULL DEFAULT '',
+    arch TEXT NOT NULL DEFAULT '',
+    uptime_seconds BIGINT NOT NULL DEFAULT 0,
+    disk_free_bytes BIGINT NOT NULL DEFAULT 0,
+    checked_at TIMESTAMP WITH TIME ZONE,
+    gathered_at TIMESTAMP WITH TIME ZONE,
+    next_check_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_node_facts_next_check_at ON node_facts(next_check_at);
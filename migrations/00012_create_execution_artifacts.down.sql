@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS execution_artifacts;
+ALTER TABLE namespaces DROP COLUMN IF EXISTS artifact_retention_days;
@@ -0,0 +1,22 @@
+-- Artifacts are kept for this many days, NULL uses the retention set in the server config and 0 keeps them forever
+ALTER TABLE namespaces ADD COLUMN artifact_retention_days INTEGER CHECK (artifact_retention_days >= 0);
+
+-- Files declared as artifacts by actions, the contents are stored in the artifact bucket under storage_key
+CREATE TABLE IF NOT EXISTS execution_artifacts (
+    id SERIAL PRIMARY KEY,
+    uuid UUID NOT NULL DEFAULT uuid_generate_v4(),
+    exec_id VARCHAR(36) NOT NULL,
+    action_id VARCHAR(50) NOT NULL,
+    node_name VARCHAR(150) NOT NULL,
+    path TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    storage_key TEXT NOT NULL,
+    namespace_id INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (namespace_id) REFERENCES namespaces(id) ON DELETE CASCADE,
+    CONSTRAINT unique_execution_artifact UNIQUE (exec_id, action_id, node_name, path)
+);
+CREATE UNIQUE INDEX idx_execution_artifacts_uuid ON execution_artifacts(uuid);
+CREATE INDEX idx_execution_artifacts_exec_id ON execution_artifacts(exec_id);
+CREATE INDEX idx_execution_artifacts_created_at ON execution_artifacts(created_at);
@@ -0,0 +1 @@
+ALTER TABLE execution_log DROP COLUMN IF EXISTS priority;
@@ -0,0 +1 @@
+ALTER TABLE execution_log ADD COLUMN priority INTEGER NOT NULL DEFAULT 0 CHECK (priority >= 0);
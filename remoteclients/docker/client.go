@@ -0,0 +1,367 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+	"github.com/cvhariharan/flowctl/sdk/remoteclient"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// dockerClient is an implementation of RemoteClient which runs commands in a running container
+// using docker exec. Files are copied using the archive API of the Docker daemon
+type dockerClient struct {
+	cli       *client.Client
+	container string
+	user      string
+	// uid and gid of the user, files are uploaded with this owner so that they
+	// can be modified by the commands run in the container
+	uid int
+	gid int
+}
+
+// ServerConfig is the configuration of the docker remote client from the docker section of the flowctl config file
+type ServerConfig struct {
+	// AllowedContainers are the names of the containers that nodes can connect to, glob patterns are supported
+	AllowedContainers []string `json:"allowed_containers"`
+}
+
+func init() {
+	remoteclient.Register("docker", NewRemoteClient)
+}
+
+// isContainerAllowed checks if the container matches one of the allowed containers
+func isContainerAllowed(allowedContainers []string, name string) bool {
+	for _, pattern := range allowedContainers {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRemoteClient creates a client for the container named by the hostname of the node.
+// The Docker daemon is configured using the DOCKER_HOST environment variables of the server.
+// Only the containers allowed in the flowctl config can be connected to.
+// Commands are run as the username of the node, the default user of the container is used when it is empty
+func NewRemoteClient(config remoteclient.NodeConfig) (remoteclient.RemoteClient, error) {
+	if config.JumpHost != nil || config.Dial != nil {
+		return nil, errors.New("docker nodes can not be reached through a jump host")
+	}
+
+	var server ServerConfig
+	if err := executor.GetServerConfig("docker", &server); err != nil {
+		return nil, err
+	}
+	if !isContainerAllowed(server.AllowedContainers, config.Hostname) {
+		return nil, fmt.Errorf("container %s is not allowed, it must be added to the allowed containers in the flowctl config", config.Hostname)
+	}
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client: %w", err)
+	}
+
+	c := &dockerClient{
+		cli:       cli,
+		container: config.Hostname,
+		user:      config.Username,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := c.KeepAlive(); err != nil {
+		cli.Close()
+		return nil, err
+	}
+
+	// Resolving the user also verifies that commands can be run in the container
+	var out bytes.Buffer
+	if err := c.exec(ctx, []string{"sh", "-c", "id -u && id -g"}, remoteclient.RunOptions{}, &out, io.Discard); err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("failed to get user of container %s: %w", c.container, err)
+	}
+	ids := strings.Fields(out.String())
+	if len(ids) != 2 {
+		cli.Close()
+		return nil, fmt.Errorf("unexpected output from id in container %s: %q", c.container, out.String())
+	}
+	if c.uid, err = strconv.Atoi(ids[0]); err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("invalid uid %q in container %s", ids[0], c.container)
+	}
+	if c.gid, err = strconv.Atoi(ids[1]); err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("invalid gid %q in container %s", ids[1], c.container)
+	}
+
+	return c, nil
+}
+
+// KeepAlive checks that the container is still running
+func (c *dockerClient) KeepAlive() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	info, err := c.cli.ContainerInspect(ctx, c.container)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container %s: %w", c.container, err)
+	}
+	if info.State == nil || !info.State.Running {
+		return fmt.Errorf("container %s is not running", c.container)
+	}
+	return nil
+}
+
+// Close closes the connection to the Docker daemon
+func (c *dockerClient) Close() error {
+	return c.cli.Close()
+}
+
+// Dial is not supported, the Docker daemon does not forward connections into containers
+func (c *dockerClient) Dial(network, address string) (net.Conn, error) {
+	return nil, fmt.Errorf("could not dial %s://%s: docker nodes do not support port forwarding", network, address)
+}
+
+// RunCommand executes a shell command in the container
+func (c *dockerClient) RunCommand(ctx context.Context, command string, stdout, stderr io.Writer) error {
+	return c.RunCommandWithOptions(ctx, command, remoteclient.RunOptions{}, stdout, stderr)
+}
+
+// RunCommandWithOptions executes a shell command in the container with the given input and terminal
+func (c *dockerClient) RunCommandWithOptions(ctx context.Context, command string, opts remoteclient.RunOptions, stdout, stderr io.Writer) error {
+	if err := c.exec(ctx, []string{"sh", "-c", command}, opts, stdout, stderr); err != nil {
+		return fmt.Errorf("failed to run command on remote: %w", err)
+	}
+	return nil
+}
+
+// pidWriter reads the PID printed on the first line of the output of a command started by exec
+// and writes the rest of the output to w
+type pidWriter struct {
+	w    io.Writer
+	line []byte
+	read bool
+	pid  atomic.Int64
+}
+
+func (p *pidWriter) Write(b []byte) (int, error) {
+	n := len(b)
+	if !p.read {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			p.line = append(p.line, b...)
+			return n, nil
+		}
+		p.line = append(p.line, b[:i]...)
+		p.read = true
+		if pid, err := strconv.ParseInt(strings.TrimSpace(string(p.line)), 10, 64); err == nil {
+			p.pid.Store(pid)
+		}
+		b = b[i+1:]
+	}
+
+	if len(b) > 0 {
+		if _, err := p.w.Write(b); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// exec runs cmd in the container and waits for it to exit. A non-zero exit code is returned as an error.
+// The command is stopped if the context is cancelled
+func (c *dockerClient) exec(ctx context.Context, cmd []string, opts remoteclient.RunOptions, stdout, stderr io.Writer) error {
+	// The PID of the command is printed before it is run so that it can be killed on cancellation
+	created, err := c.cli.ContainerExecCreate(ctx, c.container, container.ExecOptions{
+		User:         c.user,
+		Cmd:          append([]string{"sh", "-c", `echo $$ && exec "$@"`, "sh"}, cmd...),
+		Tty:          opts.TTY,
+		AttachStdin:  opts.Stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	resp, err := c.cli.ContainerExecAttach(ctx, created.ID, container.ExecAttachOptions{Tty: opts.TTY})
+	if err != nil {
+		return fmt.Errorf("failed to attach to exec: %w", err)
+	}
+	defer resp.Close()
+
+	if opts.Stdin != nil {
+		go func() {
+			io.Copy(resp.Conn, opts.Stdin)
+			resp.CloseWrite()
+		}()
+	}
+
+	out := &pidWriter{w: stdout}
+	resultCh := make(chan error, 1)
+	go func() {
+		// A terminal combines stdout and stderr into a single raw stream
+		if opts.TTY {
+			_, err := io.Copy(out, resp.Reader)
+			resultCh <- err
+			return
+		}
+		_, err := stdcopy.StdCopy(out, stderr, resp.Reader)
+		resultCh <- err
+	}()
+
+	select {
+	case <-ctx.Done():
+		// Closing the connection stops waiting for the command, it is not killed by the Docker daemon
+		resp.Close()
+		c.kill(out.pid.Load())
+		return ctx.Err()
+	case err := <-resultCh:
+		if err != nil {
+			return fmt.Errorf("failed to read exec output: %w", err)
+		}
+	}
+
+	inspect, err := c.cli.ContainerExecInspect(ctx, created.ID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect exec: %w", err)
+	}
+	if inspect.ExitCode != 0 {
+		return fmt.Errorf("command exited with status %d", inspect.ExitCode)
+	}
+	return nil
+}
+
+// kill stops a command started by exec along with its process group when it leads one
+func (c *dockerClient) kill(pid int64) {
+	if pid <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The command is killed as root since it could have switched users using become
+	script := fmt.Sprintf("kill -TERM -- -%d 2>/dev/null || kill -TERM %d", pid, pid)
+	created, err := c.cli.ContainerExecCreate(ctx, c.container, container.ExecOptions{
+		User: "0",
+		Cmd:  []string{"sh", "-c", script},
+	})
+	if err != nil {
+		return
+	}
+	c.cli.ContainerExecStart(ctx, created.ID, container.ExecStartOptions{Detach: true})
+}
+
+// Download copies a file from the container to a local path
+func (c *dockerClient) Download(ctx context.Context, remotePath, localPath string) error {
+	if err := c.downloadFile(ctx, remotePath, localPath); err != nil {
+		return fmt.Errorf("failed to download file from remote: %w", err)
+	}
+	return nil
+}
+
+// Upload copies a file from the local path to a path in the container
+func (c *dockerClient) Upload(ctx context.Context, localPath, remotePath string) error {
+	if err := c.uploadFile(ctx, localPath, remotePath); err != nil {
+		return fmt.Errorf("failed to upload file to remote: %w", err)
+	}
+	return nil
+}
+
+func (c *dockerClient) downloadFile(ctx context.Context, remotePath, localPath string) error {
+	rc, stat, err := c.cli.CopyFromContainer(ctx, c.container, remotePath)
+	if err != nil {
+		return fmt.Errorf("could not open remote file: %w", err)
+	}
+	defer rc.Close()
+
+	if !stat.Mode.IsRegular() {
+		return fmt.Errorf("remote path %s is not a regular file", remotePath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return fmt.Errorf("could not create local directory: %w", err)
+	}
+
+	// The file is the only entry of the archive
+	tr := tar.NewReader(rc)
+	if _, err := tr.Next(); err != nil {
+		return fmt.Errorf("could not read archive: %w", err)
+	}
+
+	localFile, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("could not create local file: %w", err)
+	}
+	defer localFile.Close()
+
+	if _, err := io.Copy(localFile, tr); err != nil {
+		return fmt.Errorf("could not copy file: %w", err)
+	}
+	return nil
+}
+
+func (c *dockerClient) uploadFile(ctx context.Context, localPath, remotePath string) error {
+	localFile, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("could not open local file: %w", err)
+	}
+	defer localFile.Close()
+
+	info, err := localFile.Stat()
+	if err != nil {
+		return fmt.Errorf("could not stat local file: %w", err)
+	}
+
+	// The archive is extracted into an existing directory
+	remoteDir := path.Dir(remotePath)
+	if remoteDir != "." && remoteDir != "/" {
+		if err := c.exec(ctx, []string{"mkdir", "-p", remoteDir}, remoteclient.RunOptions{}, io.Discard, io.Discard); err != nil {
+			return fmt.Errorf("could not create remote directory: %w", err)
+		}
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := tw.WriteHeader(&tar.Header{
+			Name:    path.Base(remotePath),
+			Mode:    int64(info.Mode().Perm()),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			Uid:     c.uid,
+			Gid:     c.gid,
+		})
+		if err == nil {
+			_, err = io.Copy(tw, localFile)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	if err := c.cli.CopyToContainer(ctx, c.container, remoteDir, pr, container.CopyToContainerOptions{}); err != nil {
+		pr.CloseWithError(err)
+		return fmt.Errorf("could not copy file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package docker
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+	"github.com/cvhariharan/flowctl/sdk/remoteclient"
+)
+
+func TestIsContainerAllowed(t *testing.T) {
+	allowed := []string{"web*", "db1"}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"web1", true},
+		{"web", true},
+		{"db1", true},
+		{"db2", false},
+		{"flowctl", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isContainerAllowed(allowed, tt.name); got != tt.want {
+			t.Errorf("isContainerAllowed(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if isContainerAllowed(nil, "web1") {
+		t.Error("expected no containers to be allowed by default")
+	}
+}
+
+func TestNewRemoteClientServerConfig(t *testing.T) {
+	// The remote client reads the docker config set for the executor
+	if err := executor.SetServerConfig("docker", map[string]any{"allowed_containers": []string{"web*"}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { executor.SetServerConfig("docker", ServerConfig{}) })
+
+	_, err := NewRemoteClient(remoteclient.NodeConfig{Hostname: "db1"})
+	if err == nil || !strings.Contains(err.Error(), "container db1 is not allowed") {
+		t.Fatalf("NewRemoteClient() error = %v", err)
+	}
+}
+
+func TestPIDWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := &pidWriter{w: &out}
+
+	// The PID line can be split across writes
+	for _, chunk := range []string{"12", "3\r\nhello", "\nworld\n"} {
+		if n, err := w.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+
+	if pid := w.pid.Load(); pid != 123 {
+		t.Errorf("pid = %d, want 123", pid)
+	}
+	if out.String() != "hello\nworld\n" {
+		t.Errorf("output = %q, want %q", out.String(), "hello\nworld\n")
+	}
+}
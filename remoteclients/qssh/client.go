@@ -24,6 +24,11 @@ func init() {
 }
 
 func NewRemoteClient(config remoteclient.NodeConfig) (remoteclient.RemoteClient, error) {
+	// QUIC runs over UDP which can not be forwarded through an SSH jump host
+	if config.Dial != nil {
+		return nil, fmt.Errorf("qssh node %s can not be reached through a jump host", config.Hostname)
+	}
+
 	var qconfig qssh.Config
 
 	switch config.Auth.Method {
@@ -35,10 +40,25 @@ func NewRemoteClient(config remoteclient.NodeConfig) (remoteclient.RemoteClient,
 		qconfig = qssh.KeyConfig(config.Username, privateKey)
 	case "password":
 		qconfig = qssh.PasswordConfig(config.Username, config.Auth.Key)
+	case "certificate":
+		signer, err := remoteclient.CertificateSigner(config.Auth.Key, config.Auth.Certificate)
+		if err != nil {
+			return nil, fmt.Errorf("could not load certificate for node %s: %w", config.Hostname, err)
+		}
+		qconfig = qssh.KeyConfig(config.Username, signer)
 	default:
 		return nil, fmt.Errorf("unsupported auth method: %s", config.Auth.Method)
 	}
 
+	hostKeyCallback, err := remoteclient.HostKeyCallback(config.Hostname, config.HostKey)
+	if err != nil {
+		return nil, err
+	}
+	// The node is authenticated using its SSH host key which is verified on top of the QUIC stream,
+	// the self-signed certificate of the QUIC transport is not used to identify the node
+	qconfig.SSHConfig.HostKeyCallback = hostKeyCallback
+	qconfig.SSHConfig.HostKeyAlgorithms = remoteclient.HostKeyAlgorithms(config.HostKey)
+
 	client, conn, err := qssh.Dial(fmt.Sprintf("%s:%d", config.Hostname, config.Port), qconfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial %s:%d: %w", config.Hostname, config.Port, err)
@@ -50,6 +70,10 @@ func NewRemoteClient(config remoteclient.NodeConfig) (remoteclient.RemoteClient,
 }
 
 func (q *qsshClient) RunCommand(ctx context.Context, command string, stdout, stderr io.Writer) error {
+	return q.RunCommandWithOptions(ctx, command, remoteclient.RunOptions{}, stdout, stderr)
+}
+
+func (q *qsshClient) RunCommandWithOptions(ctx context.Context, command string, opts remoteclient.RunOptions, stdout, stderr io.Writer) error {
 	session, err := q.sshClient.NewSession()
 	if err != nil {
 		return fmt.Errorf("could not create session: %w", err)
@@ -59,6 +83,13 @@ func (q *qsshClient) RunCommand(ctx context.Context, command string, stdout, std
 	// Set stdout and stderr writers
 	session.Stdout = stdout
 	session.Stderr = stderr
+	session.Stdin = opts.Stdin
+
+	if opts.TTY {
+		if err := session.RequestPty("xterm", 40, 200, remoteclient.TerminalModes()); err != nil {
+			return fmt.Errorf("could not request pseudo terminal: %w", err)
+		}
+	}
 
 	// Create a channel to receive the result
 	type result struct {
@@ -220,6 +251,14 @@ func (q *qsshClient) Dial(network, address string) (net.Conn, error) {
 	return conn, nil
 }
 
+// KeepAlive sends a keepalive request to check that the connection is still open
+func (q *qsshClient) KeepAlive() error {
+	if _, _, err := q.sshClient.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+		return fmt.Errorf("keepalive failed: %w", err)
+	}
+	return nil
+}
+
 func (q *qsshClient) Close() error {
 	if err := q.sshClient.Close(); err != nil {
 		return err
@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/cvhariharan/flowctl/sdk/remoteclient"
 	"github.com/pkg/sftp"
@@ -37,23 +38,50 @@ func NewRemoteClient(config remoteclient.NodeConfig) (remoteclient.RemoteClient,
 		authMethod = ssh.PublicKeys(signer)
 	case "password":
 		authMethod = ssh.Password(config.Auth.Key)
+	case "certificate":
+		signer, err := remoteclient.CertificateSigner(config.Auth.Key, config.Auth.Certificate)
+		if err != nil {
+			return nil, err
+		}
+		authMethod = ssh.PublicKeys(signer)
 	default:
 		return nil, fmt.Errorf("unsupported auth method: %s", config.Auth.Method)
 	}
 
+	hostKeyCallback, err := remoteclient.HostKeyCallback(config.Hostname, config.HostKey)
+	if err != nil {
+		return nil, err
+	}
+
 	sshConfig := &ssh.ClientConfig{
-		User:            config.Username,
-		Auth:            []ssh.AuthMethod{authMethod},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		User:              config.Username,
+		Auth:              []ssh.AuthMethod{authMethod},
+		HostKeyCallback:   hostKeyCallback,
+		HostKeyAlgorithms: remoteclient.HostKeyAlgorithms(config.HostKey),
+		Timeout:           30 * time.Second,
 	}
 
 	addr := fmt.Sprintf("%s:%d", config.Hostname, config.Port)
-	client, err := ssh.Dial("tcp", addr, sshConfig)
+	if config.Dial == nil {
+		client, err := ssh.Dial("tcp", addr, sshConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ssh client: %w", err)
+		}
+		return &sshClient{client: client}, nil
+	}
+
+	// The node is reached through a jump host
+	conn, err := config.Dial("tcp", addr)
 	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s through jump host: %w", addr, err)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, sshConfig)
+	if err != nil {
+		conn.Close()
 		return nil, fmt.Errorf("failed to create ssh client: %w", err)
 	}
 
-	return &sshClient{client: client}, nil
+	return &sshClient{client: ssh.NewClient(c, chans, reqs)}, nil
 }
 
 // Close closes the SSH client connection
@@ -69,8 +97,21 @@ func (c *sshClient) Dial(network, address string) (net.Conn, error) {
 	return c.client.Dial(network, address)
 }
 
+// KeepAlive sends a keepalive request to check that the connection is still open
+func (c *sshClient) KeepAlive() error {
+	if _, _, err := c.client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+		return fmt.Errorf("keepalive failed: %w", err)
+	}
+	return nil
+}
+
 // RunCommand executes a shell command on the remote host
 func (c *sshClient) RunCommand(ctx context.Context, command string, stdout, stderr io.Writer) error {
+	return c.RunCommandWithOptions(ctx, command, remoteclient.RunOptions{}, stdout, stderr)
+}
+
+// RunCommandWithOptions executes a shell command on the remote host with the given input and terminal
+func (c *sshClient) RunCommandWithOptions(ctx context.Context, command string, opts remoteclient.RunOptions, stdout, stderr io.Writer) error {
 	session, err := c.client.NewSession()
 	if err != nil {
 		return fmt.Errorf("failed to create SSH session: %w", err)
@@ -79,6 +120,13 @@ func (c *sshClient) RunCommand(ctx context.Context, command string, stdout, stde
 
 	session.Stdout = stdout
 	session.Stderr = stderr
+	session.Stdin = opts.Stdin
+
+	if opts.TTY {
+		if err := session.RequestPty("xterm", 40, 200, remoteclient.TerminalModes()); err != nil {
+			return fmt.Errorf("failed to request pseudo terminal: %w", err)
+		}
+	}
 
 	type result struct {
 		err error
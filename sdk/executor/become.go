@@ -0,0 +1,175 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/rs/xid"
+)
+
+const (
+	BecomeMethodSudo = "sudo"
+	BecomeMethodSu   = "su"
+	BecomeMethodDoas = "doas"
+)
+
+// passwordPrompt matches the password prompts of su and doas at the end of the output
+var passwordPrompt = regexp.MustCompile(`(?i)password[^:\n]*:\s*$`)
+
+var envNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Validate checks the privilege escalation settings
+func (b *Become) Validate() error {
+	switch b.method() {
+	case BecomeMethodSudo, BecomeMethodSu, BecomeMethodDoas:
+		return nil
+	default:
+		return fmt.Errorf("unsupported become method %q, must be one of sudo, su, doas", b.Method)
+	}
+}
+
+func (b *Become) method() string {
+	if b.Method == "" {
+		return BecomeMethodSudo
+	}
+	return b.Method
+}
+
+func (b *Become) user() string {
+	if b.User == "" {
+		return "root"
+	}
+	return b.User
+}
+
+// needsTTY returns true if the password has to be typed into a terminal.
+// su and doas only read passwords from a terminal, sudo reads it from stdin
+func (b *Become) needsTTY() bool {
+	return b.Password != "" && b.method() != BecomeMethodSudo
+}
+
+// command wraps the command so that it is run as the become user.
+// sudo, su and doas reset the environment, the variables are exported by sourcing envFile after switching
+// the user so that their values are not part of the command line. The file is removed once it is read.
+// The marker is printed after switching the user when it is not empty
+func (b *Become) command(command string, workingDir string, envFile string, marker string) string {
+	inner := command
+	if workingDir != "" {
+		inner = fmt.Sprintf("cd %s && %s", shellQuote(workingDir), inner)
+	}
+	if envFile != "" {
+		inner = fmt.Sprintf(". %s && (rm -f %s 2>/dev/null || true) && %s", shellQuote(envFile), shellQuote(envFile), inner)
+	}
+	if marker != "" {
+		inner = fmt.Sprintf("echo %s && %s", marker, inner)
+	}
+
+	target := "/bin/sh -c " + shellQuote(inner)
+
+	user := shellQuote(b.user())
+	switch b.method() {
+	case BecomeMethodSu:
+		return fmt.Sprintf("su -s /bin/sh -c %s %s", shellQuote(target), user)
+	case BecomeMethodDoas:
+		if b.Password == "" {
+			return fmt.Sprintf("doas -n -u %s %s", user, target)
+		}
+		return fmt.Sprintf("doas -u %s %s", user, target)
+	default:
+		if b.Password == "" {
+			return fmt.Sprintf("sudo -n -u %s -- %s", user, target)
+		}
+		// An empty prompt keeps the output clean, the password is read from stdin. Cached credentials
+		// are ignored so that the password is always read and not left on the stdin of the command
+		return fmt.Sprintf("sudo -k -S -p '' -u %s -- %s", user, target)
+	}
+}
+
+// envFileContent returns a shell script which exports the variables
+func envFileContent(env []string) (string, error) {
+	var sb strings.Builder
+	for _, e := range env {
+		name, value, _ := strings.Cut(e, "=")
+		if !envNameRegex.MatchString(name) {
+			return "", fmt.Errorf("invalid environment variable name %q", name)
+		}
+		fmt.Fprintf(&sb, "export %s=%s\n", name, shellQuote(value))
+	}
+	return sb.String(), nil
+}
+
+// newBecomeMarker returns a random string printed once the user has been switched
+func newBecomeMarker() string {
+	return fmt.Sprintf("BECOME-SUCCESS-%s", xid.New().String())
+}
+
+// promptWriter answers the password prompt of su and doas running in a terminal.
+// Output is held back until the marker is seen so that the prompt is not written to the logs.
+type promptWriter struct {
+	w        io.Writer
+	stdin    io.WriteCloser
+	password string
+	marker   []byte
+	buf      []byte
+	answered bool
+	done     bool
+}
+
+func newPromptWriter(w io.Writer, stdin io.WriteCloser, password string, marker string) *promptWriter {
+	return &promptWriter{
+		w:        w,
+		stdin:    stdin,
+		password: password,
+		marker:   []byte(marker),
+	}
+}
+
+func (p *promptWriter) Write(b []byte) (int, error) {
+	if p.done {
+		return p.w.Write(b)
+	}
+
+	p.buf = append(p.buf, b...)
+	if i := bytes.Index(p.buf, p.marker); i >= 0 {
+		p.done = true
+		// The command does not read from the terminal
+		p.stdin.Close()
+
+		rest := p.buf[i+len(p.marker):]
+		rest = bytes.TrimPrefix(rest, []byte("\r"))
+		rest = bytes.TrimPrefix(rest, []byte("\n"))
+		p.buf = nil
+		if _, err := p.w.Write(rest); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if !p.answered && passwordPrompt.Match(p.buf) {
+		p.answered = true
+		p.buf = p.buf[:0]
+		if _, err := io.WriteString(p.stdin, p.password+"\n"); err != nil {
+			return 0, fmt.Errorf("could not send become password: %w", err)
+		}
+	}
+
+	return len(b), nil
+}
+
+// flush writes the output held back when the marker was never printed, it usually contains the
+// reason why switching the user failed
+func (p *promptWriter) flush() {
+	if p.done || len(p.buf) == 0 {
+		return
+	}
+	p.w.Write(bytes.TrimSpace(p.buf))
+	p.w.Write([]byte("\n"))
+	p.buf = nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,248 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cvhariharan/flowctl/sdk/remoteclient"
+)
+
+func TestBecomeCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		become Become
+		want   string
+	}{
+		{
+			name:   "sudo",
+			become: Become{},
+			want:   `sudo -n -u 'root' -- /bin/sh -c 'cd '\''/tmp/it'\''\'\'''\''s'\'' && id'`,
+		},
+		{
+			name:   "sudo with password",
+			become: Become{User: "app", Password: "secret"},
+			want:   `sudo -k -S -p '' -u 'app' -- /bin/sh -c 'cd '\''/tmp/it'\''\'\'''\''s'\'' && id'`,
+		},
+		{
+			name:   "doas",
+			become: Become{Method: BecomeMethodDoas, User: "app"},
+			want:   `doas -n -u 'app' /bin/sh -c 'cd '\''/tmp/it'\''\'\'''\''s'\'' && id'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.become.command("id", "/tmp/it's", "", ""); got != tt.want {
+				t.Errorf("command() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBecomeCommandEnv(t *testing.T) {
+	b := Become{}
+	got := b.command("echo $GREETING", "", "/tmp/flows/.env", "MARKER")
+	want := `sudo -n -u 'root' -- /bin/sh -c 'echo MARKER && . '\''/tmp/flows/.env'\'' && (rm -f '\''/tmp/flows/.env'\'' 2>/dev/null || true) && echo $GREETING'`
+	if got != want {
+		t.Errorf("command() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEnvFileContent(t *testing.T) {
+	if _, err := envFileContent([]string{"BAD NAME=x"}); err == nil {
+		t.Error("envFileContent() accepted an invalid name")
+	}
+
+	content, err := envFileContent([]string{`GREETING=it's "$HOME" $(id)`, "EMPTY=", "EQ=a=b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command("/bin/sh", "-c", content+`printf '%s|%s|%s' "$GREETING" "$EMPTY" "$EQ"`).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `it's "$HOME" $(id)||a=b`; string(out) != want {
+		t.Errorf("output %q, want %q", out, want)
+	}
+}
+
+// becomeStubs replace sudo, su and doas with shell functions which run the command as the current user
+const becomeStubs = `
+sudo() { while [ "$1" != "--" ]; do shift; done; shift; "$@"; }
+su() { /bin/sh -c "$4"; }
+doas() { while [ "$1" != "/bin/sh" ]; do shift; done; "$@"; }
+`
+
+func TestBecomeCommandQuoting(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "it's a $dir")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content, err := envFileContent([]string{`GREETING=it's "$HOME" $(id)`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, method := range []string{BecomeMethodSudo, BecomeMethodSu, BecomeMethodDoas} {
+		t.Run(method, func(t *testing.T) {
+			envFile := filepath.Join(dir, "it's.env")
+			if err := os.WriteFile(envFile, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			b := Become{Method: method, Password: "secret"}
+			command := b.command(`printf '%s|%s' "$GREETING" "$(pwd)"`, dir, envFile, "MARKER")
+
+			out, err := exec.Command("/bin/sh", "-c", becomeStubs+command).CombinedOutput()
+			if err != nil {
+				t.Fatalf("command failed: %v: %s", err, out)
+			}
+			want := "MARKER\n" + `it's "$HOME" $(id)|` + dir
+			if string(out) != want {
+				t.Errorf("output %q, want %q", out, want)
+			}
+			if _, err := os.Stat(envFile); !os.IsNotExist(err) {
+				t.Error("environment file was not removed after it was read")
+			}
+		})
+	}
+}
+
+// shellClient runs commands locally with the become stubs in place of sudo, su and doas
+type shellClient struct {
+	remoteclient.RemoteClient
+	commands    []string
+	uploadModes []os.FileMode
+}
+
+func (c *shellClient) RunCommand(ctx context.Context, command string, stdout, stderr io.Writer) error {
+	c.commands = append(c.commands, command)
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", becomeStubs+command)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	return cmd.Run()
+}
+
+func (c *shellClient) Upload(ctx context.Context, localPath, remotePath string) error {
+	b, err := os.ReadFile(localPath)
+	if err != nil {
+		return err
+	}
+	// Like SFTP, an existing file keeps its mode
+	if err := os.WriteFile(remotePath, b, 0644); err != nil {
+		return err
+	}
+	info, err := os.Stat(remotePath)
+	if err != nil {
+		return err
+	}
+	c.uploadModes = append(c.uploadModes, info.Mode().Perm())
+	return nil
+}
+
+func (c *shellClient) Close() error { return nil }
+
+func TestRemoteLinuxBecomeEnv(t *testing.T) {
+	client := &shellClient{}
+	d, err := NewRemoteLinuxWithBecome(client, &Become{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd := d.GetWorkingDirectory()
+	t.Cleanup(func() { os.RemoveAll(wd) })
+
+	var out bytes.Buffer
+	if err := d.Exec(context.Background(), `printf %s "$TOKEN"`, wd, []string{"TOKEN=s3cret value"}, &out, io.Discard); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if out.String() != "s3cret value" {
+		t.Errorf("output %q, want the variable to be set", out.String())
+	}
+
+	for _, command := range client.commands {
+		if strings.Contains(command, "s3cret") {
+			t.Errorf("variable value was passed on the command line: %s", command)
+		}
+	}
+	if len(client.uploadModes) != 1 || client.uploadModes[0] != 0600 {
+		t.Errorf("environment file was uploaded with modes %v, want 0600", client.uploadModes)
+	}
+	entries, err := os.ReadDir(wd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("environment file was left in the working directory: %v", entries)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for _, s := range []string{"", "plain", "it's", `"$HOME" $(id) ; rm -rf /`, "'''", "a\nb"} {
+		out, err := exec.Command("/bin/sh", "-c", "printf %s "+shellQuote(s)).Output()
+		if err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+		if string(out) != s {
+			t.Errorf("shellQuote(%q) was read by the shell as %q", s, out)
+		}
+	}
+}
+
+// stdinRecorder records what is written to the terminal of the command
+type stdinRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (s *stdinRecorder) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestPromptWriter(t *testing.T) {
+	var out bytes.Buffer
+	stdin := &stdinRecorder{}
+	pw := newPromptWriter(&out, stdin, "secret", "MARKER")
+
+	// The prompt and marker can be split across writes
+	for _, chunk := range []string{"Passw", "ord: ", "\r\n", "MAR", "KER\r\nhello\n", "world\n"} {
+		if n, err := pw.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	pw.flush()
+
+	if stdin.String() != "secret\n" {
+		t.Errorf("password sent %q, want %q", stdin.String(), "secret\n")
+	}
+	if !stdin.closed {
+		t.Error("stdin was not closed after the marker")
+	}
+	if out.String() != "hello\nworld\n" {
+		t.Errorf("output %q, want %q", out.String(), "hello\nworld\n")
+	}
+}
+
+func TestPromptWriterAnswersOnce(t *testing.T) {
+	var out bytes.Buffer
+	stdin := &stdinRecorder{}
+	pw := newPromptWriter(&out, stdin, "secret", "MARKER")
+
+	// A second prompt after a wrong password is not answered, the output is flushed as the failure reason
+	for _, chunk := range []string{"Password: ", "\r\nsu: Authentication failure\r\nPassword: "} {
+		pw.Write([]byte(chunk))
+	}
+	pw.flush()
+
+	if stdin.String() != "secret\n" {
+		t.Errorf("password sent %q, want it to be sent once", stdin.String())
+	}
+	if want := "su: Authentication failure\r\nPassword:\n"; out.String() != want {
+		t.Errorf("output %q, want %q", out.String(), want)
+	}
+}
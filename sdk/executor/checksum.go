@@ -0,0 +1,105 @@
+package executor
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ChecksumDriver is implemented by node drivers that can compute checksums of files on the node.
+// It is used to transfer only the artifacts which changed and to verify transferred files
+type ChecksumDriver interface {
+	// Checksums returns the hex encoded SHA-256 checksums of the regular files under dirPath, including
+	// files in subdirectories, keyed by their slash separated path relative to dirPath.
+	// An empty map is returned if the directory does not exist
+	Checksums(ctx context.Context, dirPath string) (map[string]string, error)
+}
+
+// FileChecksum returns the hex encoded SHA-256 checksum of a local file
+func FileChecksum(path string) (string, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// LocalChecksums returns the checksums of the regular files under a local directory in the format of ChecksumDriver
+func LocalChecksums(dirPath string) (map[string]string, error) {
+	checksums := make(map[string]string)
+	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dirPath, path)
+		if err != nil {
+			return err
+		}
+		sum, err := FileChecksum(path)
+		if err != nil {
+			return err
+		}
+		checksums[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && len(checksums) == 0 {
+			return checksums, nil
+		}
+		return nil, fmt.Errorf("failed to compute checksums in %s: %w", dirPath, err)
+	}
+	return checksums, nil
+}
+
+// checksumCommand prints the checksums of the files under a directory in the sha256sum format.
+// shasum is used on systems without coreutils
+const checksumCommand = `cd %s 2>/dev/null || exit 0
+if command -v sha256sum >/dev/null 2>&1; then sum=sha256sum; else sum="shasum -a 256"; fi
+find . -type f -exec $sum {} +`
+
+// parseChecksums reads the output of sha256sum into a map of relative paths to checksums
+func parseChecksums(output string) (map[string]string, error) {
+	checksums := make(map[string]string)
+	unescape := strings.NewReplacer(`\\`, `\`, `\n`, "\n")
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		// Names with a backslash or newline are escaped and the line is prefixed with a backslash
+		escaped := strings.HasPrefix(line, `\`)
+		line = strings.TrimPrefix(line, `\`)
+
+		// <checksum> <mode><path>, the mode is a space for text and * for binary
+		if len(line) < 67 || line[64] != ' ' {
+			return nil, fmt.Errorf("unexpected checksum output %q", line)
+		}
+		sum, path := line[:64], strings.TrimPrefix(line[66:], "./")
+		if escaped {
+			path = unescape.Replace(path)
+		}
+		checksums[path] = strings.ToLower(sum)
+	}
+	return checksums, scanner.Err()
+}
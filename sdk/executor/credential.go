@@ -0,0 +1,27 @@
+package executor
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// RegistryAuth is the key data of registry credentials, it is stored as JSON.
+// An empty server address is Docker Hub
+type RegistryAuth struct {
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	ServerAddress string `json:"server_address,omitempty"`
+}
+
+// ParseRegistryAuth reads the key data of a registry credential
+func ParseRegistryAuth(keyData string) (RegistryAuth, error) {
+	var auth RegistryAuth
+	// The error is not wrapped as it could contain parts of the credential
+	if err := json.Unmarshal([]byte(keyData), &auth); err != nil {
+		return RegistryAuth{}, errors.New("registry credentials should be JSON with username, password and server_address")
+	}
+	if auth.Username == "" || auth.Password == "" {
+		return RegistryAuth{}, errors.New("registry credentials require a username and password")
+	}
+	return auth, nil
+}
@@ -0,0 +1,27 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRegistryAuth(t *testing.T) {
+	auth, err := ParseRegistryAuth(`{"username": "user", "password": "s3cret", "server_address": "ghcr.io"}`)
+	if err != nil {
+		t.Fatalf("ParseRegistryAuth() error = %v", err)
+	}
+	if auth != (RegistryAuth{Username: "user", Password: "s3cret", ServerAddress: "ghcr.io"}) {
+		t.Errorf("ParseRegistryAuth() = %+v", auth)
+	}
+
+	for _, keyData := range []string{`{"username": "user", "password": "s3cret"`, `s3cret`, `{"username": "user"}`, `{"password": "s3cret"}`} {
+		_, err := ParseRegistryAuth(keyData)
+		if err == nil {
+			t.Errorf("ParseRegistryAuth(%q) succeeded", keyData)
+			continue
+		}
+		if strings.Contains(err.Error(), "s3cret") {
+			t.Errorf("ParseRegistryAuth() error contains the credential: %v", err)
+		}
+	}
+}
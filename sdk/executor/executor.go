@@ -3,6 +3,8 @@ package executor
 import (
 	"context"
 	"io"
+
+	"github.com/cvhariharan/flowctl/sdk/remoteclient"
 )
 
 type Node struct {
@@ -12,11 +14,29 @@ type Node struct {
 	Auth           NodeAuth
 	ConnectionType string
 	OSFamily       string
+	// Become runs commands on the node with privilege escalation when set
+	Become *Become
+	// HostKey is the trusted SSH host key of the node in the authorized_keys format
+	HostKey string
+	// JumpHost is the node through which the node is reached, if any
+	JumpHost *remoteclient.JumpHost
 }
 
 type NodeAuth struct {
 	Method string
 	Key    string
+	// Certificate is the SSH certificate of Key when the certificate method is used
+	Certificate string
+}
+
+// Become configures privilege escalation for commands executed on a remote node
+type Become struct {
+	// Method is one of sudo, su or doas, sudo is used when empty
+	Method string
+	// User is the user commands are run as, root is used when empty
+	User string
+	// Password is sent when the method asks for a password
+	Password string
 }
 
 type ExecutionContext struct {
@@ -26,8 +46,24 @@ type ExecutionContext struct {
 	Stdout     io.Writer
 	Stderr     io.Writer
 	ExecID     string
+	// FlowDirectory is the directory of the flow on the flowctl host,
+	// it can be used to read files shipped along with the flow
+	FlowDirectory string
+	// GetCredential returns a decrypted credential from the namespace of the execution by name.
+	// Executors declare the key type they need, credentials of other types are refused.
+	// It is nil when credentials are not available
+	GetCredential func(ctx context.Context, name string, keyType string) (Credential, error)
+}
+
+// Credential is a credential stored in flowctl
+type Credential struct {
+	Name    string `json:"name"`
+	KeyType string `json:"key_type"`
+	KeyData string `json:"key_data"`
 }
 
 type Executor interface {
-	Execute(ctx context.Context, execCtx ExecutionContext) (outputs map[string]string, err error)
+	// Execute runs the action and returns its outputs. Output values can be any JSON compatible value,
+	// values read from dotenv files are strings
+	Execute(ctx context.Context, execCtx ExecutionContext) (outputs map[string]any, err error)
 }
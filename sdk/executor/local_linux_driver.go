@@ -120,6 +120,10 @@ func (d *LocalLinuxDriver) ListFiles(ctx context.Context, dirPath string) ([]str
 	return files, nil
 }
 
+func (d *LocalLinuxDriver) Checksums(ctx context.Context, dirPath string) (map[string]string, error) {
+	return LocalChecksums(dirPath)
+}
+
 func (d *LocalLinuxDriver) Close() error {
 	return nil
 }
@@ -34,26 +34,51 @@ func NewNodeDriver(ctx context.Context, node Node) (NodeDriver, error) {
 		if runtime.GOOS == "windows" {
 			return nil, fmt.Errorf("windows local execution not yet supported")
 		}
+		if node.Become != nil {
+			return nil, fmt.Errorf("become is only supported on remote nodes")
+		}
 		return NewLocalLinux()
 	}
 
+	remoteClient, err := Connect(node)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := NewNodeDriverWithClient(remoteClient, node)
+	if err != nil {
+		remoteClient.Close()
+		return nil, err
+	}
+	return driver, nil
+}
+
+// Connect opens a connection to a remote node. The client can be shared by the drivers
+// of several actions using NewNodeDriverWithClient
+func Connect(node Node) (remoteclient.RemoteClient, error) {
 	remoteClient, err := remoteclient.GetClient(node.ConnectionType, remoteclient.NodeConfig{
 		Hostname: node.Hostname,
 		Port:     node.Port,
 		Username: node.Username,
 		Auth: remoteclient.NodeAuth{
-			Method: node.Auth.Method,
-			Key:    node.Auth.Key,
+			Method:      node.Auth.Method,
+			Key:         node.Auth.Key,
+			Certificate: node.Auth.Certificate,
 		},
+		HostKey:  node.HostKey,
+		JumpHost: node.JumpHost,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create remote client: %w", err)
 	}
+	return remoteClient, nil
+}
 
+// NewNodeDriverWithClient creates a driver for a remote node using an existing connection.
+// Closing the driver closes the client
+func NewNodeDriverWithClient(remoteClient remoteclient.RemoteClient, node Node) (NodeDriver, error) {
 	if node.OSFamily == "windows" {
-		remoteClient.Close()
 		return nil, fmt.Errorf("windows remote execution not yet supported")
 	}
-
-	return NewRemoteLinux(remoteClient)
+	return NewRemoteLinuxWithBecome(remoteClient, node.Become)
 }
@@ -0,0 +1,115 @@
+package executor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-envparse"
+)
+
+// ParseOutputs reads the outputs written by an action.
+// dotenv contains KEY=value lines written to FC_OUTPUT, the values are strings.
+// jsonOutputs contains one or more JSON objects written to FC_OUTPUT_JSON, the values keep their JSON type
+// and take precedence over dotenv values. Either reader can be nil.
+func ParseOutputs(dotenv io.Reader, jsonOutputs io.Reader) (map[string]any, error) {
+	outputs := make(map[string]any)
+
+	if dotenv != nil {
+		env, err := envparse.Parse(dotenv)
+		if err != nil {
+			return nil, fmt.Errorf("could not load output env: %w", err)
+		}
+		for k, v := range env {
+			outputs[k] = v
+		}
+	}
+
+	if jsonOutputs == nil {
+		return outputs, nil
+	}
+
+	dec := json.NewDecoder(jsonOutputs)
+	dec.UseNumber()
+	for {
+		var obj map[string]any
+		if err := dec.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("could not load JSON outputs: %w", err)
+		}
+		for k, v := range obj {
+			outputs[k] = normalizeJSONValue(v)
+		}
+	}
+
+	return outputs, nil
+}
+
+// normalizeJSONValue converts JSON numbers to int when possible, float64 otherwise
+func normalizeJSONValue(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return int(i)
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]any:
+		for k, item := range val {
+			val[k] = normalizeJSONValue(item)
+		}
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = normalizeJSONValue(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// FormatValue formats a value for use in environment variables and logs.
+// Values which are not strings, such as lists and maps, are formatted as JSON
+func FormatValue(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case nil:
+		return ""
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+var variableRegex = regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_]*)\}|\$([a-zA-Z_][a-zA-Z0-9_]*)`)
+
+// Expand replaces $name and ${name} with the value of the input formatted using FormatValue.
+// References to unknown inputs are left as is.
+func Expand(s string, inputs map[string]any) string {
+	return variableRegex.ReplaceAllStringFunc(s, func(match string) string {
+		name := strings.Trim(match, "${}")
+		if v, ok := inputs[name]; ok {
+			return FormatValue(v)
+		}
+		return match
+	})
+}
+
+// StringOutputs formats all output values using FormatValue
+func StringOutputs(outputs map[string]any) map[string]string {
+	res := make(map[string]string, len(outputs))
+	for k, v := range outputs {
+		res[k] = FormatValue(v)
+	}
+	return res
+}
@@ -0,0 +1,114 @@
+package executor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOutputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		dotenv  string
+		json    string
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name:   "dotenv values are strings",
+			dotenv: "VERSION=1.2\nCOUNT=3\n",
+			want:   map[string]any{"VERSION": "1.2", "COUNT": "3"},
+		},
+		{
+			name:   "json takes precedence over dotenv",
+			dotenv: "VERSION=1.2\nCOMMIT=abc\n",
+			json:   `{"VERSION": "2.0"}`,
+			want:   map[string]any{"VERSION": "2.0", "COMMIT": "abc"},
+		},
+		{
+			name: "later json objects take precedence",
+			json: `{"a": 1, "b": 1}` + "\n" + `{"b": 2}`,
+			want: map[string]any{"a": 1, "b": 2},
+		},
+		{
+			name: "numbers are normalized",
+			json: `{"int": 42, "float": 1.5, "big": 9007199254740993, "exp": 1e3}`,
+			want: map[string]any{"int": 42, "float": 1.5, "big": 9007199254740993, "exp": float64(1000)},
+		},
+		{
+			name: "nested values",
+			json: `{"hosts": [{"name": "web1", "port": 22}], "meta": {"ready": true, "ratio": 0.5, "tags": null}}`,
+			want: map[string]any{
+				"hosts": []any{map[string]any{"name": "web1", "port": 22}},
+				"meta":  map[string]any{"ready": true, "ratio": 0.5, "tags": nil},
+			},
+		},
+		{
+			name:    "invalid json",
+			json:    `{"a": `,
+			wantErr: true,
+		},
+		{
+			name:    "json which is not an object",
+			json:    `[1, 2]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOutputs(strings.NewReader(tt.dotenv), strings.NewReader(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOutputs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseOutputs() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOutputsNilReaders(t *testing.T) {
+	got, err := ParseOutputs(nil, nil)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("ParseOutputs(nil, nil) = %v, %v", got, err)
+	}
+
+	got, err = ParseOutputs(nil, strings.NewReader(`{"a": 1}`))
+	if err != nil || !reflect.DeepEqual(got, map[string]any{"a": 1}) {
+		t.Fatalf("ParseOutputs(nil, json) = %v, %v", got, err)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{"plain", "plain"},
+		{nil, ""},
+		{42, "42"},
+		{1.5, "1.5"},
+		{true, "true"},
+		{[]any{"a", 1}, `["a",1]`},
+		{map[string]any{"b": 2, "a": []any{1}}, `{"a":[1],"b":2}`},
+	}
+
+	for _, tt := range tests {
+		if got := FormatValue(tt.value); got != tt.want {
+			t.Errorf("FormatValue(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+
+	if got := StringOutputs(map[string]any{"n": 1, "s": "x"}); !reflect.DeepEqual(got, map[string]string{"n": "1", "s": "x"}) {
+		t.Errorf("StringOutputs() = %v", got)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	inputs := map[string]any{"host": "example.com", "port": 8080, "ids": []any{1, 2}}
+	got := Expand("https://$host:${port}/items?ids=$ids&missing=$missing&${unclosed", inputs)
+	if want := "https://example.com:8080/items?ids=[1,2]&missing=$missing&${unclosed"; got != want {
+		t.Errorf("Expand() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package executor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// PluginProtocolVersion is the version of the executor plugin protocol.
+// It is incremented on breaking changes, plugins built against a different version are rejected during the handshake.
+//
+// Plugins are executables that talk to flowctl over stdin/stdout using newline delimited JSON PluginMessage values.
+// Anything written to stderr by the plugin is treated as diagnostic output and is reported if the plugin exits unexpectedly.
+//
+// A plugin process handles a single request:
+//  1. flowctl sends a handshake message with its protocol version, the plugin replies with a handshake
+//     message containing its protocol version, executor name and config JSON schema.
+//  2. flowctl either closes stdin (during discovery) or sends an execute message.
+//  3. While executing, the plugin streams stdout and stderr messages and can operate on the target node
+//     using driver_call messages, which flowctl answers with driver_result messages.
+//     Output of driver exec calls is streamed back using driver_stdout and driver_stderr messages.
+//  4. The plugin sends a result message with the outputs or an error and exits.
+//
+// flowctl sends a cancel message if the execution is cancelled and kills the plugin if it does not exit in time.
+//
+// Version 2 allows any JSON value in result outputs, adds the checksums driver call and the key_type
+// parameter of get_credential calls.
+const PluginProtocolVersion = 2
+
+// PluginEnvKey is set in the environment of plugin processes started by flowctl
+const PluginEnvKey = "FLOWCTL_EXECUTOR_PLUGIN"
+
+// Plugin message types
+const (
+	PluginMsgHandshake    = "handshake"
+	PluginMsgExecute      = "execute"
+	PluginMsgCancel       = "cancel"
+	PluginMsgStdout       = "stdout"
+	PluginMsgStderr       = "stderr"
+	PluginMsgResult       = "result"
+	PluginMsgDriverCall   = "driver_call"
+	PluginMsgDriverResult = "driver_result"
+	PluginMsgDriverStdout = "driver_stdout"
+	PluginMsgDriverStderr = "driver_stderr"
+)
+
+// Driver methods that can be called by plugins
+const (
+	PluginDriverExec           = "exec"
+	PluginDriverUpload         = "upload"
+	PluginDriverDownload       = "download"
+	PluginDriverCreateDir      = "create_dir"
+	PluginDriverCreateFile     = "create_file"
+	PluginDriverRemove         = "remove"
+	PluginDriverSetPermissions = "set_permissions"
+	PluginDriverListFiles      = "list_files"
+	PluginDriverChecksums      = "checksums"
+	// get_credential reads a credential from the namespace of the execution
+	PluginDriverGetCredential = "get_credential"
+)
+
+// PluginMessage is a single message exchanged between flowctl and a plugin
+type PluginMessage struct {
+	Type string `json:"type"`
+
+	// Handshake
+	ProtocolVersion int             `json:"protocol_version,omitempty"`
+	Name            string          `json:"name,omitempty"`
+	Schema          json.RawMessage `json:"schema,omitempty"`
+
+	// Execute
+	Execution *PluginExecution `json:"execution,omitempty"`
+
+	// Stream data for stdout, stderr, driver_stdout and driver_stderr
+	Data []byte `json:"data,omitempty"`
+
+	// Result
+	Outputs map[string]any `json:"outputs,omitempty"`
+	Error   string         `json:"error,omitempty"`
+
+	// Driver calls
+	CallID int64           `json:"call_id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+// PluginExecution is the ExecutionContext sent to a plugin along with details of the target node
+type PluginExecution struct {
+	// Name is the executor instance name used to create the executor
+	Name          string         `json:"name"`
+	WithConfig    string         `json:"with_config"`
+	Inputs        map[string]any `json:"inputs"`
+	ExecID        string         `json:"exec_id"`
+	FlowDirectory string         `json:"flow_directory,omitempty"`
+
+	WorkingDirectory string `json:"working_directory"`
+	TempDir          string `json:"temp_dir"`
+	IsRemote         bool   `json:"is_remote"`
+}
+
+// PluginDriverParams are the parameters of a driver call
+type PluginDriverParams struct {
+	Command     string      `json:"command,omitempty"`
+	WorkingDir  string      `json:"working_dir,omitempty"`
+	Env         []string    `json:"env,omitempty"`
+	Path        string      `json:"path,omitempty"`
+	LocalPath   string      `json:"local_path,omitempty"`
+	RemotePath  string      `json:"remote_path,omitempty"`
+	Permissions os.FileMode `json:"permissions,omitempty"`
+	Name        string      `json:"name,omitempty"`
+	KeyType     string      `json:"key_type,omitempty"`
+}
+
+// pluginConn reads and writes plugin messages over a pair of streams
+type pluginConn struct {
+	r  *bufio.Scanner
+	w  io.Writer
+	mu sync.Mutex
+}
+
+func newPluginConn(r io.Reader, w io.Writer) *pluginConn {
+	scanner := bufio.NewScanner(r)
+	// Messages carry output chunks and schemas, allow large lines
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &pluginConn{r: scanner, w: w}
+}
+
+func (c *pluginConn) send(msg PluginMessage) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("could not encode plugin message: %w", err)
+	}
+	b = append(b, '\n')
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.w.Write(b)
+	return err
+}
+
+// receive returns the next message, io.EOF is returned when the stream is closed
+func (c *pluginConn) receive() (PluginMessage, error) {
+	if !c.r.Scan() {
+		if err := c.r.Err(); err != nil {
+			return PluginMessage{}, err
+		}
+		return PluginMessage{}, io.EOF
+	}
+
+	var msg PluginMessage
+	if err := json.Unmarshal(c.r.Bytes(), &msg); err != nil {
+		return PluginMessage{}, fmt.Errorf("could not decode plugin message: %w", err)
+	}
+	return msg, nil
+}
+
+// pluginStreamWriter sends everything written to it as messages of the given type
+type pluginStreamWriter struct {
+	conn    *pluginConn
+	msgType string
+	callID  int64
+}
+
+func (w *pluginStreamWriter) Write(p []byte) (int, error) {
+	data := make([]byte, len(p))
+	copy(data, p)
+	if err := w.conn.send(PluginMessage{Type: w.msgType, CallID: w.callID, Data: data}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
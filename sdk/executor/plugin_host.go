@@ -0,0 +1,462 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	pluginHandshakeTimeout  = 10 * time.Second
+	pluginCancelGracePeriod = 10 * time.Second
+	pluginStderrTail        = 4096
+)
+
+// PluginInfo is the information returned by a plugin during the handshake
+type PluginInfo struct {
+	Name            string
+	ProtocolVersion int
+	Schema          json.RawMessage
+}
+
+// LoadPlugins discovers executable files in dir and registers each of them as an executor.
+// Plugins that fail the handshake or conflict with an already registered executor are skipped,
+// the returned error joins the reason for each skipped plugin. A missing directory is not an error.
+func LoadPlugins(ctx context.Context, dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not read plugins directory %s: %w", dir, err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var loaded []string
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.Mode()&0111 == 0 {
+			continue
+		}
+
+		name, err := LoadPlugin(ctx, filepath.Join(dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		loaded = append(loaded, name)
+	}
+
+	return loaded, errors.Join(errs...)
+}
+
+// LoadPlugin performs a handshake with the plugin at path and registers it as an executor
+// using the name and schema it reports.
+func LoadPlugin(ctx context.Context, path string) (string, error) {
+	info, err := HandshakePlugin(ctx, path)
+	if err != nil {
+		return "", err
+	}
+
+	var schema any = info.Schema
+	if len(info.Schema) == 0 {
+		schema = json.RawMessage("{}")
+	}
+
+	if err := registerPlugin(info.Name, newPluginExecutorFunc(path, info.Name), schema); err != nil {
+		return "", fmt.Errorf("could not register plugin %s: %w", path, err)
+	}
+
+	return info.Name, nil
+}
+
+// registerPlugin is the non panicking equivalent of RegisterExecutor and RegisterSchema,
+// a misbehaving plugin should not bring down flowctl
+func registerPlugin(name string, factory NewExecutorFunc, schema any) error {
+	if !isValidName(name) {
+		return fmt.Errorf("invalid executor name %q, name can only include alphabets and underscore", name)
+	}
+
+	mu.Lock()
+	if _, exists := registry[name]; exists {
+		mu.Unlock()
+		return fmt.Errorf("executor with name '%s' is already registered", name)
+	}
+	registry[name] = factory
+	mu.Unlock()
+
+	smu.Lock()
+	schemaRegistry[name] = schema
+	smu.Unlock()
+
+	return nil
+}
+
+// HandshakePlugin starts the plugin at path, performs the handshake and stops it
+func HandshakePlugin(ctx context.Context, path string) (PluginInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, pluginHandshakeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Env = append(os.Environ(), PluginEnvKey+"=1")
+	stderr := &tailBuffer{max: pluginStderrTail}
+	cmd.Stderr = stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return PluginInfo{}, fmt.Errorf("could not open plugin stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return PluginInfo{}, fmt.Errorf("could not open plugin stdout: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return PluginInfo{}, fmt.Errorf("could not start plugin %s: %w", path, err)
+	}
+
+	info, err := pluginHandshake(newPluginConn(stdout, stdin))
+	stdin.Close()
+	waitErr := cmd.Wait()
+	if err != nil {
+		return PluginInfo{}, fmt.Errorf("handshake with plugin %s failed: %w%s", path, err, stderr.describe())
+	}
+	if waitErr != nil {
+		return PluginInfo{}, fmt.Errorf("plugin %s exited with error after handshake: %w%s", path, waitErr, stderr.describe())
+	}
+
+	return info, nil
+}
+
+// pluginHandshake sends the host handshake and validates the reply of the plugin
+func pluginHandshake(conn *pluginConn) (PluginInfo, error) {
+	if err := conn.send(PluginMessage{Type: PluginMsgHandshake, ProtocolVersion: PluginProtocolVersion}); err != nil {
+		return PluginInfo{}, fmt.Errorf("could not send handshake: %w", err)
+	}
+
+	msg, err := conn.receive()
+	if err != nil {
+		return PluginInfo{}, fmt.Errorf("could not read handshake: %w", err)
+	}
+	if msg.Type != PluginMsgHandshake {
+		return PluginInfo{}, fmt.Errorf("expected handshake message, got %q", msg.Type)
+	}
+	if msg.Error != "" {
+		return PluginInfo{}, errors.New(msg.Error)
+	}
+	if msg.ProtocolVersion != PluginProtocolVersion {
+		return PluginInfo{}, fmt.Errorf("unsupported plugin protocol version %d, expected %d", msg.ProtocolVersion, PluginProtocolVersion)
+	}
+
+	return PluginInfo{
+		Name:            msg.Name,
+		ProtocolVersion: msg.ProtocolVersion,
+		Schema:          msg.Schema,
+	}, nil
+}
+
+// pluginExecutor runs an executor plugin. Each execution starts a new plugin process
+// so that a crashing plugin only fails the action it was running.
+type pluginExecutor struct {
+	path         string
+	pluginName   string
+	executorName string
+	driver       NodeDriver
+}
+
+func newPluginExecutorFunc(path, pluginName string) NewExecutorFunc {
+	return func(name string, driver NodeDriver) (Executor, error) {
+		return NewPluginExecutor(path, pluginName, name, driver), nil
+	}
+}
+
+// NewPluginExecutor returns an executor backed by the plugin binary at path.
+// pluginName is the executor name reported by the plugin during the handshake.
+func NewPluginExecutor(path, pluginName, name string, driver NodeDriver) Executor {
+	return &pluginExecutor{
+		path:         path,
+		pluginName:   pluginName,
+		executorName: name,
+		driver:       driver,
+	}
+}
+
+func (p *pluginExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (map[string]any, error) {
+	cmd := exec.Command(p.path)
+	cmd.Env = append(os.Environ(), PluginEnvKey+"=1")
+	stderr := &tailBuffer{max: pluginStderrTail}
+	cmd.Stderr = stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("could not open plugin stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("could not open plugin stdout: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("could not start plugin %s: %w", p.path, err)
+	}
+
+	// Kill the plugin if it does not exit within the grace period after cancellation
+	// or after the protocol exchange has ended
+	exited := make(chan struct{})
+	finished := make(chan struct{})
+	defer close(exited)
+	go func() {
+		select {
+		case <-exited:
+			return
+		case <-ctx.Done():
+		case <-finished:
+		}
+		select {
+		case <-exited:
+		case <-time.After(pluginCancelGracePeriod):
+			cmd.Process.Kill()
+		}
+	}()
+
+	outputs, execErr := ExecutePlugin(ctx, stdout, stdin, p.pluginName, p.executorName, p.driver, execCtx)
+	close(finished)
+	stdin.Close()
+
+	// Drain anything left so that the plugin is not blocked on a write while exiting
+	io.Copy(io.Discard, stdout)
+	waitErr := cmd.Wait()
+
+	var crashErr *PluginCrashError
+	if errors.As(execErr, &crashErr) {
+		if waitErr != nil {
+			crashErr.Err = waitErr
+		}
+		crashErr.Stderr = stderr.String()
+	}
+
+	return outputs, execErr
+}
+
+// PluginCrashError is returned when a plugin exits before sending its result
+type PluginCrashError struct {
+	Name   string
+	Err    error
+	Stderr string
+}
+
+func (e *PluginCrashError) Error() string {
+	msg := fmt.Sprintf("plugin %s exited unexpectedly", e.Name)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Stderr != "" {
+		msg += "\n" + e.Stderr
+	}
+	return msg
+}
+
+func (e *PluginCrashError) Unwrap() error {
+	return e.Err
+}
+
+// ExecutePlugin runs the host side of the plugin protocol over the given streams.
+// It performs the handshake, sends the execute request and serves driver calls from the plugin
+// until the plugin returns its result. It is used to run plugin processes and by the plugintest package.
+func ExecutePlugin(ctx context.Context, r io.Reader, w io.Writer, pluginName, name string, driver NodeDriver, execCtx ExecutionContext) (map[string]any, error) {
+	conn := newPluginConn(r, w)
+
+	info, err := pluginHandshake(conn)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, &PluginCrashError{Name: pluginName}
+		}
+		return nil, fmt.Errorf("handshake with plugin %s failed: %w", pluginName, err)
+	}
+	if info.Name != pluginName {
+		return nil, fmt.Errorf("plugin reported executor name %q, expected %q", info.Name, pluginName)
+	}
+
+	if err := conn.send(PluginMessage{
+		Type: PluginMsgExecute,
+		Execution: &PluginExecution{
+			Name:             name,
+			WithConfig:       string(execCtx.WithConfig),
+			Inputs:           execCtx.Inputs,
+			ExecID:           execCtx.ExecID,
+			FlowDirectory:    execCtx.FlowDirectory,
+			WorkingDirectory: driver.GetWorkingDirectory(),
+			TempDir:          driver.TempDir(),
+			IsRemote:         driver.IsRemote(),
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("could not send execute request to plugin %s: %w", pluginName, err)
+	}
+
+	// Forward cancellation to the plugin
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			conn.send(PluginMessage{Type: PluginMsgCancel})
+		}
+	}()
+
+	var calls sync.WaitGroup
+	defer calls.Wait()
+
+	stdout := writerOrDiscard(execCtx.Stdout)
+	stderr := writerOrDiscard(execCtx.Stderr)
+
+	for {
+		msg, err := conn.receive()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				return nil, &PluginCrashError{Name: pluginName}
+			}
+			return nil, fmt.Errorf("could not read message from plugin %s: %w", pluginName, err)
+		}
+
+		switch msg.Type {
+		case PluginMsgStdout:
+			stdout.Write(msg.Data)
+		case PluginMsgStderr:
+			stderr.Write(msg.Data)
+		case PluginMsgDriverCall:
+			calls.Add(1)
+			go func() {
+				defer calls.Done()
+				handlePluginDriverCall(ctx, conn, driver, execCtx, msg)
+			}()
+		case PluginMsgResult:
+			if msg.Error != "" {
+				// Report cancellation as such rather than the error the executor returned for it
+				if ctx.Err() != nil {
+					return msg.Outputs, ctx.Err()
+				}
+				return msg.Outputs, errors.New(msg.Error)
+			}
+			return msg.Outputs, nil
+		default:
+			return nil, fmt.Errorf("unexpected message %q from plugin %s", msg.Type, pluginName)
+		}
+	}
+}
+
+// handlePluginDriverCall runs a node driver operation requested by the plugin and sends back the result
+func handlePluginDriverCall(ctx context.Context, conn *pluginConn, driver NodeDriver, execCtx ExecutionContext, msg PluginMessage) {
+	reply := PluginMessage{Type: PluginMsgDriverResult, CallID: msg.CallID}
+
+	result, err := callPluginDriver(ctx, conn, driver, execCtx, msg)
+	if err != nil {
+		reply.Error = err.Error()
+	} else if result != nil {
+		b, err := json.Marshal(result)
+		if err != nil {
+			reply.Error = fmt.Sprintf("could not encode result: %v", err)
+		}
+		reply.Result = b
+	}
+
+	conn.send(reply)
+}
+
+func callPluginDriver(ctx context.Context, conn *pluginConn, driver NodeDriver, execCtx ExecutionContext, msg PluginMessage) (any, error) {
+	var params PluginDriverParams
+	if len(msg.Params) > 0 {
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid params for %s: %w", msg.Method, err)
+		}
+	}
+
+	switch msg.Method {
+	case PluginDriverExec:
+		stdout := &pluginStreamWriter{conn: conn, msgType: PluginMsgDriverStdout, callID: msg.CallID}
+		stderr := &pluginStreamWriter{conn: conn, msgType: PluginMsgDriverStderr, callID: msg.CallID}
+		return nil, driver.Exec(ctx, params.Command, params.WorkingDir, params.Env, stdout, stderr)
+	case PluginDriverUpload:
+		return nil, driver.Upload(ctx, params.LocalPath, params.RemotePath)
+	case PluginDriverDownload:
+		return nil, driver.Download(ctx, params.RemotePath, params.LocalPath)
+	case PluginDriverCreateDir:
+		return nil, driver.CreateDir(ctx, params.Path)
+	case PluginDriverCreateFile:
+		return nil, driver.CreateFile(ctx, params.Path)
+	case PluginDriverRemove:
+		return nil, driver.Remove(ctx, params.Path)
+	case PluginDriverSetPermissions:
+		return nil, driver.SetPermissions(ctx, params.Path, params.Permissions)
+	case PluginDriverListFiles:
+		return driver.ListFiles(ctx, params.Path)
+	case PluginDriverChecksums:
+		cd, ok := driver.(ChecksumDriver)
+		if !ok {
+			return nil, errors.New("node driver does not support checksums")
+		}
+		return cd.Checksums(ctx, params.Path)
+	case PluginDriverGetCredential:
+		if execCtx.GetCredential == nil {
+			return nil, errors.New("credentials are not available")
+		}
+		return execCtx.GetCredential(ctx, params.Name, params.KeyType)
+	default:
+		return nil, fmt.Errorf("unsupported driver method %q", msg.Method)
+	}
+}
+
+func writerOrDiscard(w io.Writer) io.Writer {
+	if w == nil {
+		return io.Discard
+	}
+	return w
+}
+
+// tailBuffer keeps the last max bytes written to it
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	max int
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf.Write(p)
+	if extra := t.buf.Len() - t.max; extra > 0 {
+		t.buf.Next(extra)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.buf.String()
+}
+
+func (t *tailBuffer) describe() string {
+	if s := t.String(); s != "" {
+		return "\n" + s
+	}
+	return ""
+}
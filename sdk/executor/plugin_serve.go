@@ -0,0 +1,317 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+// ServePlugin runs the plugin side of the protocol over stdin and stdout.
+// It should be called from the main function of a plugin binary:
+//
+//	func main() {
+//		if err := executor.ServePlugin("my_executor", GetSchema(), NewMyExecutor); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+func ServePlugin(name string, schema any, factory NewExecutorFunc) error {
+	return ServePluginIO(os.Stdin, os.Stdout, name, schema, factory)
+}
+
+// ServePluginIO runs the plugin side of the protocol over the given streams
+func ServePluginIO(r io.Reader, w io.Writer, name string, schema any, factory NewExecutorFunc) error {
+	conn := newPluginConn(r, w)
+
+	msg, err := conn.receive()
+	if err != nil {
+		return fmt.Errorf("could not read handshake: %w", err)
+	}
+	if msg.Type != PluginMsgHandshake {
+		return fmt.Errorf("expected handshake message, got %q", msg.Type)
+	}
+
+	reply := PluginMessage{Type: PluginMsgHandshake, ProtocolVersion: PluginProtocolVersion, Name: name}
+	if msg.ProtocolVersion != PluginProtocolVersion {
+		reply.Error = fmt.Sprintf("plugin %s supports protocol version %d, flowctl uses version %d", name, PluginProtocolVersion, msg.ProtocolVersion)
+		conn.send(reply)
+		return errors.New(reply.Error)
+	}
+
+	if schema != nil {
+		b, err := json.Marshal(schema)
+		if err != nil {
+			return fmt.Errorf("could not encode schema: %w", err)
+		}
+		reply.Schema = b
+	}
+	if err := conn.send(reply); err != nil {
+		return fmt.Errorf("could not send handshake: %w", err)
+	}
+
+	msg, err = conn.receive()
+	if err != nil {
+		// flowctl closes the stream after the handshake during plugin discovery
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return fmt.Errorf("could not read request: %w", err)
+	}
+	if msg.Type != PluginMsgExecute || msg.Execution == nil {
+		return fmt.Errorf("expected execute message, got %q", msg.Type)
+	}
+
+	outputs, execErr := servePluginExecution(conn, *msg.Execution, factory)
+
+	result := PluginMessage{Type: PluginMsgResult, Outputs: outputs}
+	if execErr != nil {
+		result.Error = execErr.Error()
+	}
+	if err := conn.send(result); err != nil {
+		return fmt.Errorf("could not send result: %w", err)
+	}
+
+	return nil
+}
+
+// servePluginExecution creates the executor using a driver that forwards calls to flowctl and runs it
+func servePluginExecution(conn *pluginConn, execution PluginExecution, factory NewExecutorFunc) (outputs map[string]any, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	driver := &pluginDriver{
+		conn:      conn,
+		execution: execution,
+		pending:   make(map[int64]*pluginCall),
+	}
+
+	// Messages from flowctl are dispatched until the execution ends
+	go func() {
+		for {
+			msg, err := conn.receive()
+			if err != nil {
+				// flowctl went away, stop the execution
+				cancel()
+				driver.failPending(fmt.Errorf("connection to flowctl closed: %w", err))
+				return
+			}
+
+			switch msg.Type {
+			case PluginMsgCancel:
+				cancel()
+			case PluginMsgDriverStdout, PluginMsgDriverStderr, PluginMsgDriverResult:
+				driver.dispatch(msg)
+			}
+		}
+	}()
+
+	defer func() {
+		if r := recover(); r != nil {
+			outputs = nil
+			err = fmt.Errorf("plugin panicked: %v", r)
+		}
+	}()
+
+	exec, err := factory(execution.Name, driver)
+	if err != nil {
+		return nil, fmt.Errorf("could not create executor: %w", err)
+	}
+
+	return exec.Execute(ctx, ExecutionContext{
+		WithConfig:    []byte(execution.WithConfig),
+		Inputs:        execution.Inputs,
+		Stdout:        &pluginStreamWriter{conn: conn, msgType: PluginMsgStdout},
+		Stderr:        &pluginStreamWriter{conn: conn, msgType: PluginMsgStderr},
+		ExecID:        execution.ExecID,
+		FlowDirectory: execution.FlowDirectory,
+		GetCredential: driver.getCredential,
+	})
+}
+
+type pluginCall struct {
+	stdout io.Writer
+	stderr io.Writer
+	result chan PluginMessage
+}
+
+// pluginDriver is the NodeDriver given to executors running inside a plugin.
+// Operations are forwarded to flowctl which runs them using the driver of the target node.
+type pluginDriver struct {
+	conn      *pluginConn
+	execution PluginExecution
+
+	lastID  atomic.Int64
+	mu      sync.Mutex
+	pending map[int64]*pluginCall
+	closed  error
+}
+
+func (d *pluginDriver) dispatch(msg PluginMessage) {
+	d.mu.Lock()
+	call, ok := d.pending[msg.CallID]
+	if ok && msg.Type == PluginMsgDriverResult {
+		delete(d.pending, msg.CallID)
+	}
+	d.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	switch msg.Type {
+	case PluginMsgDriverStdout:
+		call.stdout.Write(msg.Data)
+	case PluginMsgDriverStderr:
+		call.stderr.Write(msg.Data)
+	case PluginMsgDriverResult:
+		call.result <- msg
+	}
+}
+
+func (d *pluginDriver) failPending(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.closed = err
+	for id, call := range d.pending {
+		call.result <- PluginMessage{Type: PluginMsgDriverResult, CallID: id, Error: err.Error()}
+		delete(d.pending, id)
+	}
+}
+
+// call sends a driver call to flowctl and waits for the result
+func (d *pluginDriver) call(ctx context.Context, method string, params PluginDriverParams, stdout, stderr io.Writer, result any) error {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("could not encode params: %w", err)
+	}
+
+	call := &pluginCall{
+		stdout: writerOrDiscard(stdout),
+		stderr: writerOrDiscard(stderr),
+		result: make(chan PluginMessage, 1),
+	}
+
+	id := d.lastID.Add(1)
+	d.mu.Lock()
+	if d.closed != nil {
+		d.mu.Unlock()
+		return d.closed
+	}
+	d.pending[id] = call
+	d.mu.Unlock()
+
+	if err := d.conn.send(PluginMessage{Type: PluginMsgDriverCall, CallID: id, Method: method, Params: b}); err != nil {
+		d.mu.Lock()
+		delete(d.pending, id)
+		d.mu.Unlock()
+		return fmt.Errorf("could not send %s call: %w", method, err)
+	}
+
+	// flowctl cancels driver calls when the execution is cancelled, the result is still awaited
+	// so that the output of the call is not interleaved with later calls
+	var msg PluginMessage
+	select {
+	case msg = <-call.result:
+	case <-ctx.Done():
+		msg = <-call.result
+	}
+
+	if msg.Error != "" {
+		return errors.New(msg.Error)
+	}
+	if result != nil && len(msg.Result) > 0 {
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			return fmt.Errorf("could not decode %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+func (d *pluginDriver) Upload(ctx context.Context, localPath, remotePath string) error {
+	return d.call(ctx, PluginDriverUpload, PluginDriverParams{LocalPath: localPath, RemotePath: remotePath}, nil, nil, nil)
+}
+
+func (d *pluginDriver) Download(ctx context.Context, remotePath, localPath string) error {
+	return d.call(ctx, PluginDriverDownload, PluginDriverParams{LocalPath: localPath, RemotePath: remotePath}, nil, nil, nil)
+}
+
+func (d *pluginDriver) CreateDir(ctx context.Context, path string) error {
+	return d.call(ctx, PluginDriverCreateDir, PluginDriverParams{Path: path}, nil, nil, nil)
+}
+
+func (d *pluginDriver) CreateFile(ctx context.Context, path string) error {
+	return d.call(ctx, PluginDriverCreateFile, PluginDriverParams{Path: path}, nil, nil, nil)
+}
+
+func (d *pluginDriver) GetWorkingDirectory() string {
+	return d.execution.WorkingDirectory
+}
+
+func (d *pluginDriver) Remove(ctx context.Context, path string) error {
+	return d.call(ctx, PluginDriverRemove, PluginDriverParams{Path: path}, nil, nil, nil)
+}
+
+func (d *pluginDriver) SetPermissions(ctx context.Context, path string, perms os.FileMode) error {
+	return d.call(ctx, PluginDriverSetPermissions, PluginDriverParams{Path: path, Permissions: perms}, nil, nil, nil)
+}
+
+func (d *pluginDriver) Exec(ctx context.Context, command string, workingDir string, env []string, stdout, stderr io.Writer) error {
+	return d.call(ctx, PluginDriverExec, PluginDriverParams{Command: command, WorkingDir: workingDir, Env: env}, stdout, stderr, nil)
+}
+
+func (d *pluginDriver) Dial(network, address string) (net.Conn, error) {
+	return nil, fmt.Errorf("dial is not supported in executor plugins")
+}
+
+func (d *pluginDriver) IsRemote() bool {
+	return d.execution.IsRemote
+}
+
+func (d *pluginDriver) TempDir() string {
+	return d.execution.TempDir
+}
+
+func (d *pluginDriver) Join(parts ...string) string {
+	if d.execution.IsRemote {
+		return path.Join(parts...)
+	}
+	return filepath.Join(parts...)
+}
+
+func (d *pluginDriver) ListFiles(ctx context.Context, dirPath string) ([]string, error) {
+	var files []string
+	if err := d.call(ctx, PluginDriverListFiles, PluginDriverParams{Path: dirPath}, nil, nil, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (d *pluginDriver) Checksums(ctx context.Context, dirPath string) (map[string]string, error) {
+	var checksums map[string]string
+	if err := d.call(ctx, PluginDriverChecksums, PluginDriverParams{Path: dirPath}, nil, nil, &checksums); err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
+
+// getCredential reads a credential through flowctl
+func (d *pluginDriver) getCredential(ctx context.Context, name string, keyType string) (Credential, error) {
+	var cred Credential
+	if err := d.call(ctx, PluginDriverGetCredential, PluginDriverParams{Name: name, KeyType: keyType}, nil, nil, &cred); err != nil {
+		return Credential{}, err
+	}
+	return cred, nil
+}
+
+// Close is a no-op, the driver of the target node is managed by flowctl
+func (d *pluginDriver) Close() error {
+	return nil
+}
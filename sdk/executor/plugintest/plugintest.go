@@ -0,0 +1,71 @@
+// Package plugintest provides helpers for testing executor plugins.
+//
+// Run serves a plugin in-process over the plugin protocol so that executors can be tested
+// without building a binary, RunBinary runs a built plugin the same way flowctl does.
+package plugintest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+)
+
+// Plugin describes a plugin served in-process
+type Plugin struct {
+	Name    string
+	Schema  any
+	Factory executor.NewExecutorFunc
+}
+
+// Run executes the plugin through the plugin protocol on the given driver
+func Run(ctx context.Context, p Plugin, driver executor.NodeDriver, execCtx executor.ExecutionContext) (map[string]any, error) {
+	hostR, pluginW := io.Pipe()
+	pluginR, hostW := io.Pipe()
+
+	served := make(chan error, 1)
+	go func() {
+		err := executor.ServePluginIO(pluginR, pluginW, p.Name, p.Schema, p.Factory)
+		pluginW.CloseWithError(io.EOF)
+		served <- err
+	}()
+
+	outputs, err := executor.ExecutePlugin(ctx, hostR, hostW, p.Name, p.Name, driver, execCtx)
+	hostW.Close()
+	hostR.Close()
+
+	if serveErr := <-served; serveErr != nil && !errors.Is(serveErr, io.ErrClosedPipe) {
+		return outputs, errors.Join(err, fmt.Errorf("plugin error: %w", serveErr))
+	}
+
+	return outputs, err
+}
+
+// RunBinary performs the handshake with the plugin binary at path and executes it on the given driver.
+// Each call starts a new plugin process, as flowctl does.
+func RunBinary(ctx context.Context, path string, driver executor.NodeDriver, execCtx executor.ExecutionContext) (map[string]any, error) {
+	info, err := executor.HandshakePlugin(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return executor.NewPluginExecutor(path, info.Name, info.Name, driver).Execute(ctx, execCtx)
+}
+
+// Build compiles the plugin package pkg into a temporary directory and returns the path of the binary
+func Build(t testing.TB, pkg string) string {
+	t.Helper()
+
+	out := filepath.Join(t.TempDir(), filepath.Base(pkg))
+	cmd := exec.Command("go", "build", "-o", out, pkg)
+	if b, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("could not build plugin %s: %v\n%s", pkg, err, b)
+	}
+
+	return out
+}
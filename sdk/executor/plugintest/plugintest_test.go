@@ -0,0 +1,214 @@
+package plugintest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cvhariharan/flowctl/sdk/executor"
+)
+
+// testExecutor exercises the plugin protocol based on the mode in its config
+type testExecutor struct {
+	driver executor.NodeDriver
+}
+
+func newTestExecutor(name string, driver executor.NodeDriver) (executor.Executor, error) {
+	return &testExecutor{driver: driver}, nil
+}
+
+func (e *testExecutor) Execute(ctx context.Context, execCtx executor.ExecutionContext) (map[string]any, error) {
+	switch mode := string(execCtx.WithConfig); mode {
+	case "echo":
+		fmt.Fprintf(execCtx.Stdout, "hello %v\n", execCtx.Inputs["name"])
+		fmt.Fprint(execCtx.Stderr, "warning\n")
+		return map[string]any{"exec_id": execCtx.ExecID}, nil
+	case "exec":
+		var stdout bytes.Buffer
+		if err := e.driver.Exec(ctx, "echo $GREETING", e.driver.GetWorkingDirectory(), []string{"GREETING=from driver"}, &stdout, execCtx.Stderr); err != nil {
+			return nil, err
+		}
+		files, err := e.driver.ListFiles(ctx, e.driver.GetWorkingDirectory())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"stdout": strings.TrimSpace(stdout.String()), "files": fmt.Sprint(len(files))}, nil
+	case "wait":
+		<-ctx.Done()
+		return nil, ctx.Err()
+	case "fail":
+		return nil, errors.New("action failed")
+	case "panic":
+		panic("executor bug")
+	case "crash":
+		fmt.Fprintln(os.Stderr, "fatal: plugin crashed")
+		os.Exit(3)
+	}
+	return nil, fmt.Errorf("unknown mode %q", execCtx.WithConfig)
+}
+
+var testPlugin = Plugin{
+	Name:    "plugin_test",
+	Schema:  map[string]any{"type": "string"},
+	Factory: newTestExecutor,
+}
+
+// The test binary doubles as the plugin binary when started by flowctl
+func TestMain(m *testing.M) {
+	if os.Getenv(executor.PluginEnvKey) == "1" {
+		if err := executor.ServePlugin(testPlugin.Name, testPlugin.Schema, testPlugin.Factory); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func newDriver(t *testing.T) executor.NodeDriver {
+	t.Helper()
+	driver, err := executor.NewLocalLinux()
+	if err != nil {
+		t.Fatalf("NewLocalLinux() error = %v", err)
+	}
+	t.Cleanup(func() {
+		driver.Remove(context.Background(), driver.GetWorkingDirectory())
+		driver.Close()
+	})
+	return driver
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("streams output", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		outputs, err := Run(ctx, testPlugin, newDriver(t), executor.ExecutionContext{
+			WithConfig: []byte("echo"),
+			Inputs:     map[string]any{"name": "flowctl"},
+			Stdout:     &stdout,
+			Stderr:     &stderr,
+			ExecID:     "exec-1",
+		})
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if stdout.String() != "hello flowctl\n" || stderr.String() != "warning\n" {
+			t.Errorf("Run() stdout = %q, stderr = %q", stdout.String(), stderr.String())
+		}
+		if outputs["exec_id"] != "exec-1" {
+			t.Errorf("Run() outputs = %v", outputs)
+		}
+	})
+
+	t.Run("driver calls", func(t *testing.T) {
+		outputs, err := Run(ctx, testPlugin, newDriver(t), executor.ExecutionContext{WithConfig: []byte("exec")})
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if outputs["stdout"] != "from driver" || outputs["files"] != "0" {
+			t.Errorf("Run() outputs = %v", outputs)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for mode, want := range map[string]string{"fail": "action failed", "panic": "plugin panicked: executor bug"} {
+			_, err := Run(ctx, testPlugin, newDriver(t), executor.ExecutionContext{WithConfig: []byte(mode)})
+			if err == nil || err.Error() != want {
+				t.Errorf("Run(%s) error = %v, want %q", mode, err, want)
+			}
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		_, err := Run(ctx, testPlugin, newDriver(t), executor.ExecutionContext{WithConfig: []byte("wait")})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Run() error = %v, want deadline exceeded", err)
+		}
+	})
+}
+
+func TestRunBinary(t *testing.T) {
+	ctx := context.Background()
+	path, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable() error = %v", err)
+	}
+
+	info, err := executor.HandshakePlugin(ctx, path)
+	if err != nil {
+		t.Fatalf("HandshakePlugin() error = %v", err)
+	}
+	if info.Name != testPlugin.Name || info.ProtocolVersion != executor.PluginProtocolVersion || string(info.Schema) != `{"type":"string"}` {
+		t.Errorf("HandshakePlugin() = %+v", info)
+	}
+
+	t.Run("executes", func(t *testing.T) {
+		var stdout bytes.Buffer
+		outputs, err := RunBinary(ctx, path, newDriver(t), executor.ExecutionContext{
+			WithConfig: []byte("echo"),
+			Inputs:     map[string]any{"name": "binary"},
+			Stdout:     &stdout,
+			ExecID:     "exec-2",
+		})
+		if err != nil {
+			t.Fatalf("RunBinary() error = %v", err)
+		}
+		if stdout.String() != "hello binary\n" || outputs["exec_id"] != "exec-2" {
+			t.Errorf("RunBinary() stdout = %q, outputs = %v", stdout.String(), outputs)
+		}
+	})
+
+	t.Run("crash isolation", func(t *testing.T) {
+		_, err := RunBinary(ctx, path, newDriver(t), executor.ExecutionContext{WithConfig: []byte("crash")})
+		var crashErr *executor.PluginCrashError
+		if !errors.As(err, &crashErr) {
+			t.Fatalf("RunBinary() error = %v, want PluginCrashError", err)
+		}
+		if !strings.Contains(crashErr.Stderr, "fatal: plugin crashed") {
+			t.Errorf("PluginCrashError.Stderr = %q", crashErr.Stderr)
+		}
+	})
+}
+
+func TestLoadPlugins(t *testing.T) {
+	// The executor registry is global, the plugin can only be registered once per process
+	if _, err := executor.GetSchema(testPlugin.Name); err == nil {
+		t.Skip("plugin already registered")
+	}
+
+	path, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.Symlink(path, dir+"/plugin"); err != nil {
+		t.Fatalf("Symlink() error = %v", err)
+	}
+	if err := os.WriteFile(dir+"/README", []byte("not a plugin"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	loaded, err := executor.LoadPlugins(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("LoadPlugins() error = %v", err)
+	}
+	if len(loaded) != 1 || loaded[0] != testPlugin.Name {
+		t.Fatalf("LoadPlugins() = %v", loaded)
+	}
+
+	if _, err := executor.GetSchema(testPlugin.Name); err != nil {
+		t.Errorf("GetSchema() error = %v", err)
+	}
+	if _, err := executor.LoadPlugins(context.Background(), dir); err == nil {
+		t.Errorf("LoadPlugins() registered a duplicate executor")
+	}
+}
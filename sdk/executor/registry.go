@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"sync"
@@ -14,6 +15,10 @@ var (
 	schemaRegistry = make(map[string]interface{})
 	mu             sync.RWMutex
 	smu            sync.RWMutex
+
+	// serverConfigs hold the configuration of executors from the flowctl config file
+	serverConfigs = make(map[string]json.RawMessage)
+	cmu           sync.RWMutex
 )
 
 var validNameRegex = regexp.MustCompile(`^[a-zA-Z_]+$`)
@@ -70,6 +75,37 @@ func GetSchema(name string) (interface{}, error) {
 	return schema, nil
 }
 
+// SetServerConfig sets the configuration of an executor from the flowctl config file.
+// It is set on startup, before any actions are run. Remote clients read the configuration of the
+// executor with the same name, e.g. the docker executor and remote client share the docker config
+func SetServerConfig(name string, config any) error {
+	b, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("could not encode config of executor '%s': %w", name, err)
+	}
+
+	cmu.Lock()
+	defer cmu.Unlock()
+	serverConfigs[name] = b
+	return nil
+}
+
+// GetServerConfig decodes the configuration of an executor from the flowctl config file into v.
+// v is left unchanged if the executor has no configuration
+func GetServerConfig(name string, v any) error {
+	cmu.RLock()
+	b, ok := serverConfigs[name]
+	cmu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("invalid config of executor '%s': %w", name, err)
+	}
+	return nil
+}
+
 // GetNewExecutorFunc is used to retrieve the NewExecutorFunc for an executor
 func GetNewExecutorFunc(name string) (NewExecutorFunc, error) {
 	mu.RLock()
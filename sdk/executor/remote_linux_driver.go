@@ -17,11 +17,30 @@ import (
 type RemoteLinuxDriver struct {
 	client           remoteclient.RemoteClient
 	workingDirectory string
+	become           *Become
 }
 
 func NewRemoteLinux(client remoteclient.RemoteClient) (NodeDriver, error) {
+	return NewRemoteLinuxWithBecome(client, nil)
+}
+
+// NewRemoteLinuxWithBecome creates a driver which runs commands passed to Exec with privilege escalation.
+// File operations use the login user, files and directories created by the driver are made accessible to the become user.
+func NewRemoteLinuxWithBecome(client remoteclient.RemoteClient, become *Become) (NodeDriver, error) {
+	if become != nil {
+		if err := become.Validate(); err != nil {
+			return nil, err
+		}
+		if become.Password != "" {
+			if _, ok := client.(remoteclient.InteractiveClient); !ok {
+				return nil, fmt.Errorf("remote client does not support passwords for become method %s", become.method())
+			}
+		}
+	}
+
 	r := &RemoteLinuxDriver{
 		client: client,
+		become: become,
 	}
 	wd := r.Join(r.TempDir(), fmt.Sprintf("flows-%s", xid.New().String()))
 	if err := r.CreateDir(context.Background(), wd); err != nil {
@@ -49,17 +68,41 @@ func (d *RemoteLinuxDriver) Download(ctx context.Context, remotePath, localPath
 
 func (d *RemoteLinuxDriver) CreateDir(ctx context.Context, dirPath string) error {
 	cmd := fmt.Sprintf("mkdir -p %s", dirPath)
-	return d.client.RunCommand(ctx, cmd, io.Discard, io.Discard)
+	if err := d.client.RunCommand(ctx, cmd, io.Discard, io.Discard); err != nil {
+		return err
+	}
+	return d.grantAccess(ctx, dirPath)
 }
 
 func (d *RemoteLinuxDriver) CreateFile(ctx context.Context, filePath string) error {
 	cmd := fmt.Sprintf("touch %s", filePath)
-	return d.client.RunCommand(ctx, cmd, io.Discard, io.Discard)
+	if err := d.client.RunCommand(ctx, cmd, io.Discard, io.Discard); err != nil {
+		return err
+	}
+	return d.grantAccess(ctx, filePath)
 }
 
 func (d *RemoteLinuxDriver) Remove(ctx context.Context, filePath string) error {
 	cmd := fmt.Sprintf("rm -rf %s", filePath)
-	return d.client.RunCommand(ctx, cmd, io.Discard, io.Discard)
+	err := d.client.RunCommand(ctx, cmd, io.Discard, io.Discard)
+	if err == nil || d.become == nil {
+		return err
+	}
+	// Files created by the become user might not be removable by the login user
+	return d.run(ctx, cmd, "", nil, io.Discard, io.Discard)
+}
+
+// grantAccess allows a become user other than root to write to files created by the login user.
+// ACLs are used when available so that the path is not writable by every user
+func (d *RemoteLinuxDriver) grantAccess(ctx context.Context, filePath string) error {
+	if d.become == nil || d.become.user() == "root" {
+		return nil
+	}
+	cmd := fmt.Sprintf("setfacl -m u:%s:rwX %s 2>/dev/null || chmod a+rwX %s", shellQuote(d.become.user()), filePath, filePath)
+	if err := d.client.RunCommand(ctx, cmd, io.Discard, io.Discard); err != nil {
+		return fmt.Errorf("could not grant access to %s for become user %s: %w", filePath, d.become.user(), err)
+	}
+	return nil
 }
 
 func (d *RemoteLinuxDriver) SetPermissions(ctx context.Context, filePath string, perms os.FileMode) error {
@@ -68,6 +111,10 @@ func (d *RemoteLinuxDriver) SetPermissions(ctx context.Context, filePath string,
 }
 
 func (d *RemoteLinuxDriver) Exec(ctx context.Context, command string, workingDir string, env []string, stdout, stderr io.Writer) error {
+	if d.become != nil {
+		return d.run(ctx, command, workingDir, env, stdout, stderr)
+	}
+
 	var parts []string
 
 	// Add environment variable exports
@@ -89,6 +136,87 @@ func (d *RemoteLinuxDriver) Exec(ctx context.Context, command string, workingDir
 	return d.client.RunCommand(ctx, fullCommand, stdout, stderr)
 }
 
+// run executes the command as the become user
+func (d *RemoteLinuxDriver) run(ctx context.Context, command string, workingDir string, env []string, stdout, stderr io.Writer) error {
+	var envFile string
+	if len(env) > 0 {
+		var err error
+		envFile, err = d.uploadEnv(ctx, env)
+		if err != nil {
+			return err
+		}
+		// The file is removed by the command once it is read, this removes it when the command did not run
+		defer d.client.RunCommand(context.Background(), fmt.Sprintf("rm -f %s", shellQuote(envFile)), io.Discard, io.Discard)
+	}
+
+	if d.become.Password == "" {
+		return d.client.RunCommand(ctx, d.become.command(command, workingDir, envFile, ""), stdout, stderr)
+	}
+
+	client := d.client.(remoteclient.InteractiveClient)
+	if !d.become.needsTTY() {
+		return client.RunCommandWithOptions(ctx, d.become.command(command, workingDir, envFile, ""), remoteclient.RunOptions{
+			Stdin: strings.NewReader(d.become.Password + "\n"),
+		}, stdout, stderr)
+	}
+
+	// The password prompt is answered once it is seen in the output of the terminal
+	stdinReader, stdinWriter := io.Pipe()
+	defer stdinWriter.Close()
+
+	marker := newBecomeMarker()
+	pw := newPromptWriter(stdout, stdinWriter, d.become.Password, marker)
+	err := client.RunCommandWithOptions(ctx, d.become.command(command, workingDir, envFile, marker), remoteclient.RunOptions{
+		Stdin: stdinReader,
+		TTY:   true,
+	}, pw, stderr)
+	pw.flush()
+	return err
+}
+
+// uploadEnv writes the environment of a command run as the become user to a file on the node.
+// Inputs and secrets would be visible to other users in ps and logged by sudo if they were passed on the
+// command line. Only the login user and the become user can read the file
+func (d *RemoteLinuxDriver) uploadEnv(ctx context.Context, env []string) (string, error) {
+	content, err := envFileContent(env)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp("", "flowctl-env-*")
+	if err != nil {
+		return "", fmt.Errorf("could not create environment file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return "", fmt.Errorf("could not write environment file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("could not write environment file: %w", err)
+	}
+
+	envFile := d.Join(d.workingDirectory, fmt.Sprintf(".env-%s", xid.New().String()))
+	// The file is created with mode 0600 before the upload so that other users can never read it
+	if err := d.client.RunCommand(ctx, fmt.Sprintf("(umask 077 && : > %s)", shellQuote(envFile)), io.Discard, io.Discard); err != nil {
+		return "", fmt.Errorf("could not create environment file: %w", err)
+	}
+	if d.become.user() != "root" {
+		// Unlike the working directory, the file is not made readable by every user when ACLs are not supported
+		cmd := fmt.Sprintf("setfacl -m u:%s:r %s", shellQuote(d.become.user()), shellQuote(envFile))
+		if err := d.client.RunCommand(ctx, cmd, io.Discard, io.Discard); err != nil {
+			d.client.RunCommand(context.Background(), fmt.Sprintf("rm -f %s", shellQuote(envFile)), io.Discard, io.Discard)
+			return "", fmt.Errorf("could not share environment file with become user %s, setfacl is required to pass variables to users other than root: %w", d.become.user(), err)
+		}
+	}
+
+	if err := d.client.Upload(ctx, f.Name(), envFile); err != nil {
+		d.client.RunCommand(context.Background(), fmt.Sprintf("rm -f %s", shellQuote(envFile)), io.Discard, io.Discard)
+		return "", fmt.Errorf("could not upload environment file: %w", err)
+	}
+	return envFile, nil
+}
+
 func (d *RemoteLinuxDriver) Dial(network, address string) (net.Conn, error) {
 	return d.client.Dial(network, address)
 }
@@ -128,6 +256,15 @@ func (d *RemoteLinuxDriver) ListFiles(ctx context.Context, dirPath string) ([]st
 	return result, nil
 }
 
+// Checksums computes the checksums of the files under dirPath on the node with sha256sum
+func (d *RemoteLinuxDriver) Checksums(ctx context.Context, dirPath string) (map[string]string, error) {
+	var output strings.Builder
+	if err := d.client.RunCommand(ctx, fmt.Sprintf(checksumCommand, shellQuote(dirPath)), &output, io.Discard); err != nil {
+		return nil, fmt.Errorf("failed to compute checksums in %s: %w", dirPath, err)
+	}
+	return parseChecksums(output.String())
+}
+
 func (d *RemoteLinuxDriver) Close() error {
 	return d.client.Close()
 }
@@ -0,0 +1,122 @@
+package remoteclient
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// CertificateValidity is how long the certificates signed for connecting to a node are valid.
+// The certificate is only checked during authentication, established connections are not affected when it expires
+const CertificateValidity = 5 * time.Minute
+
+// certificateClockSkew backdates certificates so that nodes with a clock slightly behind accept them
+const certificateClockSkew = time.Minute
+
+// UserCertificate is an ephemeral key pair with an SSH user certificate signed by a certificate authority
+type UserCertificate struct {
+	// PrivateKey is the ephemeral private key in the PEM format
+	PrivateKey string
+	// Certificate is the signed certificate in the authorized_keys format
+	Certificate string
+	Serial      uint64
+	KeyID       string
+	Principals  []string
+	ValidAfter  time.Time
+	ValidBefore time.Time
+}
+
+// SignUserCertificate generates an ed25519 key pair and signs a user certificate for it using the CA private key.
+// The certificate is valid for the principals from now until validity has passed
+func SignUserCertificate(caKey string, keyID string, principals []string, validity time.Duration) (UserCertificate, error) {
+	ca, err := ssh.ParsePrivateKey([]byte(caKey))
+	if err != nil {
+		return UserCertificate{}, fmt.Errorf("could not parse CA key: %w", err)
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return UserCertificate{}, fmt.Errorf("could not generate key pair: %w", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return UserCertificate{}, fmt.Errorf("could not create public key: %w", err)
+	}
+
+	// The serial is limited to 63 bits so that it can be stored as a signed integer
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return UserCertificate{}, fmt.Errorf("could not generate serial: %w", err)
+	}
+	serial := binary.BigEndian.Uint64(b[:]) &^ (1 << 63)
+
+	now := time.Now().Truncate(time.Second)
+	validAfter := now.Add(-certificateClockSkew)
+	validBefore := now.Add(validity)
+
+	cert := &ssh.Certificate{
+		Key:             sshPub,
+		Serial:          serial,
+		CertType:        ssh.UserCert,
+		KeyId:           keyID,
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(validAfter.Unix()),
+		ValidBefore:     uint64(validBefore.Unix()),
+		Permissions: ssh.Permissions{
+			// A terminal is used for privilege escalation and port forwarding when the node is a jump host
+			Extensions: map[string]string{
+				"permit-pty":             "",
+				"permit-port-forwarding": "",
+			},
+		},
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		return UserCertificate{}, fmt.Errorf("could not sign certificate: %w", err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		return UserCertificate{}, fmt.Errorf("could not marshal private key: %w", err)
+	}
+
+	return UserCertificate{
+		PrivateKey:  string(pem.EncodeToMemory(block)),
+		Certificate: strings.TrimSpace(string(ssh.MarshalAuthorizedKey(cert))),
+		Serial:      serial,
+		KeyID:       keyID,
+		Principals:  principals,
+		ValidAfter:  validAfter,
+		ValidBefore: validBefore,
+	}, nil
+}
+
+// CertificateSigner returns a signer which authenticates using the certificate of the private key.
+// Both are expected in the formats of UserCertificate
+func CertificateSigner(privateKey string, certificate string) (ssh.Signer, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(certificate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, errors.New("failed to parse certificate: not an SSH certificate")
+	}
+
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("certificate does not match the private key: %w", err)
+	}
+	return certSigner, nil
+}
@@ -0,0 +1,131 @@
+package remoteclient
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newCAKey(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate CA key: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatalf("could not marshal CA key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("could not create CA signer: %v", err)
+	}
+	return string(pem.EncodeToMemory(block)), signer.PublicKey()
+}
+
+// authenticate connects to a server trusting caPub for user certificates and returns the authentication error
+func authenticate(t *testing.T, caPub ssh.PublicKey, user string, signer ssh.Signer) error {
+	t.Helper()
+
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return bytes.Equal(auth.Marshal(), caPub.Marshal())
+		},
+	}
+	serverConfig := &ssh.ServerConfig{PublicKeyCallback: checker.Authenticate}
+	serverConfig.AddHostKey(newHostKey(t))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		ssh.NewServerConn(conn, serverConfig)
+	}()
+
+	client, err := ssh.Dial("tcp", listener.Addr().String(), &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err == nil {
+		client.Close()
+	}
+	return err
+}
+
+func TestSignUserCertificate(t *testing.T) {
+	caKey, caPub := newCAKey(t)
+
+	cert, err := SignUserCertificate(caKey, "flowctl:exec:action:node", []string{"deploy"}, CertificateValidity)
+	if err != nil {
+		t.Fatalf("SignUserCertificate returned error: %v", err)
+	}
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(cert.Certificate))
+	if err != nil {
+		t.Fatalf("could not parse certificate: %v", err)
+	}
+	sshCert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		t.Fatalf("expected an SSH certificate, got %T", pub)
+	}
+	if sshCert.CertType != ssh.UserCert || sshCert.Serial != cert.Serial || sshCert.KeyId != cert.KeyID {
+		t.Errorf("unexpected certificate: type %d serial %d key id %q", sshCert.CertType, sshCert.Serial, sshCert.KeyId)
+	}
+	if cert.Serial >= 1<<63 {
+		t.Errorf("serial %d does not fit in a signed integer", cert.Serial)
+	}
+	if validity := cert.ValidBefore.Sub(time.Now()); validity > CertificateValidity || validity < CertificateValidity-time.Minute {
+		t.Errorf("unexpected validity %s", validity)
+	}
+
+	signer, err := CertificateSigner(cert.PrivateKey, cert.Certificate)
+	if err != nil {
+		t.Fatalf("CertificateSigner returned error: %v", err)
+	}
+
+	if err := authenticate(t, caPub, "deploy", signer); err != nil {
+		t.Errorf("expected the certificate to be accepted for its principal, got: %v", err)
+	}
+	if err := authenticate(t, caPub, "root", signer); err == nil {
+		t.Error("expected the certificate to be rejected for another user")
+	}
+
+	_, otherCA := newCAKey(t)
+	if err := authenticate(t, otherCA, "deploy", signer); err == nil {
+		t.Error("expected the certificate to be rejected by a node trusting another CA")
+	}
+}
+
+func TestCertificateSignerMismatch(t *testing.T) {
+	caKey, _ := newCAKey(t)
+
+	first, err := SignUserCertificate(caKey, "first", []string{"deploy"}, CertificateValidity)
+	if err != nil {
+		t.Fatalf("SignUserCertificate returned error: %v", err)
+	}
+	second, err := SignUserCertificate(caKey, "second", []string{"deploy"}, CertificateValidity)
+	if err != nil {
+		t.Fatalf("SignUserCertificate returned error: %v", err)
+	}
+
+	if _, err := CertificateSigner(first.PrivateKey, second.Certificate); err == nil {
+		t.Error("expected an error when the certificate does not match the private key")
+	}
+	if _, err := CertificateSigner(first.PrivateKey, FormatHostKey(newHostKey(t).PublicKey())); err == nil {
+		t.Error("expected an error for a public key which is not a certificate")
+	}
+}
@@ -0,0 +1,212 @@
+package remoteclient
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// HostKeyError is returned when the host key presented by a node is not trusted
+type HostKeyError struct {
+	Hostname string
+	// Key is the host key presented by the node in the authorized_keys format
+	Key         string
+	Fingerprint string
+	// Expected is the fingerprint of the trusted host key, it is empty if the node does not have one
+	Expected string
+}
+
+func (e *HostKeyError) Error() string {
+	if e.Mismatch() {
+		return fmt.Sprintf("host key mismatch for %s: expected %s but got %s, the host key might have changed or the connection is being intercepted", e.Hostname, e.Expected, e.Fingerprint)
+	}
+	return fmt.Sprintf("host key %s of %s is not trusted", e.Fingerprint, e.Hostname)
+}
+
+// Mismatch returns true if the node has a trusted host key which is different from the presented key
+func (e *HostKeyError) Mismatch() bool {
+	return e.Expected != ""
+}
+
+// ParseHostKey parses a host key in the authorized_keys format
+func ParseHostKey(key string) (ssh.PublicKey, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid host key: %w", err)
+	}
+	return pub, nil
+}
+
+// FormatHostKey formats a host key in the authorized_keys format without a comment
+func FormatHostKey(key ssh.PublicKey) string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+}
+
+// HostKeyFingerprint returns the type and the SHA256 fingerprint of a host key in the authorized_keys format
+func HostKeyFingerprint(key string) (string, string, error) {
+	pub, err := ParseHostKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	return pub.Type(), ssh.FingerprintSHA256(pub), nil
+}
+
+// HostKeyCallback returns a callback which only accepts the trusted host key of a node.
+// A *HostKeyError is returned by the callback for any other key, including when trustedKey is empty
+func HostKeyCallback(hostname string, trustedKey string) (ssh.HostKeyCallback, error) {
+	var trusted ssh.PublicKey
+	if trustedKey != "" {
+		var err error
+		trusted, err = ParseHostKey(trustedKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return func(_ string, _ net.Addr, key ssh.PublicKey) error {
+		if trusted != nil && bytes.Equal(key.Marshal(), trusted.Marshal()) {
+			return nil
+		}
+
+		hkErr := &HostKeyError{
+			Hostname:    hostname,
+			Key:         FormatHostKey(key),
+			Fingerprint: ssh.FingerprintSHA256(key),
+		}
+		if trusted != nil {
+			hkErr.Expected = ssh.FingerprintSHA256(trusted)
+		}
+		return hkErr
+	}, nil
+}
+
+// HostKeyAlgorithms returns the host key algorithms to negotiate so that a node with several
+// host keys presents the trusted one. nil is returned when there is no trusted key
+func HostKeyAlgorithms(trustedKey string) []string {
+	if trustedKey == "" {
+		return nil
+	}
+	pub, err := ParseHostKey(trustedKey)
+	if err != nil {
+		return nil
+	}
+	if pub.Type() == ssh.KeyAlgoRSA {
+		return []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+	}
+	return []string{pub.Type()}
+}
+
+// hostKeyPreference is the order in which host keys found in known_hosts are preferred
+var hostKeyPreference = []string{
+	ssh.KeyAlgoED25519,
+	ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoECDSA384,
+	ssh.KeyAlgoECDSA256,
+	ssh.KeyAlgoRSA,
+}
+
+// LookupKnownHosts returns the host key of hostname:port from a known_hosts file in the authorized_keys format.
+// Plain and hashed host names are supported, wildcard patterns and certificate authorities are ignored.
+// When the host has several keys the strongest key type is returned. An empty string is returned if no key is found
+func LookupKnownHosts(data []byte, hostname string, port int) (string, error) {
+	address := knownhosts.Normalize(net.JoinHostPort(hostname, strconv.Itoa(port)))
+
+	var keys []ssh.PublicKey
+	var revoked [][]byte
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		marker, hosts, key, _, _, err := ssh.ParseKnownHosts(line)
+		if err != nil {
+			return "", fmt.Errorf("invalid known_hosts entry on line %d: %w", lineNum, err)
+		}
+
+		switch marker {
+		case "revoked":
+			revoked = append(revoked, key.Marshal())
+		case "":
+			for _, h := range hosts {
+				if matchKnownHost(h, address) {
+					keys = append(keys, key)
+					break
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("could not read known_hosts: %w", err)
+	}
+
+	var best ssh.PublicKey
+	bestRank := len(hostKeyPreference)
+	for _, key := range keys {
+		if isRevoked(key, revoked) {
+			continue
+		}
+		rank := len(hostKeyPreference)
+		for i, t := range hostKeyPreference {
+			if key.Type() == t {
+				rank = i
+				break
+			}
+		}
+		if best == nil || rank < bestRank {
+			best, bestRank = key, rank
+		}
+	}
+	if best == nil {
+		return "", nil
+	}
+	return FormatHostKey(best), nil
+}
+
+// matchKnownHost checks if a host entry of known_hosts matches the normalized address
+func matchKnownHost(entry string, address string) bool {
+	if !strings.HasPrefix(entry, "|1|") {
+		return strings.EqualFold(entry, address)
+	}
+
+	// Hashed host names have the format |1|base64(salt)|base64(hmac-sha1(salt, host))
+	parts := strings.Split(entry[len("|1|"):], "|")
+	if len(parts) != 2 {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+	hash, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(address))
+	return hmac.Equal(mac.Sum(nil), hash)
+}
+
+func isRevoked(key ssh.PublicKey, revoked [][]byte) bool {
+	for _, r := range revoked {
+		if bytes.Equal(key.Marshal(), r) {
+			return true
+		}
+	}
+	return false
+}
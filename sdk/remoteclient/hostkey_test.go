@@ -0,0 +1,147 @@
+package remoteclient
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newHostKey(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("could not create signer: %v", err)
+	}
+	return signer
+}
+
+func hashHost(host string) string {
+	salt := []byte("0123456789abcdefghij")
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(host))
+	return fmt.Sprintf("|1|%s|%s", base64.StdEncoding.EncodeToString(salt), base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+func TestLookupKnownHosts(t *testing.T) {
+	plain := FormatHostKey(newHostKey(t).PublicKey())
+	port := FormatHostKey(newHostKey(t).PublicKey())
+	hashed := FormatHostKey(newHostKey(t).PublicKey())
+	revoked := FormatHostKey(newHostKey(t).PublicKey())
+
+	knownHosts := fmt.Sprintf(`# comment
+web1.example.com,10.0.0.1 %s
+[web2.example.com]:2222 %s
+%s %s
+revoked.example.com %s
+@revoked * %s
+`, plain, port, hashHost("hashed.example.com"), hashed, revoked, revoked)
+
+	tests := []struct {
+		hostname string
+		port     int
+		want     string
+	}{
+		{"web1.example.com", 22, plain},
+		{"10.0.0.1", 22, plain},
+		{"web1.example.com", 2222, ""},
+		{"web2.example.com", 2222, port},
+		{"web2.example.com", 22, ""},
+		{"hashed.example.com", 22, hashed},
+		{"revoked.example.com", 22, ""},
+		{"unknown.example.com", 22, ""},
+	}
+
+	for _, tt := range tests {
+		got, err := LookupKnownHosts([]byte(knownHosts), tt.hostname, tt.port)
+		if err != nil {
+			t.Fatalf("LookupKnownHosts(%s:%d) returned error: %v", tt.hostname, tt.port, err)
+		}
+		if got != tt.want {
+			t.Errorf("LookupKnownHosts(%s:%d) = %q, want %q", tt.hostname, tt.port, got, tt.want)
+		}
+	}
+
+	if _, err := LookupKnownHosts([]byte("web1.example.com not-a-key\n"), "web1.example.com", 22); err == nil {
+		t.Error("expected an error for an invalid entry")
+	}
+}
+
+// dialHost runs an SSH handshake against a server presenting hostKey and returns the client error
+func dialHost(t *testing.T, hostKey ssh.Signer, trustedKey string) error {
+	t.Helper()
+
+	serverConfig := &ssh.ServerConfig{NoClientAuth: true}
+	serverConfig.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		serverConn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer serverConn.Close()
+		ssh.NewServerConn(serverConn, serverConfig)
+	}()
+
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("could not connect: %v", err)
+	}
+	defer clientConn.Close()
+
+	callback, err := HostKeyCallback("node.example.com", trustedKey)
+	if err != nil {
+		t.Fatalf("HostKeyCallback returned error: %v", err)
+	}
+	conn, _, _, err := ssh.NewClientConn(clientConn, "node.example.com:22", &ssh.ClientConfig{
+		User:              "flowctl",
+		HostKeyCallback:   callback,
+		HostKeyAlgorithms: HostKeyAlgorithms(trustedKey),
+	})
+	if err == nil {
+		conn.Close()
+	}
+	return err
+}
+
+func TestHostKeyCallback(t *testing.T) {
+	hostKey := newHostKey(t)
+	trusted := FormatHostKey(hostKey.PublicKey())
+	fingerprint := ssh.FingerprintSHA256(hostKey.PublicKey())
+
+	if err := dialHost(t, hostKey, trusted); err != nil {
+		t.Fatalf("expected the trusted host key to be accepted, got: %v", err)
+	}
+
+	var hkErr *HostKeyError
+	if err := dialHost(t, hostKey, ""); !errors.As(err, &hkErr) {
+		t.Fatalf("expected a HostKeyError for an untrusted node, got: %v", err)
+	}
+	if hkErr.Mismatch() || hkErr.Key != trusted || hkErr.Fingerprint != fingerprint {
+		t.Errorf("unexpected HostKeyError for an untrusted node: %+v", hkErr)
+	}
+
+	other := FormatHostKey(newHostKey(t).PublicKey())
+	if err := dialHost(t, hostKey, other); !errors.As(err, &hkErr) {
+		t.Fatalf("expected a HostKeyError for a changed host key, got: %v", err)
+	}
+	if !hkErr.Mismatch() || hkErr.Fingerprint != fingerprint {
+		t.Errorf("unexpected HostKeyError for a changed host key: %+v", hkErr)
+	}
+}
@@ -1,7 +1,12 @@
 package remoteclient
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"strconv"
 	"sync"
 )
 
@@ -11,12 +16,51 @@ type NodeConfig struct {
 	Port     int
 	Username string
 	Auth     NodeAuth
+	// HostKey is the trusted host key of the node in the authorized_keys format.
+	// Connections to nodes without a trusted host key fail with a *HostKeyError
+	HostKey string
+	// JumpHost is the node used to reach this node, it can have a jump host of its own
+	JumpHost *JumpHost
+	// Dial is set by GetClient when the node is reached through a jump host.
+	// Clients must use it instead of connecting to the node directly
+	Dial func(network, address string) (net.Conn, error)
+}
+
+// JumpHost is a node through which connections to another node are made
+type JumpHost struct {
+	Name           string
+	ConnectionType string
+	Config         NodeConfig
+}
+
+// JumpHostError is returned when a node could not be reached because connecting to its jump host failed.
+// Err is a *JumpHostError as well when a jump host further up the chain failed
+type JumpHostError struct {
+	Name     string
+	Hostname string
+	Port     int
+	Err      error
+}
+
+func (e *JumpHostError) Error() string {
+	address := net.JoinHostPort(e.Hostname, strconv.Itoa(e.Port))
+	if e.Name != "" {
+		return fmt.Sprintf("jump host %s (%s): %v", e.Name, address, e.Err)
+	}
+	return fmt.Sprintf("jump host %s: %v", address, e.Err)
+}
+
+func (e *JumpHostError) Unwrap() error {
+	return e.Err
 }
 
 // NodeAuth contains authentication information for a node
 type NodeAuth struct {
+	// Method is one of private_key, password or certificate
 	Method string
 	Key    string
+	// Certificate is the SSH certificate of the private key used with the certificate method
+	Certificate string
 }
 
 // NewRemoteClientFunc defines the signature for creating a new RemoteClient.
@@ -38,12 +82,70 @@ func Register(protocolName string, factory NewRemoteClientFunc) {
 }
 
 // GetClient is called by executors to get a client for a specific protocol.
+// When the node has a jump host, the connection to the jump host is made first and closed along with the client
 func GetClient(protocolName string, config NodeConfig) (RemoteClient, error) {
 	mu.RLock()
-	defer mu.RUnlock()
 	factory, ok := registry[protocolName]
+	mu.RUnlock()
 	if !ok {
 		return nil, fmt.Errorf("remote client for protocol '%s' is not registered", protocolName)
 	}
-	return factory(config)
+
+	if config.JumpHost == nil {
+		return factory(config)
+	}
+
+	jump, err := GetClient(config.JumpHost.ConnectionType, config.JumpHost.Config)
+	if err != nil {
+		return nil, &JumpHostError{
+			Name:     config.JumpHost.Name,
+			Hostname: config.JumpHost.Config.Hostname,
+			Port:     config.JumpHost.Config.Port,
+			Err:      err,
+		}
+	}
+
+	config.Dial = jump.Dial
+	client, err := factory(config)
+	if err != nil {
+		jump.Close()
+		return nil, err
+	}
+	return &jumpClient{RemoteClient: client, jump: jump}, nil
+}
+
+// jumpClient is a client connected through a jump host
+type jumpClient struct {
+	RemoteClient
+	jump RemoteClient
+}
+
+func (c *jumpClient) RunCommandWithOptions(ctx context.Context, command string, opts RunOptions, stdout, stderr io.Writer) error {
+	ic, ok := c.RemoteClient.(InteractiveClient)
+	if !ok {
+		return errors.New("remote client does not support interactive commands")
+	}
+	return ic.RunCommandWithOptions(ctx, command, opts, stdout, stderr)
+}
+
+// KeepAlive checks the connection to the jump host and then to the node
+func (c *jumpClient) KeepAlive() error {
+	if ka, ok := c.jump.(KeepAliveClient); ok {
+		if err := ka.KeepAlive(); err != nil {
+			return err
+		}
+	}
+	if ka, ok := c.RemoteClient.(KeepAliveClient); ok {
+		return ka.KeepAlive()
+	}
+	return nil
+}
+
+// Close closes the connection to the node and then to the jump host
+func (c *jumpClient) Close() error {
+	err := c.RemoteClient.Close()
+	if jerr := c.jump.Close(); err == nil {
+		err = jerr
+	}
+	return err
 }
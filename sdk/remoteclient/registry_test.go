@@ -0,0 +1,136 @@
+package remoteclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+)
+
+// fakeClient records the connections made through it
+type fakeClient struct {
+	name   string
+	mu     sync.Mutex
+	dialed []string
+	closed bool
+}
+
+func (f *fakeClient) RunCommand(ctx context.Context, command string, stdout, stderr io.Writer) error {
+	return nil
+}
+
+func (f *fakeClient) Download(ctx context.Context, remotePath, localPath string) error {
+	return nil
+}
+
+func (f *fakeClient) Upload(ctx context.Context, localPath, remotePath string) error {
+	return nil
+}
+
+func (f *fakeClient) Dial(network, address string) (net.Conn, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dialed = append(f.dialed, address)
+	client, _ := net.Pipe()
+	return client, nil
+}
+
+func (f *fakeClient) Close() error {
+	f.closed = true
+	return nil
+}
+
+var (
+	fakeClients = make(map[string]*fakeClient)
+	// unreachable hosts fail when a client is created for them
+	unreachable = map[string]bool{"down.example.com": true}
+)
+
+func init() {
+	Register("fake", func(config NodeConfig) (RemoteClient, error) {
+		address := net.JoinHostPort(config.Hostname, fmt.Sprint(config.Port))
+		if config.Dial != nil {
+			conn, err := config.Dial("tcp", address)
+			if err != nil {
+				return nil, err
+			}
+			conn.Close()
+		}
+		if unreachable[config.Hostname] {
+			return nil, errors.New("connection refused")
+		}
+		c := &fakeClient{name: config.Hostname}
+		fakeClients[config.Hostname] = c
+		return c, nil
+	})
+}
+
+func jumpHost(name, hostname string, next *JumpHost) *JumpHost {
+	return &JumpHost{
+		Name:           name,
+		ConnectionType: "fake",
+		Config:         NodeConfig{Hostname: hostname, Port: 22, JumpHost: next},
+	}
+}
+
+func TestGetClientJumpHost(t *testing.T) {
+	chain := jumpHost("bastion", "bastion.example.com", jumpHost("edge", "edge.example.com", nil))
+
+	client, err := GetClient("fake", NodeConfig{Hostname: "web.example.com", Port: 22, JumpHost: chain})
+	if err != nil {
+		t.Fatalf("GetClient returned error: %v", err)
+	}
+
+	edge, bastion := fakeClients["edge.example.com"], fakeClients["bastion.example.com"]
+	if len(edge.dialed) != 1 || edge.dialed[0] != "bastion.example.com:22" {
+		t.Errorf("expected the edge jump host to dial the bastion, got %v", edge.dialed)
+	}
+	if len(bastion.dialed) != 1 || bastion.dialed[0] != "web.example.com:22" {
+		t.Errorf("expected the bastion to dial the node, got %v", bastion.dialed)
+	}
+
+	if _, ok := client.(InteractiveClient); !ok {
+		t.Error("expected a client connected through a jump host to be an InteractiveClient")
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	for _, host := range []string{"web.example.com", "bastion.example.com", "edge.example.com"} {
+		if !fakeClients[host].closed {
+			t.Errorf("expected the client of %s to be closed", host)
+		}
+	}
+}
+
+func TestGetClientJumpHostError(t *testing.T) {
+	chain := jumpHost("bastion", "bastion.example.com", jumpHost("edge", "down.example.com", nil))
+
+	_, err := GetClient("fake", NodeConfig{Hostname: "web.example.com", Port: 22, JumpHost: chain})
+	if err == nil {
+		t.Fatal("expected an error when a jump host is unreachable")
+	}
+
+	// The failing hop is found by following the nested errors
+	var hops []string
+	for {
+		var jhErr *JumpHostError
+		if !errors.As(err, &jhErr) {
+			break
+		}
+		hops = append(hops, jhErr.Name)
+		err = jhErr.Err
+	}
+	if len(hops) != 2 || hops[0] != "bastion" || hops[1] != "edge" {
+		t.Errorf("expected the error to go through bastion and edge, got %v", hops)
+	}
+
+	want := "jump host bastion (bastion.example.com:22): jump host edge (down.example.com:22): connection refused"
+	_, err = GetClient("fake", NodeConfig{Hostname: "web.example.com", Port: 22, JumpHost: chain})
+	if err.Error() != want {
+		t.Errorf("unexpected error message %q, want %q", err.Error(), want)
+	}
+}
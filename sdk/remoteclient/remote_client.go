@@ -4,6 +4,8 @@ import (
 	"context"
 	"io"
 	"net"
+
+	"golang.org/x/crypto/ssh"
 )
 
 // RemoteClient defines an interface for interacting with a remote machine.
@@ -20,3 +22,35 @@ type RemoteClient interface {
 	// Close terminates the connection to the remote machine.
 	Close() error
 }
+
+// RunOptions configures how a command is run by an InteractiveClient
+type RunOptions struct {
+	// Stdin is connected to the standard input of the command
+	Stdin io.Reader
+	// TTY allocates a pseudo terminal without echo for the command. The output of
+	// the command is written to stdout as a terminal combines stdout and stderr
+	TTY bool
+}
+
+// InteractiveClient is implemented by remote clients that can provide input to commands.
+// It is used to answer password prompts when running commands with privilege escalation
+type InteractiveClient interface {
+	RunCommandWithOptions(ctx context.Context, command string, opts RunOptions, stdout io.Writer, stderr io.Writer) error
+}
+
+// KeepAliveClient is implemented by remote clients that can check if their connection is still usable.
+// It is used to detect dropped connections before reusing a client for another command
+type KeepAliveClient interface {
+	KeepAlive() error
+}
+
+// TerminalModes returns the terminal modes used for RunOptions.TTY by SSH based clients.
+// Echo is disabled so that input such as passwords is not written to the output
+func TerminalModes() ssh.TerminalModes {
+	return ssh.TerminalModes{
+		ssh.ECHO:          0,
+		ssh.ONLCR:         0,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+}
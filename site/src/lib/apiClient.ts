@@ -18,6 +18,12 @@ import type {
   NodeResp,
   NodesPaginateResponse,
   NodeStatsResp,
+  ImportKnownHostsResp,
+  ImportNodesReq,
+  InventoryReportResp,
+  InventorySourceReq,
+  InventorySourceResp,
+  InventorySourcesResp,
   CredentialReq,
   CredentialResp,
   CredentialsPaginateResponse,
@@ -274,6 +280,51 @@ export const apiClient = {
       baseFetch<void>(`/api/v1/${namespace}/nodes/${id}`, {
         method: 'DELETE',
       }),
+    scanHostKey: (namespace: string, id: string) =>
+      baseFetch<NodeResp>(`/api/v1/${namespace}/nodes/${id}/host-key/scan`, {
+        method: 'POST',
+      }),
+    approveHostKey: (namespace: string, id: string, fingerprint: string) =>
+      baseFetch<NodeResp>(`/api/v1/${namespace}/nodes/${id}/host-key/approve`, {
+        method: 'POST',
+        body: JSON.stringify({ fingerprint }),
+      }),
+    resetHostKey: (namespace: string, id: string) =>
+      baseFetch<NodeResp>(`/api/v1/${namespace}/nodes/${id}/host-key`, {
+        method: 'DELETE',
+      }),
+    importKnownHosts: (namespace: string, knownHosts: string) =>
+      baseFetch<ImportKnownHostsResp>(`/api/v1/${namespace}/nodes/host-keys/import`, {
+        method: 'POST',
+        body: JSON.stringify({ known_hosts: knownHosts }),
+      }),
+    importNodes: (namespace: string, req: ImportNodesReq) =>
+      baseFetch<InventoryReportResp>(`/api/v1/${namespace}/nodes/import`, {
+        method: 'POST',
+        body: JSON.stringify(req),
+      }),
+    inventorySources: {
+      list: (namespace: string) =>
+        baseFetch<InventorySourcesResp>(`/api/v1/${namespace}/nodes/inventory-sources`),
+      create: (namespace: string, source: InventorySourceReq) =>
+        baseFetch<InventorySourceResp>(`/api/v1/${namespace}/nodes/inventory-sources`, {
+          method: 'POST',
+          body: JSON.stringify(source),
+        }),
+      update: (namespace: string, id: string, source: InventorySourceReq) =>
+        baseFetch<InventorySourceResp>(`/api/v1/${namespace}/nodes/inventory-sources/${id}`, {
+          method: 'PUT',
+          body: JSON.stringify(source),
+        }),
+      delete: (namespace: string, id: string) =>
+        baseFetch<void>(`/api/v1/${namespace}/nodes/inventory-sources/${id}`, {
+          method: 'DELETE',
+        }),
+      sync: (namespace: string, id: string) =>
+        baseFetch<InventorySourceResp>(`/api/v1/${namespace}/nodes/inventory-sources/${id}/sync`, {
+          method: 'POST',
+        }),
+    },
   },
 
   // Credentials
@@ -345,6 +396,8 @@ export const apiClient = {
       baseFetch<{message: string; execID: string}>(`/api/v1/${namespace}/flows/executions/${execId}/cancel`, {
         method: 'POST',
       }),
+    artifactUrl: (namespace: string, execId: string, artifactId: string) =>
+      `/api/v1/${namespace}/flows/executions/${execId}/artifacts/${artifactId}`,
   },
 
   // Executors
@@ -112,7 +112,14 @@ export interface FlowLogResp {
 
 // Node types
 export interface NodeAuth {
-  method: "private_key" | "password";
+  method: "private_key" | "password" | "ssh_ca";
+  credential_id: string;
+}
+
+export interface NodeBecome {
+  enabled: boolean;
+  user: string;
+  method: "sudo" | "su" | "doas";
   credential_id: string;
 }
 
@@ -121,9 +128,11 @@ export interface NodeReq {
   hostname: string;
   port: number;
   username: string;
-  connection_type: "ssh" | "qssh";
+  connection_type: "ssh" | "qssh" | "docker";
   tags: string[];
   auth: NodeAuth;
+  become: NodeBecome;
+  jump_host_id?: string;
 }
 
 export interface NodeResp {
@@ -136,18 +145,93 @@ export interface NodeResp {
   connection_type: string;
   tags: string[];
   auth: NodeAuth;
+  become: NodeBecome;
+  host_key: NodeHostKey | null;
+  pending_host_key: NodeHostKey | null;
+  jump_host_id: string;
+  // Gathered by the health check, null until the node has been checked
+  facts: NodeFacts | null;
+}
+
+export interface NodeFacts {
+  reachable: boolean;
+  error: string;
+  latency_ms: number;
+  os: string;
+  kernel: string;
+  arch: string;
+  uptime_seconds: number;
+  disk_free_bytes: number;
+  checked_at: string;
+  gathered_at: string;
+}
+
+export interface NodeHostKey {
+  type: string;
+  fingerprint: string;
+  key: string;
+}
+
+export interface ImportKnownHostsResp {
+  nodes: NodeResp[];
+}
+
+// Inventory types
+export type InventoryFormat = "csv" | "ini" | "yaml" | "json";
+
+export interface InventoryDefaults {
+  port?: number;
+  username?: string;
+  connection_type?: "" | "ssh" | "qssh" | "docker";
+  credential_id?: string;
+}
+
+export interface ImportNodesReq {
+  format: InventoryFormat;
+  content: string;
+  defaults: InventoryDefaults;
+}
+
+export interface InventoryReportResp {
+  added: string[];
+  updated: string[];
+  removed: string[];
+  errors: string[];
+}
+
+export interface InventorySourceReq {
+  name: string;
+  type: "file" | "script";
+  path: string;
+  format: InventoryFormat;
+  sync_interval: number;
+  defaults: InventoryDefaults;
+}
+
+export interface InventorySourceResp extends InventorySourceReq {
+  id: string;
+  next_sync_at: string;
+  last_synced_at: string;
+  last_error: string;
+  last_report: InventoryReportResp;
+}
+
+export interface InventorySourcesResp {
+  sources: InventorySourceResp[];
 }
 
 export interface NodeStatsResp {
   total_hosts: number;
   ssh_hosts: number;
   qssh_hosts: number;
+  docker_hosts: number;
+  unreachable_hosts: number;
 }
 
 // Credential types
 export interface CredentialReq {
   name: string;
-  key_type: "private_key" | "password";
+  key_type: "private_key" | "password" | "kubeconfig" | "registry" | "ssh_ca";
   key_data: string;
 }
 
@@ -161,6 +245,7 @@ export interface CredentialResp {
 // Namespace types
 export interface NamespaceReq {
   name: string;
+  artifact_retention_days?: number | null;
 }
 
 export interface Namespace {
@@ -171,6 +256,7 @@ export interface Namespace {
 export interface NamespaceResp {
   id: string;
   name: string;
+  artifact_retention_days: number | null;
 }
 
 export interface NamespacesPaginateResponse
@@ -257,6 +343,29 @@ export interface ExecutionSummary {
   started_at: string;
   completed_at: string;
   duration: string;
+  ssh_certificates?: SSHCertificate[];
+  artifacts?: ExecutionArtifact[];
+}
+
+export interface ExecutionArtifact {
+  id: string;
+  action_id: string;
+  node_name: string;
+  path: string;
+  size_bytes: number;
+  checksum: string;
+  created_at: string;
+}
+
+export interface SSHCertificate {
+  action_id: string;
+  node_name: string;
+  serial: string;
+  key_id: string;
+  principals: string[];
+  valid_after: string;
+  valid_before: string;
+  issued_at: string;
 }
 
 // Pagination types
@@ -127,4 +127,19 @@ export function formatTime(dateString: string | null | undefined, fallback: stri
   } catch {
     return fallback;
   }
-}
\ No newline at end of file
+}
+/**
+ * Formats a size in bytes using binary units
+ * @param bytes - Size in bytes
+ * @returns Formatted size, for example 1.5 MiB
+ */
+export function formatBytes(bytes: number): string {
+  const units = ['B', 'KiB', 'MiB', 'GiB', 'TiB'];
+  let value = bytes;
+  let unit = 0;
+  while (value >= 1024 && unit < units.length - 1) {
+    value /= 1024;
+    unit++;
+  }
+  return unit === 0 ? `${value} ${units[unit]}` : `${value.toFixed(1)} ${units[unit]}`;
+}
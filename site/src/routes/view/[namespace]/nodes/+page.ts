@@ -32,15 +32,16 @@ export const load: PageLoad = async ({ params, url, parent }) => {
 		const page = Number(url.searchParams.get('page') || '1');
 		const search = url.searchParams.get('search') || '';
 
-		// Fetch nodes data, stats, and credentials in parallel
-		const [nodesResponse, statsResponse, credentialsResponse] = await Promise.all([
+		// Fetch nodes data, stats, credentials and jump host candidates in parallel
+		const [nodesResponse, statsResponse, credentialsResponse, jumpHostsResponse] = await Promise.all([
 			apiClient.nodes.list(namespace, {
 				page,
 				count_per_page: DEFAULT_PAGE_SIZE,
 				filter: search || undefined
 			}),
 			apiClient.nodes.getStats(namespace),
-			apiClient.credentials.list(namespace)
+			apiClient.credentials.list(namespace),
+			apiClient.nodes.list(namespace, { count_per_page: 100 })
 		]);
 
 		return {
@@ -50,6 +51,7 @@ export const load: PageLoad = async ({ params, url, parent }) => {
 			currentPage: page,
 			searchQuery: search,
 			credentials: credentialsResponse.credentials || [],
+			jumpHosts: jumpHostsResponse.nodes || [],
 			stats: statsResponse,
 			namespace
 		};